@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+)
+
+const accessTokenCookie = "chirpy_access_token"
+
+// setAccessTokenCookie mirrors the access token into a cookie when the hosted
+// /app frontend is served from the same origin as the API. SameSite=Strict
+// keeps the cookie off cross-site requests, so it cannot be used for CSRF.
+func (cfg *apiConfig) setAccessTokenCookie(w http.ResponseWriter, token string, expires time.Time) {
+	if !cfg.accessTokenCookie {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessTokenCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   os.Getenv("PLATFORM") != "dev",
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// accessToken returns the JWT sent with the request. The Authorization header
+// always takes precedence; the cookie is only consulted in cookie mode.
+func (cfg *apiConfig) accessToken(r *http.Request) (string, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err == nil || !cfg.accessTokenCookie {
+		return token, err
+	}
+	cookie, cookieErr := r.Cookie(accessTokenCookie)
+	if cookieErr != nil || cookie.Value == "" {
+		return "", fmt.Errorf("%w and no access token cookie", err)
+	}
+	return cookie.Value, nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	adminActionApproved = "approved"
+	adminActionRejected = "rejected"
+	// adminActionTTL is how long a request waits for a second admin.
+	adminActionTTL = 24 * time.Hour
+
+	adminActionUserPurge = "user.purge"
+)
+
+// errAdminActionStale is returned by an action whose target no longer
+// allows it, for example a user restored since the purge was requested.
+var errAdminActionStale = errors.New("target changed since the action was requested")
+
+// adminActions run approved actions. They get the transaction that marks
+// the request approved, so the action happens exactly when the approval
+// sticks. The result is returned to the approver and kept in the audit log.
+var adminActions = map[string]func(cfg *apiConfig, ctx context.Context, qtx *database.Store, targetID string) (map[string]any, error){
+	adminActionUserPurge: (*apiConfig).purgeUserNow,
+}
+
+// secondAdminRequired reports whether irreversible actions need another
+// admin's approval. Only dev lets the requester approve their own.
+func secondAdminRequired() bool {
+	return os.Getenv("PLATFORM") != "dev"
+}
+
+type adminActionResponse struct {
+	ID          uuid.UUID      `json:"id"`
+	Action      string         `json:"action"`
+	TargetID    string         `json:"target_id"`
+	RequestedBy *uuid.UUID     `json:"requested_by"`
+	Status      string         `json:"status"`
+	DecidedBy   *uuid.UUID     `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time     `json:"decided_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+	Result      map[string]any `json:"result,omitempty"`
+}
+
+func newAdminActionResponse(a database.PendingAdminAction) adminActionResponse {
+	resp := adminActionResponse{
+		ID:          a.ID,
+		Action:      a.Action,
+		TargetID:    a.TargetID,
+		RequestedBy: nullUUIDPtr(a.RequestedBy),
+		Status:      a.Status,
+		DecidedBy:   nullUUIDPtr(a.DecidedBy),
+		CreatedAt:   a.CreatedAt,
+		ExpiresAt:   a.ExpiresAt,
+	}
+	if a.DecidedAt.Valid {
+		resp.DecidedAt = &a.DecidedAt.Time
+	}
+	return resp
+}
+
+// requestAdminAction queues action on targetID for a second admin and
+// answers 202. Where no second admin is required it is approved and run
+// straight away.
+func (cfg *apiConfig) requestAdminAction(w http.ResponseWriter, r *http.Request, action, targetID string) {
+	adminID, _ := adminUserIDFromContext(r.Context())
+	if _, err := cfg.database.ExpirePendingAdminActions(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't request action", err)
+		return
+	}
+	pending, err := cfg.database.CreatePendingAdminAction(r.Context(), database.CreatePendingAdminActionParams{
+		Action:      action,
+		TargetID:    targetID,
+		RequestedBy: uuid.NullUUID{UUID: adminID, Valid: adminID != uuid.Nil},
+		ExpiresAt:   time.Now().Add(adminActionTTL),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, "This action is already waiting for approval", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't request action", err)
+		return
+	}
+	cfg.recordAudit(r.Context(), adminID, "admin_action.requested", "admin_action", pending.ID.String(), map[string]any{
+		"action":    pending.Action,
+		"target_id": pending.TargetID,
+	})
+	if !secondAdminRequired() {
+		cfg.approveAdminAction(w, r, pending.ID)
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, newAdminActionResponse(pending))
+}
+
+// handlerAdminPurgeUser permanently deletes a soft-deleted user without
+// waiting for the restore window, once a second admin approves.
+func (cfg *apiConfig) handlerAdminPurgeUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	user, err := cfg.database.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithDBError(w, "Couldn't get user", err)
+		return
+	}
+	if !user.IsDeleted {
+		respondWithError(w, http.StatusConflict, "Only deleted users can be purged", nil)
+		return
+	}
+	cfg.requestAdminAction(w, r, adminActionUserPurge, user.ID.String())
+}
+
+// purgeUserNow purges a soft-deleted user as the purge job would once the
+// restore window passes.
+func (cfg *apiConfig) purgeUserNow(ctx context.Context, qtx *database.Store, targetID string) (map[string]any, error) {
+	userID, err := uuid.Parse(targetID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := qtx.GetUserByID(ctx, userID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return nil, errAdminActionStale
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsDeleted {
+		return nil, errAdminActionStale
+	}
+	u := database.ListPurgeableUsersRow{ID: user.ID, DeletedAt: user.DeletedAt}
+	report, err := cfg.purgeUserTx(ctx, qtx, u, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if report.ID == uuid.Nil {
+		return nil, errAdminActionStale
+	}
+	return map[string]any{
+		"deletion_report_id": report.ID,
+		"verified":           report.Verified,
+	}, nil
+}
+
+func (cfg *apiConfig) handlerAdminListPendingActions(w http.ResponseWriter, r *http.Request) {
+	actions, err := cfg.database.ListPendingAdminActions(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get pending actions", err)
+		return
+	}
+	var entries []adminActionResponse
+	for _, a := range actions {
+		entries = append(entries, newAdminActionResponse(a))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerAdminApproveAction(w http.ResponseWriter, r *http.Request) {
+	action, ok := cfg.adminActionFromPath(w, r)
+	if !ok {
+		return
+	}
+	adminID, _ := adminUserIDFromContext(r.Context())
+	if !mayApproveAdminAction(action, adminID) {
+		respondWithError(w, http.StatusForbidden, "Another admin has to approve this action", nil)
+		return
+	}
+	cfg.approveAdminAction(w, r, action.ID)
+}
+
+// mayApproveAdminAction reports whether adminID may approve action: anyone
+// but the admin who requested it, unless no second admin is required.
+func mayApproveAdminAction(action database.PendingAdminAction, adminID uuid.UUID) bool {
+	if !secondAdminRequired() {
+		return true
+	}
+	return !action.RequestedBy.Valid || action.RequestedBy.UUID != adminID
+}
+
+// handlerAdminRejectAction turns a request down. The requesting admin may
+// reject their own request to withdraw it.
+func (cfg *apiConfig) handlerAdminRejectAction(w http.ResponseWriter, r *http.Request) {
+	pending, ok := cfg.adminActionFromPath(w, r)
+	if !ok {
+		return
+	}
+	adminID, _ := adminUserIDFromContext(r.Context())
+	action, err := cfg.database.DecidePendingAdminAction(r.Context(), database.DecidePendingAdminActionParams{
+		ID:        pending.ID,
+		Status:    adminActionRejected,
+		DecidedBy: uuid.NullUUID{UUID: adminID, Valid: adminID != uuid.Nil},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Action is no longer pending", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reject action", err)
+		return
+	}
+	cfg.recordAudit(r.Context(), adminID, "admin_action.rejected", "admin_action", action.ID.String(), map[string]any{
+		"action":    action.Action,
+		"target_id": action.TargetID,
+	})
+	respondWithJSON(w, http.StatusOK, newAdminActionResponse(action))
+}
+
+func (cfg *apiConfig) adminActionFromPath(w http.ResponseWriter, r *http.Request) (database.PendingAdminAction, bool) {
+	actionID, err := uuid.Parse(r.PathValue("actionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid action ID", err)
+		return database.PendingAdminAction{}, false
+	}
+	action, err := cfg.database.GetPendingAdminAction(r.Context(), actionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Action not found", nil)
+		return database.PendingAdminAction{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get action", err)
+		return database.PendingAdminAction{}, false
+	}
+	return action, true
+}
+
+// approveAdminAction marks the action approved and runs it in one
+// transaction, so a failed run leaves the request pending.
+func (cfg *apiConfig) approveAdminAction(w http.ResponseWriter, r *http.Request, actionID uuid.UUID) {
+	ctx := r.Context()
+	adminID, _ := adminUserIDFromContext(ctx)
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve action", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+
+	action, err := qtx.DecidePendingAdminAction(ctx, database.DecidePendingAdminActionParams{
+		ID:        actionID,
+		Status:    adminActionApproved,
+		DecidedBy: uuid.NullUUID{UUID: adminID, Valid: adminID != uuid.Nil},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Action is no longer pending", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve action", err)
+		return
+	}
+	run, ok := adminActions[action.Action]
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve action", errors.New("unknown admin action "+action.Action))
+		return
+	}
+	result, err := run(cfg, ctx, qtx, action.TargetID)
+	if errors.Is(err, errAdminActionStale) {
+		respondWithError(w, http.StatusConflict, "The target has changed since the action was requested; reject it instead", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't run action", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve action", err)
+		return
+	}
+
+	metadata := map[string]any{
+		"action":    action.Action,
+		"target_id": action.TargetID,
+		"result":    result,
+	}
+	if action.RequestedBy.Valid {
+		metadata["requested_by"] = action.RequestedBy.UUID
+	}
+	cfg.recordAudit(ctx, adminID, "admin_action.approved", "admin_action", action.ID.String(), metadata)
+
+	resp := newAdminActionResponse(action)
+	resp.Result = result
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// recordAudit appends an entry to the audit log. Failures are logged rather
+// than returned so a broken audit write never undoes the action itself.
+func (cfg *apiConfig) recordAudit(ctx context.Context, actorID uuid.UUID, action, targetType, targetID string, metadata map[string]any) {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	dat, err := json.Marshal(metadata)
+	if err != nil {
+		log.Printf("Error marshalling audit metadata for %s: %s", action, err)
+		dat = []byte("{}")
+	}
+	err = cfg.database.CreateAuditLog(ctx, database.CreateAuditLogParams{
+		ActorID:    uuid.NullUUID{UUID: actorID, Valid: actorID != uuid.Nil},
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Metadata:   dat,
+	})
+	if err != nil {
+		log.Printf("Error writing audit log entry %s for %s %s: %s", action, targetType, targetID, err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+type userContextKey struct{}
+
+func userFromContext(ctx context.Context) (database.User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(database.User)
+	return user, ok
+}
+
+func userIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	user, ok := userFromContext(ctx)
+	return user.ID, ok
+}
+
+// validateJWT checks one of Chirpy's own access tokens, allowing for the
+// configured clock skew between servers.
+func (cfg *apiConfig) validateJWT(token string) (uuid.UUID, error) {
+	return auth.ValidateJWTWithLeeway(token, cfg.tokenSecret, cfg.jwtLeeway)
+}
+
+var (
+	errInvalidAccessToken     = errors.New("invalid access token")
+	errPasswordChangeRequired = errors.New("password change required")
+)
+
+// passwordChangeRoute is the only route an account that must change its
+// password can use.
+const passwordChangeRoute = "PUT /api/users"
+
+// authenticateJWT validates one of Chirpy's own access tokens and loads its
+// user. Tokens issued before the user's credentials were last rotated are
+// rejected. Errors other than errInvalidAccessToken are database failures.
+func (cfg *apiConfig) authenticateJWT(ctx context.Context, token string) (database.User, error) {
+	claims, err := auth.ParseJWT(token, cfg.tokenSecret, cfg.jwtLeeway)
+	if err != nil {
+		return database.User{}, fmt.Errorf("%w: %w", errInvalidAccessToken, err)
+	}
+	user, err := cfg.database.GetUserByID(ctx, claims.UserID)
+	if errors.Is(err, database.ErrUserNotFound) || (err == nil && user.IsDeleted) {
+		return database.User{}, fmt.Errorf("%w: no such user", errInvalidAccessToken)
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+	if claims.Version != user.TokenVersion {
+		return database.User{}, fmt.Errorf("%w: token was revoked", errInvalidAccessToken)
+	}
+	return user, nil
+}
+
+// middlewareAuthenticate validates the access token once, loads its user
+// and stores it in the request context for userFromContext. Only Chirpy's
+// own JWTs are accepted; routes open to OAuth clients use userForToken.
+func (cfg *apiConfig) middlewareAuthenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := cfg.accessToken(r)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
+			return
+		}
+		user, err := cfg.authenticateJWT(r.Context(), token)
+		if errors.Is(err, errInvalidAccessToken) {
+			respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+			return
+		}
+		if user.PasswordChangeRequired && r.Pattern != passwordChangeRoute {
+			respondWithError(w, http.StatusForbidden, "You must change your password first", errPasswordChangeRequired)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, user)))
+	})
+}
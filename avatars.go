@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/google/uuid"
+)
+
+const maxAvatarBytes = 2 << 20
+
+// avatarTypes are the image types accepted as avatars, with the extension
+// stored files get.
+var avatarTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// handlerUploadAvatar replaces the caller's avatar with an image sent as
+// the "file" field of a multipart form. The new URL is stored in
+// users.avatar_url, so it shows up wherever the user's profile does; the
+// old file is left for the media purge job.
+func (cfg *apiConfig) handlerUploadAvatar(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		AvatarURL string `json:"avatar_url"`
+	}
+
+	token, err := cfg.accessToken(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
+		return
+	}
+	userID, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeProfileWrite)
+	if err != nil {
+		respondWithTokenError(w, err)
+		return
+	}
+	upload, ok := readMediaUpload(w, r, maxAvatarBytes, avatarTypes)
+	if !ok {
+		return
+	}
+	defer upload.file.Close()
+
+	avatarID := uuid.New()
+	key := "avatars/" + userID.String() + "/" + avatarID.String() + upload.ext
+	if err := cfg.media.Put(r.Context(), key, upload.body, upload.size, upload.contentType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store avatar", err)
+		return
+	}
+	before, after, err := cfg.saveAvatar(r.Context(), database.CreateUserAvatarParams{
+		ID:          avatarID,
+		UserID:      uuid.NullUUID{UUID: userID, Valid: true},
+		StorageKey:  key,
+		ContentType: upload.contentType,
+		SizeBytes:   upload.size,
+	}, cfg.mediaURL(r, key))
+	if err != nil {
+		if err := cfg.media.Delete(context.Background(), key); err != nil {
+			log.Printf("Error removing unsaved avatar %s: %s", key, err)
+		}
+		respondWithDBError(w, "Couldn't save avatar", err)
+		return
+	}
+	cfg.recordPIIChanges(r.Context(), before, after, piiSourceUser)
+	respondWithJSON(w, http.StatusOK, returnVals{AvatarURL: after.AvatarUrl.String})
+}
+
+// saveAvatar makes avatar the user's current one, detaching the previous
+// one, and returns the user before and after.
+func (cfg *apiConfig) saveAvatar(ctx context.Context, avatar database.CreateUserAvatarParams, url string) (database.User, database.User, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, database.User{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+
+	before, err := qtx.GetUserByID(ctx, avatar.UserID.UUID)
+	if err != nil {
+		return database.User{}, database.User{}, err
+	}
+	if err := qtx.DetachUserAvatar(ctx, avatar.UserID); err != nil {
+		return database.User{}, database.User{}, err
+	}
+	if _, err := qtx.CreateUserAvatar(ctx, avatar); err != nil {
+		return database.User{}, database.User{}, err
+	}
+	after, err := qtx.UpdateUserAvatarURL(ctx, database.UpdateUserAvatarURLParams{
+		AvatarUrl: sql.NullString{String: url, Valid: true},
+		ID:        before.ID,
+	})
+	if err != nil {
+		return database.User{}, database.User{}, err
+	}
+	return before, after, tx.Commit()
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/backup"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	backupStatusSucceeded = "succeeded"
+	backupStatusFailed    = "failed"
+	backupTimeout         = 2 * time.Hour
+)
+
+// runCommand handles the command line subcommands. It returns false when
+// the arguments don't name one, in which case the server starts.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	var err error
+	switch args[0] {
+	case "backup":
+		err = runBackupCommand(args[1:])
+	case "restore":
+		err = runRestoreCommand(args[1:])
+	default:
+		return false
+	}
+	if err != nil {
+		log.Fatalf("%s: %s", args[0], err)
+	}
+	return true
+}
+
+// runBackupCommand writes a dump to a file, or to stdout so it can be piped
+// to object storage, e.g. `chirpy backup | aws s3 cp - s3://bucket/key`.
+func runBackupCommand(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("o", "-", "file to write the dump to, - for stdout")
+	fs.Parse(args)
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	n, err := backup.Tools{}.Dump(context.Background(), os.Getenv("DB_URL"), w)
+	if err != nil {
+		if *output != "-" {
+			os.Remove(*output)
+		}
+		return err
+	}
+	log.Printf("Wrote %d byte backup", n)
+	return nil
+}
+
+// runRestoreCommand replaces the database contents with a dump. It asks for
+// -yes because everything written since the dump is lost.
+func runRestoreCommand(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "confirm that the current database contents will be replaced")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: restore -yes <file|->")
+	}
+	if !*yes {
+		return errors.New("restoring replaces all data in the database; rerun with -yes to continue")
+	}
+
+	var r io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if err := (backup.Tools{}).Restore(context.Background(), os.Getenv("DB_URL"), r); err != nil {
+		return err
+	}
+	log.Println("Restore complete")
+	return nil
+}
+
+type backupResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	FileName    string     `json:"file_name"`
+	SizeBytes   *int64     `json:"size_bytes"`
+	Error       *string    `json:"error"`
+	RequestedBy *uuid.UUID `json:"requested_by"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *string    `json:"finished_at"`
+}
+
+func newBackupResponse(b database.Backup) backupResponse {
+	resp := backupResponse{
+		ID:          b.ID,
+		Status:      b.Status,
+		FileName:    b.FileName,
+		Error:       nullStringPtr(b.Error),
+		RequestedBy: nullUUIDPtr(b.RequestedBy),
+		StartedAt:   b.StartedAt,
+		FinishedAt:  nullTimeString(b.FinishedAt),
+	}
+	if b.SizeBytes.Valid {
+		resp.SizeBytes = &b.SizeBytes.Int64
+	}
+	return resp
+}
+
+// handlerAdminStartBackup starts a dump into BACKUP_DIR in the background.
+// Poll GET /admin/backups/{backupID} for the outcome.
+func (cfg *apiConfig) handlerAdminStartBackup(w http.ResponseWriter, r *http.Request) {
+	if cfg.backupDir == "" {
+		respondWithError(w, http.StatusNotFound, "Backups are not configured", nil)
+		return
+	}
+	if !cfg.backupRunning.CompareAndSwap(false, true) {
+		respondWithError(w, http.StatusConflict, "A backup is already running", nil)
+		return
+	}
+	adminID, _ := adminUserIDFromContext(r.Context())
+	b, err := cfg.database.CreateBackup(r.Context(), database.CreateBackupParams{
+		FileName:    backup.FileName(time.Now()),
+		RequestedBy: uuid.NullUUID{UUID: adminID, Valid: adminID != uuid.Nil},
+	})
+	if err != nil {
+		cfg.backupRunning.Store(false)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start backup", err)
+		return
+	}
+	cfg.recordAudit(r.Context(), adminID, "backup.started", "backup", b.ID.String(), nil)
+	go cfg.runBackup(b)
+	respondWithJSON(w, http.StatusAccepted, newBackupResponse(b))
+}
+
+func (cfg *apiConfig) runBackup(b database.Backup) {
+	defer cfg.backupRunning.Store(false)
+	ctx, cancel := context.WithTimeout(context.Background(), backupTimeout)
+	defer cancel()
+
+	size, err := cfg.writeBackup(ctx, filepath.Join(cfg.backupDir, b.FileName))
+	params := database.FinishBackupParams{ID: b.ID, Status: backupStatusSucceeded}
+	if err != nil {
+		log.Printf("Error taking backup %s: %s", b.FileName, err)
+		params.Status = backupStatusFailed
+		params.Error = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		params.SizeBytes = sql.NullInt64{Int64: size, Valid: true}
+	}
+	if _, err := cfg.database.FinishBackup(ctx, params); err != nil {
+		log.Printf("Error recording backup %s: %s", b.FileName, err)
+	}
+	cfg.recordAudit(ctx, uuid.Nil, "backup."+params.Status, "backup", b.ID.String(), nil)
+}
+
+func (cfg *apiConfig) writeBackup(ctx context.Context, path string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := backup.Tools{}.Dump(ctx, cfg.databaseURL, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return n, nil
+}
+
+func (cfg *apiConfig) handlerAdminListBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := cfg.database.ListBackups(r.Context(), 100)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get backups", err)
+		return
+	}
+	var entries []backupResponse
+	for _, b := range backups {
+		entries = append(entries, newBackupResponse(b))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerAdminGetBackup(w http.ResponseWriter, r *http.Request) {
+	backupID, err := uuid.Parse(r.PathValue("backupID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid backup ID", err)
+		return
+	}
+	b, err := cfg.database.GetBackup(r.Context(), backupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Backup not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get backup", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newBackupResponse(b))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// A bot is a normal account that is labelled as automated and linked to the
+// user who runs it. The bot links itself by presenting one of its owner's
+// developer keys, which proves the owner agreed; while that key is live,
+// its tier sets the bot's monthly write quota in place of the user tiers.
+const userTierBot = "bot"
+
+var botWriteQuotas = map[string]int32{
+	developerTierFree:     10000,
+	developerTierStandard: 100000,
+	developerTierPartner:  1000000,
+}
+
+// usageLimits returns the tier name and monthly quotas for a user.
+func (cfg *apiConfig) usageLimits(ctx context.Context, userID uuid.UUID, isChirpyRed bool) (string, map[string]int32) {
+	tier := userTier(isChirpyRed)
+	keyTier, err := cfg.database.GetBotQuotaTier(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error checking bot quota for user %s: %s", userID, err)
+		}
+		return tier, userUsageQuotas[tier]
+	}
+	return userTierBot, map[string]int32{
+		usageKindWrite: botWriteQuotas[keyTier],
+		usageKindRead:  userUsageQuotas[tier][usageKindRead],
+	}
+}
+
+// botOwners maps bot accounts to their owners.
+type botOwners map[uuid.UUID]uuid.UUID
+
+// label marks author as automated if it is a bot.
+func (b botOwners) label(author chirpAuthor) chirpAuthor {
+	if owner, ok := b[author.ID]; ok {
+		author.IsAutomated = true
+		author.AutomatedBy = &owner
+	}
+	return author
+}
+
+// botOwners looks up which of userIDs are bots in one query.
+func (cfg *apiConfig) botOwners(ctx context.Context, userIDs []uuid.UUID) (botOwners, error) {
+	bots := botOwners{}
+	if len(userIDs) == 0 {
+		return bots, nil
+	}
+	rows, err := cfg.database.ListBotOwners(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		bots[row.UserID] = row.OwnerID
+	}
+	return bots, nil
+}
+
+type botAccountResponse struct {
+	UserID      uuid.UUID `json:"user_id"`
+	IsAutomated bool      `json:"is_automated"`
+	AutomatedBy uuid.UUID `json:"automated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// handlerSetAutomated labels the caller's account as a bot run by the owner
+// of the developer key in the request body.
+func (cfg *apiConfig) handlerSetAutomated(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		DeveloperKey string `json:"developer_key"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	key := strings.TrimSpace(params.DeveloperKey)
+	if key == "" {
+		respondWithError(w, http.StatusBadRequest, "The owner's developer key is required", nil)
+		return
+	}
+	k, err := cfg.database.GetDeveloperAPIKeyByHash(r.Context(), auth.HashToken(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		cfg.checkCanary(r, canaryKindAPIKey, key)
+		respondWithError(w, http.StatusBadRequest, "Invalid developer key", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check developer key", err)
+		return
+	}
+	if k.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "A bot must be owned by another account", nil)
+		return
+	}
+	bot, err := cfg.database.SetBotAccount(r.Context(), database.SetBotAccountParams{
+		UserID:         userID,
+		OwnerID:        k.UserID,
+		DeveloperKeyID: uuid.NullUUID{UUID: k.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark account as automated", err)
+		return
+	}
+	cfg.recordAudit(r.Context(), userID, "user.automated_set", "user", userID.String(), map[string]any{
+		"owner_id":         bot.OwnerID,
+		"developer_key_id": k.ID,
+	})
+	respondWithJSON(w, http.StatusOK, botAccountResponse{
+		UserID:      bot.UserID,
+		IsAutomated: true,
+		AutomatedBy: bot.OwnerID,
+		CreatedAt:   bot.CreatedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerClearAutomated(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	deleted, err := cfg.database.DeleteBotAccount(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update account", err)
+		return
+	}
+	if deleted > 0 {
+		cfg.recordAudit(r.Context(), userID, "user.automated_cleared", "user", userID.String(), nil)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+)
+
+// branding is how this deployment presents itself, taken from the
+// branding.* settings so a white-labeled instance needs no rebuild.
+type branding struct {
+	SiteName     string `json:"site_name"`
+	LogoURL      string `json:"logo_url,omitempty"`
+	AccentColor  string `json:"accent_color"`
+	SupportEmail string `json:"support_email,omitempty"`
+}
+
+func (cfg *apiConfig) branding(ctx context.Context) branding {
+	return branding{
+		SiteName:     cfg.settings.Get(ctx, settings.BrandingSiteName),
+		LogoURL:      cfg.settings.Get(ctx, settings.BrandingLogoURL),
+		AccentColor:  cfg.settings.Get(ctx, settings.BrandingAccentColor),
+		SupportEmail: cfg.settings.Get(ctx, settings.BrandingSupportEmail),
+	}
+}
+
+func (cfg *apiConfig) siteName(ctx context.Context) string {
+	return cfg.settings.Get(ctx, settings.BrandingSiteName)
+}
+
+func (cfg *apiConfig) handlerBranding(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	respondWithJSON(w, http.StatusOK, cfg.branding(r.Context()))
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/mail"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/google/uuid"
+)
+
+const (
+	canaryKindRefreshToken = "refresh_token"
+	canaryKindAPIKey       = "api_key"
+	// dependencyCanaryWebhook names the circuit breaker guarding the
+	// canary alert webhook.
+	dependencyCanaryWebhook = "canary_webhook"
+)
+
+type canaryTokenResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	Kind            string     `json:"kind"`
+	Label           string     `json:"label"`
+	CreatedAt       time.Time  `json:"created_at"`
+	TriggerCount    int32      `json:"trigger_count"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	Token           string     `json:"token,omitempty"`
+}
+
+func newCanaryTokenResponse(c database.CanaryToken) canaryTokenResponse {
+	resp := canaryTokenResponse{
+		ID:           c.ID,
+		Kind:         c.Kind,
+		Label:        c.Label,
+		CreatedAt:    c.CreatedAt,
+		TriggerCount: c.TriggerCount,
+	}
+	if c.LastTriggeredAt.Valid {
+		resp.LastTriggeredAt = &c.LastTriggeredAt.Time
+	}
+	return resp
+}
+
+// handlerAdminCreateCanary mints a canary token. It is indistinguishable
+// from a real refresh token or API key, and is shown only once; plant it
+// somewhere a leak would expose, such as a backup or a config file.
+func (cfg *apiConfig) handlerAdminCreateCanary(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Kind  string `json:"kind"`
+		Label string `json:"label"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	params.Label = strings.TrimSpace(params.Label)
+	if params.Label == "" {
+		respondWithError(w, http.StatusBadRequest, "Label is required", nil)
+		return
+	}
+	var token string
+	var err error
+	switch params.Kind {
+	case canaryKindRefreshToken:
+		token, err = auth.MakeRefreshToken()
+	case canaryKindAPIKey:
+		token, err = auth.MakeAPIKey()
+	default:
+		respondWithError(w, http.StatusBadRequest, `Kind must be "refresh_token" or "api_key"`, nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create canary token", err)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	canary, err := cfg.database.CreateCanaryToken(r.Context(), database.CreateCanaryTokenParams{
+		Kind:      params.Kind,
+		Label:     params.Label,
+		TokenHash: auth.HashToken(token),
+		CreatedBy: uuid.NullUUID{UUID: adminID, Valid: adminID != uuid.Nil},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create canary token", err)
+		return
+	}
+	cfg.recordAudit(r.Context(), adminID, "canary.created", "canary_token", canary.ID.String(), map[string]any{
+		"kind":  canary.Kind,
+		"label": canary.Label,
+	})
+
+	resp := newCanaryTokenResponse(canary)
+	resp.Token = token
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) handlerAdminListCanaries(w http.ResponseWriter, r *http.Request) {
+	canaries, err := cfg.database.ListCanaryTokens(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get canary tokens", err)
+		return
+	}
+	var entries []canaryTokenResponse
+	for _, c := range canaries {
+		entries = append(entries, newCanaryTokenResponse(c))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerAdminDeleteCanary(w http.ResponseWriter, r *http.Request) {
+	canaryID, err := uuid.Parse(r.PathValue("canaryID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid canary ID", err)
+		return
+	}
+	deleted, err := cfg.database.DeleteCanaryToken(r.Context(), canaryID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete canary token", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Canary token not found", nil)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "canary.deleted", "canary_token", canaryID.String(), nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canaryUse describes the request that presented a canary token.
+type canaryUse struct {
+	Path       string    `json:"path"`
+	RemoteAddr string    `json:"remote_addr"`
+	UserAgent  string    `json:"user_agent"`
+	At         time.Time `json:"at"`
+}
+
+// checkCanary is called with a credential that didn't match a real one. If
+// it is a canary, the use is counted and alerted on. The caller answers the
+// request as it would for any unknown credential, so whoever holds the
+// token can't tell it was a trap.
+func (cfg *apiConfig) checkCanary(r *http.Request, kind, token string) {
+	use := canaryUse{
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		At:         time.Now().UTC(),
+	}
+	canary, err := cfg.tripCanary(r.Context(), kind, token, use)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking canary tokens: %s", err)
+		return
+	}
+	log.Printf("SECURITY: canary token %s (%s %q) used on %s from %s", canary.ID, canary.Kind, canary.Label, use.Path, use.RemoteAddr)
+	cfg.recordAudit(r.Context(), uuid.Nil, "canary.triggered", "canary_token", canary.ID.String(), map[string]any{
+		"path":        use.Path,
+		"remote_addr": use.RemoteAddr,
+		"user_agent":  use.UserAgent,
+	})
+}
+
+// tripCanary counts a use of the canary matching token, if there is one,
+// and queues its alerts in the same transaction. It fails with
+// sql.ErrNoRows when no canary matches.
+func (cfg *apiConfig) tripCanary(ctx context.Context, kind, token string, use canaryUse) (database.CanaryToken, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.CanaryToken{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	canary, err := qtx.TripCanaryToken(ctx, database.TripCanaryTokenParams{
+		TokenHash: auth.HashToken(token),
+		Kind:      kind,
+	})
+	if err != nil {
+		return database.CanaryToken{}, err
+	}
+	if err := cfg.enqueueCanaryAlerts(ctx, qtx, canary, use); err != nil {
+		return database.CanaryToken{}, err
+	}
+	return canary, tx.Commit()
+}
+
+// enqueueCanaryAlerts queues alerts to the canary.webhook_url and
+// canary.alert_email settings, whichever are set.
+func (cfg *apiConfig) enqueueCanaryAlerts(ctx context.Context, q *database.Store, canary database.CanaryToken, use canaryUse) error {
+	if webhookURL := cfg.settings.Get(ctx, settings.CanaryWebhookURL); webhookURL != "" {
+		body, err := canaryWebhookBody(canary, use)
+		if err != nil {
+			return err
+		}
+		if err := enqueueWebhook(ctx, q, dependencyCanaryWebhook, webhookURL, body); err != nil {
+			return err
+		}
+	}
+	if to := cfg.settings.Get(ctx, settings.CanaryAlertEmail); to != "" {
+		err := enqueueEmail(ctx, q, mail.Message{
+			To:      to,
+			Subject: cfg.siteName(ctx) + " canary token used: " + canary.Label,
+			Body: fmt.Sprintf("The %s canary token %q (%s) was used at %s.\n\nPath: %s\nRemote address: %s\nUser agent: %s\n\nWherever this token was planted has leaked.",
+				canary.Kind, canary.Label, canary.ID, use.At.Format(time.RFC3339), use.Path, use.RemoteAddr, use.UserAgent),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func canaryWebhookBody(canary database.CanaryToken, use canaryUse) ([]byte, error) {
+	type payload struct {
+		CanaryID uuid.UUID `json:"canary_id"`
+		Kind     string    `json:"kind"`
+		Label    string    `json:"label"`
+		Use      canaryUse `json:"use"`
+	}
+	return json.Marshal(payload{
+		CanaryID: canary.ID,
+		Kind:     canary.Kind,
+		Label:    canary.Label,
+		Use:      use,
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	chaosHeader = "X-Chaos-Injected"
+	// chaosDefaultRoute matches every route without a rule of its own.
+	chaosDefaultRoute = "*"
+)
+
+// chaosRule is the fault injected into one route: a delay before the
+// handler runs and a share of requests answered with an error instead.
+type chaosRule struct {
+	LatencyMS int     `json:"latency_ms"`
+	JitterMS  int     `json:"jitter_ms"`
+	ErrorRate float64 `json:"error_rate"`
+	Status    int     `json:"status"`
+}
+
+type chaosConfig struct {
+	rules  map[string]chaosRule
+	random func() float64
+}
+
+// loadChaosConfig reads CHAOS_RULES, a JSON object of fault rules keyed by
+// route pattern, or "*" for every other route:
+//
+//	{"GET /api/chirps": {"latency_ms": 200, "jitter_ms": 100, "error_rate": 0.1, "status": 503}}
+//
+// Faults are only injected when PLATFORM is dev or staging; elsewhere the
+// rules are ignored so a leftover variable can't break production.
+func loadChaosConfig(routes []route) (chaosConfig, error) {
+	cfg := chaosConfig{rules: map[string]chaosRule{}, random: rand.Float64}
+	raw := os.Getenv("CHAOS_RULES")
+	if raw == "" {
+		return cfg, nil
+	}
+	if platform := os.Getenv("PLATFORM"); platform != "dev" && platform != "staging" {
+		log.Printf("Ignoring CHAOS_RULES: fault injection is only enabled when PLATFORM is dev or staging")
+		return cfg, nil
+	}
+	var rules map[string]chaosRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return cfg, fmt.Errorf("invalid CHAOS_RULES: %w", err)
+	}
+	known := map[string]bool{chaosDefaultRoute: true}
+	for _, rt := range routes {
+		known[rt.pattern] = true
+	}
+	for pattern, rule := range rules {
+		if !known[pattern] {
+			return cfg, fmt.Errorf("invalid CHAOS_RULES: no route %q", pattern)
+		}
+		if rule.LatencyMS < 0 || rule.JitterMS < 0 {
+			return cfg, fmt.Errorf("invalid CHAOS_RULES: negative latency for %q", pattern)
+		}
+		if rule.ErrorRate < 0 || rule.ErrorRate > 1 {
+			return cfg, fmt.Errorf("invalid CHAOS_RULES: error_rate for %q must be between 0 and 1", pattern)
+		}
+		if rule.Status == 0 {
+			rule.Status = http.StatusServiceUnavailable
+		}
+		if rule.Status < 400 || rule.Status > 599 {
+			return cfg, fmt.Errorf("invalid CHAOS_RULES: status for %q must be 4xx or 5xx", pattern)
+		}
+		cfg.rules[pattern] = rule
+	}
+	if len(cfg.rules) > 0 {
+		log.Printf("Fault injection enabled for %d route rules", len(cfg.rules))
+	}
+	return cfg, nil
+}
+
+func (c chaosConfig) rule(pattern string) (chaosRule, bool) {
+	if rule, ok := c.rules[pattern]; ok {
+		return rule, true
+	}
+	rule, ok := c.rules[chaosDefaultRoute]
+	return rule, ok
+}
+
+// middlewareChaos delays and fails requests to pattern as its chaos rule
+// says. Injected responses carry X-Chaos-Injected so they can be told apart
+// from real failures.
+func (cfg *apiConfig) middlewareChaos(pattern string, next http.Handler) http.Handler {
+	rule, ok := cfg.chaos.rule(pattern)
+	if !ok {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay := time.Duration(rule.LatencyMS) * time.Millisecond
+		if rule.JitterMS > 0 {
+			delay += time.Duration(cfg.chaos.random() * float64(rule.JitterMS) * float64(time.Millisecond))
+		}
+		if delay > 0 {
+			w.Header().Add(chaosHeader, "latency")
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if rule.ErrorRate > 0 && cfg.chaos.random() < rule.ErrorRate {
+			w.Header().Add(chaosHeader, "error")
+			respondWithError(w, rule.Status, "Injected fault", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
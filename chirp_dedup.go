@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/counter"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/google/uuid"
+)
+
+// chirpBodyHash identifies a chirp body for duplicate detection. It is taken
+// before links are shortened, since each post gets fresh short codes, and
+// ignores differences in whitespace.
+func chirpBodyHash(body string) sql.NullString {
+	normalized := strings.Join(strings.Fields(body), " ")
+	if normalized == "" {
+		return sql.NullString{}
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return sql.NullString{String: hex.EncodeToString(sum[:]), Valid: true}
+}
+
+// recentDuplicateChirp returns the ID of a chirp with the same body that
+// userID posted within the chirp.duplicate_window_seconds setting, or
+// uuid.Nil if there is none. A window of zero turns the check off.
+func (cfg *apiConfig) recentDuplicateChirp(ctx context.Context, userID uuid.UUID, bodyHash sql.NullString) (uuid.UUID, error) {
+	window := time.Duration(cfg.settings.Int(ctx, settings.ChirpDuplicateWindowSeconds)) * time.Second
+	if window == 0 || !bodyHash.Valid {
+		return uuid.Nil, nil
+	}
+	id, err := cfg.database.FindRecentDuplicateMessage(ctx, database.FindRecentDuplicateMessageParams{
+		UserID:    userID,
+		BodyHash:  bodyHash,
+		CreatedAt: time.Now().Add(-window),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	return id, err
+}
+
+func respondWithDuplicateChirp(w http.ResponseWriter, existingID uuid.UUID) {
+	type errorResponse struct {
+		Error           string    `json:"error"`
+		ExistingChirpID uuid.UUID `json:"existing_chirp_id"`
+	}
+	w.Header().Set("Location", "/api/chirps/"+existingID.String())
+	respondWithJSON(w, http.StatusConflict, errorResponse{
+		Error:           "You just posted this chirp",
+		ExistingChirpID: existingID,
+	})
+}
+
+// duplicateChirpsFlagged reports whether a duplicate chirp is held for
+// moderation instead of rejected, per the chirp.duplicate_action setting.
+func (cfg *apiConfig) duplicateChirpsFlagged(ctx context.Context) bool {
+	return cfg.settings.Get(ctx, settings.ChirpDuplicateAction) == settings.DuplicateFlag
+}
+
+// allowChirpPost limits how many chirps userID can post per minute, per the
+// chirp.max_per_minute setting, using the shared counter store so the limit
+// holds across instances. It writes the 429 itself and returns false when
+// the limit is hit. A limit of zero turns it off, and if the counter store
+// is unreachable the chirp is let through.
+func (cfg *apiConfig) allowChirpPost(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	perMinute := cfg.settings.Int(r.Context(), settings.ChirpMaxPerMinute)
+	if perMinute == 0 {
+		return true
+	}
+	limiter := counter.Limiter{
+		Store:  cfg.counters,
+		Name:   "chirp",
+		Limit:  int64(perMinute),
+		Window: time.Minute,
+	}
+	decision, err := limiter.Allow(r.Context(), userID.String(), time.Now())
+	if err != nil {
+		log.Printf("Error checking chirp rate limit: %s", err)
+		return true
+	}
+	if decision.Allowed {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(time.Until(decision.ResetAt))))
+	respondWithError(w, http.StatusTooManyRequests, "You're posting too fast, try again later", nil)
+	return false
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/google/uuid"
+)
+
+// editedChirpStatus is the status of a chirp after an edit. A chirp
+// waiting for a moderator keeps waiting; a published one goes through
+// moderation again, so an edit can't slip past it.
+func editedChirpStatus(current string, accountCreatedAt time.Time, probation time.Duration, body string, profanityAction profanity.Action) string {
+	if current == chirpStatusPending {
+		return chirpStatusPending
+	}
+	return initialChirpStatus(accountCreatedAt, probation, body, profanityAction)
+}
+
+func (cfg *apiConfig) handlerChirpsUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	type returnVals struct {
+		Id             uuid.UUID  `json:"id"`
+		CreatedAt      string     `json:"created_at"`
+		UpdatedAt      string     `json:"updated_at"`
+		Body           string     `json:"body"`
+		UserID         uuid.UUID  `json:"user_id"`
+		Status         string     `json:"status"`
+		Visibility     string     `json:"visibility"`
+		InReplyTo      *uuid.UUID `json:"in_reply_to,omitempty"`
+		PostedBy       *uuid.UUID `json:"posted_by,omitempty"`
+		ExpiresAt      *string    `json:"expires_at,omitempty"`
+		ContentWarning *string    `json:"content_warning"`
+		LikeCount      int64      `json:"like_count"`
+		LikedByMe      bool       `json:"liked_by_me"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	token, err := cfg.accessToken(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
+		return
+	}
+	userID, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeChirpsWrite)
+	if err != nil {
+		respondWithTokenError(w, err)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	message, err := cfg.database.GetMessageByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithDBError(w, "Couldn't get chirp", err)
+		return
+	}
+	if message.Status == chirpStatusRejected {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+	if message.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You are not allowed to edit this chirp", nil)
+		return
+	}
+	user, err := cfg.database.GetUserByID(r.Context(), userID)
+	if err != nil || user.IsDeleted {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find user", err)
+		return
+	}
+
+	bodyHash := chirpBodyHash(params.Body)
+	body, shortLinks, err := cfg.shortenLinks(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't shorten links", err)
+		return
+	}
+	if len(body) > cfg.settings.Int(r.Context(), settings.ChirpMaxLength) {
+		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+		return
+	}
+	profanityCheck := cfg.profanityFilter(r.Context()).Check(profanity.ContextChirpBody, body)
+	if profanityCheck.Action == profanity.ActionReject {
+		respondWithError(w, http.StatusBadRequest, "Chirp contains prohibited language", nil)
+		return
+	}
+	probation := time.Duration(cfg.settings.Int(r.Context(), settings.ProbationHours)) * time.Hour
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	updated, err := qtx.UpdateMessageBody(r.Context(), database.UpdateMessageBodyParams{
+		Body:     profanityCheck.Text,
+		Status:   editedChirpStatus(message.Status, user.CreatedAt, probation, body, profanityCheck.Action),
+		BodyHash: bodyHash,
+		ID:       message.ID,
+		UserID:   userID,
+	})
+	if err != nil {
+		respondWithDBError(w, "Couldn't update chirp", err)
+		return
+	}
+	for _, link := range shortLinks {
+		err = qtx.CreateLinkRedirect(r.Context(), database.CreateLinkRedirectParams{
+			Code:      link.Code,
+			TargetUrl: link.Target,
+			MessageID: updated.ID,
+			UserID:    userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save shortened link", err)
+			return
+		}
+	}
+	if err := qtx.DeleteChirpHashtags(r.Context(), updated.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save hashtags", err)
+		return
+	}
+	if tags := extractHashtags(updated.Body); len(tags) > 0 {
+		err = qtx.AddChirpHashtags(r.Context(), database.AddChirpHashtagsParams{Tags: tags, ChirpID: updated.ID})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save hashtags", err)
+			return
+		}
+	}
+	err = appendEvent(r.Context(), qtx, eventChirpEdited, aggregateChirp, updated.ID, map[string]any{"status": updated.Status})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	likes, err := cfg.chirpLikes(r.Context(), userID, []uuid.UUID{updated.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get likes", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnVals{
+		Id:             updated.ID,
+		CreatedAt:      updated.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      updated.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Body:           updated.Body,
+		UserID:         updated.UserID,
+		Status:         updated.Status,
+		Visibility:     updated.Visibility,
+		InReplyTo:      nullUUIDPtr(updated.ParentID),
+		PostedBy:       nullUUIDPtr(updated.PostedByID),
+		ExpiresAt:      nullTimeString(updated.ExpiresAt),
+		ContentWarning: nullStringPtr(updated.ContentWarning),
+		LikeCount:      likes[updated.ID].count,
+		LikedByMe:      likes[updated.ID].likedByMe,
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+)
+
+const chirpExpiryPurgeInterval = time.Minute
+
+var (
+	errChirpExpiryPast    = errors.New("expires_at must be in the future")
+	errChirpExpiryTooLate = errors.New("expires_at is further out than your maximum chirp lifetime")
+)
+
+// chirpExpiry checks a requested expiry against the longest lifetime the
+// author is allowed. A nil request means the chirp never expires.
+func chirpExpiry(now time.Time, requested *time.Time, maxLifetime time.Duration) (sql.NullTime, error) {
+	if requested == nil {
+		return sql.NullTime{}, nil
+	}
+	if !requested.After(now) {
+		return sql.NullTime{}, errChirpExpiryPast
+	}
+	if requested.Sub(now) > maxLifetime {
+		return sql.NullTime{}, errChirpExpiryTooLate
+	}
+	return sql.NullTime{Time: requested.UTC(), Valid: true}, nil
+}
+
+// maxChirpLifetime is how long the user's chirps may live before expiring.
+// Chirpy Red members get a longer ceiling.
+func (cfg *apiConfig) maxChirpLifetime(ctx context.Context, user database.User) time.Duration {
+	key := settings.ChirpMaxLifetimeHours
+	if user.IsChirpyRed {
+		key = settings.ChirpRedMaxLifetimeHours
+	}
+	return time.Duration(cfg.settings.Int(ctx, key)) * time.Hour
+}
+
+// runChirpExpiryPurge deletes chirps once they have expired. Read queries
+// already hide them, so this only reclaims the rows.
+func (cfg *apiConfig) runChirpExpiryPurge(ctx context.Context) {
+	ticker := time.NewTicker(chirpExpiryPurgeInterval)
+	defer ticker.Stop()
+	for {
+		cfg.purgeExpiredChirps(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) purgeExpiredChirps(ctx context.Context) {
+	n, err := cfg.deleteExpiredChirps(ctx)
+	if err != nil {
+		log.Printf("Error purging expired chirps: %s", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d expired chirps", n)
+	}
+}
+
+// deleteExpiredChirps deletes expired chirps and records a ChirpDeleted
+// event for each in the same transaction.
+func (cfg *apiConfig) deleteExpiredChirps(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	deleted, err := qtx.DeleteExpiredMessages(ctx, sql.NullTime{Time: time.Now().UTC(), Valid: true})
+	if err != nil {
+		return 0, err
+	}
+	for _, chirp := range deleted {
+		err := appendEvent(ctx, qtx, eventChirpDeleted, aggregateChirp, chirp.ID, map[string]any{
+			"user_id": chirp.UserID,
+			"reason":  "expired",
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(deleted), tx.Commit()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/google/uuid"
+)
+
+// newChirpID returns a time-ordered UUIDv7 for a new chirp and the time
+// embedded in it. The chirp is stored with that time as created_at, so a
+// v7 ID is enough to resume pagination without looking the chirp up.
+func newChirpID() (uuid.UUID, time.Time, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.Nil, time.Time{}, err
+	}
+	createdAt, _ := chirpIDTime(id)
+	return id, createdAt, nil
+}
+
+// chirpIDTime returns the creation time carried by a v7 chirp ID. Chirps
+// created before IDs were time-ordered have random v4 IDs, which carry none.
+func chirpIDTime(id uuid.UUID) (time.Time, bool) {
+	if id.Version() != 7 {
+		return time.Time{}, false
+	}
+	sec, nsec := id.Time().UnixTime()
+	return time.Unix(sec, nsec).UTC(), true
+}
+
+// chirpCursor is the (created_at, id) position of chirp id.
+func (cfg *apiConfig) chirpCursor(ctx context.Context, id uuid.UUID) (api.Cursor, error) {
+	createdAt, ok := chirpIDTime(id)
+	if !ok {
+		chirp, err := cfg.database.GetMessageByID(ctx, id)
+		if err != nil {
+			return api.Cursor{}, err
+		}
+		createdAt = chirp.CreatedAt
+	}
+	return api.Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// parseChirpCursor accepts either an opaque cursor from next_cursor or a
+// bare chirp ID, which clients polling for new chirps pass as after.
+func (cfg *apiConfig) parseChirpCursor(ctx context.Context, raw string) (api.Cursor, error) {
+	if id, err := uuid.Parse(raw); err == nil {
+		return cfg.chirpCursor(ctx, id)
+	}
+	return api.DecodeCursor(raw)
+}
+
+func nullCursor(c *api.Cursor) (sql.NullTime, uuid.NullUUID) {
+	if c == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: c.CreatedAt, Valid: true}, uuid.NullUUID{UUID: c.ID, Valid: true}
+}
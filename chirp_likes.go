@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/google/uuid"
+)
+
+type chirpLikeCount struct {
+	count     int64
+	likedByMe bool
+}
+
+// chirpLikes counts the likes on a page of chirps in one query. Chirps
+// nobody has liked are missing from the map, which reads as zero.
+func (cfg *apiConfig) chirpLikes(ctx context.Context, viewer uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]chirpLikeCount, error) {
+	likes := map[uuid.UUID]chirpLikeCount{}
+	if len(ids) == 0 {
+		return likes, nil
+	}
+	rows, err := cfg.database.CountChirpLikes(ctx, database.CountChirpLikesParams{
+		ViewerID: viewer,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		likes[row.ChirpID] = chirpLikeCount{count: row.LikeCount, likedByMe: row.LikedByMe}
+	}
+	return likes, nil
+}
+
+func (cfg *apiConfig) handlerChirpLike(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, true)
+}
+
+func (cfg *apiConfig) handlerChirpUnlike(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, false)
+}
+
+// setChirpLike likes or unlikes a chirp for the caller. Both are
+// idempotent, so a client retrying a request gets the same answer.
+func (cfg *apiConfig) setChirpLike(w http.ResponseWriter, r *http.Request, like bool) {
+	type returnVals struct {
+		ChirpID   uuid.UUID `json:"chirp_id"`
+		LikeCount int64     `json:"like_count"`
+		LikedByMe bool      `json:"liked_by_me"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	token, err := cfg.accessToken(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
+		return
+	}
+	userID, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeChirpsWrite)
+	if err != nil {
+		respondWithTokenError(w, err)
+		return
+	}
+	message, err := cfg.database.GetMessageByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithDBError(w, "Couldn't get chirp", err)
+		return
+	}
+	visible, err := cfg.chirpVisibleTo(r.Context(), message.Visibility, message.UserID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+	if message.Status != chirpStatusPublished || !visible {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+
+	if like {
+		err = cfg.database.LikeChirp(r.Context(), database.LikeChirpParams{UserID: userID, ChirpID: chirpID})
+	} else {
+		err = cfg.database.UnlikeChirp(r.Context(), database.UnlikeChirpParams{UserID: userID, ChirpID: chirpID})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update like", err)
+		return
+	}
+	likes, err := cfg.chirpLikes(r.Context(), userID, []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count likes", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnVals{
+		ChirpID:   chirpID,
+		LikeCount: likes[chirpID].count,
+		LikedByMe: likes[chirpID].likedByMe,
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+)
+
+// Free accounts can post chirp.free_daily_quota chirps in any 24 hours.
+// Chirpy Red members and bots post without a daily limit; bots are held
+// to their monthly write quota instead.
+const chirpQuotaWindow = 24 * time.Hour
+
+// chirpQuota is how much of the daily quota a user has used.
+type chirpQuota struct {
+	limit int
+	used  int
+	// resetAt is when the oldest chirp in the window leaves it.
+	resetAt time.Time
+}
+
+func (q chirpQuota) exceeded() bool {
+	return q.used >= q.limit
+}
+
+func (q chirpQuota) setHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Chirp-Quota-Limit", strconv.Itoa(q.limit))
+	w.Header().Set("X-Chirp-Quota-Remaining", strconv.Itoa(max(q.limit-q.used, 0)))
+	w.Header().Set("X-Chirp-Quota-Reset", strconv.FormatInt(q.resetAt.Unix(), 10))
+}
+
+// dailyChirpQuota returns user's daily chirp quota, or false if they have
+// none. Chirps count until 24 hours after they were posted, so the window
+// slides rather than resetting at midnight.
+func (cfg *apiConfig) dailyChirpQuota(ctx context.Context, user database.User, now time.Time) (chirpQuota, bool, error) {
+	limit := cfg.settings.Int(ctx, settings.ChirpFreeDailyQuota)
+	if limit == 0 {
+		return chirpQuota{}, false, nil
+	}
+	if tier, _ := cfg.usageLimits(ctx, user.ID, user.IsChirpyRed); tier != userTierFree {
+		return chirpQuota{}, false, nil
+	}
+	recent, err := cfg.database.CountRecentMessagesByUser(ctx, database.CountRecentMessagesByUserParams{
+		UserID:    user.ID,
+		CreatedAt: now.Add(-chirpQuotaWindow),
+	})
+	if err != nil {
+		return chirpQuota{}, false, err
+	}
+	return chirpQuota{
+		limit:   limit,
+		used:    int(recent.Count),
+		resetAt: recent.OldestCreatedAt.Add(chirpQuotaWindow),
+	}, true, nil
+}
+
+// allowChirpQuota enforces the daily chirp quota, setting the quota headers
+// and writing the error itself when the quota is used up. Like the monthly
+// quota, free users get 402 because upgrading lifts it.
+func (cfg *apiConfig) allowChirpQuota(w http.ResponseWriter, r *http.Request, user database.User) bool {
+	now := time.Now()
+	quota, limited, err := cfg.dailyChirpQuota(r.Context(), user, now)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check chirp quota", err)
+		return false
+	}
+	if !limited {
+		return true
+	}
+	if !quota.exceeded() {
+		// This chirp will count once it is created.
+		quota.used++
+		if quota.used == 1 {
+			quota.resetAt = now.Add(chirpQuotaWindow)
+		}
+		quota.setHeaders(w)
+		return true
+	}
+	quota.setHeaders(w)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(quota.resetAt.Sub(now))))
+	respondWithError(w, usageExceededStatus(userTierFree), "Daily chirp quota reached; upgrade to Chirpy Red to post without a limit", nil)
+	return false
+}
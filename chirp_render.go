@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/markdown"
+)
+
+// parseChirpRender reads the render parameter of a chirp read. Bodies are
+// stored and returned as raw text; render=html adds a rendered_body with
+// the markdown subset turned into sanitized HTML for web clients.
+func parseChirpRender(w http.ResponseWriter, r *http.Request) (renderHTML bool, ok bool) {
+	switch r.URL.Query().Get("render") {
+	case "":
+		return false, true
+	case "html":
+		return true, true
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid render parameter", nil)
+		return false, false
+	}
+}
+
+// renderedBody is the rendered_body field for an already cleaned body. It
+// is omitted unless HTML was asked for and the body isn't hidden.
+func renderedBody(renderHTML bool, body string) *string {
+	if !renderHTML || body == "" {
+		return nil
+	}
+	rendered := markdown.ToHTML(body)
+	return &rendered
+}
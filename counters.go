@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/counter"
+)
+
+const (
+	fileserverHitsKey    = "fileserver_hits"
+	counterPurgeInterval = 10 * time.Minute
+)
+
+// configureCounters picks where counters and rate limits live. The default
+// keeps them in memory, which is only correct for a single instance;
+// COUNTER_STORE=postgres shares them between every instance using the
+// database.
+func (cfg *apiConfig) configureCounters(ctx context.Context) error {
+	switch store := os.Getenv("COUNTER_STORE"); store {
+	case "", "memory":
+		cfg.counters = counter.NewMemory()
+	case "postgres":
+		pg := counter.NewPostgres(cfg.database.Queries)
+		cfg.counters = pg
+		go runCounterPurge(ctx, pg)
+	default:
+		return fmt.Errorf("unknown COUNTER_STORE %q", store)
+	}
+	return nil
+}
+
+func runCounterPurge(ctx context.Context, store *counter.Postgres) {
+	ticker := time.NewTicker(counterPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := store.Purge(ctx, time.Now()); err != nil {
+			log.Printf("Error purging expired counters: %s", err)
+		}
+	}
+}
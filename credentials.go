@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/mail"
+)
+
+type rotateCredentialsResponse struct {
+	RefreshTokensRevoked   int64     `json:"refresh_tokens_revoked"`
+	OAuthTokensRevoked     int64     `json:"oauth_tokens_revoked"`
+	PasswordChangeRequired bool      `json:"password_change_required"`
+	RotatedAt              time.Time `json:"rotated_at"`
+}
+
+// handlerRotateCredentials signs the caller out everywhere: every refresh
+// token is revoked and the token version bumped, so access tokens already
+// issued stop working too, including the one used for this request. With
+// require_password_change the next login can only change the password.
+// Access tokens granted to OAuth apps are revoked as well, so a leaked
+// password can't keep working through an app it was used to approve.
+func (cfg *apiConfig) handlerRotateCredentials(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		RequirePasswordChange bool `json:"require_password_change"`
+	}
+
+	user, _ := userFromContext(r.Context())
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if params.RequirePasswordChange && cfg.passwordLoginDisabled() {
+		respondWithError(w, http.StatusBadRequest, "Passwords are managed by your identity provider", nil)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate credentials", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	revoked, err := qtx.RevokeUserRefreshTokens(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate credentials", err)
+		return
+	}
+	oauthRevoked, err := qtx.RevokeUserOAuthAccessTokens(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate credentials", err)
+		return
+	}
+	if _, err := qtx.RotateUserCredentials(r.Context(), database.RotateUserCredentialsParams{
+		RequirePasswordChange: params.RequirePasswordChange,
+		ID:                    user.ID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate credentials", err)
+		return
+	}
+	resp := rotateCredentialsResponse{
+		RefreshTokensRevoked:   revoked,
+		OAuthTokensRevoked:     oauthRevoked,
+		PasswordChangeRequired: params.RequirePasswordChange,
+		RotatedAt:              time.Now().UTC(),
+	}
+	if err := enqueueEmail(r.Context(), qtx, cfg.credentialRotationEmail(r.Context(), user, resp)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate credentials", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate credentials", err)
+		return
+	}
+
+	cfg.recordAudit(r.Context(), user.ID, "user.credentials_rotated", "user", user.ID.String(), map[string]any{
+		"refresh_tokens_revoked":   revoked,
+		"oauth_tokens_revoked":     oauthRevoked,
+		"password_change_required": params.RequirePasswordChange,
+	})
+	// The cookie holds an access token that no longer works.
+	cfg.setAccessTokenCookie(w, "", time.Unix(0, 0))
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// credentialRotationEmail tells user their sessions were ended, in case
+// it wasn't them.
+func (cfg *apiConfig) credentialRotationEmail(ctx context.Context, user database.User, rotated rotateCredentialsResponse) mail.Message {
+	site := cfg.siteName(ctx)
+	body := fmt.Sprintf("Your %s account was signed out everywhere at %s and %d sessions were ended.",
+		site, rotated.RotatedAt.Format(time.RFC1123), rotated.RefreshTokensRevoked)
+	if rotated.OAuthTokensRevoked > 0 {
+		body += " Apps you had connected to your account will need to be approved again."
+	}
+	if rotated.PasswordChangeRequired {
+		body += " You'll need to choose a new password the next time you sign in."
+	}
+	body += " If this wasn't you, contact support right away."
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Your " + site + " account was secured",
+		Body:    body,
+	}
+}
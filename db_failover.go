@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	dbProbeMinBackoff = 500 * time.Millisecond
+	dbProbeMaxBackoff = 30 * time.Second
+	dbProbeTimeout    = 5 * time.Second
+	// dbMaxIdleConns is database/sql's default idle pool size.
+	dbMaxIdleConns = 2
+)
+
+// isConnectionError reports whether err means the database connection is
+// gone rather than that the query itself failed. A read-only error counts
+// too: after a failover the old primary comes back as a replica.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03", "25006":
+			// admin_shutdown, crash_shutdown, cannot_connect_now,
+			// read_only_sql_transaction
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+	return false
+}
+
+// dbFailover wraps the connection pool for the generated queries. The first
+// connection error marks the database down: idle connections are dropped so
+// the next dial resolves the primary's address again, and a background probe
+// pings with backoff until it answers. While down, requests are turned away
+// by middlewareDBAvailable instead of queueing behind dial timeouts.
+type dbFailover struct {
+	db  *sql.DB
+	now func() time.Time
+
+	mu        sync.Mutex
+	down      bool
+	downSince time.Time
+	retryAt   time.Time
+}
+
+func newDBFailover(db *sql.DB) *dbFailover {
+	return &dbFailover{db: db, now: time.Now}
+}
+
+func (f *dbFailover) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := f.db.ExecContext(ctx, query, args...)
+	f.observe(err)
+	return res, err
+}
+
+func (f *dbFailover) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := f.db.PrepareContext(ctx, query)
+	f.observe(err)
+	return stmt, err
+}
+
+func (f *dbFailover) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := f.db.QueryContext(ctx, query, args...)
+	f.observe(err)
+	return rows, err
+}
+
+func (f *dbFailover) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := f.db.QueryRowContext(ctx, query, args...)
+	f.observe(row.Err())
+	return row
+}
+
+func (f *dbFailover) observe(err error) {
+	if !isConnectionError(err) {
+		return
+	}
+	f.mu.Lock()
+	if f.down {
+		f.mu.Unlock()
+		return
+	}
+	f.down = true
+	f.downSince = f.now()
+	f.retryAt = f.downSince.Add(dbProbeMinBackoff)
+	f.mu.Unlock()
+
+	log.Printf("Database connection lost, failing requests until it is back: %s", err)
+	// Dropping the idle pool closes connections to the old primary.
+	f.db.SetMaxIdleConns(0)
+	f.db.SetMaxIdleConns(dbMaxIdleConns)
+	go f.probe()
+}
+
+func (f *dbFailover) probe() {
+	backoff := dbProbeMinBackoff
+	for {
+		time.Sleep(backoff)
+		ctx, cancel := context.WithTimeout(context.Background(), dbProbeTimeout)
+		err := f.db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			f.mu.Lock()
+			f.down = false
+			downtime := f.now().Sub(f.downSince)
+			f.mu.Unlock()
+			log.Printf("Database connection restored after %s", downtime.Round(time.Millisecond))
+			return
+		}
+		backoff = min(backoff*2, dbProbeMaxBackoff)
+		f.mu.Lock()
+		f.retryAt = f.now().Add(backoff)
+		f.mu.Unlock()
+	}
+}
+
+// unavailable reports whether the database is down and, if so, how many
+// seconds until the next reconnect attempt.
+func (f *dbFailover) unavailable() (bool, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.down {
+		return false, 0
+	}
+	return true, retryAfterSeconds(f.retryAt.Sub(f.now()))
+}
+
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
+
+// middlewareDBAvailable answers 503 with Retry-After while the database is
+// down. Probes and the static app don't touch the database.
+func (cfg *apiConfig) middlewareDBAvailable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.dbFailover == nil || isProbePath(r.URL.Path) || strings.HasPrefix(r.URL.Path, "/app/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if down, retryAfter := cfg.dbFailover.unavailable(); down {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			respondWithError(w, http.StatusServiceUnavailable, "Service temporarily unavailable, please retry", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
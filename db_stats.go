@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+// dbQueryLogThreshold is the statement count above which a request is
+// logged, since that many queries is usually an N+1 loop.
+const dbQueryLogThreshold = 25
+
+// dbStats counts the statements run on behalf of one request. Handlers may
+// query from several goroutines, so the counters are atomic.
+type dbStats struct {
+	queries  atomic.Int64
+	duration atomic.Int64
+}
+
+type dbStatsKey struct{}
+
+func withDBStats(ctx context.Context) (context.Context, *dbStats) {
+	stats := &dbStats{}
+	return context.WithValue(ctx, dbStatsKey{}, stats), stats
+}
+
+func dbStatsFromContext(ctx context.Context) *dbStats {
+	stats, _ := ctx.Value(dbStatsKey{}).(*dbStats)
+	return stats
+}
+
+// countingDB records every statement in the dbStats of the context it runs
+// with. Statements run through a transaction use the *sql.Tx directly and
+// aren't counted. Query time stops when the first row is ready, not when
+// the rows have been read.
+type countingDB struct {
+	database.DBTX
+}
+
+func (c countingDB) observe(ctx context.Context, start time.Time) {
+	if stats := dbStatsFromContext(ctx); stats != nil {
+		stats.queries.Add(1)
+		stats.duration.Add(int64(time.Since(start)))
+	}
+}
+
+func (c countingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer c.observe(ctx, time.Now())
+	return c.DBTX.ExecContext(ctx, query, args...)
+}
+
+func (c countingDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	defer c.observe(ctx, time.Now())
+	return c.DBTX.PrepareContext(ctx, query)
+}
+
+func (c countingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer c.observe(ctx, time.Now())
+	return c.DBTX.QueryContext(ctx, query, args...)
+}
+
+func (c countingDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer c.observe(ctx, time.Now())
+	return c.DBTX.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/redact"
+)
+
+const (
+	debugCaptureHeader = "X-Debug-Capture"
+	// debugCaptureLimit is how much of each body is kept. Longer bodies
+	// are logged as truncated, without their content.
+	debugCaptureLimit = 64 << 10
+)
+
+type debugCaptureConfig struct {
+	secret string
+	routes map[string]bool
+}
+
+// loadDebugCaptureConfig reads DEBUG_CAPTURE_ROUTES, a comma separated list
+// of route patterns such as "POST /api/chirps" whose bodies are always
+// logged, and DEBUG_CAPTURE_SECRET, which signs X-Debug-Capture headers for
+// capturing single requests on any route.
+func loadDebugCaptureConfig(routes []route) (debugCaptureConfig, error) {
+	cfg := debugCaptureConfig{
+		secret: os.Getenv("DEBUG_CAPTURE_SECRET"),
+		routes: map[string]bool{},
+	}
+	known := map[string]bool{}
+	for _, rt := range routes {
+		known[rt.pattern] = true
+	}
+	for _, pattern := range strings.Split(os.Getenv("DEBUG_CAPTURE_ROUTES"), ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if !known[pattern] {
+			return cfg, fmt.Errorf("invalid DEBUG_CAPTURE_ROUTES: no route %q", pattern)
+		}
+		cfg.routes[pattern] = true
+	}
+	return cfg, nil
+}
+
+type debugCaptureEntry struct {
+	Route             string `json:"route"`
+	Method            string `json:"method"`
+	Path              string `json:"path"`
+	Query             string `json:"query,omitempty"`
+	Status            int    `json:"status"`
+	DurationMS        int64  `json:"duration_ms"`
+	DBQueries         int64  `json:"db_queries"`
+	DBTimeMS          int64  `json:"db_time_ms"`
+	RequestBody       string `json:"request_body,omitempty"`
+	RequestTruncated  bool   `json:"request_truncated,omitempty"`
+	ResponseBody      string `json:"response_body,omitempty"`
+	ResponseTruncated bool   `json:"response_truncated,omitempty"`
+}
+
+// middlewareDebugCapture logs the redacted request and response bodies of
+// requests to a route listed in DEBUG_CAPTURE_ROUTES, or of any request
+// carrying a valid X-Debug-Capture header.
+func (cfg *apiConfig) middlewareDebugCapture(pattern string, next http.Handler) http.Handler {
+	always := cfg.debugCapture.routes[pattern]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !always && !auth.VerifyDebugHeader(cfg.debugCapture.secret, r.Header.Get(debugCaptureHeader), time.Now()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		var reqBody []byte
+		if r.Body != nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(r.Body, debugCaptureLimit+1))
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+		}
+		cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(cw, r)
+
+		entry := debugCaptureEntry{
+			Route:             pattern,
+			Method:            r.Method,
+			Path:              r.URL.Path,
+			Status:            cw.status,
+			DurationMS:        time.Since(start).Milliseconds(),
+			RequestTruncated:  len(reqBody) > debugCaptureLimit,
+			ResponseTruncated: cw.truncated,
+		}
+		if db := dbStatsFromContext(r.Context()); db != nil {
+			entry.DBQueries = db.queries.Load()
+			entry.DBTimeMS = time.Duration(db.duration.Load()).Milliseconds()
+		}
+		if r.URL.RawQuery != "" {
+			entry.Query, _ = redact.Form([]byte(r.URL.RawQuery))
+		}
+		if !entry.RequestTruncated {
+			entry.RequestBody = redact.Body(r.Header.Get("Content-Type"), reqBody)
+		}
+		if !entry.ResponseTruncated {
+			entry.ResponseBody = redact.Body(cw.Header().Get("Content-Type"), cw.body.Bytes())
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			log.Printf("Error encoding debug capture: %s", err)
+			return
+		}
+		log.Printf("debug capture %s", line)
+	})
+}
+
+// captureWriter keeps a copy of the first debugCaptureLimit bytes of a
+// response.
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+}
+
+func (w *captureWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	if room := debugCaptureLimit - w.body.Len(); len(b) > room {
+		w.body.Write(b[:max(room, 0)])
+		w.truncated = true
+	} else {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// streaming handlers can still flush.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// handlerAdminDebugCapture issues an X-Debug-Capture header value, so an
+// operator can have a single client's requests logged while reproducing a
+// problem.
+func (cfg *apiConfig) handlerAdminDebugCapture(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		TTLSeconds int `json:"ttl_seconds"`
+	}
+	type returnVals struct {
+		Header    string    `json:"header"`
+		Value     string    `json:"value"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if cfg.debugCapture.secret == "" {
+		respondWithError(w, http.StatusNotFound, "Debug capture is not configured", nil)
+		return
+	}
+	params := parameters{TTLSeconds: 15 * 60}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	ttl := time.Duration(params.TTLSeconds) * time.Second
+	if ttl <= 0 || ttl > auth.DebugHeaderMaxTTL {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("ttl_seconds must be between 1 and %d", int(auth.DebugHeaderMaxTTL.Seconds())), nil)
+		return
+	}
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "debug_capture.issued", "debug_capture", "", map[string]any{"expires_at": expires})
+	respondWithJSON(w, http.StatusCreated, returnVals{
+		Header:    debugCaptureHeader,
+		Value:     auth.SignDebugHeader(cfg.debugCapture.secret, expires),
+		ExpiresAt: expires.UTC(),
+	})
+}
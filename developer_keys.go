@@ -0,0 +1,292 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// Developer API keys identify third-party apps rather than users. They are
+// only accepted on public read endpoints, where each request is counted
+// against the key's daily quota. Requests without a key are not metered.
+const (
+	developerKeyHeader    = "X-API-Key"
+	developerUsageDays    = 30
+	developerTierFree     = "free"
+	developerTierStandard = "standard"
+	developerTierPartner  = "partner"
+)
+
+var developerTierQuotas = map[string]int32{
+	developerTierFree:     1000,
+	developerTierStandard: 25000,
+	developerTierPartner:  250000,
+}
+
+// developerQuotaDay is the UTC day a request is counted against.
+func developerQuotaDay(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// developerQuotaReset is when the quota for now's day starts over.
+func developerQuotaReset(now time.Time) time.Time {
+	return developerQuotaDay(now).AddDate(0, 0, 1)
+}
+
+type developerKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Tier       string     `json:"tier"`
+	DailyQuota int32      `json:"daily_quota"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func newDeveloperKeyResponse(k database.DeveloperApiKey) developerKeyResponse {
+	resp := developerKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Tier:       k.Tier,
+		DailyQuota: developerTierQuotas[k.Tier],
+		CreatedAt:  k.CreatedAt,
+	}
+	if k.RevokedAt.Valid {
+		resp.RevokedAt = &k.RevokedAt.Time
+	}
+	return resp
+}
+
+func (cfg *apiConfig) developerKey(r *http.Request) (database.DeveloperApiKey, error) {
+	key := strings.TrimSpace(r.Header.Get(developerKeyHeader))
+	if key == "" {
+		return database.DeveloperApiKey{}, errors.New("missing " + developerKeyHeader + " header")
+	}
+	k, err := cfg.database.GetDeveloperAPIKeyByHash(r.Context(), auth.HashToken(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		cfg.checkCanary(r, canaryKindAPIKey, key)
+	}
+	return k, err
+}
+
+// middlewareDeveloperQuota meters requests that carry a developer key and
+// rejects them once the key's daily quota is used up.
+func (cfg *apiConfig) middlewareDeveloperQuota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(developerKeyHeader) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, err := cfg.developerKey(r)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusUnauthorized, "Invalid API key", nil)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check API key", err)
+			return
+		}
+		now := time.Now()
+		used, err := cfg.database.IncrementDeveloperAPIUsage(r.Context(), database.IncrementDeveloperAPIUsageParams{
+			KeyID: key.ID,
+			Day:   developerQuotaDay(now),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't record API usage", err)
+			return
+		}
+
+		quota := developerTierQuotas[key.Tier]
+		reset := developerQuotaReset(now)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(int(quota)))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(max(quota-used, 0))))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if used > quota {
+			w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+			respondWithError(w, http.StatusTooManyRequests, "Daily API quota exceeded", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) handlerCreateDeveloperKey(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Name is required", nil)
+		return
+	}
+
+	plain, err := auth.MakeAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create API key", err)
+		return
+	}
+	key, err := cfg.database.CreateDeveloperAPIKey(r.Context(), database.CreateDeveloperAPIKeyParams{
+		UserID:  userID,
+		Name:    params.Name,
+		KeyHash: auth.HashToken(plain),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create API key", err)
+		return
+	}
+	resp := newDeveloperKeyResponse(key)
+	resp.Key = plain
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) handlerListDeveloperKeys(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	keys, err := cfg.database.ListDeveloperAPIKeysForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get API keys", err)
+		return
+	}
+	var entries []developerKeyResponse
+	for _, key := range keys {
+		entries = append(entries, newDeveloperKeyResponse(key))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerRevokeDeveloperKey(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	keyID, err := uuid.Parse(r.PathValue("keyID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid key ID", err)
+		return
+	}
+	n, err := cfg.database.RevokeDeveloperAPIKey(r.Context(), database.RevokeDeveloperAPIKeyParams{
+		ID:     keyID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke API key", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "API key not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerDeveloperUsage reports the calling key's quota and its request
+// counts for recent days. It is authenticated by the developer key and is
+// not itself metered.
+func (cfg *apiConfig) handlerDeveloperUsage(w http.ResponseWriter, r *http.Request) {
+	type dailyUsage struct {
+		Date     string `json:"date"`
+		Requests int32  `json:"requests"`
+	}
+	type returnVals struct {
+		KeyID      uuid.UUID    `json:"key_id"`
+		Tier       string       `json:"tier"`
+		DailyQuota int32        `json:"daily_quota"`
+		UsedToday  int32        `json:"used_today"`
+		Remaining  int32        `json:"remaining"`
+		ResetsAt   time.Time    `json:"resets_at"`
+		Days       []dailyUsage `json:"days"`
+	}
+
+	key, err := cfg.developerKey(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing API key", err)
+		return
+	}
+	now := time.Now()
+	today := developerQuotaDay(now)
+	usage, err := cfg.database.ListDeveloperAPIUsage(r.Context(), database.ListDeveloperAPIUsageParams{
+		KeyID: key.ID,
+		Day:   today.AddDate(0, 0, -(developerUsageDays - 1)),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get API usage", err)
+		return
+	}
+
+	quota := developerTierQuotas[key.Tier]
+	resp := returnVals{
+		KeyID:      key.ID,
+		Tier:       key.Tier,
+		DailyQuota: quota,
+		ResetsAt:   developerQuotaReset(now),
+		Days:       []dailyUsage{},
+	}
+	for _, day := range usage {
+		if day.Day.Equal(today) {
+			resp.UsedToday = day.Requests
+		}
+		resp.Days = append(resp.Days, dailyUsage{
+			Date:     day.Day.Format(time.DateOnly),
+			Requests: day.Requests,
+		})
+	}
+	resp.Remaining = max(quota-resp.UsedToday, 0)
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerAdminSetDeveloperKeyTier(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Tier string `json:"tier"`
+	}
+
+	keyID, err := uuid.Parse(r.PathValue("keyID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid key ID", err)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if _, ok := developerTierQuotas[params.Tier]; !ok {
+		respondWithError(w, http.StatusBadRequest, "Tier must be free, standard or partner", nil)
+		return
+	}
+	key, err := cfg.database.SetDeveloperAPIKeyTier(r.Context(), database.SetDeveloperAPIKeyTierParams{
+		Tier: params.Tier,
+		ID:   keyID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "API key not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update API key", err)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "developer_key.tier_set", "developer_key", key.ID.String(), map[string]any{
+		"tier": params.Tier,
+	})
+
+	respondWithJSON(w, http.StatusOK, newDeveloperKeyResponse(key))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// shutdownTimeout bounds how long in-flight requests get to finish once the
+// server stops accepting new ones.
+const shutdownTimeout = 30 * time.Second
+
+// isProbePath reports whether path is a load balancer or orchestrator
+// probe. Probes bypass the database and schema gates so they can report on
+// the instance themselves.
+func isProbePath(path string) bool {
+	return path == "/api/healthz" || path == "/api/readyz"
+}
+
+// handlerReadiness tells the load balancer whether to send this instance
+// traffic. Unlike /api/healthz, which only says the process is alive, it
+// fails while draining, while caches are warming, while the database is
+// down and while the schema is incompatible.
+func (cfg *apiConfig) handlerReadiness(w http.ResponseWriter, r *http.Request) {
+	if cfg.draining.Load() {
+		respondWithError(w, http.StatusServiceUnavailable, "Draining", nil)
+		return
+	}
+	if cfg.warming.Load() {
+		respondWithError(w, http.StatusServiceUnavailable, "Warming up", nil)
+		return
+	}
+	if cfg.dbFailover != nil {
+		if down, retryAfter := cfg.dbFailover.unavailable(); down {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			respondWithError(w, http.StatusServiceUnavailable, "Database unavailable", nil)
+			return
+		}
+	}
+	if err := cfg.schemaGate.incompatible(); err != nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Database schema incompatible", err)
+		return
+	}
+	endpointHealt(w, r)
+}
+
+// handlerAdminDrain starts failing /api/readyz on the instance that
+// receives it, so the load balancer stops sending it new requests while the
+// ones it has finish. It doesn't stop the server; the deploy does that once
+// traffic has moved.
+func (cfg *apiConfig) handlerAdminDrain(w http.ResponseWriter, r *http.Request) {
+	cfg.setDraining(r, true)
+	respondWithJSON(w, http.StatusAccepted, map[string]bool{"draining": true})
+}
+
+// handlerAdminUndrain puts the instance back into rotation, for a rollout
+// that was called off.
+func (cfg *apiConfig) handlerAdminUndrain(w http.ResponseWriter, r *http.Request) {
+	cfg.setDraining(r, false)
+	respondWithJSON(w, http.StatusOK, map[string]bool{"draining": false})
+}
+
+func (cfg *apiConfig) setDraining(r *http.Request, draining bool) {
+	action := "server.drain_started"
+	if !draining {
+		action = "server.drain_cancelled"
+	}
+	if cfg.draining.Swap(draining) != draining {
+		log.Printf("%s by admin request", action)
+	}
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, action, "server", "", nil)
+}
+
+// shutdown drains the instance, waits drainDelay for the load balancer to
+// notice /api/readyz failing, then stops the server once in-flight requests
+// finish or shutdownTimeout passes.
+func (cfg *apiConfig) shutdown(server *http.Server, drainDelay time.Duration) {
+	cfg.draining.Store(true)
+	log.Printf("Shutting down: draining for %s", drainDelay)
+	time.Sleep(drainDelay)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down: %s", err)
+	}
+}
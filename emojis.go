@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+var (
+	emojiShortcodePattern = regexp.MustCompile(`^[a-z0-9_]{2,32}$`)
+	emojiPattern          = regexp.MustCompile(`:([a-z0-9_]{2,32}):`)
+)
+
+type customEmojiResponse struct {
+	Shortcode string    `json:"shortcode"`
+	URL       string    `json:"url"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// emojiShortcodes returns the distinct :shortcodes: written in body.
+func emojiShortcodes(body string) []string {
+	var codes []string
+	seen := map[string]bool{}
+	for _, m := range emojiPattern.FindAllStringSubmatch(body, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			codes = append(codes, m[1])
+		}
+	}
+	return codes
+}
+
+// customEmojis maps shortcodes to image URLs.
+type customEmojis map[string]string
+
+// in returns the custom emoji used in body, for a chirp's emojis field.
+// Clients swap each :shortcode: in the body for its image, as with
+// Mastodon's emojis.
+func (e customEmojis) in(body string) map[string]string {
+	var used map[string]string
+	for _, code := range emojiShortcodes(body) {
+		if u, ok := e[code]; ok {
+			if used == nil {
+				used = map[string]string{}
+			}
+			used[code] = u
+		}
+	}
+	return used
+}
+
+// customEmojis looks up the custom emoji used in any of bodies in one
+// query.
+func (cfg *apiConfig) customEmojis(ctx context.Context, bodies []string) (customEmojis, error) {
+	var codes []string
+	seen := map[string]bool{}
+	for _, body := range bodies {
+		for _, code := range emojiShortcodes(body) {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	emojis := customEmojis{}
+	if len(codes) == 0 {
+		return emojis, nil
+	}
+	rows, err := cfg.database.ListCustomEmojisByShortcode(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		emojis[row.Shortcode] = row.ImageUrl
+	}
+	return emojis, nil
+}
+
+func (cfg *apiConfig) handlerListEmojis(w http.ResponseWriter, r *http.Request) {
+	rows, err := cfg.database.ListCustomEmojis(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get emojis", err)
+		return
+	}
+	var entries []customEmojiResponse
+	for _, row := range rows {
+		entries = append(entries, customEmojiResponse{Shortcode: row.Shortcode, URL: row.ImageUrl, UpdatedAt: row.UpdatedAt})
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerAdminSetEmoji(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		URL string `json:"url"`
+	}
+
+	shortcode := r.PathValue("shortcode")
+	if !emojiShortcodePattern.MatchString(shortcode) {
+		respondWithError(w, http.StatusBadRequest, "Shortcode must be 2 to 32 lowercase letters, digits or underscores", nil)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if u, err := url.Parse(params.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		respondWithError(w, http.StatusBadRequest, "URL must be an http or https URL", nil)
+		return
+	}
+	emoji, err := cfg.database.UpsertCustomEmoji(r.Context(), database.UpsertCustomEmojiParams{
+		Shortcode: shortcode,
+		ImageUrl:  params.URL,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save emoji", err)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "emoji.set", "emoji", shortcode, map[string]any{
+		"url": params.URL,
+	})
+
+	respondWithJSON(w, http.StatusOK, customEmojiResponse{
+		Shortcode: emoji.Shortcode,
+		URL:       emoji.ImageUrl,
+		UpdatedAt: emoji.UpdatedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerAdminDeleteEmoji(w http.ResponseWriter, r *http.Request) {
+	shortcode := r.PathValue("shortcode")
+	deleted, err := cfg.database.DeleteCustomEmoji(r.Context(), shortcode)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete emoji", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Emoji not found", nil)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "emoji.deleted", "emoji", shortcode, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
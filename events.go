@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// Domain event types. Each is appended by the transaction that makes the
+// change, so the log never disagrees with the tables.
+const (
+	eventUserCreated        = "UserCreated"
+	eventUserUpgraded       = "UserUpgraded"
+	eventUserRedExpired     = "UserRedExpired"
+	eventUserApproved       = "UserApproved"
+	eventUserProfileUpdated = "UserProfileUpdated"
+	eventUserDeleted        = "UserDeleted"
+	eventUserRestored       = "UserRestored"
+	eventChirpPosted        = "ChirpPosted"
+	eventChirpEdited        = "ChirpEdited"
+	eventChirpModerated     = "ChirpModerated"
+	eventChirpDeleted       = "ChirpDeleted"
+
+	aggregateUser  = "user"
+	aggregateChirp = "chirp"
+)
+
+// appendEvent records a domain event through q, which should be the
+// transaction making the change. Payloads hold IDs and flags only, never
+// personal data, since events outlive purged users.
+func appendEvent(ctx context.Context, q *database.Store, eventType, aggregateType string, aggregateID uuid.UUID, payload map[string]any) error {
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return q.AppendEvent(ctx, database.AppendEventParams{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       dat,
+	})
+}
+
+// createUser creates a user and its UserCreated event together. source
+// says how the account came to be: signup, sso or scim.
+func (cfg *apiConfig) createUser(ctx context.Context, params database.CreateUserParams, source string) (database.User, error) {
+	return cfg.changeUser(ctx, eventUserCreated, map[string]any{
+		"source":     source,
+		"waitlisted": params.WaitlistStatus.Valid,
+	}, func(q *database.Store) (database.User, error) {
+		return q.CreateUser(ctx, params)
+	})
+}
+
+// updateUserProfile changes the user's handle and display name and records
+// UserProfileUpdated.
+func (cfg *apiConfig) updateUserProfile(ctx context.Context, params database.UpdateUserProfileParams) (database.User, error) {
+	return cfg.changeUser(ctx, eventUserProfileUpdated, nil, func(q *database.Store) (database.User, error) {
+		return q.UpdateUserProfile(ctx, params)
+	})
+}
+
+// softDeleteUser deletes a user, restorably, and records UserDeleted.
+// source is admin or scim.
+func (cfg *apiConfig) softDeleteUser(ctx context.Context, userID uuid.UUID, source string) (database.User, error) {
+	return cfg.changeUser(ctx, eventUserDeleted, map[string]any{"source": source}, func(q *database.Store) (database.User, error) {
+		return q.SoftDeleteUser(ctx, userID)
+	})
+}
+
+// restoreUser undoes softDeleteUser and records UserRestored.
+func (cfg *apiConfig) restoreUser(ctx context.Context, params database.RestoreUserParams, source string) (database.User, error) {
+	return cfg.changeUser(ctx, eventUserRestored, map[string]any{"source": source}, func(q *database.Store) (database.User, error) {
+		return q.RestoreUser(ctx, params)
+	})
+}
+
+// changeUser runs change and appends the event it causes in one
+// transaction.
+func (cfg *apiConfig) changeUser(ctx context.Context, eventType string, payload map[string]any, change func(q *database.Store) (database.User, error)) (database.User, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	user, err := change(qtx)
+	if err != nil {
+		return database.User{}, err
+	}
+	if err := appendEvent(ctx, qtx, eventType, aggregateUser, user.ID, payload); err != nil {
+		return database.User{}, err
+	}
+	return user, tx.Commit()
+}
+
+// upgradeUser gives a user Chirpy Red through q, the caller's transaction,
+// and records the UserUpgraded event. It fails with ErrUserNotFound for
+// unknown users.
+func upgradeUser(ctx context.Context, q *database.Store, userID uuid.UUID, payload map[string]any) error {
+	updated, err := q.AddUserChirpyRed(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return database.ErrUserNotFound
+	}
+	return appendEvent(ctx, q, eventUserUpgraded, aggregateUser, userID, payload)
+}
+
+// handlerAdminListEvents pages through the event log in order, for
+// consumers rebuilding projections or feeding analytics. after is the id
+// of the last event already seen.
+func (cfg *apiConfig) handlerAdminListEvents(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		ID            int64           `json:"id"`
+		Type          string          `json:"type"`
+		AggregateType string          `json:"aggregate_type"`
+		AggregateID   uuid.UUID       `json:"aggregate_id"`
+		Payload       json.RawMessage `json:"payload"`
+		OccurredAt    time.Time       `json:"occurred_at"`
+	}
+
+	query := r.URL.Query()
+	params := database.ListEventsParams{RowLimit: 100}
+	if raw := query.Get("after"); raw != "" {
+		after, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || after < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid after parameter", err)
+			return
+		}
+		params.AfterID = after
+	}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return
+		}
+		params.RowLimit = int32(n)
+	}
+	if eventType := query.Get("type"); eventType != "" {
+		params.EventType = sql.NullString{String: eventType, Valid: true}
+	}
+	events, err := cfg.database.ListEvents(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get events", err)
+		return
+	}
+	var entries []entry
+	for _, e := range events {
+		entries = append(entries, entry{
+			ID:            e.ID,
+			Type:          e.EventType,
+			AggregateType: e.AggregateType,
+			AggregateID:   e.AggregateID,
+			Payload:       e.Payload,
+			OccurredAt:    e.OccurredAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
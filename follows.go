@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// followEntry is one user in a follower or following list. The flags are
+// relative to the viewer, so a client can render "Follows you" and follow
+// buttons without a request per user; they are false for anonymous reads.
+type followEntry struct {
+	chirpAuthor
+	FollowedAt time.Time `json:"followed_at"`
+	FollowsYou bool      `json:"follows_you"`
+	Following  bool      `json:"following"`
+	IsMutual   bool      `json:"is_mutual"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself", nil)
+		return
+	}
+	followee, err := cfg.database.GetUserByID(r.Context(), followeeID)
+	if err != nil || followee.IsDeleted {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	err = cfg.database.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followee.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnfollowUser is idempotent, like unliking a chirp.
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	err = cfg.database.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerListFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, true)
+}
+
+func (cfg *apiConfig) handlerListFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, false)
+}
+
+// listFollows serves a page of a user's followers, or of the users they
+// follow, newest first. Pass next_cursor back in before for the next page.
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, followers bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	user, err := cfg.database.GetUserByID(r.Context(), userID)
+	if err != nil || user.IsDeleted {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	query := r.URL.Query()
+	params := database.ListFollowersParams{
+		ViewerID: cfg.optionalViewer(r),
+		UserID:   user.ID,
+		RowLimit: 100,
+	}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return
+		}
+		params.RowLimit = int32(n)
+	}
+	if raw := query.Get("before"); raw != "" {
+		cursor, err := api.DecodeCursor(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before parameter", err)
+			return
+		}
+		params.BeforeCreatedAt, params.BeforeID = nullCursor(&cursor)
+	}
+
+	var rows []database.ListFollowersRow
+	if followers {
+		rows, err = cfg.database.ListFollowers(r.Context(), params)
+	} else {
+		var following []database.ListFollowingRow
+		following, err = cfg.database.ListFollowing(r.Context(), database.ListFollowingParams(params))
+		for _, row := range following {
+			rows = append(rows, database.ListFollowersRow(row))
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follows", err)
+		return
+	}
+	var entries []followEntry
+	for _, row := range rows {
+		entries = append(entries, followEntry{
+			chirpAuthor: newChirpAuthor(row.ID, row.Handle, row.DisplayName, row.AvatarUrl, row.IsVerified),
+			FollowedAt:  row.FollowedAt,
+			FollowsYou:  row.FollowsViewer,
+			Following:   row.FollowedByViewer,
+			IsMutual:    row.FollowsViewer && row.FollowedByViewer,
+		})
+	}
+	var next string
+	if len(rows) == int(params.RowLimit) {
+		last := rows[len(rows)-1]
+		next = api.Cursor{CreatedAt: last.FollowedAt, ID: last.ID}.Encode()
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries).WithCursors(next, ""))
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	adminSessionCookie   = "chirpy_admin_session"
+	adminLoginCSRFCookie = "chirpy_admin_csrf"
+	adminSessionDuration = 12 * time.Hour
+)
+
+type adminSessionContextKey struct{}
+
+type adminUserContextKey struct{}
+
+type adminLoginData struct {
+	CSRFToken string
+	Error     string
+}
+
+func adminSessionFromContext(ctx context.Context) (database.GetAdminSessionRow, bool) {
+	session, ok := ctx.Value(adminSessionContextKey{}).(database.GetAdminSessionRow)
+	return session, ok
+}
+
+func adminUserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(adminUserContextKey{}).(uuid.UUID)
+	return userID, ok
+}
+
+func setAdminCookie(w http.ResponseWriter, name, value string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/admin",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   os.Getenv("PLATFORM") != "dev",
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func validCSRFToken(r *http.Request, expected string) bool {
+	token := r.PostFormValue("csrf_token")
+	if token == "" {
+		token = r.Header.Get("X-CSRF-Token")
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+func (cfg *apiConfig) middlewareAdminSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(adminSessionCookie)
+		if err != nil {
+			http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+			return
+		}
+		session, err := cfg.database.GetAdminSession(r.Context(), cookie.Value)
+		if err != nil {
+			setAdminCookie(w, adminSessionCookie, "", time.Unix(0, 0))
+			http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !validCSRFToken(r, session.CsrfToken) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminSessionContextKey{}, session)))
+	})
+}
+
+// middlewareAdminAPI guards JSON admin endpoints. It accepts either a bearer
+// JWT belonging to an admin user or an admin session cookie, in which case
+// unsafe methods must also carry the session's CSRF token.
+func (cfg *apiConfig) middlewareAdminAPI(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if token, err := auth.GetBearerToken(r.Header); err == nil {
+			user, err := cfg.authenticateJWT(ctx, token)
+			if errors.Is(err, errInvalidAccessToken) {
+				respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+				return
+			}
+			if err != nil || !user.IsAdmin || user.PasswordChangeRequired {
+				respondWithError(w, http.StatusForbidden, "Admin access required", nil)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, adminUserContextKey{}, user.ID)))
+			return
+		}
+
+		cookie, err := r.Cookie(adminSessionCookie)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Admin session or token required", nil)
+			return
+		}
+		session, err := cfg.database.GetAdminSession(ctx, cookie.Value)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired admin session", nil)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !validCSRFToken(r, session.CsrfToken) {
+			respondWithError(w, http.StatusForbidden, "Invalid CSRF token", nil)
+			return
+		}
+		ctx = context.WithValue(ctx, adminSessionContextKey{}, session)
+		ctx = context.WithValue(ctx, adminUserContextKey{}, session.UserID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (cfg *apiConfig) renderAdminLogin(w http.ResponseWriter, code int, msg string) {
+	csrfToken, err := auth.MakeSessionToken()
+	if err != nil {
+		http.Error(w, "Couldn't create CSRF token", http.StatusInternalServerError)
+		return
+	}
+	setAdminCookie(w, adminLoginCSRFCookie, csrfToken, time.Now().Add(time.Hour))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	cfg.loginTemplate.Execute(w, adminLoginData{CSRFToken: csrfToken, Error: msg})
+}
+
+func (cfg *apiConfig) handlerAdminLoginPage(w http.ResponseWriter, r *http.Request) {
+	cfg.renderAdminLogin(w, http.StatusOK, "")
+}
+
+func (cfg *apiConfig) handlerAdminLogin(w http.ResponseWriter, r *http.Request) {
+	csrfCookie, err := r.Cookie(adminLoginCSRFCookie)
+	if err != nil || !validCSRFToken(r, csrfCookie.Value) {
+		cfg.renderAdminLogin(w, http.StatusForbidden, "Your login form expired, please try again")
+		return
+	}
+	if cfg.passwordLoginDisabled() {
+		cfg.renderAdminLogin(w, http.StatusForbidden, "Password login is disabled, sign in with SSO")
+		return
+	}
+	email := r.PostFormValue("email")
+	password := r.PostFormValue("password")
+	if email == "" || password == "" {
+		cfg.renderAdminLogin(w, http.StatusBadRequest, "Email and password are required")
+		return
+	}
+	user, err := cfg.database.GetUserByEmail(r.Context(), email)
+	if err != nil || auth.CheckPasswordHash(password, user.HashedPassword) != nil || !user.IsAdmin || user.IsDeleted {
+		cfg.renderAdminLogin(w, http.StatusUnauthorized, "Incorrect email or password")
+		return
+	}
+
+	if err := cfg.startAdminSession(w, r, user.ID); err != nil {
+		http.Error(w, "Couldn't create session", http.StatusInternalServerError)
+		return
+	}
+	setAdminCookie(w, adminLoginCSRFCookie, "", time.Unix(0, 0))
+	http.Redirect(w, r, "/admin/metrics", http.StatusSeeOther)
+}
+
+// startAdminSession creates a console session for an admin and sets its
+// cookie.
+func (cfg *apiConfig) startAdminSession(w http.ResponseWriter, r *http.Request, userID uuid.UUID) error {
+	sessionToken, err := auth.MakeSessionToken()
+	if err != nil {
+		return err
+	}
+	csrfToken, err := auth.MakeSessionToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(adminSessionDuration)
+	_, err = cfg.database.CreateAdminSession(r.Context(), database.CreateAdminSessionParams{
+		Token:     sessionToken,
+		UserID:    userID,
+		CsrfToken: csrfToken,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	setAdminCookie(w, adminSessionCookie, sessionToken, expiresAt)
+	return nil
+}
+
+func (cfg *apiConfig) handlerAdminLogout(w http.ResponseWriter, r *http.Request) {
+	session, ok := adminSessionFromContext(r.Context())
+	if ok {
+		cfg.database.DeleteAdminSession(r.Context(), session.Token)
+	}
+	setAdminCookie(w, adminSessionCookie, "", time.Unix(0, 0))
+	http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+}
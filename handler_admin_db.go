@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+// handlerAdminSlowQueries lists the most expensive statements recorded by
+// pg_stat_statements, by total time or with ?sort=calls by call count.
+// Query text is normalized by Postgres, so it carries no parameter values.
+func (cfg *apiConfig) handlerAdminSlowQueries(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		Query          string  `json:"query"`
+		Calls          int64   `json:"calls"`
+		TotalTimeMS    float64 `json:"total_time_ms"`
+		MeanTimeMS     float64 `json:"mean_time_ms"`
+		Rows           int64   `json:"rows"`
+		CacheHitRatio  float64 `json:"cache_hit_ratio"`
+		SharedBlksRead int64   `json:"shared_blks_read"`
+	}
+
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = "total_time"
+	}
+	if sortBy != "total_time" && sortBy != "calls" {
+		respondWithError(w, http.StatusBadRequest, `Sort must be "total_time" or "calls"`, nil)
+		return
+	}
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return
+		}
+		limit = n
+	}
+
+	available, err := cfg.database.PgStatStatementsAvailable(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check for pg_stat_statements", err)
+		return
+	}
+	if !available {
+		respondWithError(w, http.StatusNotFound, "pg_stat_statements is not installed on this database", nil)
+		return
+	}
+	stats, err := cfg.database.ListSlowQueries(r.Context(), database.ListSlowQueriesParams{
+		SortBy: sortBy,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get query statistics", err)
+		return
+	}
+	var entries []returnVals
+	for _, s := range stats {
+		entries = append(entries, returnVals{
+			Query:          s.Query,
+			Calls:          s.Calls,
+			TotalTimeMS:    s.TotalExecTime,
+			MeanTimeMS:     s.MeanExecTime,
+			Rows:           s.Rows,
+			CacheHitRatio:  cacheHitRatio(s.SharedBlksHit, s.SharedBlksRead),
+			SharedBlksRead: s.SharedBlksRead,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+// cacheHitRatio is the share of blocks served from shared buffers. A low
+// ratio on a hot query usually means it scans more than it should.
+func cacheHitRatio(hit, read int64) float64 {
+	if hit+read == 0 {
+		return 1
+	}
+	return float64(hit) / float64(hit+read)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+type emailDomainRuleResponse struct {
+	Domain    string    `json:"domain"`
+	Rule      string    `json:"rule"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (cfg *apiConfig) handlerAdminListEmailDomains(w http.ResponseWriter, r *http.Request) {
+	rules, err := cfg.database.ListEmailDomainRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get email domain rules", err)
+		return
+	}
+	var entries []emailDomainRuleResponse
+	for _, rule := range rules {
+		entries = append(entries, emailDomainRuleResponse{
+			Domain:    rule.Domain,
+			Rule:      rule.Rule,
+			UpdatedAt: rule.UpdatedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerAdminSetEmailDomain(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Rule string `json:"rule"`
+	}
+
+	domain := normalizeEmailDomain(r.PathValue("domain"))
+	if !emailDomainPattern.MatchString(domain) {
+		respondWithError(w, http.StatusBadRequest, "Invalid domain", nil)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if params.Rule != emailDomainAllow && params.Rule != emailDomainDeny {
+		respondWithError(w, http.StatusBadRequest, `Rule must be "allow" or "deny"`, nil)
+		return
+	}
+	rule, err := cfg.database.UpsertEmailDomainRule(r.Context(), database.UpsertEmailDomainRuleParams{
+		Domain: domain,
+		Rule:   params.Rule,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save email domain rule", err)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "email_domain.set", "email_domain", domain, map[string]any{
+		"rule": params.Rule,
+	})
+
+	respondWithJSON(w, http.StatusOK, emailDomainRuleResponse{
+		Domain:    rule.Domain,
+		Rule:      rule.Rule,
+		UpdatedAt: rule.UpdatedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerAdminDeleteEmailDomain(w http.ResponseWriter, r *http.Request) {
+	domain := normalizeEmailDomain(r.PathValue("domain"))
+	deleted, err := cfg.database.DeleteEmailDomainRule(r.Context(), domain)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete email domain rule", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "No rule for that domain", nil)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "email_domain.deleted", "email_domain", domain, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+const exportBatchSize = 500
+
+// handlerAdminExportChirps streams every published chirp created in
+// [since, until) as NDJSON, oldest first. Each line carries a cursor that can
+// be passed back as ?cursor= to resume after that chirp.
+func (cfg *apiConfig) handlerAdminExportChirps(w http.ResponseWriter, r *http.Request) {
+	type exportLine struct {
+		ID        uuid.UUID  `json:"id"`
+		CreatedAt string     `json:"created_at"`
+		UpdatedAt string     `json:"updated_at"`
+		Body      string     `json:"body"`
+		UserID    uuid.UUID  `json:"user_id"`
+		InReplyTo *uuid.UUID `json:"in_reply_to,omitempty"`
+		Cursor    string     `json:"cursor"`
+	}
+
+	query := r.URL.Query()
+	after := api.Cursor{}
+	until := time.Now().UTC()
+	if raw := query.Get("since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid since parameter, expected RFC 3339", err)
+			return
+		}
+		// Step back a tick so chirps created exactly at since are included.
+		after.CreatedAt = since.UTC().Add(-time.Microsecond)
+	}
+	if raw := query.Get("until"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid until parameter, expected RFC 3339", err)
+			return
+		}
+		until = parsed.UTC()
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := api.DecodeCursor(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor parameter", err)
+			return
+		}
+		after = cursor
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for {
+		batch, err := cfg.database.ExportPublishedMessages(r.Context(), database.ExportPublishedMessagesParams{
+			AfterCreatedAt: after.CreatedAt,
+			AfterID:        after.ID,
+			Until:          until,
+			RowLimit:       exportBatchSize,
+		})
+		if err != nil {
+			log.Printf("Error exporting chirps after %s: %s", after.Encode(), err)
+			enc.Encode(map[string]string{"error": "export interrupted, resume from the last cursor"})
+			return
+		}
+		for _, msg := range batch {
+			after = api.Cursor{CreatedAt: msg.CreatedAt, ID: msg.ID}
+			line := exportLine{
+				ID:        msg.ID,
+				CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+				UpdatedAt: msg.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+				Body:      msg.Body,
+				UserID:    msg.UserID,
+				InReplyTo: nullUUIDPtr(msg.ParentID),
+				Cursor:    after.Encode(),
+			}
+			if err := enc.Encode(line); err != nil {
+				return
+			}
+		}
+		rc.Flush()
+		if len(batch) < exportBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/metrics"
+)
+
+// statusRecorder remembers the status code written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// middlewareRequestStats wraps the whole mux and records every request under
+// the pattern it matched, so path parameters don't blow up the label set.
+// It also counts the database statements each request runs.
+func (cfg *apiConfig) middlewareRequestStats(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, db := withDBStats(r.Context())
+		r = r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		elapsed := time.Since(start)
+		queries, dbTime := int(db.queries.Load()), time.Duration(db.duration.Load())
+		cfg.requestStats.Observe(route, rec.status, elapsed)
+		cfg.requestStats.ObserveQueries(route, queries, dbTime)
+		cfg.sloMonitor.Observe(route, elapsed)
+		if queries > dbQueryLogThreshold {
+			log.Printf("%s ran %d database queries in %s", route, queries, dbTime.Round(time.Millisecond))
+		}
+	})
+}
+
+// handlerAdminMetrics serves the HTML page to browser sessions and the
+// OpenMetrics exposition when ?format=openmetrics is given. Scrapers
+// authenticate like any other admin API client.
+func (cfg *apiConfig) handlerAdminMetrics() http.Handler {
+	page := cfg.middlewareAdminSession(http.HandlerFunc(cfg.middlewareMetricsGet))
+	openMetrics := cfg.middlewareAdminAPI(http.HandlerFunc(cfg.handlerAdminOpenMetrics))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "openmetrics" {
+			openMetrics.ServeHTTP(w, r)
+			return
+		}
+		page.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) handlerAdminOpenMetrics(w http.ResponseWriter, r *http.Request) {
+	hits, err := cfg.counters.Get(r.Context(), fileserverHitsKey)
+	if err != nil {
+		log.Printf("Error reading fileserver hits: %s", err)
+	}
+	w.Header().Set("Content-Type", metrics.OpenMetricsContentType)
+	w.WriteHeader(http.StatusOK)
+	metrics.WriteOpenMetrics(w, metrics.Snapshot{
+		FileserverHits: hits,
+		Routes:         cfg.requestStats.Snapshot(),
+		Queries:        cfg.requestStats.QuerySnapshot(),
+		Breakers:       cfg.breakers.Stats(),
+	})
+}
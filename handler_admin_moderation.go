@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+type moderationChirp struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+	Body      string    `json:"body"`
+	UserID    uuid.UUID `json:"user_id"`
+	Status    string    `json:"status"`
+}
+
+func newModerationChirp(msg database.Message) moderationChirp {
+	return moderationChirp{
+		ID:        msg.ID,
+		CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: msg.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Body:      msg.Body,
+		UserID:    msg.UserID,
+		Status:    msg.Status,
+	}
+}
+
+func (cfg *apiConfig) handlerAdminPendingChirps(w http.ResponseWriter, r *http.Request) {
+	messages, err := cfg.database.ListPendingMessages(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get pending chirps", err)
+		return
+	}
+	var chirps []moderationChirp
+	for _, msg := range messages {
+		chirps = append(chirps, newModerationChirp(msg))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(chirps).WithTotal(int64(len(chirps))))
+}
+
+func (cfg *apiConfig) handlerAdminApproveChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setPendingChirpStatus(w, r, chirpStatusPublished)
+}
+
+func (cfg *apiConfig) handlerAdminRejectChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setPendingChirpStatus(w, r, chirpStatusRejected)
+}
+
+func (cfg *apiConfig) setPendingChirpStatus(w http.ResponseWriter, r *http.Request, status string) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	msg, err := cfg.decidePendingChirp(r.Context(), database.SetPendingMessageStatusParams{
+		Status: status,
+		ID:     chirpID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No pending chirp with that ID", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newModerationChirp(msg))
+}
+
+// decidePendingChirp publishes or rejects a pending chirp and records the
+// ChirpModerated event.
+func (cfg *apiConfig) decidePendingChirp(ctx context.Context, params database.SetPendingMessageStatusParams) (database.Message, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Message{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	msg, err := qtx.SetPendingMessageStatus(ctx, params)
+	if err != nil {
+		return database.Message{}, err
+	}
+	if err := appendEvent(ctx, qtx, eventChirpModerated, aggregateChirp, msg.ID, map[string]any{"status": msg.Status}); err != nil {
+		return database.Message{}, err
+	}
+	return msg, tx.Commit()
+}
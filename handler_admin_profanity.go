@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+)
+
+type profanityWordResponse struct {
+	Word      string                                 `json:"word"`
+	Actions   map[profanity.Context]profanity.Action `json:"actions"`
+	UpdatedAt time.Time                              `json:"updated_at"`
+}
+
+// profanityWords groups rule rows, which come sorted by word, into one
+// entry per word.
+func profanityWords(rows []database.ProfanityRule) []profanityWordResponse {
+	var entries []profanityWordResponse
+	for _, row := range rows {
+		if len(entries) == 0 || entries[len(entries)-1].Word != row.Word {
+			entries = append(entries, profanityWordResponse{Word: row.Word, Actions: map[profanity.Context]profanity.Action{}})
+		}
+		entry := &entries[len(entries)-1]
+		entry.Actions[profanity.Context(row.Context)] = profanity.Action(row.Action)
+		if row.UpdatedAt.After(entry.UpdatedAt) {
+			entry.UpdatedAt = row.UpdatedAt
+		}
+	}
+	return entries
+}
+
+func (cfg *apiConfig) handlerAdminListProfanity(w http.ResponseWriter, r *http.Request) {
+	rows, err := cfg.database.ListProfanityRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get profanity rules", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(profanityWords(rows)))
+}
+
+// handlerAdminSetProfanityWord replaces the actions for a word, for example
+// {"actions": {"default": "mask", "display_name": "reject"}}.
+func (cfg *apiConfig) handlerAdminSetProfanityWord(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Actions map[profanity.Context]profanity.Action `json:"actions"`
+	}
+
+	word := strings.ToLower(strings.TrimSpace(r.PathValue("word")))
+	if word == "" || strings.ContainsFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		respondWithError(w, http.StatusBadRequest, "Word must only contain letters and digits", nil)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if err := profanity.ValidateActions(params.Actions); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid actions: "+err.Error(), nil)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save profanity rules", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	if _, err := qtx.DeleteProfanityWord(r.Context(), word); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save profanity rules", err)
+		return
+	}
+	for ctx, action := range params.Actions {
+		err := qtx.SetProfanityRule(r.Context(), database.SetProfanityRuleParams{
+			Word:    word,
+			Context: string(ctx),
+			Action:  string(action),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save profanity rules", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save profanity rules", err)
+		return
+	}
+	cfg.profanityRules.Invalidate()
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "profanity.word_set", "profanity_word", word, map[string]any{
+		"actions": params.Actions,
+	})
+
+	respondWithJSON(w, http.StatusOK, profanityWordResponse{
+		Word:      word,
+		Actions:   params.Actions,
+		UpdatedAt: time.Now().UTC(),
+	})
+}
+
+func (cfg *apiConfig) handlerAdminDeleteProfanityWord(w http.ResponseWriter, r *http.Request) {
+	word := strings.ToLower(strings.TrimSpace(r.PathValue("word")))
+	deleted, err := cfg.database.DeleteProfanityWord(r.Context(), word)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete profanity rules", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Word not found", nil)
+		return
+	}
+	cfg.profanityRules.Invalidate()
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "profanity.word_deleted", "profanity_word", word, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
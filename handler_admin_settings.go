@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+)
+
+type settingResponse struct {
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Default    string `json:"default"`
+	Overridden bool   `json:"overridden"`
+}
+
+func (cfg *apiConfig) settingResponse(r *http.Request, key string) settingResponse {
+	def, _ := settings.Lookup(key)
+	return settingResponse{
+		Key:        key,
+		Value:      cfg.settings.Get(r.Context(), key),
+		Default:    def.Default,
+		Overridden: cfg.settings.Overridden(r.Context(), key),
+	}
+}
+
+func (cfg *apiConfig) handlerAdminListSettings(w http.ResponseWriter, r *http.Request) {
+	var entries []settingResponse
+	for _, key := range settings.Keys() {
+		entries = append(entries, cfg.settingResponse(r, key))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerAdminUpdateSetting(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Value string `json:"value"`
+	}
+
+	key := r.PathValue("key")
+	if _, ok := settings.Lookup(key); !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown setting", nil)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if err := settings.Validate(key, params.Value); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	_, err = cfg.database.UpsertSetting(r.Context(), database.UpsertSettingParams{
+		Key:   key,
+		Value: params.Value,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save setting", err)
+		return
+	}
+	cfg.settings.Invalidate()
+	respondWithJSON(w, http.StatusOK, cfg.settingResponse(r, key))
+}
+
+func (cfg *apiConfig) handlerAdminResetSetting(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if _, ok := settings.Lookup(key); !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown setting", nil)
+		return
+	}
+	err := cfg.database.DeleteSetting(r.Context(), key)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset setting", err)
+		return
+	}
+	cfg.settings.Invalidate()
+	w.WriteHeader(http.StatusNoContent)
+}
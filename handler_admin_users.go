@@ -0,0 +1,171 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerAdminVerifyUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setUserVerified(w, r, true)
+}
+
+func (cfg *apiConfig) handlerAdminUnverifyUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setUserVerified(w, r, false)
+}
+
+func (cfg *apiConfig) setUserVerified(w http.ResponseWriter, r *http.Request, verified bool) {
+	type returnVals struct {
+		ID         uuid.UUID `json:"id"`
+		Email      string    `json:"email"`
+		IsVerified bool      `json:"is_verified"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	user, err := cfg.database.SetUserVerified(r.Context(), database.SetUserVerifiedParams{
+		IsVerified: verified,
+		ID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	action := "user.verified"
+	if !verified {
+		action = "user.unverified"
+	}
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, action, "user", user.ID.String(), nil)
+
+	respondWithJSON(w, http.StatusOK, returnVals{
+		ID:         user.ID,
+		Email:      user.Email,
+		IsVerified: user.IsVerified,
+	})
+}
+
+func (cfg *apiConfig) handlerAdminAuditLogs(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		ID         uuid.UUID       `json:"id"`
+		CreatedAt  time.Time       `json:"created_at"`
+		ActorID    *uuid.UUID      `json:"actor_id"`
+		Action     string          `json:"action"`
+		TargetType string          `json:"target_type"`
+		TargetID   string          `json:"target_id"`
+		Metadata   json.RawMessage `json:"metadata"`
+	}
+
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return
+		}
+		limit = n
+	}
+	entries, err := cfg.database.ListAuditLogs(r.Context(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get audit logs", err)
+		return
+	}
+	var logs []returnVals
+	for _, entry := range entries {
+		item := returnVals{
+			ID:         entry.ID,
+			CreatedAt:  entry.CreatedAt,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			Metadata:   entry.Metadata,
+		}
+		if entry.ActorID.Valid {
+			item.ActorID = &entry.ActorID.UUID
+		}
+		logs = append(logs, item)
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(logs))
+}
+
+func (cfg *apiConfig) handlerAdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		ID              uuid.UUID `json:"id"`
+		Email           string    `json:"email"`
+		DeletedAt       time.Time `json:"deleted_at"`
+		RestorableUntil time.Time `json:"restorable_until"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	user, err := cfg.softDeleteUser(r.Context(), userID, "admin")
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found or already deleted", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete user", err)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "user.deleted", "user", user.ID.String(), nil)
+
+	respondWithJSON(w, http.StatusOK, returnVals{
+		ID:              user.ID,
+		Email:           user.Email,
+		DeletedAt:       user.DeletedAt.Time,
+		RestorableUntil: user.DeletedAt.Time.Add(userRestoreWindow),
+	})
+}
+
+func (cfg *apiConfig) handlerAdminRestoreUser(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		ID    uuid.UUID `json:"id"`
+		Email string    `json:"email"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	user, err := cfg.restoreUser(r.Context(), database.RestoreUserParams{
+		ID:           userID,
+		DeletedAfter: time.Now().Add(-userRestoreWindow),
+	}, "admin")
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No restorable deleted user with that ID", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore user", err)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "user.restored", "user", user.ID.String(), nil)
+
+	respondWithJSON(w, http.StatusOK, returnVals{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+}
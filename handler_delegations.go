@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// A post delegation lets the delegate publish chirps that are displayed as
+// the owner's. The owner creates it and it only takes effect once the
+// delegate accepts; either side can remove it.
+type delegationResponse struct {
+	OwnerID    uuid.UUID  `json:"owner_id"`
+	DelegateID uuid.UUID  `json:"delegate_id"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+func newDelegationResponse(d database.PostDelegation) delegationResponse {
+	resp := delegationResponse{
+		OwnerID:    d.OwnerID,
+		DelegateID: d.DelegateID,
+		Status:     "pending",
+		CreatedAt:  d.CreatedAt,
+	}
+	if d.AcceptedAt.Valid {
+		resp.Status = "accepted"
+		resp.AcceptedAt = &d.AcceptedAt.Time
+	}
+	return resp
+}
+
+func (cfg *apiConfig) handlerCreateDelegation(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		DelegateID uuid.UUID `json:"delegate_id"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if params.DelegateID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't delegate posting to yourself", nil)
+		return
+	}
+	delegate, err := cfg.database.GetUserByID(r.Context(), params.DelegateID)
+	if err != nil || delegate.IsDeleted {
+		respondWithError(w, http.StatusNotFound, "Delegate not found", nil)
+		return
+	}
+	delegation, err := cfg.database.CreatePostDelegation(r.Context(), database.CreatePostDelegationParams{
+		OwnerID:    userID,
+		DelegateID: delegate.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create delegation", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, newDelegationResponse(delegation))
+}
+
+func (cfg *apiConfig) handlerAcceptDelegation(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	ownerID, err := uuid.Parse(r.PathValue("ownerID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid owner ID", err)
+		return
+	}
+	delegation, err := cfg.database.AcceptPostDelegation(r.Context(), database.AcceptPostDelegationParams{
+		OwnerID:    ownerID,
+		DelegateID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No pending delegation from that user", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't accept delegation", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newDelegationResponse(delegation))
+}
+
+func (cfg *apiConfig) handlerDeleteDelegation(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	ownerID, err := uuid.Parse(r.PathValue("ownerID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid owner ID", err)
+		return
+	}
+	delegateID, err := uuid.Parse(r.PathValue("delegateID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delegate ID", err)
+		return
+	}
+	if userID != ownerID && userID != delegateID {
+		respondWithError(w, http.StatusForbidden, "You can only remove your own delegations", nil)
+		return
+	}
+	deleted, err := cfg.database.DeletePostDelegation(r.Context(), database.DeletePostDelegationParams{
+		OwnerID:    ownerID,
+		DelegateID: delegateID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete delegation", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Delegation not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerListDelegations(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	delegations, err := cfg.database.ListPostDelegationsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get delegations", err)
+		return
+	}
+	var entries []delegationResponse
+	for _, d := range delegations {
+		entries = append(entries, newDelegationResponse(d))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/links"
+	"github.com/google/uuid"
+)
+
+const linkCodeLength = 7
+
+// publicBaseURL is the origin used when building links back to Chirpy,
+// taken from BASE_URL or, failing that, from the incoming request. The
+// request's Host is up to the client, so the fallback is only fit for
+// responses, never for anything stored.
+func (cfg *apiConfig) publicBaseURL(r *http.Request) string {
+	if cfg.baseURL != "" {
+		return cfg.baseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// shortenLinks replaces the URLs in body with short links under BASE_URL.
+// The short links are stored in the chirp, so without BASE_URL the body is
+// left as it is.
+func (cfg *apiConfig) shortenLinks(body string) (string, []links.Link, error) {
+	if cfg.baseURL == "" {
+		return body, nil, nil
+	}
+	return links.Shorten(body, cfg.baseURL, func() (string, error) {
+		return links.NewCode(linkCodeLength)
+	})
+}
+
+func (cfg *apiConfig) handlerLinkRedirect(w http.ResponseWriter, r *http.Request) {
+	target, err := cfg.database.RecordLinkClick(r.Context(), r.PathValue("code"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Link not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve link", err)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (cfg *apiConfig) handlerChirpLinkStats(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		Code          string     `json:"code"`
+		ShortURL      string     `json:"short_url"`
+		TargetURL     string     `json:"target_url"`
+		ClickCount    int64      `json:"click_count"`
+		LastClickedAt *time.Time `json:"last_clicked_at"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	message, err := cfg.database.GetMessageByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithDBError(w, "Couldn't get chirp", err)
+		return
+	}
+	if message.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Only the author can see link analytics", nil)
+		return
+	}
+	redirects, err := cfg.database.ListLinkRedirectsForMessage(r.Context(), database.ListLinkRedirectsForMessageParams{
+		MessageID: chirpID,
+		UserID:    userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get link analytics", err)
+		return
+	}
+
+	baseURL := cfg.publicBaseURL(r)
+	var stats []returnVals
+	for _, redirect := range redirects {
+		item := returnVals{
+			Code:       redirect.Code,
+			ShortURL:   baseURL + "/l/" + redirect.Code,
+			TargetURL:  redirect.TargetUrl,
+			ClickCount: redirect.ClickCount,
+		}
+		if redirect.LastClickedAt.Valid {
+			item.LastClickedAt = &redirect.LastClickedAt.Time
+		}
+		stats = append(stats, item)
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(stats))
+}
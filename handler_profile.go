@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/google/uuid"
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
+	}
+	type returnVals struct {
+		ID          uuid.UUID  `json:"id"`
+		Handle      string     `json:"handle,omitempty"`
+		DisplayName string     `json:"display_name,omitempty"`
+		AvatarURL   string     `json:"avatar_url,omitempty"`
+		IsVerified  bool       `json:"is_verified"`
+		IsAutomated bool       `json:"is_automated"`
+		AutomatedBy *uuid.UUID `json:"automated_by,omitempty"`
+	}
+
+	token, err := cfg.accessToken(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
+		return
+	}
+	userID, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeProfileWrite)
+	if err != nil {
+		respondWithTokenError(w, err)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	params.Handle = strings.ToLower(strings.TrimSpace(params.Handle))
+	params.DisplayName = strings.TrimSpace(params.DisplayName)
+	if params.Handle != "" && !handlePattern.MatchString(params.Handle) {
+		respondWithError(w, http.StatusBadRequest, "Handle must be 3-30 characters of letters, digits or underscores", nil)
+		return
+	}
+	if len(params.DisplayName) > cfg.settings.Int(r.Context(), settings.DisplayNameMaxLength) {
+		respondWithError(w, http.StatusBadRequest, "Display name is too long", nil)
+		return
+	}
+	profanityCheck := cfg.profanityFilter(r.Context()).Check(profanity.ContextDisplayName, params.DisplayName)
+	switch profanityCheck.Action {
+	case profanity.ActionReject:
+		respondWithError(w, http.StatusBadRequest, "Display name contains prohibited language", nil)
+		return
+	case profanity.ActionFlag:
+		cfg.recordAudit(r.Context(), userID, "profile.display_name_flagged", "user", userID.String(), map[string]any{
+			"display_name": params.DisplayName,
+			"matches":      profanityCheck.Matches,
+		})
+	}
+	params.DisplayName = profanityCheck.Text
+
+	before, err := cfg.database.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithDBError(w, "Couldn't update profile", err)
+		return
+	}
+	user, err := cfg.updateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		Handle:      sql.NullString{String: params.Handle, Valid: params.Handle != ""},
+		DisplayName: sql.NullString{String: params.DisplayName, Valid: params.DisplayName != ""},
+		ID:          userID,
+	})
+	if err != nil {
+		respondWithDBError(w, "Couldn't update profile", err)
+		return
+	}
+	cfg.recordPIIChanges(r.Context(), before, user, piiSourceUser)
+	bots, err := cfg.botOwners(r.Context(), []uuid.UUID{user.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get profile", err)
+		return
+	}
+	owner, automated := bots[user.ID]
+	resp := returnVals{
+		ID:          user.ID,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName.String,
+		AvatarURL:   user.AvatarUrl.String,
+		IsVerified:  user.IsVerified,
+		IsAutomated: automated,
+	}
+	if automated {
+		resp.AutomatedBy = &owner
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
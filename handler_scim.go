@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/redact"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/scim"
+	"github.com/google/uuid"
+)
+
+// unsetPassword matches the users.hashed_password column default. It is
+// not a bcrypt hash, so provisioned users can't log in with a password
+// until they are given one.
+const unsetPassword = "NOT_SET"
+
+// scimHTTPError carries the status and SCIM error type for a failed change.
+type scimHTTPError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *scimHTTPError) Error() string {
+	return e.detail
+}
+
+// respondWithSCIMError writes a SCIM error. Like respondWithError it logs
+// err through redact.Text, since driver errors can quote an email address.
+func respondWithSCIMError(w http.ResponseWriter, code int, scimType, detail string, err error) {
+	if err != nil {
+		log.Println(redact.Text(err.Error()))
+	}
+	scim.Write(w, code, scim.NewError(code, scimType, detail))
+}
+
+// middlewareSCIM only lets requests through that carry the provisioning
+// token from SCIM_TOKEN. Without a token configured SCIM is disabled.
+func (cfg *apiConfig) middlewareSCIM(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.scimToken == "" {
+			respondWithSCIMError(w, http.StatusNotFound, "", "SCIM provisioning is not enabled", nil)
+			return
+		}
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.scimToken)) != 1 {
+			respondWithSCIMError(w, http.StatusUnauthorized, "", "Invalid provisioning token", err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) scimUser(r *http.Request, user database.User) scim.User {
+	location := cfg.publicBaseURL(r) + "/scim/v2/Users/" + user.ID.String()
+	return scim.NewUser(user.ID.String(), user.Email, !user.IsDeleted, user.CreatedAt, user.UpdatedAt, location)
+}
+
+func (cfg *apiConfig) scimUserFromPath(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithSCIMError(w, http.StatusNotFound, "", "User not found", nil)
+		return database.User{}, false
+	}
+	user, err := cfg.database.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithSCIMError(w, http.StatusNotFound, "", "User not found", nil)
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithSCIMError(w, http.StatusInternalServerError, "", "Couldn't get user", err)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// applySCIMChanges updates the email and active state of a user. Turning a
+// user inactive soft deletes them; turning them active again restores them
+// while the restore window is open.
+func (cfg *apiConfig) applySCIMChanges(ctx context.Context, user database.User, changes scim.Changes) (database.User, error) {
+	if changes.Email != nil && *changes.Email != user.Email {
+		email := strings.TrimSpace(*changes.Email)
+		if !strings.Contains(email, "@") {
+			return user, &scimHTTPError{http.StatusBadRequest, scim.ErrInvalidValue, "A valid email is required"}
+		}
+		if existing, err := cfg.database.GetUserByEmail(ctx, email); err == nil && existing.ID != user.ID {
+			return user, &scimHTTPError{http.StatusConflict, scim.ErrUniqueness, "Email is already in use"}
+		}
+		updated, err := cfg.database.UpdateUserEmail(ctx, database.UpdateUserEmailParams{
+			Email: email,
+			ID:    user.ID,
+		})
+		if err != nil {
+			return user, err
+		}
+		cfg.recordAudit(ctx, uuid.Nil, "user.email_changed", "user", user.ID.String(), map[string]any{
+			"source": "scim",
+		})
+		cfg.recordPIIChanges(ctx, user, updated, piiSourceSCIM)
+		user = updated
+	}
+
+	if changes.Active != nil && *changes.Active == user.IsDeleted {
+		var err error
+		action := "user.deleted"
+		if *changes.Active {
+			action = "user.restored"
+			user, err = cfg.restoreUser(ctx, database.RestoreUserParams{
+				ID:           user.ID,
+				DeletedAfter: time.Now().Add(-userRestoreWindow),
+			}, "scim")
+			if errors.Is(err, sql.ErrNoRows) {
+				return user, &scimHTTPError{http.StatusBadRequest, scim.ErrInvalidValue, "User can no longer be reactivated"}
+			}
+		} else {
+			user, err = cfg.softDeleteUser(ctx, user.ID, "scim")
+		}
+		if err != nil {
+			return user, err
+		}
+		cfg.recordAudit(ctx, uuid.Nil, action, "user", user.ID.String(), map[string]any{
+			"source": "scim",
+		})
+	}
+	return user, nil
+}
+
+func (cfg *apiConfig) respondWithSCIMChangeError(w http.ResponseWriter, err error) {
+	var scimErr *scimHTTPError
+	if errors.As(err, &scimErr) {
+		respondWithSCIMError(w, scimErr.status, scimErr.scimType, scimErr.detail, nil)
+		return
+	}
+	respondWithSCIMError(w, http.StatusInternalServerError, "", "Couldn't update user", err)
+}
+
+func (cfg *apiConfig) handlerSCIMListUsers(w http.ResponseWriter, r *http.Request) {
+	email, ok, err := scim.ParseUserNameFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidFilter, err.Error(), nil)
+		return
+	}
+	if !ok {
+		respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidFilter, "A userName eq filter is required", nil)
+		return
+	}
+	var users []scim.User
+	user, err := cfg.database.GetUserByEmail(r.Context(), email)
+	if err == nil {
+		users = append(users, cfg.scimUser(r, user))
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondWithSCIMError(w, http.StatusInternalServerError, "", "Couldn't get users", err)
+		return
+	}
+	scim.Write(w, http.StatusOK, scim.NewListResponse(users))
+}
+
+func (cfg *apiConfig) handlerSCIMGetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.scimUserFromPath(w, r)
+	if !ok {
+		return
+	}
+	scim.Write(w, http.StatusOK, cfg.scimUser(r, user))
+}
+
+func (cfg *apiConfig) handlerSCIMCreateUser(w http.ResponseWriter, r *http.Request) {
+	var params scim.User
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidSyntax, "Couldn't decode user", err)
+		return
+	}
+	email := strings.TrimSpace(params.PrimaryEmail())
+	if !strings.Contains(email, "@") {
+		respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidValue, "A valid email is required", nil)
+		return
+	}
+	if _, err := cfg.database.GetUserByEmail(r.Context(), email); err == nil {
+		respondWithSCIMError(w, http.StatusConflict, scim.ErrUniqueness, "Email is already in use", nil)
+		return
+	}
+	hashedPassword := unsetPassword
+	if params.Password != "" {
+		hashed, err := auth.HashPassword(params.Password)
+		if err != nil {
+			respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidValue, "Couldn't use password", err)
+			return
+		}
+		hashedPassword = hashed
+	}
+	user, err := cfg.createUser(r.Context(), database.CreateUserParams{
+		Email:          email,
+		HashedPassword: hashedPassword,
+	}, "scim")
+	if errors.Is(err, database.ErrDuplicateEmail) {
+		respondWithSCIMError(w, http.StatusConflict, scim.ErrUniqueness, "Email is already in use", nil)
+		return
+	}
+	if err != nil {
+		respondWithSCIMError(w, http.StatusInternalServerError, "", "Couldn't create user", err)
+		return
+	}
+	cfg.recordAudit(r.Context(), uuid.Nil, "user.provisioned", "user", user.ID.String(), map[string]any{
+		"source": "scim",
+	})
+	if params.Active != nil && !*params.Active {
+		user, err = cfg.applySCIMChanges(r.Context(), user, scim.Changes{Active: params.Active})
+		if err != nil {
+			cfg.respondWithSCIMChangeError(w, err)
+			return
+		}
+	}
+
+	resource := cfg.scimUser(r, user)
+	w.Header().Set("Location", resource.Meta.Location)
+	scim.Write(w, http.StatusCreated, resource)
+}
+
+func (cfg *apiConfig) handlerSCIMReplaceUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.scimUserFromPath(w, r)
+	if !ok {
+		return
+	}
+	var params scim.User
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidSyntax, "Couldn't decode user", err)
+		return
+	}
+	email := params.PrimaryEmail()
+	active := params.Active == nil || *params.Active
+	user, err := cfg.applySCIMChanges(r.Context(), user, scim.Changes{Email: &email, Active: &active})
+	if err != nil {
+		cfg.respondWithSCIMChangeError(w, err)
+		return
+	}
+	scim.Write(w, http.StatusOK, cfg.scimUser(r, user))
+}
+
+func (cfg *apiConfig) handlerSCIMPatchUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.scimUserFromPath(w, r)
+	if !ok {
+		return
+	}
+	var params scim.PatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidSyntax, "Couldn't decode patch", err)
+		return
+	}
+	changes, err := scim.ParsePatch(params)
+	if err != nil {
+		respondWithSCIMError(w, http.StatusBadRequest, scim.ErrInvalidPath, err.Error(), nil)
+		return
+	}
+	user, err = cfg.applySCIMChanges(r.Context(), user, changes)
+	if err != nil {
+		cfg.respondWithSCIMChangeError(w, err)
+		return
+	}
+	scim.Write(w, http.StatusOK, cfg.scimUser(r, user))
+}
+
+// handlerSCIMDeleteUser deactivates rather than removes the user, so the
+// usual restore window and purge job apply.
+func (cfg *apiConfig) handlerSCIMDeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.scimUserFromPath(w, r)
+	if !ok {
+		return
+	}
+	inactive := false
+	if _, err := cfg.applySCIMChanges(r.Context(), user, scim.Changes{Active: &inactive}); err != nil {
+		cfg.respondWithSCIMChangeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// chirpPageData feeds templates/chirp.html, the server-rendered permalink
+// page that carries Open Graph tags for link unfurling.
+type chirpPageData struct {
+	SiteName    string
+	Title       string
+	Body        string
+	AuthorName  string
+	AvatarURL   string
+	URL         string
+	OEmbedURL   string
+	PublishedAt string
+}
+
+func chirpPermalink(baseURL string, id uuid.UUID) string {
+	return baseURL + "/chirps/" + id.String()
+}
+
+func chirpAuthorName(chirp database.GetMessageWithAuthorByIDRow, siteName string) string {
+	switch {
+	case chirp.AuthorDisplayName.Valid:
+		return chirp.AuthorDisplayName.String
+	case chirp.AuthorHandle.Valid:
+		return "@" + chirp.AuthorHandle.String
+	}
+	return "A " + siteName + " user"
+}
+
+func (cfg *apiConfig) handlerChirpPage(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	chirp, err := cfg.database.GetMessageWithAuthorByID(r.Context(), chirpID)
+	if errors.Is(err, database.ErrChirpNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Couldn't get chirp", http.StatusInternalServerError)
+		return
+	}
+	if !chirpVisible(chirp.Visibility, chirp.UserID, uuid.Nil, nil, false) {
+		http.NotFound(w, r)
+		return
+	}
+
+	baseURL := cfg.publicBaseURL(r)
+	permalink := chirpPermalink(baseURL, chirp.ID)
+	siteName := cfg.siteName(r.Context())
+	authorName := chirpAuthorName(chirp, siteName)
+	data := chirpPageData{
+		SiteName:    siteName,
+		Title:       authorName + " on " + siteName,
+		Body:        publicChirpBody(cfg.readableBody(r.Context(), chirp.Body, chirp.BodyFiltered), chirp.ContentWarning),
+		AuthorName:  authorName,
+		AvatarURL:   chirp.AuthorAvatarUrl.String,
+		URL:         permalink,
+		OEmbedURL:   baseURL + "/api/oembed?format=json&url=" + url.QueryEscape(permalink),
+		PublishedAt: chirp.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	var page bytes.Buffer
+	if err := cfg.chirpTemplate.Execute(&page, data); err != nil {
+		log.Printf("Error rendering chirp page: %s", err)
+		http.Error(w, "Couldn't render chirp", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+}
+
+func (cfg *apiConfig) handlerOEmbed(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		Version      string `json:"version"`
+		Type         string `json:"type"`
+		ProviderName string `json:"provider_name"`
+		ProviderURL  string `json:"provider_url"`
+		AuthorName   string `json:"author_name"`
+		Title        string `json:"title"`
+		HTML         string `json:"html"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		CacheAge     int    `json:"cache_age"`
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		respondWithError(w, http.StatusNotImplemented, "Only the json format is supported", nil)
+		return
+	}
+	baseURL := cfg.publicBaseURL(r)
+	rawID, ok := strings.CutPrefix(r.URL.Query().Get("url"), baseURL+"/chirps/")
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "URL is not a Chirpy chirp", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(strings.TrimSuffix(rawID, "/"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "URL is not a Chirpy chirp", nil)
+		return
+	}
+	chirp, err := cfg.database.GetMessageWithAuthorByID(r.Context(), chirpID)
+	if errors.Is(err, database.ErrChirpNotFound) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+	if !chirpVisible(chirp.Visibility, chirp.UserID, uuid.Nil, nil, false) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+
+	permalink := chirpPermalink(baseURL, chirp.ID)
+	siteName := cfg.siteName(r.Context())
+	authorName := chirpAuthorName(chirp, siteName)
+	// The rich type requires both dimensions; the height is a typical
+	// rendering of the blockquote, since its text wraps to fit.
+	width, height := 550, 200
+	html := fmt.Sprintf(`<blockquote class="chirpy-chirp"><p>%s</p>&mdash; %s <a href="%s">%s</a></blockquote>`,
+		template.HTMLEscapeString(publicChirpBody(cfg.readableBody(r.Context(), chirp.Body, chirp.BodyFiltered), chirp.ContentWarning)),
+		template.HTMLEscapeString(authorName),
+		template.HTMLEscapeString(permalink),
+		chirp.CreatedAt.Format("Jan 2, 2006"),
+	)
+	if n, err := strconv.Atoi(r.URL.Query().Get("maxwidth")); err == nil && n > 0 && n < width {
+		width = n
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("maxheight")); err == nil && n > 0 && n < height {
+		height = n
+	}
+	respondWithJSON(w, http.StatusOK, returnVals{
+		Version:      "1.0",
+		Type:         "rich",
+		ProviderName: siteName,
+		ProviderURL:  baseURL,
+		AuthorName:   authorName,
+		Title:        authorName + " on " + siteName,
+		HTML:         html,
+		Width:        width,
+		Height:       height,
+		CacheAge:     3600,
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/google/uuid"
+)
+
+// handlerChirpsThread returns the conversation around a chirp: its ancestors
+// from the root down, the chirp itself at depth 0, then its replies in
+// depth-first order with positive depths.
+func (cfg *apiConfig) handlerChirpsThread(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		Id             uuid.UUID         `json:"id"`
+		CreatedAt      string            `json:"created_at"`
+		UpdatedAt      string            `json:"updated_at"`
+		Body           string            `json:"body"`
+		RenderedBody   *string           `json:"rendered_body,omitempty"`
+		Emojis         map[string]string `json:"emojis,omitempty"`
+		Media          []mediaResponse   `json:"media,omitempty"`
+		UserID         uuid.UUID         `json:"user_id"`
+		InReplyTo      *uuid.UUID        `json:"in_reply_to,omitempty"`
+		Depth          int32             `json:"depth"`
+		Author         chirpAuthor       `json:"author"`
+		PostedBy       *uuid.UUID        `json:"posted_by,omitempty"`
+		ContentWarning *string           `json:"content_warning"`
+		BodyHidden     bool              `json:"body_hidden"`
+		LikeCount      int64             `json:"like_count"`
+		LikedByMe      bool              `json:"liked_by_me"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	renderHTML, ok := parseChirpRender(w, r)
+	if !ok {
+		return
+	}
+	messages, err := cfg.database.GetMessageThread(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get thread", err)
+		return
+	}
+
+	viewer := cfg.optionalViewer(r)
+	reveal := cfg.revealSensitive(r, viewer)
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	likes, err := cfg.chirpLikes(r.Context(), viewer, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get likes", err)
+		return
+	}
+	authorIDs := make([]uuid.UUID, 0, len(messages))
+	bodies := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		authorIDs = append(authorIDs, msg.UserID)
+		bodies = append(bodies, msg.Body)
+	}
+	bots, err := cfg.botOwners(r.Context(), authorIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get authors", err)
+		return
+	}
+	followed, err := cfg.viewerFollows(r.Context(), viewer, authorIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follows", err)
+		return
+	}
+	emojis, err := cfg.customEmojis(r.Context(), bodies)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get emojis", err)
+		return
+	}
+	media, err := cfg.chirpMedia(r, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
+		return
+	}
+	found := false
+	var chirps []returnVals
+	for _, msg := range messages {
+		if !chirpVisible(msg.Visibility, msg.UserID, viewer, followed, false) {
+			continue
+		}
+		if msg.ID == chirpID {
+			found = true
+		}
+		body, hidden := sensitiveBody(msg.Body, msg.ContentWarning, msg.UserID, viewer, reveal)
+		body = cfg.readableBody(r.Context(), body, msg.BodyFiltered)
+		chirps = append(chirps, returnVals{
+			Id:             msg.ID,
+			CreatedAt:      msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:      msg.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Body:           body,
+			RenderedBody:   renderedBody(renderHTML, body),
+			Emojis:         emojis.in(body),
+			Media:          media[msg.ID],
+			UserID:         msg.UserID,
+			InReplyTo:      nullUUIDPtr(msg.ParentID),
+			Depth:          msg.Depth,
+			Author:         bots.label(newChirpAuthor(msg.UserID, msg.AuthorHandle, msg.AuthorDisplayName, msg.AuthorAvatarUrl, msg.AuthorIsVerified)),
+			PostedBy:       nullUUIDPtr(msg.PostedByID),
+			ContentWarning: nullStringPtr(msg.ContentWarning),
+			BodyHidden:     hidden,
+			LikeCount:      likes[msg.ID].count,
+			LikedByMe:      likes[msg.ID].likedByMe,
+		})
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(chirps))
+}
@@ -1,28 +1,47 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"sort"
-	"strings"
+	"strconv"
+	"time"
 
-	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
 	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
 	"github.com/google/uuid"
 )
 
 func (cfg *apiConfig) handlerChirpsValidate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body   string    `json:"body"`
-		UserID uuid.UUID `json:"user_id"`
+		Body           string     `json:"body"`
+		UserID         uuid.UUID  `json:"user_id"`
+		InReplyTo      *uuid.UUID `json:"in_reply_to"`
+		OnBehalfOf     *uuid.UUID `json:"on_behalf_of"`
+		Visibility     string     `json:"visibility"`
+		ExpiresAt      *time.Time `json:"expires_at"`
+		ContentWarning string     `json:"content_warning"`
 	}
 	type returnVals struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt string    `json:"created_at"`
-		UpdatedAt string    `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
-		Token     string    `json:"token"`
+		Id             uuid.UUID  `json:"id"`
+		CreatedAt      string     `json:"created_at"`
+		UpdatedAt      string     `json:"updated_at"`
+		Body           string     `json:"body"`
+		UserID         uuid.UUID  `json:"user_id"`
+		Status         string     `json:"status"`
+		Visibility     string     `json:"visibility"`
+		InReplyTo      *uuid.UUID `json:"in_reply_to,omitempty"`
+		PostedBy       *uuid.UUID `json:"posted_by,omitempty"`
+		ExpiresAt      *string    `json:"expires_at,omitempty"`
+		ContentWarning *string    `json:"content_warning"`
+		LikeCount      int64      `json:"like_count"`
+		LikedByMe      bool       `json:"liked_by_me"`
+		Token          string     `json:"token"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -32,58 +51,259 @@ func (cfg *apiConfig) handlerChirpsValidate(w http.ResponseWriter, r *http.Reque
 		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
 		return
 	}
-	token, err := auth.GetBearerToken(r.Header)
+	token, err := cfg.accessToken(r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
 		return
 	}
-	auth, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	auth, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeChirpsWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		respondWithTokenError(w, err)
 		return
 	}
 	if auth == uuid.Nil {
 		respondWithError(w, http.StatusUnauthorized, "Invalid user ID in token", nil)
 		return
 	}
-	const maxChirpLength = 140
+	if params.Visibility == "" {
+		params.Visibility = chirpVisibilityPublic
+	}
+	if !validChirpVisibility(params.Visibility) {
+		respondWithError(w, http.StatusBadRequest, "Visibility must be public, followers or unlisted", nil)
+		return
+	}
+	contentWarning, ok := parseContentWarning(params.ContentWarning)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Content warning is too long", nil)
+		return
+	}
+	bodyHash := chirpBodyHash(params.Body)
+	body, shortLinks, err := cfg.shortenLinks(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't shorten links", err)
+		return
+	}
+	params.Body = body
+	maxChirpLength := cfg.settings.Int(r.Context(), settings.ChirpMaxLength)
 	if len(params.Body) > maxChirpLength {
 		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
 		return
 	}
-	messages, err := cfg.database.CreateMessage(r.Context(), database.CreateMessageParams{
-		Body:   params.Body,
-		UserID: auth,
+	profanityCheck := cfg.profanityFilter(r.Context()).Check(profanity.ContextChirpBody, params.Body)
+	if profanityCheck.Action == profanity.ActionReject {
+		respondWithError(w, http.StatusBadRequest, "Chirp contains prohibited language", nil)
+		return
+	}
+	parentID := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.database.GetMessageByID(r.Context(), *params.InReplyTo)
+		if err != nil || parent.Status != chirpStatusPublished {
+			respondWithError(w, http.StatusBadRequest, "Chirp being replied to doesn't exist", err)
+			return
+		}
+		visible, err := cfg.chirpVisibleTo(r.Context(), parent.Visibility, parent.UserID, auth)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp being replied to", err)
+			return
+		}
+		if !visible {
+			respondWithError(w, http.StatusBadRequest, "Chirp being replied to doesn't exist", nil)
+			return
+		}
+		parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+	user, err := cfg.database.GetUserByID(r.Context(), auth)
+	if err != nil || user.IsDeleted {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find user", err)
+		return
+	}
+	// When posting on someone else's behalf the chirp belongs to the owner,
+	// including their probation status, and the poster is kept separately.
+	postedBy := uuid.NullUUID{}
+	if params.OnBehalfOf != nil && *params.OnBehalfOf != auth {
+		delegated, err := cfg.database.HasAcceptedPostDelegation(r.Context(), database.HasAcceptedPostDelegationParams{
+			OwnerID:    *params.OnBehalfOf,
+			DelegateID: auth,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check delegation", err)
+			return
+		}
+		if !delegated {
+			respondWithError(w, http.StatusForbidden, "You aren't allowed to post on behalf of this user", nil)
+			return
+		}
+		user, err = cfg.database.GetUserByID(r.Context(), *params.OnBehalfOf)
+		if err != nil || user.IsDeleted {
+			respondWithError(w, http.StatusForbidden, "You aren't allowed to post on behalf of this user", err)
+			return
+		}
+		postedBy = uuid.NullUUID{UUID: auth, Valid: true}
+	}
+	duplicateID, err := cfg.recentDuplicateChirp(r.Context(), user.ID, bodyHash)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check for duplicate chirps", err)
+		return
+	}
+	duplicate := duplicateID != uuid.Nil
+	if duplicate && !cfg.duplicateChirpsFlagged(r.Context()) {
+		respondWithDuplicateChirp(w, duplicateID)
+		return
+	}
+	if !cfg.allowChirpPost(w, r, user.ID) {
+		return
+	}
+	if !cfg.allowChirpQuota(w, r, user) {
+		return
+	}
+	expiresAt, err := chirpExpiry(time.Now(), params.ExpiresAt, cfg.maxChirpLifetime(r.Context(), user))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	probation := time.Duration(cfg.settings.Int(r.Context(), settings.ProbationHours)) * time.Hour
+	status := initialChirpStatus(user.CreatedAt, probation, params.Body, profanityCheck.Action)
+	if duplicate {
+		status = chirpStatusPending
+	}
+	chirpID, createdAt, err := newChirpID()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create message", err)
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create message", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	messages, err := qtx.CreateMessage(r.Context(), database.CreateMessageParams{
+		ID:             chirpID,
+		CreatedAt:      createdAt,
+		Body:           profanityCheck.Text,
+		UserID:         user.ID,
+		Status:         status,
+		ParentID:       parentID,
+		PostedByID:     postedBy,
+		Visibility:     params.Visibility,
+		ExpiresAt:      expiresAt,
+		ContentWarning: contentWarning,
+		BodyHash:       bodyHash,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create message", err)
+		return
+	}
+	for _, link := range shortLinks {
+		err = qtx.CreateLinkRedirect(r.Context(), database.CreateLinkRedirectParams{
+			Code:      link.Code,
+			TargetUrl: link.Target,
+			MessageID: messages.ID,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save shortened link", err)
+			return
+		}
+	}
+	if tags := extractHashtags(messages.Body); len(tags) > 0 {
+		err = qtx.AddChirpHashtags(r.Context(), database.AddChirpHashtagsParams{Tags: tags, ChirpID: messages.ID})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save hashtags", err)
+			return
+		}
+	}
+	err = appendEvent(r.Context(), qtx, eventChirpPosted, aggregateChirp, messages.ID, map[string]any{
+		"user_id":     messages.UserID,
+		"posted_by":   nullUUIDPtr(messages.PostedByID),
+		"in_reply_to": nullUUIDPtr(messages.ParentID),
+		"status":      messages.Status,
+		"visibility":  messages.Visibility,
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create message", err)
 		return
 	}
-	messages.Body = cleanProfanity(messages.Body)
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create message", err)
+		return
+	}
 	respondWithJSON(w, http.StatusCreated, &returnVals{
-		Id:        messages.ID,
-		CreatedAt: messages.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: messages.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		Body:      messages.Body,
-		UserID:    messages.UserID,
-		Token:     token,
+		Id:             messages.ID,
+		CreatedAt:      messages.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      messages.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Body:           messages.Body,
+		UserID:         messages.UserID,
+		Status:         messages.Status,
+		Visibility:     messages.Visibility,
+		InReplyTo:      nullUUIDPtr(messages.ParentID),
+		PostedBy:       nullUUIDPtr(messages.PostedByID),
+		ExpiresAt:      nullTimeString(messages.ExpiresAt),
+		ContentWarning: nullStringPtr(messages.ContentWarning),
+		Token:          token,
 	})
 }
 
-func cleanProfanity(msg string) string {
-	profaneWords := map[string]bool{
-		"kerfuffle": true,
-		"sharbert":  true,
-		"fornax":    true,
+func (cfg *apiConfig) profanityFilter(ctx context.Context) *profanity.Filter {
+	return cfg.profanityRules.Filter(ctx)
+}
+
+func (cfg *apiConfig) cleanProfanity(ctx context.Context, msg string) string {
+	return cfg.profanityFilter(ctx).Check(profanity.ContextChirpBody, msg).Text
+}
+
+// readableBody returns a stored chirp body as readers see it. Bodies are
+// cleaned when they are written; older ones are cleaned here until the
+// backfill gets to them.
+func (cfg *apiConfig) readableBody(ctx context.Context, body string, filtered bool) string {
+	if filtered {
+		return body
 	}
+	return cfg.cleanProfanity(ctx, body)
+}
 
-	words := strings.Split(msg, " ")
-	for i, word := range words {
-		if profaneWords[strings.ToLower(word)] {
-			words[i] = "****"
-		}
+type chirpAuthor struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsVerified  bool      `json:"is_verified"`
+	// IsAutomated and AutomatedBy are set by botOwners.label.
+	IsAutomated bool       `json:"is_automated"`
+	AutomatedBy *uuid.UUID `json:"automated_by,omitempty"`
+}
+
+func newChirpAuthor(id uuid.UUID, handle, displayName, avatarURL sql.NullString, verified bool) chirpAuthor {
+	return chirpAuthor{
+		ID:          id,
+		Handle:      handle.String,
+		DisplayName: displayName.String,
+		AvatarURL:   avatarURL.String,
+		IsVerified:  verified,
+	}
+}
+
+func nullUUIDPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullTimeString(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
 	}
-	return strings.Join(words, " ")
+	s := t.Time.Format("2006-01-02T15:04:05Z07:00")
+	return &s
 }
 
 type HttpQueriesOptions struct {
@@ -92,65 +312,238 @@ type HttpQueriesOptions struct {
 
 func (cfg *apiConfig) handlerChirpsGetAll(w http.ResponseWriter, r *http.Request) {
 	type returnVals struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt string    `json:"created_at"`
-		UpdatedAt string    `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+		Id             uuid.UUID         `json:"id"`
+		CreatedAt      string            `json:"created_at"`
+		UpdatedAt      string            `json:"updated_at"`
+		Body           string            `json:"body"`
+		RenderedBody   *string           `json:"rendered_body,omitempty"`
+		Emojis         map[string]string `json:"emojis,omitempty"`
+		Media          []mediaResponse   `json:"media,omitempty"`
+		UserID         uuid.UUID         `json:"user_id"`
+		Author         chirpAuthor       `json:"author"`
+		PostedBy       *uuid.UUID        `json:"posted_by,omitempty"`
+		Visibility     string            `json:"visibility"`
+		ExpiresAt      *string           `json:"expires_at,omitempty"`
+		ContentWarning *string           `json:"content_warning"`
+		BodyHidden     bool              `json:"body_hidden"`
+		LikeCount      int64             `json:"like_count"`
+		LikedByMe      bool              `json:"liked_by_me"`
 	}
 
-	author := r.URL.Query().Get("author_id")
-	sorts := r.URL.Query().Get("sort")
+	query := r.URL.Query()
+	sorts := query.Get("sort")
 	if sorts != "" && sorts != "asc" && sorts != "desc" {
 		respondWithError(w, http.StatusBadRequest, "Invalid sort parameter", nil)
 		return
 	}
+	renderHTML, ok := parseChirpRender(w, r)
+	if !ok {
+		return
+	}
+	params := database.GetMessagesWithAuthorParams{RowLimit: 100}
+	var author uuid.NullUUID
+	if raw := query.Get("author_id"); raw != "" {
+		authorID, err := uuid.Parse(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author_id parameter", err)
+			return
+		}
+		author = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return
+		}
+		params.RowLimit = int32(n)
+	}
+
+	// after and before take a next_cursor, or a chirp ID for clients
+	// polling for chirps newer than the last one they saw. Pages run
+	// oldest first, so next_cursor goes in after, unless sort=desc, in
+	// which case it goes in before.
+	for _, bound := range []struct {
+		name      string
+		createdAt *sql.NullTime
+		id        *uuid.NullUUID
+	}{
+		{"after", &params.AfterCreatedAt, &params.AfterID},
+		{"before", &params.BeforeCreatedAt, &params.BeforeID},
+	} {
+		raw := query.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		cursor, err := cfg.parseChirpCursor(r.Context(), raw)
+		if errors.Is(err, database.ErrChirpNotFound) {
+			respondWithError(w, http.StatusBadRequest, "Chirp in "+bound.name+" parameter not found", nil)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid "+bound.name+" parameter", err)
+			return
+		}
+		*bound.createdAt, *bound.id = nullCursor(&cursor)
+	}
 
-	messages, err := cfg.database.GetMessages(r.Context())
+	viewer := cfg.optionalViewer(r)
+	reveal := cfg.revealSensitive(r, viewer)
+	messages, err := cfg.getChirpPage(r.Context(), params, author, sorts == "desc")
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't get messages", err)
 		return
 	}
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	likes, err := cfg.chirpLikes(r.Context(), viewer, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get likes", err)
+		return
+	}
+	muted, err := cfg.viewerMutes(r.Context(), viewer)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get muted words", err)
+		return
+	}
+	authorIDs := make([]uuid.UUID, 0, len(messages))
+	bodies := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		authorIDs = append(authorIDs, msg.UserID)
+		bodies = append(bodies, msg.Body)
+	}
+	bots, err := cfg.botOwners(r.Context(), authorIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get authors", err)
+		return
+	}
+	followed, err := cfg.viewerFollows(r.Context(), viewer, authorIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follows", err)
+		return
+	}
+	emojis, err := cfg.customEmojis(r.Context(), bodies)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get emojis", err)
+		return
+	}
+	media, err := cfg.chirpMedia(r, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
+		return
+	}
 	var chirps []returnVals
-	if sorts == "desc" {
-		sort.Slice(messages, func(i, j int) bool {
-			return messages[i].CreatedAt.After(messages[j].CreatedAt)
+	for _, msg := range messages {
+		if !chirpVisible(msg.Visibility, msg.UserID, viewer, followed, true) {
+			continue
+		}
+		if msg.UserID != viewer && muted.matches(msg.Body) {
+			continue
+		}
+		body, hidden := sensitiveBody(msg.Body, msg.ContentWarning, msg.UserID, viewer, reveal)
+		body = cfg.readableBody(r.Context(), body, msg.BodyFiltered)
+		chirps = append(chirps, returnVals{
+			Id:             msg.ID,
+			CreatedAt:      msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:      msg.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Body:           body,
+			RenderedBody:   renderedBody(renderHTML, body),
+			Emojis:         emojis.in(body),
+			Media:          media[msg.ID],
+			UserID:         msg.UserID,
+			Author:         bots.label(newChirpAuthor(msg.UserID, msg.AuthorHandle, msg.AuthorDisplayName, msg.AuthorAvatarUrl, msg.AuthorIsVerified)),
+			PostedBy:       nullUUIDPtr(msg.PostedByID),
+			Visibility:     msg.Visibility,
+			ExpiresAt:      nullTimeString(msg.ExpiresAt),
+			ContentWarning: nullStringPtr(msg.ContentWarning),
+			BodyHidden:     hidden,
+			LikeCount:      likes[msg.ID].count,
+			LikedByMe:      likes[msg.ID].likedByMe,
+		})
+	}
+	// The cursor comes from the last row read rather than the last chirp
+	// returned, so chirps hidden from or muted by this viewer aren't read
+	// again.
+	var next string
+	if len(messages) == int(params.RowLimit) {
+		last := messages[len(messages)-1]
+		next = api.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(chirps).WithCursors(next, ""))
+}
+
+// getChirpPage reads a page of the timeline, or of one author's chirps
+// when author is set. Each order and filter has its own query so that
+// Postgres can walk the matching (created_at, id) index in either
+// direction and stop at the limit.
+func (cfg *apiConfig) getChirpPage(ctx context.Context, params database.GetMessagesWithAuthorParams, author uuid.NullUUID, newestFirst bool) ([]database.GetMessagesWithAuthorRow, error) {
+	if !author.Valid && !newestFirst {
+		return cfg.database.GetMessagesWithAuthor(ctx, params)
+	}
+	var messages []database.GetMessagesWithAuthorRow
+	switch {
+	case !author.Valid:
+		rows, err := cfg.database.GetMessagesWithAuthorDesc(ctx, database.GetMessagesWithAuthorDescParams(params))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			messages = append(messages, database.GetMessagesWithAuthorRow(row))
+		}
+	case newestFirst:
+		rows, err := cfg.database.GetMessagesByAuthorDesc(ctx, database.GetMessagesByAuthorDescParams{
+			AuthorID:        author.UUID,
+			AfterCreatedAt:  params.AfterCreatedAt,
+			AfterID:         params.AfterID,
+			BeforeCreatedAt: params.BeforeCreatedAt,
+			BeforeID:        params.BeforeID,
+			RowLimit:        params.RowLimit,
 		})
-	} else if sorts == "asc" {
-		sort.Slice(messages, func(i, j int) bool {
-			return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			messages = append(messages, database.GetMessagesWithAuthorRow(row))
+		}
+	default:
+		rows, err := cfg.database.GetMessagesByAuthor(ctx, database.GetMessagesByAuthorParams{
+			AuthorID:        author.UUID,
+			AfterCreatedAt:  params.AfterCreatedAt,
+			AfterID:         params.AfterID,
+			BeforeCreatedAt: params.BeforeCreatedAt,
+			BeforeID:        params.BeforeID,
+			RowLimit:        params.RowLimit,
 		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			messages = append(messages, database.GetMessagesWithAuthorRow(row))
+		}
 	}
-	for _, msg := range messages {
-		if author != "" && msg.UserID == uuid.MustParse(author) {
-			chirps = append(chirps, returnVals{
-				Id:        msg.ID,
-				CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				UpdatedAt: msg.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				Body:      cleanProfanity(msg.Body),
-				UserID:    msg.UserID,
-			})
-		} else if author == "" {
-			chirps = append(chirps, returnVals{
-				Id:        msg.ID,
-				CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				UpdatedAt: msg.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				Body:      cleanProfanity(msg.Body),
-				UserID:    msg.UserID,
-			})
-		}
-
-	}
-	respondWithJSON(w, http.StatusOK, chirps)
+	return messages, nil
 }
 
 func (cfg *apiConfig) handlerChirpsGetByID(w http.ResponseWriter, r *http.Request) {
 	type returnVals struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt string    `json:"created_at"`
-		UpdatedAt string    `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+		Id             uuid.UUID         `json:"id"`
+		CreatedAt      string            `json:"created_at"`
+		UpdatedAt      string            `json:"updated_at"`
+		Body           string            `json:"body"`
+		RenderedBody   *string           `json:"rendered_body,omitempty"`
+		Emojis         map[string]string `json:"emojis,omitempty"`
+		Media          []mediaResponse   `json:"media,omitempty"`
+		UserID         uuid.UUID         `json:"user_id"`
+		Author         chirpAuthor       `json:"author"`
+		PostedBy       *uuid.UUID        `json:"posted_by,omitempty"`
+		Visibility     string            `json:"visibility"`
+		ExpiresAt      *string           `json:"expires_at,omitempty"`
+		ContentWarning *string           `json:"content_warning"`
+		BodyHidden     bool              `json:"body_hidden"`
+		LikeCount      int64             `json:"like_count"`
+		LikedByMe      bool              `json:"liked_by_me"`
 	}
 
 	idStrg := r.PathValue("chirpID")
@@ -158,17 +551,64 @@ func (cfg *apiConfig) handlerChirpsGetByID(w http.ResponseWriter, r *http.Reques
 		respondWithError(w, http.StatusBadRequest, "Chirp ID is required", nil)
 		return
 	}
+	renderHTML, ok := parseChirpRender(w, r)
+	if !ok {
+		return
+	}
 
-	chripts, err := cfg.database.GetMessageByID(r.Context(), uuid.MustParse(idStrg))
+	chripts, err := cfg.database.GetMessageWithAuthorByID(r.Context(), uuid.MustParse(idStrg))
+	if err != nil {
+		respondWithDBError(w, "Couldn't get message", err)
+		return
+	}
+	viewer := cfg.optionalViewer(r)
+	visible, err := cfg.chirpVisibleTo(r.Context(), chripts.Visibility, chripts.UserID, viewer)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get message", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "Couldn't get message", nil)
+		return
+	}
+	body, hidden := sensitiveBody(chripts.Body, chripts.ContentWarning, chripts.UserID, viewer, cfg.revealSensitive(r, viewer))
+	body = cfg.readableBody(r.Context(), body, chripts.BodyFiltered)
+	likes, err := cfg.chirpLikes(r.Context(), viewer, []uuid.UUID{chripts.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get likes", err)
+		return
+	}
+	bots, err := cfg.botOwners(r.Context(), []uuid.UUID{chripts.UserID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get author", err)
+		return
+	}
+	emojis, err := cfg.customEmojis(r.Context(), []string{chripts.Body})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get emojis", err)
+		return
+	}
+	media, err := cfg.chirpMedia(r, []uuid.UUID{chripts.ID})
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Couldn't get message", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
 		return
 	}
 	respondWithJSON(w, http.StatusOK, &returnVals{
-		Id:        chripts.ID,
-		CreatedAt: chripts.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: chripts.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		Body:      cleanProfanity(chripts.Body),
-		UserID:    chripts.UserID,
+		Id:             chripts.ID,
+		CreatedAt:      chripts.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      chripts.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Body:           body,
+		RenderedBody:   renderedBody(renderHTML, body),
+		Emojis:         emojis.in(body),
+		Media:          media[chripts.ID],
+		UserID:         chripts.UserID,
+		Author:         bots.label(newChirpAuthor(chripts.UserID, chripts.AuthorHandle, chripts.AuthorDisplayName, chripts.AuthorAvatarUrl, chripts.AuthorIsVerified)),
+		PostedBy:       nullUUIDPtr(chripts.PostedByID),
+		Visibility:     chripts.Visibility,
+		ExpiresAt:      nullTimeString(chripts.ExpiresAt),
+		ContentWarning: nullStringPtr(chripts.ContentWarning),
+		BodyHidden:     hidden,
+		LikeCount:      likes[chripts.ID].count,
+		LikedByMe:      likes[chripts.ID].likedByMe,
 	})
 }
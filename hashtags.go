@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+const (
+	maxHashtagLength    = 100
+	maxHashtagsPerChirp = 10
+)
+
+// hashtagPattern matches a # that starts a word, so URL fragments and
+// HTML entities like &#39; aren't taken for tags.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]+)`)
+
+// extractHashtags returns the distinct tags in body, lowercased, in the
+// order they first appear. Tags made only of digits and underscores, like
+// #1, are ignored, as are overlong ones.
+func extractHashtags(body string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, m := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		tag, ok := normalizeHashtag(m[1])
+		if !ok || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+		if len(tags) == maxHashtagsPerChirp {
+			break
+		}
+	}
+	return tags
+}
+
+// normalizeHashtag lowercases tag, dropping a leading #, and reports
+// whether it is a valid hashtag.
+func normalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || len(tag) > maxHashtagLength {
+		return "", false
+	}
+	hasLetter := false
+	for _, r := range tag {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r), r == '_':
+		default:
+			return "", false
+		}
+	}
+	return tag, hasLetter
+}
+
+func (cfg *apiConfig) handlerHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag, ok := normalizeHashtag(r.PathValue("tag"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Invalid hashtag", nil)
+		return
+	}
+	page, ok := parseTimelinePage(w, r)
+	if !ok {
+		return
+	}
+	rows, err := cfg.database.GetMessagesByHashtag(r.Context(), database.GetMessagesByHashtagParams{
+		Tag:             tag,
+		BeforeCreatedAt: page.beforeCreatedAt,
+		BeforeID:        page.beforeID,
+		RowLimit:        page.limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get messages", err)
+		return
+	}
+	messages := make([]database.GetMessagesWithAuthorRow, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, database.GetMessagesWithAuthorRow(row))
+	}
+	cfg.respondWithTimeline(w, r, messages, page.limit)
+}
@@ -0,0 +1,33 @@
+package api
+
+// Page is the envelope returned by every list endpoint so clients can
+// handle paging the same way regardless of the resource.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// NewPage wraps data in a Page, making sure an empty result is encoded as
+// an empty array rather than null.
+func NewPage[T any](data []T) Page[T] {
+	if data == nil {
+		data = []T{}
+	}
+	return Page[T]{Data: data}
+}
+
+// WithTotal sets the total number of items across all pages. Only use it
+// when the count is cheap to compute.
+func (p Page[T]) WithTotal(total int64) Page[T] {
+	p.Total = &total
+	return p
+}
+
+// WithCursors sets the cursors pointing at the next and previous pages.
+func (p Page[T]) WithCursors(next, prev string) Page[T] {
+	p.NextCursor = next
+	p.PrevCursor = prev
+	return p
+}
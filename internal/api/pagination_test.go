@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPageJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		page Page[int]
+		want string
+	}{
+		{
+			name: "nil data encodes as empty array",
+			page: NewPage[int](nil),
+			want: `{"data":[]}`,
+		},
+		{
+			name: "data without cursors",
+			page: NewPage([]int{1, 2}),
+			want: `{"data":[1,2]}`,
+		},
+		{
+			name: "data with total",
+			page: NewPage([]int{1}).WithTotal(10),
+			want: `{"data":[1],"total":10}`,
+		},
+		{
+			name: "zero total is still reported",
+			page: NewPage[int](nil).WithTotal(0),
+			want: `{"data":[],"total":0}`,
+		},
+		{
+			name: "data with cursors",
+			page: NewPage([]int{3}).WithCursors("next", "prev"),
+			want: `{"data":[3],"next_cursor":"next","prev_cursor":"prev"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.page)
+			if err != nil {
+				t.Fatalf("json.Marshal() unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{
+			name:   "zero value",
+			cursor: Cursor{CreatedAt: time.Unix(0, 0).UTC()},
+		},
+		{
+			name: "sub-second precision",
+			cursor: Cursor{
+				CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC),
+				ID:        uuid.MustParse("8f4c1e7a-5b7e-4d56-9a0c-3c6b7f7f0a11"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeCursor() unexpected error: %v", err)
+			}
+			if !got.CreatedAt.Equal(tt.cursor.CreatedAt) || got.ID != tt.cursor.ID {
+				t.Errorf("DecodeCursor() = %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, s := range []string{"", "not base64!", "bm8tc2VwYXJhdG9y"} {
+		if _, err := DecodeCursor(s); err == nil {
+			t.Errorf("DecodeCursor(%q) expected error, got nil", s)
+		}
+	}
+}
@@ -2,8 +2,12 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -20,7 +24,87 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
+// RefreshTokenPrefix starts every refresh token, so secret scanners can
+// recognise leaked ones.
+const RefreshTokenPrefix = "chrp_rt_"
+
+var ErrMalformedRefreshToken = errors.New("malformed refresh token")
+
+// MakeRefreshToken returns a token of the form chrp_rt_<id>_<secret>_<crc>,
+// where crc is the CRC-32 of everything before it. The checksum isn't a
+// security measure; it lets CheckRefreshToken reject typos and guesses
+// without a database lookup.
 func MakeRefreshToken() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	secret, err := makeRandomToken()
+	if err != nil {
+		return "", err
+	}
+	body := RefreshTokenPrefix + hex.EncodeToString(id) + "_" + secret
+	return body + "_" + refreshTokenChecksum(body), nil
+}
+
+// CheckRefreshToken reports whether token is well formed. Tokens issued
+// before the prefixed format, which are 64 hex characters, are still
+// accepted until they expire.
+func CheckRefreshToken(token string) error {
+	if isHex(token, 64) {
+		return nil
+	}
+	rest, ok := strings.CutPrefix(token, RefreshTokenPrefix)
+	if !ok {
+		return ErrMalformedRefreshToken
+	}
+	parts := strings.Split(rest, "_")
+	if len(parts) != 3 || !isHex(parts[0], 16) || !isHex(parts[1], 64) || !isHex(parts[2], 8) {
+		return ErrMalformedRefreshToken
+	}
+	body := token[:len(token)-len(parts[2])-1]
+	if parts[2] != refreshTokenChecksum(body) {
+		return ErrMalformedRefreshToken
+	}
+	return nil
+}
+
+func refreshTokenChecksum(body string) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(body)))
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// MakeSessionToken returns a random token suitable for browser session
+// identifiers and CSRF tokens.
+func MakeSessionToken() (string, error) {
+	return makeRandomToken()
+}
+
+// MakeAPIKey returns a new developer API key. Only its hash is stored, so
+// the key itself can be shown to its owner once.
+func MakeAPIKey() (string, error) {
+	token, err := makeRandomToken()
+	if err != nil {
+		return "", err
+	}
+	return "chirpy_" + token, nil
+}
+
+// HashToken returns the value stored in place of a high-entropy secret such
+// as an API key or OAuth token, so a database leak doesn't expose them.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func makeRandomToken() (string, error) {
 	key := make([]byte, 32) // 256 bits
 	n, err := rand.Read(key)
 	if err != nil {
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckRefreshToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, RefreshTokenPrefix) {
+		t.Fatalf("MakeRefreshToken() = %q, want prefix %q", token, RefreshTokenPrefix)
+	}
+	legacy, err := makeRandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip one character of the secret, keeping it hex.
+	i := len(RefreshTokenPrefix) + 17
+	flipped := byte('0')
+	if token[i] == '0' {
+		flipped = '1'
+	}
+	typo := token[:i] + string(flipped) + token[i+1:]
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: token},
+		{name: "legacy hex", token: legacy},
+		{name: "typo", token: typo, wantErr: true},
+		{name: "missing checksum", token: token[:strings.LastIndex(token, "_")], wantErr: true},
+		{name: "uppercase", token: strings.ToUpper(token), wantErr: true},
+		{name: "wrong prefix", token: "chirpy_" + strings.TrimPrefix(token, RefreshTokenPrefix), wantErr: true},
+		{name: "empty", token: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRefreshToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckRefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
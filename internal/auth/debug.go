@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DebugHeaderMaxTTL caps how long a signed debug header stays valid, so one
+// that ends up in a ticket or a shell history soon stops working.
+const DebugHeaderMaxTTL = time.Hour
+
+// SignDebugHeader returns an X-Debug-Capture value valid until expires. It
+// has the form "<unix seconds>.<hex HMAC-SHA256 of the seconds>".
+func SignDebugHeader(secret string, expires time.Time) string {
+	ts := strconv.FormatInt(expires.Unix(), 10)
+	return ts + "." + debugSignature(secret, ts)
+}
+
+// VerifyDebugHeader reports whether value was made by SignDebugHeader with
+// secret and is still valid at now.
+func VerifyDebugHeader(secret, value string, now time.Time) bool {
+	if secret == "" {
+		return false
+	}
+	ts, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	seconds, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	expires := time.Unix(seconds, 0)
+	if !now.Before(expires) || expires.Sub(now) > DebugHeaderMaxTTL {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(debugSignature(secret, ts)))
+}
+
+func debugSignature(secret, ts string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
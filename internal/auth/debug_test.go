@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyDebugHeader(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	valid := SignDebugHeader("secret", now.Add(10*time.Minute))
+	_, sig, _ := strings.Cut(valid, ".")
+	tampered := strconv.FormatInt(now.Add(20*time.Minute).Unix(), 10) + "." + sig
+
+	tests := []struct {
+		name   string
+		secret string
+		value  string
+		want   bool
+	}{
+		{name: "valid", secret: "secret", value: valid, want: true},
+		{name: "wrong secret", secret: "other", value: valid, want: false},
+		{name: "no secret configured", secret: "", value: SignDebugHeader("", now.Add(time.Minute)), want: false},
+		{name: "expired", secret: "secret", value: SignDebugHeader("secret", now.Add(-time.Second)), want: false},
+		{name: "valid for too long", secret: "secret", value: SignDebugHeader("secret", now.Add(2*time.Hour)), want: false},
+		{name: "tampered expiry", secret: "secret", value: tampered, want: false},
+		{name: "malformed", secret: "secret", value: "garbage", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyDebugHeader(tt.secret, tt.value, now); got != tt.want {
+				t.Errorf("VerifyDebugHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
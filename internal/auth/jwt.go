@@ -9,12 +9,33 @@ import (
 	"github.com/google/uuid"
 )
 
+// Claims are what Chirpy's access tokens carry.
+type Claims struct {
+	UserID uuid.UUID
+	// Version is the user's token version when the token was issued. Tokens
+	// from before the version was introduced have version 0.
+	Version int32
+}
+
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Version int32 `json:"ver,omitempty"`
+}
+
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	return MakeVersionedJWT(userID, 0, tokenSecret, expiresIn)
+}
+
+// MakeVersionedJWT is MakeJWT for a user whose token version is version.
+func MakeVersionedJWT(userID uuid.UUID, version int32, tokenSecret string, expiresIn time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Version: version,
 	})
 	tokenString, err := token.SignedString([]byte(tokenSecret))
 	if err != nil {
@@ -23,25 +44,44 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return tokenString, nil
 }
 
+// MaxJWTLeeway bounds the clock skew ValidateJWTWithLeeway tolerates, so a
+// misconfiguration can't stretch token lifetimes much.
+const MaxJWTLeeway = 5 * time.Minute
+
 func ValidateJWT(tokenString string, tokenSecret string) (uuid.UUID, error) {
-	calims := &jwt.RegisteredClaims{}
+	return ValidateJWTWithLeeway(tokenString, tokenSecret, 0)
+}
+
+// ValidateJWTWithLeeway is ValidateJWT allowing the time-based claims to be
+// off by up to leeway, for servers whose clocks drift apart slightly.
+func ValidateJWTWithLeeway(tokenString string, tokenSecret string, leeway time.Duration) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, tokenSecret, leeway)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+// ParseJWT is ValidateJWTWithLeeway returning every claim Chirpy uses.
+func ParseJWT(tokenString string, tokenSecret string, leeway time.Duration) (Claims, error) {
+	calims := &jwtClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, calims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(tokenSecret), nil
-	})
+	}, jwt.WithLeeway(min(leeway, MaxJWTLeeway)))
 	if err != nil {
-		return uuid.Nil, err
+		return Claims{}, err
 	}
 	if !token.Valid {
-		return uuid.Nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		return Claims{}, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
 	userID, err := uuid.Parse(calims.Subject)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID in token: %w", err)
+		return Claims{}, fmt.Errorf("invalid user ID in token: %w", err)
 	}
-	return userID, nil
+	return Claims{UserID: userID, Version: calims.Version}, nil
 
 }
 
@@ -205,6 +205,38 @@ func TestMakeJWTAndValidateJWT_Integration(t *testing.T) {
 	}
 }
 
+func TestValidateJWTWithLeeway(t *testing.T) {
+	userID := uuid.New()
+	secret := "leeway-secret"
+	expired, err := MakeJWT(userID, secret, -10*time.Second)
+	if err != nil {
+		t.Fatalf("MakeJWT() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		leeway  time.Duration
+		wantErr bool
+	}{
+		{name: "no leeway", leeway: 0, wantErr: true},
+		{name: "leeway covers the skew", leeway: 30 * time.Second, wantErr: false},
+		{name: "leeway too small", leeway: 5 * time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateJWTWithLeeway(expired, secret, tt.leeway)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWTWithLeeway() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	longExpired, _ := MakeJWT(userID, secret, -MaxJWTLeeway-time.Minute)
+	if _, err := ValidateJWTWithLeeway(longExpired, secret, time.Hour); err == nil {
+		t.Error("ValidateJWTWithLeeway() accepted a leeway above MaxJWTLeeway")
+	}
+}
+
 func TestValidateJWT_DifferentSigningMethods(t *testing.T) {
 	secret := "test-secret"
 
@@ -331,3 +363,31 @@ func BenchmarkValidateJWT(b *testing.B) {
 		}
 	}
 }
+
+func TestParseJWTVersion(t *testing.T) {
+	userID := uuid.New()
+	secret := "version-secret"
+	tests := []struct {
+		name string
+		make func() (string, error)
+		want int32
+	}{
+		{name: "versioned", make: func() (string, error) { return MakeVersionedJWT(userID, 3, secret, time.Hour) }, want: 3},
+		{name: "unversioned", make: func() (string, error) { return MakeJWT(userID, secret, time.Hour) }, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.make()
+			if err != nil {
+				t.Fatalf("making token failed: %v", err)
+			}
+			claims, err := ParseJWT(token, secret, 0)
+			if err != nil {
+				t.Fatalf("ParseJWT() failed: %v", err)
+			}
+			if claims.UserID != userID || claims.Version != tt.want {
+				t.Errorf("ParseJWT() = %+v, want user %v version %d", claims, userID, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// mediaLabel separates media URL signatures from other HMACs made with the
+// same secret.
+const mediaLabel = "chirpy media url\n"
+
+// SignMediaURL returns the signature that lets key be fetched until
+// expires, as the hex HMAC-SHA256 of the key and the expiry in unix
+// seconds.
+func SignMediaURL(secret, key string, expires time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(mediaLabel))
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyMediaURL reports whether signature was made by SignMediaURL for
+// key with secret, and the expiry, in unix seconds, is after now.
+func VerifyMediaURL(secret, key, expires, signature string, now time.Time) bool {
+	if secret == "" {
+		return false
+	}
+	seconds, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return false
+	}
+	exp := time.Unix(seconds, 0)
+	if !now.Before(exp) {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignMediaURL(secret, key, exp)))
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyMediaURL(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	sig := SignMediaURL("secret", "chirps/1/a.png", expires)
+
+	tests := []struct {
+		name    string
+		secret  string
+		key     string
+		expires string
+		sig     string
+		want    bool
+	}{
+		{name: "valid", secret: "secret", key: "chirps/1/a.png", expires: exp, sig: sig, want: true},
+		{name: "wrong secret", secret: "other", key: "chirps/1/a.png", expires: exp, sig: sig, want: false},
+		{name: "no secret configured", secret: "", key: "chirps/1/a.png", expires: exp, sig: SignMediaURL("", "chirps/1/a.png", expires), want: false},
+		{name: "other key", secret: "secret", key: "chirps/1/b.png", expires: exp, sig: sig, want: false},
+		{name: "extended expiry", secret: "secret", key: "chirps/1/a.png", expires: strconv.FormatInt(expires.Add(time.Hour).Unix(), 10), sig: sig, want: false},
+		{name: "expired", secret: "secret", key: "chirps/1/a.png", expires: strconv.FormatInt(now.Unix(), 10), sig: SignMediaURL("secret", "chirps/1/a.png", now), want: false},
+		{name: "malformed expiry", secret: "secret", key: "chirps/1/a.png", expires: "soon", sig: sig, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyMediaURL(tt.secret, tt.key, tt.expires, tt.sig, now); got != tt.want {
+				t.Errorf("VerifyMediaURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// reportLabel separates report signatures from other HMACs made with the
+// same secret.
+const reportLabel = "chirpy deletion report\n"
+
+// SignReport returns the hex HMAC-SHA256 of report, so a copy handed to an
+// auditor can later be shown to be unaltered.
+func SignReport(secret string, report []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(reportLabel))
+	mac.Write(report)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyReport reports whether signature was made by SignReport for report
+// with secret.
+func VerifyReport(secret string, report []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(SignReport(secret, report)))
+}
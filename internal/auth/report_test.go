@@ -0,0 +1,28 @@
+package auth
+
+import "testing"
+
+func TestVerifyReport(t *testing.T) {
+	report := []byte(`{"user_id":"0190f0b2-7a6e-7c1e-9c1e-1234567890ab","verified":true}`)
+	sig := SignReport("secret", report)
+
+	tests := []struct {
+		name   string
+		secret string
+		report []byte
+		sig    string
+		want   bool
+	}{
+		{name: "valid", secret: "secret", report: report, sig: sig, want: true},
+		{name: "altered report", secret: "secret", report: []byte(`{"user_id":"0190f0b2-7a6e-7c1e-9c1e-1234567890ab","verified":false}`), sig: sig},
+		{name: "wrong secret", secret: "other", report: report, sig: sig},
+		{name: "debug header signature", secret: "secret", report: report, sig: debugSignature("secret", string(report))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyReport(tt.secret, tt.report, tt.sig); got != tt.want {
+				t.Errorf("VerifyReport() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WebhookMaxAge is how far a webhook's timestamp may be from the current
+// time, in either direction.
+const WebhookMaxAge = 5 * time.Minute
+
+const maxWebhookNonceLength = 128
+
+var (
+	ErrWebhookSignature = errors.New("missing or invalid X-Webhook-Signature header")
+	ErrWebhookTimestamp = errors.New("missing or invalid X-Webhook-Timestamp header")
+	ErrWebhookStale     = errors.New("webhook timestamp is too old or in the future")
+	ErrWebhookNonce     = errors.New("missing or invalid X-Webhook-Nonce header")
+	ErrWebhookReplayed  = errors.New("webhook nonce has already been used")
+)
+
+// NonceStore remembers the nonces of accepted webhooks. ClaimNonce returns
+// false if the nonce was already claimed for that source. Nonces only need
+// to be kept for twice WebhookMaxAge, after which the timestamp check
+// rejects a replay on its own.
+type NonceStore interface {
+	ClaimNonce(ctx context.Context, source, nonce string) (bool, error)
+}
+
+// SignWebhook returns the X-Webhook-Signature for a webhook: the hex
+// HMAC-SHA256 of its timestamp, nonce and body joined with ".". Covering
+// the timestamp and nonce means a captured request can't be sent again
+// with fresh ones.
+func SignWebhook(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the X-Webhook-Signature of a webhook with body, and
+// only then that its X-Webhook-Timestamp (unix seconds) is fresh. It
+// returns the X-Webhook-Nonce, which the caller claims with
+// ClaimWebhookNonce in the transaction that applies the webhook, so a
+// delivery that fails can be retried.
+func VerifyWebhook(h http.Header, body []byte, secret string, now time.Time) (string, error) {
+	timestamp, nonce := h.Get("X-Webhook-Timestamp"), h.Get("X-Webhook-Nonce")
+	signature := h.Get("X-Webhook-Signature")
+	if secret == "" || !hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, nonce, body))) {
+		return "", ErrWebhookSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", ErrWebhookTimestamp
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > WebhookMaxAge || age < -WebhookMaxAge {
+		return "", ErrWebhookStale
+	}
+	if nonce == "" || len(nonce) > maxWebhookNonceLength {
+		return "", ErrWebhookNonce
+	}
+	return nonce, nil
+}
+
+// ClaimWebhookNonce records nonce for source, failing with
+// ErrWebhookReplayed if it was seen before. Other errors come from the
+// store.
+func ClaimWebhookNonce(ctx context.Context, store NonceStore, source, nonce string) error {
+	fresh, err := store.ClaimNonce(ctx, source, nonce)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrWebhookReplayed
+	}
+	return nil
+}
+
+// IsWebhookReplayError reports whether err is a rejection by VerifyWebhook
+// or ClaimWebhookNonce rather than a store failure.
+func IsWebhookReplayError(err error) bool {
+	return errors.Is(err, ErrWebhookSignature) || errors.Is(err, ErrWebhookTimestamp) ||
+		errors.Is(err, ErrWebhookStale) || errors.Is(err, ErrWebhookNonce) ||
+		errors.Is(err, ErrWebhookReplayed)
+}
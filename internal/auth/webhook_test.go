@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type memoryNonces map[string]bool
+
+func (m memoryNonces) ClaimNonce(ctx context.Context, source, nonce string) (bool, error) {
+	key := source + "/" + nonce
+	if m[key] {
+		return false, nil
+	}
+	m[key] = true
+	return true, nil
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"event":"user.upgraded"}`)
+	signed := func(secret string, ts time.Time, nonce string, body []byte) http.Header {
+		h := http.Header{}
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		h.Set("X-Webhook-Timestamp", timestamp)
+		h.Set("X-Webhook-Nonce", nonce)
+		h.Set("X-Webhook-Signature", SignWebhook(secret, timestamp, nonce, body))
+		return h
+	}
+	// resigned is a captured request with a fresh timestamp and nonce put
+	// on it by someone without the secret.
+	resigned := signed("secret", now.Add(-time.Hour), "old", body)
+	resigned.Set("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	resigned.Set("X-Webhook-Nonce", "new")
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		secret  string
+		wantErr error
+	}{
+		{name: "fresh", header: signed("secret", now.Add(-time.Minute), "n1", body), body: body, secret: "secret"},
+		{name: "replay with fresh headers", header: resigned, body: body, secret: "secret", wantErr: ErrWebhookSignature},
+		{name: "altered body", header: signed("secret", now, "n2", body), body: []byte(`{}`), secret: "secret", wantErr: ErrWebhookSignature},
+		{name: "wrong secret", header: signed("other", now, "n3", body), body: body, secret: "secret", wantErr: ErrWebhookSignature},
+		{name: "no secret configured", header: signed("", now, "n4", body), body: body, secret: "", wantErr: ErrWebhookSignature},
+		{name: "too old", header: signed("secret", now.Add(-6*time.Minute), "n5", body), body: body, secret: "secret", wantErr: ErrWebhookStale},
+		{name: "too far ahead", header: signed("secret", now.Add(6*time.Minute), "n6", body), body: body, secret: "secret", wantErr: ErrWebhookStale},
+		{name: "missing nonce", header: signed("secret", now, "", body), body: body, secret: "secret", wantErr: ErrWebhookNonce},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce, err := VerifyWebhook(tt.header, tt.body, tt.secret, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && !IsWebhookReplayError(err) {
+				t.Errorf("IsWebhookReplayError(%v) = false", err)
+			}
+			if err == nil && nonce != tt.header.Get("X-Webhook-Nonce") {
+				t.Errorf("VerifyWebhook() nonce = %q", nonce)
+			}
+		})
+	}
+}
+
+func TestClaimWebhookNonce(t *testing.T) {
+	store := memoryNonces{"polka/seen": true}
+	ctx := context.Background()
+	if err := ClaimWebhookNonce(ctx, store, "polka", "n1"); err != nil {
+		t.Errorf("ClaimWebhookNonce() of a new nonce = %v", err)
+	}
+	if err := ClaimWebhookNonce(ctx, store, "stripe", "seen"); err != nil {
+		t.Errorf("ClaimWebhookNonce() of another source's nonce = %v", err)
+	}
+	if err := ClaimWebhookNonce(ctx, store, "polka", "seen"); !errors.Is(err, ErrWebhookReplayed) {
+		t.Errorf("ClaimWebhookNonce() of a used nonce = %v, want %v", err, ErrWebhookReplayed)
+	}
+}
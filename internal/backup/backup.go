@@ -0,0 +1,162 @@
+// Package backup takes and restores logical dumps of the Chirpy database by
+// streaming through pg_dump and pg_restore. Dumps use pg_dump's custom
+// format, which is compressed and taken from a single snapshot, so it is
+// consistent even while the server keeps writing.
+//
+// The database URL is never passed to the tools as an argument, where any
+// user on the host could read the password from the process list. The
+// password and connection options travel in the tools' environment instead.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tools are the client binaries to run. Empty fields use the ones on PATH.
+type Tools struct {
+	PgDump    string
+	PgRestore string
+}
+
+func (t Tools) pgDump() string {
+	if t.PgDump == "" {
+		return "pg_dump"
+	}
+	return t.PgDump
+}
+
+func (t Tools) pgRestore() string {
+	if t.PgRestore == "" {
+		return "pg_restore"
+	}
+	return t.PgRestore
+}
+
+// FileName is the name given to a dump taken at t.
+func FileName(t time.Time) string {
+	return "chirpy-" + t.UTC().Format("20060102T150405Z") + ".dump"
+}
+
+// urlParamEnv maps the query parameters a database URL may carry to the
+// libpq environment variables that set them.
+var urlParamEnv = map[string]string{
+	"application_name": "PGAPPNAME",
+	"connect_timeout":  "PGCONNECT_TIMEOUT",
+	"sslcert":          "PGSSLCERT",
+	"sslkey":           "PGSSLKEY",
+	"sslmode":          "PGSSLMODE",
+	"sslrootcert":      "PGSSLROOTCERT",
+}
+
+// connection splits a postgres:// URL into the host, port, user and
+// database flags for pg_dump and pg_restore, and the environment variables
+// that carry the password and any other options.
+func connection(databaseURL string) (args, env []string, err error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing database URL: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, nil, errors.New("database URL must be a postgres:// URL")
+	}
+	if host := u.Hostname(); host != "" {
+		args = append(args, "--host="+host)
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "--port="+port)
+	}
+	if user := u.User.Username(); user != "" {
+		args = append(args, "--username="+user)
+	}
+	if password, ok := u.User.Password(); ok {
+		env = append(env, "PGPASSWORD="+password)
+	}
+	if name := strings.TrimPrefix(u.Path, "/"); name != "" {
+		args = append(args, "--dbname="+name)
+	}
+
+	query := u.Query()
+	params := make([]string, 0, len(query))
+	for param := range query {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		name, ok := urlParamEnv[param]
+		if !ok {
+			return nil, nil, fmt.Errorf("database URL parameter %q isn't supported for backups", param)
+		}
+		env = append(env, name+"="+query.Get(param))
+	}
+	return args, env, nil
+}
+
+// command runs tool against the database at databaseURL with the given
+// options.
+func command(ctx context.Context, tool, databaseURL string, opts ...string) (*exec.Cmd, error) {
+	args, env, err := connection(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, tool, append(opts, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd, nil
+}
+
+// Dump writes a dump of the database to w and returns the number of bytes
+// written.
+func (t Tools) Dump(ctx context.Context, databaseURL string, w io.Writer) (int64, error) {
+	cmd, err := command(ctx, t.pgDump(), databaseURL, "--format=custom", "--no-owner", "--no-privileges")
+	if err != nil {
+		return 0, err
+	}
+	counter := &countingWriter{w: w}
+	err = run(cmd, nil, counter)
+	return counter.n, err
+}
+
+// Restore replaces the contents of the database with the dump read from r.
+// It runs in a single transaction, so a failed restore leaves the database
+// as it was.
+func (t Tools) Restore(ctx context.Context, databaseURL string, r io.Reader) error {
+	cmd, err := command(ctx, t.pgRestore(), databaseURL, "--clean", "--if-exists", "--no-owner", "--no-privileges", "--single-transaction", "--exit-on-error")
+	if err != nil {
+		return err
+	}
+	return run(cmd, r, io.Discard)
+}
+
+func run(cmd *exec.Cmd, stdin io.Reader, stdout io.Writer) error {
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,189 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned without calling the dependency while its breaker is
+// open or already probing.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Settings control when a breaker trips and how long calls may take.
+type Settings struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting a single
+	// probe call through.
+	OpenTimeout time.Duration
+	// CallTimeout bounds every call so a slow dependency can't hold on to
+	// handler goroutines. Zero means no timeout.
+	CallTimeout time.Duration
+}
+
+// DefaultSettings are used by NewRegistry when no settings are given.
+var DefaultSettings = Settings{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+	CallTimeout:      10 * time.Second,
+}
+
+// Stats is a snapshot of a breaker's state and counters.
+type Stats struct {
+	Name       string
+	State      State
+	Successes  uint64
+	Failures   uint64
+	Rejections uint64
+}
+
+// Breaker guards calls to a single external dependency.
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	consecutive int
+	openedAt    time.Time
+	probing     bool
+	successes   uint64
+	failures    uint64
+	rejections  uint64
+}
+
+func New(name string, settings Settings) *Breaker {
+	return &Breaker{name: name, settings: settings, now: time.Now}
+}
+
+// Do calls fn unless the breaker is open. While half-open only one probe
+// call is allowed at a time; its outcome closes or re-opens the breaker.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !b.allow() {
+		return ErrOpen
+	}
+	if b.settings.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.settings.CallTimeout)
+		defer cancel()
+	}
+	err := fn(ctx)
+	b.record(err)
+	return err
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		b.state = StateHalfOpen
+	}
+	switch b.state {
+	case StateOpen:
+		b.rejections++
+		return false
+	case StateHalfOpen:
+		if b.probing {
+			b.rejections++
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.successes++
+		b.consecutive = 0
+		b.state = StateClosed
+		return
+	}
+	b.failures++
+	b.consecutive++
+	if b.state == StateHalfOpen || b.consecutive >= b.settings.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
+
+func (b *Breaker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Stats{
+		Name:       b.name,
+		State:      b.state,
+		Successes:  b.successes,
+		Failures:   b.failures,
+		Rejections: b.rejections,
+	}
+}
+
+// Registry hands out one breaker per dependency name.
+type Registry struct {
+	settings Settings
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewRegistry(settings Settings) *Registry {
+	return &Registry{settings: settings, breakers: map[string]*Breaker{}}
+}
+
+// Get returns the breaker for name, creating it on first use.
+func (r *Registry) Get(name string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[name]
+	if !ok {
+		b = New(name, r.settings)
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// Stats returns a snapshot of every breaker ordered by name.
+func (r *Registry) Stats() []Stats {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	stats := make([]Stats, 0, len(breakers))
+	for _, b := range breakers {
+		stats = append(stats, b.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
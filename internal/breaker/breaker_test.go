@@ -0,0 +1,87 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDependency = errors.New("dependency failed")
+
+func TestBreakerTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := New("mailer", Settings{FailureThreshold: 2, OpenTimeout: time.Minute})
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errDependency }
+	succeed := func(ctx context.Context) error { return nil }
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		fn        func(ctx context.Context) error
+		wantErr   error
+		wantState State
+	}{
+		{name: "first failure stays closed", fn: fail, wantErr: errDependency, wantState: StateClosed},
+		{name: "threshold opens", fn: fail, wantErr: errDependency, wantState: StateOpen},
+		{name: "open rejects", fn: succeed, wantErr: ErrOpen, wantState: StateOpen},
+		{name: "failed probe re-opens", advance: time.Minute, fn: fail, wantErr: errDependency, wantState: StateOpen},
+		{name: "still open before timeout", advance: time.Second, fn: succeed, wantErr: ErrOpen, wantState: StateOpen},
+		{name: "successful probe closes", advance: time.Minute, fn: succeed, wantErr: nil, wantState: StateClosed},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		err := b.Do(ctx, step.fn)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: Do() error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if got := b.Stats().State; got != step.wantState {
+			t.Fatalf("%s: state = %s, want %s", step.name, got, step.wantState)
+		}
+	}
+
+	stats := b.Stats()
+	if stats.Successes != 1 || stats.Failures != 3 || stats.Rejections != 2 {
+		t.Errorf("Stats() = %+v, want 1 success, 3 failures, 2 rejections", stats)
+	}
+}
+
+func TestBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := New("webhook", Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	b.Do(ctx, func(ctx context.Context) error { return errDependency })
+	now = now.Add(time.Minute)
+
+	err := b.Do(ctx, func(ctx context.Context) error {
+		if err := b.Do(ctx, func(ctx context.Context) error { return nil }); !errors.Is(err, ErrOpen) {
+			t.Errorf("concurrent probe error = %v, want ErrOpen", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	if got := b.Stats().State; got != StateClosed {
+		t.Errorf("state after probe = %s, want closed", got)
+	}
+}
+
+func TestBreakerCallTimeout(t *testing.T) {
+	b := New("translation", Settings{FailureThreshold: 1, OpenTimeout: time.Minute, CallTimeout: 10 * time.Millisecond})
+	err := b.Do(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do() error = %v, want deadline exceeded", err)
+	}
+	if got := b.Stats().State; got != StateOpen {
+		t.Errorf("state = %s, want open", got)
+	}
+}
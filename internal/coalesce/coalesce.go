@@ -0,0 +1,136 @@
+package coalesce
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// callTimeout bounds a shared execution. It runs detached from the
+// leader's request, so a client that hangs up doesn't fail it for the
+// callers waiting on it.
+const callTimeout = 30 * time.Second
+
+// perRequestHeaders belong to the leader's response alone and are not
+// replayed to the other callers.
+var perRequestHeaders = []string{"Date", "Set-Cookie"}
+
+// Group coalesces identical in-flight GET requests onto a single execution
+// of the wrapped handler and replays its response to every waiting caller.
+type Group struct {
+	mu      sync.Mutex
+	calls   map[string]*call
+	timeout time.Duration
+}
+
+type call struct {
+	done chan struct{}
+	// ok is false when the shared execution timed out or panicked. Its
+	// response isn't replayed; waiting callers run the handler themselves.
+	ok     bool
+	status int
+	header http.Header
+	body   []byte
+}
+
+func New() *Group {
+	return &Group{calls: map[string]*call{}, timeout: callTimeout}
+}
+
+// Key identifies requests that can share a response: same path, query,
+// credentials and requested representation.
+func Key(r *http.Request) string {
+	key := r.URL.Path + "?" + r.URL.RawQuery + "\x00" + r.Header.Get("Authorization") + "\x00" + r.Header.Get("Accept")
+	for _, c := range r.Cookies() {
+		key += "\x00" + c.Name + "=" + c.Value
+	}
+	return key
+}
+
+// Middleware runs next once per key at a time. Requests other than GET pass
+// through untouched.
+func (g *Group) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := Key(r)
+
+		g.mu.Lock()
+		if c, ok := g.calls[key]; ok {
+			g.mu.Unlock()
+			select {
+			case <-c.done:
+				if c.ok {
+					c.replay(w)
+				} else {
+					next.ServeHTTP(w, r)
+				}
+			case <-r.Context().Done():
+			}
+			return
+		}
+		c := &call{done: make(chan struct{})}
+		g.calls[key] = c
+		g.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), g.timeout)
+		defer cancel()
+		buf := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+		completed := false
+		defer func() {
+			c.ok = completed && ctx.Err() == nil
+			c.status, c.header, c.body = buf.status, buf.header, buf.body.Bytes()
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+		next.ServeHTTP(buf, r.WithContext(ctx))
+		completed = true
+		for k, v := range buf.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+	})
+}
+
+func (c *call) replay(w http.ResponseWriter) {
+	for k, v := range c.header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	for _, k := range perRequestHeaders {
+		w.Header().Del(k)
+	}
+	w.WriteHeader(c.status)
+	w.Write(c.body)
+}
+
+// bufferedResponse captures a response so it can be sent to several clients.
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.wroteHeader {
+		return
+	}
+	b.wroteHeader = true
+	b.status = code
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
@@ -0,0 +1,188 @@
+package coalesce
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareCoalescesConcurrentGETs(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	handler := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		started <- struct{}{}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+
+	const n = 5
+	recorders := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	recorders[0] = httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(recorders[0], httptest.NewRequest("GET", "/api/chirps?sort=desc", nil))
+	}()
+	<-started
+	for i := 1; i < n; i++ {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rec *httptest.ResponseRecorder) {
+			defer wg.Done()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/chirps?sort=desc", nil))
+		}(recorders[i])
+	}
+	// Give the followers a chance to queue up behind the leader.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("handler called %d times, want 1", got)
+	}
+	for i, rec := range recorders {
+		if rec.Code != http.StatusOK || rec.Body.String() != `{"ok":true}` {
+			t.Errorf("response %d = %d %q", i, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("response %d Content-Type = %q", i, rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+// leaderAndFollower sends leader through handler and, once the handler has
+// started, a follower for the same URL. It returns the follower's response
+// after both are done.
+func leaderAndFollower(handler http.Handler, leader *http.Request, started <-chan struct{}, release func()) *httptest.ResponseRecorder {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), leader)
+	}()
+	<-started
+	follower := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(follower, httptest.NewRequest("GET", leader.URL.String(), nil))
+	}()
+	// Give the follower a chance to queue up behind the leader.
+	time.Sleep(50 * time.Millisecond)
+	release()
+	wg.Wait()
+	return follower
+}
+
+func TestMiddlewareOutlivesLeaderDisconnect(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	handler := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		if r.Context().Err() != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "leader"})
+		w.Write([]byte("ok"))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := httptest.NewRequest("GET", "/api/chirps", nil).WithContext(ctx)
+	rec := leaderAndFollower(handler, leader, started, func() {
+		cancel()
+		close(release)
+	})
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("follower got %d %q after the leader hung up, want 200 \"ok\"", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Set-Cookie"); got != "" {
+		t.Errorf("follower got the leader's cookie %q", got)
+	}
+}
+
+func TestMiddlewareRerunsTimedOutCall(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{}, 1)
+	group := New()
+	group.timeout = 200 * time.Millisecond
+	handler := group.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			started <- struct{}{}
+			<-r.Context().Done()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	rec := leaderAndFollower(handler, httptest.NewRequest("GET", "/api/chirps", nil), started, func() {})
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("follower got %d %q from a timed-out call, want its own 200", rec.Code, rec.Body.String())
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler called %d times, want 2", got)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    func() *http.Request
+		sameKey bool
+	}{
+		{
+			name:    "same path and query",
+			a:       func() *http.Request { return httptest.NewRequest("GET", "/api/chirps?sort=asc", nil) },
+			b:       func() *http.Request { return httptest.NewRequest("GET", "/api/chirps?sort=asc", nil) },
+			sameKey: true,
+		},
+		{
+			name: "different query",
+			a:    func() *http.Request { return httptest.NewRequest("GET", "/api/chirps?sort=asc", nil) },
+			b:    func() *http.Request { return httptest.NewRequest("GET", "/api/chirps?sort=desc", nil) },
+		},
+		{
+			name: "different accept",
+			a:    func() *http.Request { return httptest.NewRequest("GET", "/api/chirps", nil) },
+			b: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/chirps", nil)
+				r.Header.Set("Accept", "application/json; case=camel")
+				return r
+			},
+		},
+		{
+			name: "different credentials",
+			a: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/chirps", nil)
+				r.Header.Set("Authorization", "Bearer a")
+				return r
+			},
+			b: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/chirps", nil)
+				r.Header.Set("Authorization", "Bearer b")
+				return r
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Key(tt.a()) == Key(tt.b()); got != tt.sameKey {
+				t.Errorf("Key() equal = %v, want %v", got, tt.sameKey)
+			}
+		})
+	}
+}
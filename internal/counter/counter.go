@@ -0,0 +1,145 @@
+// Package counter keeps named counters and fixed-window rate limits. The
+// in-memory store is per process; the Postgres store is shared by every
+// instance behind the load balancer, so metrics and limits add up across
+// them.
+package counter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+// Store holds counters by key.
+type Store interface {
+	// Add adds delta to key and returns the new value. A counter with a
+	// non-zero expiresAt starts over from zero once that time has passed.
+	Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error)
+	// Get returns the current value of key, zero if it isn't set.
+	Get(ctx context.Context, key string) (int64, error)
+	// Delete resets key to zero.
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryEntry struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// Memory is a Store for a single instance.
+type Memory struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]memoryEntry
+}
+
+func NewMemory() *Memory {
+	return &Memory{now: time.Now, entries: map[string]memoryEntry{}}
+}
+
+func (m *Memory) Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purge()
+	entry := m.entries[key]
+	entry.value += delta
+	entry.expiresAt = expiresAt
+	m.entries[key] = entry
+	return entry.value, nil
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purge()
+	return m.entries[key].value, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *Memory) purge() {
+	now := m.now()
+	for key, entry := range m.entries {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// Postgres is a Store backed by the shared_counters table. Expired rows are
+// ignored on read and removed by Purge.
+type Postgres struct {
+	db *database.Queries
+}
+
+func NewPostgres(db *database.Queries) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error) {
+	return p.db.AddSharedCounter(ctx, database.AddSharedCounterParams{
+		Key:       key,
+		Value:     delta,
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: !expiresAt.IsZero()},
+	})
+}
+
+func (p *Postgres) Get(ctx context.Context, key string) (int64, error) {
+	value, err := p.db.GetSharedCounter(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return value, err
+}
+
+func (p *Postgres) Delete(ctx context.Context, key string) error {
+	return p.db.DeleteSharedCounter(ctx, key)
+}
+
+// Purge removes counters that expired before now.
+func (p *Postgres) Purge(ctx context.Context, now time.Time) (int64, error) {
+	return p.db.DeleteExpiredSharedCounters(ctx, sql.NullTime{Time: now, Valid: true})
+}
+
+// Decision is the outcome of a rate limit check.
+type Decision struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	ResetAt   time.Time
+}
+
+// Limiter allows up to Limit hits per key in each fixed Window.
+type Limiter struct {
+	Store  Store
+	Name   string
+	Limit  int64
+	Window time.Duration
+}
+
+// Allow counts a hit for key at now and reports whether it is within the
+// limit.
+func (l Limiter) Allow(ctx context.Context, key string, now time.Time) (Decision, error) {
+	start := now.Truncate(l.Window)
+	reset := start.Add(l.Window)
+	count, err := l.Store.Add(ctx, fmt.Sprintf("limit:%s:%s:%d", l.Name, key, start.Unix()), 1, reset)
+	if err != nil {
+		return Decision{}, err
+	}
+	return Decision{
+		Allowed:   count <= l.Limit,
+		Limit:     l.Limit,
+		Remaining: max(l.Limit-count, 0),
+		ResetAt:   reset,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package counter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	if got, _ := m.Add(ctx, "hits", 2, time.Time{}); got != 2 {
+		t.Errorf("Add() = %d, want 2", got)
+	}
+	if got, _ := m.Add(ctx, "hits", 3, time.Time{}); got != 5 {
+		t.Errorf("Add() = %d, want 5", got)
+	}
+	m.Add(ctx, "window", 1, now.Add(time.Minute))
+	if got, _ := m.Get(ctx, "window"); got != 1 {
+		t.Errorf("Get() before expiry = %d, want 1", got)
+	}
+
+	now = now.Add(time.Minute)
+	if got, _ := m.Get(ctx, "window"); got != 0 {
+		t.Errorf("Get() after expiry = %d, want 0", got)
+	}
+	if got, _ := m.Get(ctx, "hits"); got != 5 {
+		t.Errorf("Get() without expiry = %d, want 5", got)
+	}
+	m.Delete(ctx, "hits")
+	if got, _ := m.Get(ctx, "hits"); got != 0 {
+		t.Errorf("Get() after Delete = %d, want 0", got)
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return start }
+	l := Limiter{Store: m, Name: "login", Limit: 2, Window: time.Minute}
+
+	tests := []struct {
+		name          string
+		key           string
+		at            time.Time
+		wantAllowed   bool
+		wantRemaining int64
+		wantReset     time.Time
+	}{
+		{name: "first hit", key: "a", at: start, wantAllowed: true, wantRemaining: 1, wantReset: start.Add(time.Minute)},
+		{name: "second hit", key: "a", at: start.Add(10 * time.Second), wantAllowed: true, wantRemaining: 0, wantReset: start.Add(time.Minute)},
+		{name: "over the limit", key: "a", at: start.Add(20 * time.Second), wantAllowed: false, wantRemaining: 0, wantReset: start.Add(time.Minute)},
+		{name: "other key", key: "b", at: start.Add(30 * time.Second), wantAllowed: true, wantRemaining: 1, wantReset: start.Add(time.Minute)},
+		{name: "next window", key: "a", at: start.Add(time.Minute), wantAllowed: true, wantRemaining: 1, wantReset: start.Add(2 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := l.Allow(ctx, tt.key, tt.at)
+			if err != nil {
+				t.Fatalf("Allow() error = %v", err)
+			}
+			if got.Allowed != tt.wantAllowed || got.Remaining != tt.wantRemaining || !got.ResetAt.Equal(tt.wantReset) {
+				t.Errorf("Allow() = %+v, want allowed %v, remaining %d, reset %v", got, tt.wantAllowed, tt.wantRemaining, tt.wantReset)
+			}
+		})
+	}
+}
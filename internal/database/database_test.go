@@ -3,10 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // Mock database interface for testing
@@ -330,3 +332,63 @@ func TestDBTXInterface(t *testing.T) {
 	// Test that *sql.Tx would implement DBTX interface (compile-time check)
 	var _ DBTX = (*sql.Tx)(nil)
 }
+
+func TestDomainError(t *testing.T) {
+	other := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		err      error
+		notFound error
+		want     error
+	}{
+		{name: "no error", err: nil, notFound: ErrUserNotFound, want: nil},
+		{name: "missing row", err: sql.ErrNoRows, notFound: ErrUserNotFound, want: ErrUserNotFound},
+		{name: "missing row without a domain error", err: sql.ErrNoRows, notFound: nil, want: sql.ErrNoRows},
+		{name: "duplicate email", err: &pq.Error{Code: "23505", Constraint: "users_email_key"}, want: ErrDuplicateEmail},
+		{name: "duplicate handle", err: &pq.Error{Code: "23505", Constraint: "users_handle_key"}, want: ErrDuplicateHandle},
+		{name: "other error passes through", err: other, notFound: ErrUserNotFound, want: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := domainError(tt.err, tt.notFound)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("domainError() = %v, want nil", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Errorf("domainError() = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("domainError() = %v, lost the original error %v", got, tt.err)
+			}
+		})
+	}
+}
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		revokedAt sql.NullTime
+		want      error
+	}{
+		{name: "valid", expiresAt: now.Add(time.Hour), want: nil},
+		{name: "expired", expiresAt: now.Add(-time.Second), want: ErrTokenExpired},
+		{name: "expires now", expiresAt: now, want: ErrTokenExpired},
+		{name: "revoked", expiresAt: now.Add(time.Hour), revokedAt: sql.NullTime{Time: now.Add(-time.Minute), Valid: true}, want: ErrTokenRevoked},
+		{name: "revoked and expired", expiresAt: now.Add(-time.Second), revokedAt: sql.NullTime{Time: now.Add(-time.Minute), Valid: true}, want: ErrTokenRevoked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := GetUserFromRefreshTokenRow{RefreshTokenExpiresAt: tt.expiresAt, RefreshTokenRevokedAt: tt.revokedAt}
+			if got := checkRefreshToken(row, now); !errors.Is(got, tt.want) || (tt.want == nil && got != nil) {
+				t.Errorf("checkRefreshToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
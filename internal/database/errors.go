@@ -0,0 +1,134 @@
+package database
+
+// Not generated: Store wraps the generated queries that callers need to
+// tell apart by outcome, and turns sql.ErrNoRows and constraint violations
+// into domain errors. The original error stays in the chain, so
+// errors.Is(err, sql.ErrNoRows) keeps working.
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrChirpNotFound   = errors.New("chirp not found")
+	ErrDuplicateEmail  = errors.New("email is already in use")
+	ErrDuplicateHandle = errors.New("handle is already taken")
+	ErrTokenNotFound   = errors.New("token not found")
+	ErrTokenExpired    = errors.New("token has expired")
+	ErrTokenRevoked    = errors.New("token has been revoked")
+)
+
+// uniqueViolations maps unique constraints to the error for a duplicate.
+var uniqueViolations = map[string]error{
+	"users_email_key":  ErrDuplicateEmail,
+	"users_handle_key": ErrDuplicateHandle,
+}
+
+// domainError translates err, using notFound for a missing row.
+func domainError(err error, notFound error) error {
+	if err == nil {
+		return nil
+	}
+	if notFound != nil && errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", notFound, err)
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		if domain, ok := uniqueViolations[pqErr.Constraint]; ok {
+			return fmt.Errorf("%w: %w", domain, err)
+		}
+	}
+	return err
+}
+
+// Store is what the rest of the app queries through. Queries that aren't
+// overridden here return the generated errors unchanged.
+type Store struct {
+	*Queries
+	now func() time.Time
+}
+
+func NewStore(db DBTX) *Store {
+	return &Store{Queries: New(db), now: time.Now}
+}
+
+func (s *Store) WithTx(tx *sql.Tx) *Store {
+	return &Store{Queries: s.Queries.WithTx(tx), now: s.now}
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	user, err := s.Queries.CreateUser(ctx, arg)
+	return user, domainError(err, nil)
+}
+
+func (s *Store) GetMessageByID(ctx context.Context, id uuid.UUID) (Message, error) {
+	message, err := s.Queries.GetMessageByID(ctx, id)
+	return message, domainError(err, ErrChirpNotFound)
+}
+
+func (s *Store) GetMessageWithAuthorByID(ctx context.Context, id uuid.UUID) (GetMessageWithAuthorByIDRow, error) {
+	message, err := s.Queries.GetMessageWithAuthorByID(ctx, id)
+	return message, domainError(err, ErrChirpNotFound)
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	user, err := s.Queries.GetUserByEmail(ctx, email)
+	return user, domainError(err, ErrUserNotFound)
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	user, err := s.Queries.GetUserByID(ctx, id)
+	return user, domainError(err, ErrUserNotFound)
+}
+
+// GetUserFromRefreshToken returns the user a refresh token belongs to. It
+// fails with ErrTokenRevoked or ErrTokenExpired for tokens that exist but
+// can no longer be used, so callers can't mistake them for valid ones.
+func (s *Store) GetUserFromRefreshToken(ctx context.Context, token string) (GetUserFromRefreshTokenRow, error) {
+	row, err := s.Queries.GetUserFromRefreshToken(ctx, token)
+	if err != nil {
+		return row, domainError(err, ErrTokenNotFound)
+	}
+	if err := checkRefreshToken(row, s.now()); err != nil {
+		return GetUserFromRefreshTokenRow{}, err
+	}
+	return row, nil
+}
+
+func checkRefreshToken(row GetUserFromRefreshTokenRow, now time.Time) error {
+	if row.RefreshTokenRevokedAt.Valid {
+		return ErrTokenRevoked
+	}
+	if !now.Before(row.RefreshTokenExpiresAt) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+func (s *Store) UpdateMessageBody(ctx context.Context, arg UpdateMessageBodyParams) (Message, error) {
+	message, err := s.Queries.UpdateMessageBody(ctx, arg)
+	return message, domainError(err, ErrChirpNotFound)
+}
+
+func (s *Store) UpdateUser(ctx context.Context, arg UpdateUserParams) (string, error) {
+	email, err := s.Queries.UpdateUser(ctx, arg)
+	return email, domainError(err, ErrUserNotFound)
+}
+
+func (s *Store) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	user, err := s.Queries.UpdateUserEmail(ctx, arg)
+	return user, domainError(err, ErrUserNotFound)
+}
+
+func (s *Store) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	user, err := s.Queries.UpdateUserProfile(ctx, arg)
+	return user, domainError(err, ErrUserNotFound)
+}
@@ -6,17 +6,290 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Message struct {
+type AdminSession struct {
+	Token     string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	CsrfToken string
+	ExpiresAt time.Time
+}
+
+type AuditLog struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	ActorID    uuid.NullUUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Metadata   json.RawMessage
+}
+
+type Backup struct {
+	ID          uuid.UUID
+	Status      string
+	FileName    string
+	SizeBytes   sql.NullInt64
+	Error       sql.NullString
+	RequestedBy uuid.NullUUID
+	StartedAt   time.Time
+	FinishedAt  sql.NullTime
+}
+
+type BotAccount struct {
+	UserID         uuid.UUID
+	OwnerID        uuid.UUID
+	DeveloperKeyID uuid.NullUUID
+	CreatedAt      time.Time
+}
+
+type CanaryToken struct {
+	ID              uuid.UUID
+	Kind            string
+	Label           string
+	TokenHash       string
+	CreatedBy       uuid.NullUUID
+	CreatedAt       time.Time
+	TriggerCount    int32
+	LastTriggeredAt sql.NullTime
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+}
+
+type ChirpMedium struct {
+	ID             uuid.UUID
+	ChirpID        uuid.NullUUID
+	StorageKey     string
+	ContentType    string
+	SizeBytes      int64
+	CreatedAt      time.Time
+	VariantsStatus string
+}
+
+type ChirpMediaVariant struct {
+	MediaID     uuid.UUID
+	Name        string
+	StorageKey  string
+	ContentType string
+	Width       int32
+	Height      int32
+	SizeBytes   int64
+	CreatedAt   time.Time
+}
+
+type ChirpyRedExpiration struct {
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+type CustomEmoji struct {
+	Shortcode string
+	ImageUrl  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type DeletionReport struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	Verified  bool
+	Report    string
+	Signature string
+}
+
+type DeveloperApiKey struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	KeyHash   string
+	Tier      string
+	CreatedAt time.Time
+	RevokedAt sql.NullTime
+}
+
+type DeveloperApiUsage struct {
+	KeyID    uuid.UUID
+	Day      time.Time
+	Requests int32
+}
+
+type EmailDomainRule struct {
+	Domain    string
+	Rule      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Event struct {
+	ID            int64
+	EventType     string
+	AggregateType string
+	AggregateID   uuid.UUID
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type LinkRedirect struct {
+	Code          string
+	CreatedAt     time.Time
+	TargetUrl     string
+	MessageID     uuid.UUID
+	UserID        uuid.UUID
+	ClickCount    int64
+	LastClickedAt sql.NullTime
+}
+
+type List struct {
 	ID        uuid.UUID
+	OwnerID   uuid.UUID
+	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+}
+
+type ListMember struct {
+	ListID  uuid.UUID
+	UserID  uuid.UUID
+	AddedAt time.Time
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Body           string
+	UserID         uuid.UUID
+	Status         string
+	ParentID       uuid.NullUUID
+	PostedByID     uuid.NullUUID
+	Visibility     string
+	ExpiresAt      sql.NullTime
+	ContentWarning sql.NullString
+	BodyHash       sql.NullString
+	BodyFiltered   bool
+}
+
+type MutedWord struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Phrase    string
+	CreatedAt time.Time
+}
+
+type OauthAccessToken struct {
+	TokenHash string
+	ClientID  uuid.UUID
 	UserID    uuid.UUID
+	Scope     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt sql.NullTime
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string
+	ClientID      uuid.UUID
+	UserID        uuid.UUID
+	RedirectUri   string
+	Scope         string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+type OauthClient struct {
+	ID           uuid.UUID
+	OwnerID      uuid.UUID
+	Name         string
+	SecretHash   sql.NullString
+	RedirectUris []string
+	CreatedAt    time.Time
+}
+
+type Outbox struct {
+	ID            uuid.UUID
+	Kind          string
+	Payload       json.RawMessage
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	CreatedAt     time.Time
+}
+
+type PendingAdminAction struct {
+	ID          uuid.UUID
+	Action      string
+	TargetID    string
+	RequestedBy uuid.NullUUID
+	Status      string
+	DecidedBy   uuid.NullUUID
+	DecidedAt   sql.NullTime
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type PolicyAcceptance struct {
+	UserID          uuid.UUID
+	PolicyVersionID uuid.UUID
+	AcceptedAt      time.Time
+}
+
+type PolicyVersion struct {
+	ID          uuid.UUID
+	Version     string
+	Url         string
+	PublishedAt time.Time
+}
+
+type PostDelegation struct {
+	OwnerID    uuid.UUID
+	DelegateID uuid.UUID
+	CreatedAt  time.Time
+	AcceptedAt sql.NullTime
+}
+
+type ProfanityRule struct {
+	Word      string
+	Context   string
+	Action    string
+	UpdatedAt time.Time
+}
+
+type RedGift struct {
+	ID          uuid.UUID
+	GifterID    uuid.NullUUID
+	RecipientID uuid.UUID
+	Months      int32
+	CreatedAt   time.Time
+	SeenAt      sql.NullTime
 }
 
 type RefreshToken struct {
@@ -28,11 +301,84 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type SchemaCompatibility struct {
+	Version         int64
+	OldestAppSchema int64
+}
+
+type SearchIndexCursor struct {
+	IndexName   string
+	LastEventID int64
+	UpdatedAt   time.Time
+}
+
+type Setting struct {
+	Key       string
+	Value     string
+	UpdatedAt time.Time
+}
+
+type SharedCounter struct {
+	Key       string
+	Value     int64
+	ExpiresAt sql.NullTime
+}
+
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Email                  string
+	HashedPassword         string
+	IsChirpyRed            bool
+	IsAdmin                bool
+	Handle                 sql.NullString
+	DisplayName            sql.NullString
+	AvatarUrl              sql.NullString
+	IsVerified             bool
+	IsDeleted              bool
+	DeletedAt              sql.NullTime
+	ShowSensitive          bool
+	WaitlistStatus         sql.NullString
+	TokenVersion           int32
+	PasswordChangeRequired bool
+}
+
+type UserApiUsage struct {
+	UserID   uuid.UUID
+	Month    time.Time
+	Kind     string
+	Requests int32
+}
+
+type UserAvatar struct {
+	ID          uuid.UUID
+	UserID      uuid.NullUUID
+	StorageKey  string
+	ContentType string
+	SizeBytes   int64
+	CreatedAt   time.Time
+}
+
+type UserIdentity struct {
+	Issuer    string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserPiiHistory struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Field     string
+	OldValue  sql.NullString
+	NewValue  sql.NullString
+	Source    string
+	ChangedAt time.Time
+}
+
+type WebhookNonce struct {
+	Source     string
+	Nonce      string
+	ReceivedAt time.Time
 }
@@ -0,0 +1,23 @@
+package database
+
+// Not generated: goose_db_version belongs to the migration tool rather than
+// the migrations, so sqlc can't type-check queries against it.
+
+import (
+	"context"
+)
+
+const getSchemaVersion = `
+SELECT COALESCE(MAX(version_id), 0)::bigint AS version_id
+FROM goose_db_version
+WHERE is_applied
+`
+
+// GetSchemaVersion returns the number of the latest migration applied to
+// the database.
+func (q *Queries) GetSchemaVersion(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getSchemaVersion)
+	var version_id int64
+	err := row.Scan(&version_id)
+	return version_id, err
+}
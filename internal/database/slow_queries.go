@@ -0,0 +1,88 @@
+package database
+
+// Not generated: pg_stat_statements is an optional extension that isn't
+// part of the migrations, so sqlc can't type-check queries against it.
+
+import (
+	"context"
+)
+
+const pgStatStatementsAvailable = `
+SELECT EXISTS (
+    SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements'
+) AS available
+`
+
+// PgStatStatementsAvailable reports whether the pg_stat_statements
+// extension is installed in the current database.
+func (q *Queries) PgStatStatementsAvailable(ctx context.Context) (bool, error) {
+	row := q.db.QueryRowContext(ctx, pgStatStatementsAvailable)
+	var available bool
+	err := row.Scan(&available)
+	return available, err
+}
+
+const listSlowQueries = `
+SELECT
+    s.query,
+    s.calls,
+    s.total_exec_time,
+    s.mean_exec_time,
+    s.rows,
+    s.shared_blks_hit,
+    s.shared_blks_read
+FROM pg_stat_statements s
+JOIN pg_database d ON d.oid = s.dbid
+WHERE d.datname = current_database()
+ORDER BY
+    CASE WHEN $1::text = 'calls' THEN s.calls::float8 ELSE s.total_exec_time END DESC
+LIMIT $2
+`
+
+type ListSlowQueriesParams struct {
+	SortBy string
+	Limit  int32
+}
+
+type SlowQuery struct {
+	Query          string
+	Calls          int64
+	TotalExecTime  float64
+	MeanExecTime   float64
+	Rows           int64
+	SharedBlksHit  int64
+	SharedBlksRead int64
+}
+
+// ListSlowQueries returns the statements with the most total execution time,
+// or the most calls when SortBy is "calls". Needs PostgreSQL 13 or later.
+func (q *Queries) ListSlowQueries(ctx context.Context, arg ListSlowQueriesParams) ([]SlowQuery, error) {
+	rows, err := q.db.QueryContext(ctx, listSlowQueries, arg.SortBy, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SlowQuery
+	for rows.Next() {
+		var i SlowQuery
+		if err := rows.Scan(
+			&i.Query,
+			&i.Calls,
+			&i.TotalExecTime,
+			&i.MeanExecTime,
+			&i.Rows,
+			&i.SharedBlksHit,
+			&i.SharedBlksRead,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,40 +7,76 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const addUserChirpyRed = `-- name: AddUserChirpyRed :exec
+const addUserChirpyRed = `-- name: AddUserChirpyRed :execrows
 UPDATE users
 SET is_chirpy_red = TRUE
 WHERE id = $1
 `
 
-func (q *Queries) AddUserChirpyRed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, addUserChirpyRed, id)
-	return err
+func (q *Queries) AddUserChirpyRed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addUserChirpyRed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const createMessage = `-- name: CreateMessage :one
 
-INSERT INTO messages (id, body, user_id) 
+INSERT INTO messages (id, created_at, updated_at, body, user_id, status, parent_id, posted_by_id, visibility, expires_at, content_warning, body_hash, body_filtered)
 VALUES (
-    gen_random_uuid(),
     $1,
-    $2
+    $2,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    TRUE
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, status, parent_id, posted_by_id, visibility, expires_at, content_warning, body_hash, body_filtered
 `
 
 type CreateMessageParams struct {
-	Body   string
-	UserID uuid.UUID
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Body           string
+	UserID         uuid.UUID
+	Status         string
+	ParentID       uuid.NullUUID
+	PostedByID     uuid.NullUUID
+	Visibility     string
+	ExpiresAt      sql.NullTime
+	ContentWarning sql.NullString
+	BodyHash       sql.NullString
 }
 
 func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
-	row := q.db.QueryRowContext(ctx, createMessage, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createMessage,
+		arg.ID,
+		arg.CreatedAt,
+		arg.Body,
+		arg.UserID,
+		arg.Status,
+		arg.ParentID,
+		arg.PostedByID,
+		arg.Visibility,
+		arg.ExpiresAt,
+		arg.ContentWarning,
+		arg.BodyHash,
+	)
 	var i Message
 	err := row.Scan(
 		&i.ID,
@@ -48,6 +84,14 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Status,
+		&i.ParentID,
+		&i.PostedByID,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.BodyHash,
+		&i.BodyFiltered,
 	)
 	return i, err
 }
@@ -76,24 +120,26 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, waitlist_status)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	WaitlistStatus sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.WaitlistStatus)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -102,11 +148,22 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
 	)
 	return i, err
 }
 
-const deleteChirpsByID = `-- name: DeleteChirpsByID :exec
+const deleteChirpsByID = `-- name: DeleteChirpsByID :execrows
 DELETE FROM messages WHERE id = $1 AND user_id = $2
 `
 
@@ -115,9 +172,12 @@ type DeleteChirpsByIDParams struct {
 	UserID uuid.UUID
 }
 
-func (q *Queries) DeleteChirpsByID(ctx context.Context, arg DeleteChirpsByIDParams) error {
-	_, err := q.db.ExecContext(ctx, deleteChirpsByID, arg.ID, arg.UserID)
-	return err
+func (q *Queries) DeleteChirpsByID(ctx context.Context, arg DeleteChirpsByIDParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteChirpsByID, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteUser = `-- name: DeleteUser :exec
@@ -130,7 +190,7 @@ func (q *Queries) DeleteUser(ctx context.Context) error {
 }
 
 const getMessageByID = `-- name: GetMessageByID :one
-SELECT id, created_at, updated_at, body, user_id FROM messages WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, status, parent_id, posted_by_id, visibility, expires_at, content_warning, body_hash, body_filtered FROM messages WHERE id = $1
 `
 
 func (q *Queries) GetMessageByID(ctx context.Context, id uuid.UUID) (Message, error) {
@@ -142,12 +202,20 @@ func (q *Queries) GetMessageByID(ctx context.Context, id uuid.UUID) (Message, er
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Status,
+		&i.ParentID,
+		&i.PostedByID,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.BodyHash,
+		&i.BodyFiltered,
 	)
 	return i, err
 }
 
 const getMessages = `-- name: GetMessages :many
-SELECT id, created_at, updated_at, body, user_id FROM messages ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, status, parent_id, posted_by_id, visibility, expires_at, content_warning, body_hash, body_filtered FROM messages ORDER BY created_at
 `
 
 func (q *Queries) GetMessages(ctx context.Context) ([]Message, error) {
@@ -165,6 +233,14 @@ func (q *Queries) GetMessages(ctx context.Context) ([]Message, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Status,
+			&i.ParentID,
+			&i.PostedByID,
+			&i.Visibility,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.BodyHash,
+			&i.BodyFiltered,
 		); err != nil {
 			return nil, err
 		}
@@ -180,7 +256,7 @@ func (q *Queries) GetMessages(ctx context.Context) ([]Message, error) {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -193,19 +269,222 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
 	)
 	return i, err
 }
 
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT u.id, u.created_at, u.updated_at, u.email, u.hashed_password, u.is_chirpy_red
+SELECT u.id, u.created_at, u.updated_at, u.email, u.hashed_password, u.is_chirpy_red, u.is_admin, u.handle, u.display_name, u.avatar_url, u.is_verified, u.is_deleted, u.deleted_at, u.show_sensitive, u.waitlist_status, u.token_version, u.password_change_required, rt.expires_at AS refresh_token_expires_at, rt.revoked_at AS refresh_token_revoked_at
 FROM refresh_tokens rt
 JOIN users u ON rt.user_id = u.id
-WHERE rt.token = $1 AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+WHERE rt.token = $1 AND NOT u.is_deleted
 `
 
-func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (User, error) {
+type GetUserFromRefreshTokenRow struct {
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Email                  string
+	HashedPassword         string
+	IsChirpyRed            bool
+	IsAdmin                bool
+	Handle                 sql.NullString
+	DisplayName            sql.NullString
+	AvatarUrl              sql.NullString
+	IsVerified             bool
+	IsDeleted              bool
+	DeletedAt              sql.NullTime
+	ShowSensitive          bool
+	WaitlistStatus         sql.NullString
+	TokenVersion           int32
+	PasswordChangeRequired bool
+	RefreshTokenExpiresAt  time.Time
+	RefreshTokenRevokedAt  sql.NullTime
+}
+
+func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (GetUserFromRefreshTokenRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, token)
+	var i GetUserFromRefreshTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+		&i.RefreshTokenExpiresAt,
+		&i.RefreshTokenRevokedAt,
+	)
+	return i, err
+}
+
+const listPurgeableUsers = `-- name: ListPurgeableUsers :many
+SELECT id, deleted_at FROM users
+WHERE is_deleted AND deleted_at <= $1::timestamp
+ORDER BY deleted_at
+`
+
+type ListPurgeableUsersRow struct {
+	ID        uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) ListPurgeableUsers(ctx context.Context, deletedBefore time.Time) ([]ListPurgeableUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPurgeableUsers, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPurgeableUsersRow
+	for rows.Next() {
+		var i ListPurgeableUsersRow
+		if err := rows.Scan(&i.ID, &i.DeletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWaitlistedUsers = `-- name: ListWaitlistedUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required FROM users
+WHERE waitlist_status = 'pending' AND NOT is_deleted
+ORDER BY created_at
+LIMIT $1
+`
+
+func (q *Queries) ListWaitlistedUsers(ctx context.Context, limit int32) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listWaitlistedUsers, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.IsAdmin,
+			&i.Handle,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.IsVerified,
+			&i.IsDeleted,
+			&i.DeletedAt,
+			&i.ShowSensitive,
+			&i.WaitlistStatus,
+			&i.TokenVersion,
+			&i.PasswordChangeRequired,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedUser = `-- name: PurgeDeletedUser :execrows
+DELETE FROM users
+WHERE id = $1 AND is_deleted AND deleted_at <= $2::timestamp
+`
+
+type PurgeDeletedUserParams struct {
+	ID            uuid.UUID
+	DeletedBefore time.Time
+}
+
+func (q *Queries) PurgeDeletedUser(ctx context.Context, arg PurgeDeletedUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUser, arg.ID, arg.DeletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreUser = `-- name: RestoreUser :one
+UPDATE users
+SET is_deleted = FALSE,
+    deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND is_deleted AND deleted_at > $2::timestamp
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type RestoreUserParams struct {
+	ID           uuid.UUID
+	DeletedAfter time.Time
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, restoreUser, arg.ID, arg.DeletedAfter)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -214,6 +493,17 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Us
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
 	)
 	return i, err
 }
@@ -230,11 +520,298 @@ func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
 	return err
 }
 
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rotateUserCredentials = `-- name: RotateUserCredentials :one
+UPDATE users
+SET token_version = token_version + 1,
+    password_change_required = password_change_required OR $1::boolean,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING token_version
+`
+
+type RotateUserCredentialsParams struct {
+	RequirePasswordChange bool
+	ID                    uuid.UUID
+}
+
+// RotateUserCredentials invalidates every access token issued to the user
+// so far. A password change already required stays required.
+func (q *Queries) RotateUserCredentials(ctx context.Context, arg RotateUserCredentialsParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, rotateUserCredentials, arg.RequirePasswordChange, arg.ID)
+	var token_version int32
+	err := row.Scan(&token_version)
+	return token_version, err
+}
+
+const setUserAdmin = `-- name: SetUserAdmin :one
+UPDATE users
+SET is_admin = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type SetUserAdminParams struct {
+	IsAdmin bool
+	ID      uuid.UUID
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAdmin, arg.IsAdmin, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
+
+const setUserShowSensitive = `-- name: SetUserShowSensitive :one
+UPDATE users
+SET show_sensitive = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type SetUserShowSensitiveParams struct {
+	ShowSensitive bool
+	ID            uuid.UUID
+}
+
+func (q *Queries) SetUserShowSensitive(ctx context.Context, arg SetUserShowSensitiveParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserShowSensitive, arg.ShowSensitive, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
+
+const setUserVerified = `-- name: SetUserVerified :one
+UPDATE users
+SET is_verified = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type SetUserVerifiedParams struct {
+	IsVerified bool
+	ID         uuid.UUID
+}
+
+func (q *Queries) SetUserVerified(ctx context.Context, arg SetUserVerifiedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserVerified, arg.IsVerified, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
+
+const setWaitlistStatus = `-- name: SetWaitlistStatus :many
+UPDATE users
+SET waitlist_status = $1,
+    updated_at = NOW()
+WHERE id = ANY($2::uuid[]) AND waitlist_status = 'pending'
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type SetWaitlistStatusParams struct {
+	WaitlistStatus sql.NullString
+	Ids            []uuid.UUID
+}
+
+func (q *Queries) SetWaitlistStatus(ctx context.Context, arg SetWaitlistStatusParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, setWaitlistStatus, arg.WaitlistStatus, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.IsAdmin,
+			&i.Handle,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.IsVerified,
+			&i.IsDeleted,
+			&i.DeletedAt,
+			&i.ShowSensitive,
+			&i.WaitlistStatus,
+			&i.TokenVersion,
+			&i.PasswordChangeRequired,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :one
+UPDATE users
+SET is_deleted = TRUE,
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND is_deleted = FALSE
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+func (q *Queries) SoftDeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
+
+const updateMessageBody = `-- name: UpdateMessageBody :one
+UPDATE messages
+SET body = $1,
+    status = $2,
+    body_hash = $3,
+    body_filtered = TRUE,
+    updated_at = NOW()
+WHERE id = $4 AND user_id = $5
+RETURNING id, created_at, updated_at, body, user_id, status, parent_id, posted_by_id, visibility, expires_at, content_warning, body_hash, body_filtered
+`
+
+type UpdateMessageBodyParams struct {
+	Body     string
+	Status   string
+	BodyHash sql.NullString
+	ID       uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) UpdateMessageBody(ctx context.Context, arg UpdateMessageBodyParams) (Message, error) {
+	row := q.db.QueryRowContext(ctx, updateMessageBody,
+		arg.Body,
+		arg.Status,
+		arg.BodyHash,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Status,
+		&i.ParentID,
+		&i.PostedByID,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.BodyHash,
+		&i.BodyFiltered,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET updated_at = NOW(),
     email = $1,
-    hashed_password = $2
+    hashed_password = $2,
+    password_change_required = FALSE
 WHERE id = $3
 RETURNING email
 `
@@ -251,3 +828,119 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (string,
 	err := row.Scan(&email)
 	return email, err
 }
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE users
+SET updated_at = NOW(),
+    email = $1
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type UpdateUserEmailParams struct {
+	Email string
+	ID    uuid.UUID
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserEmail, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
+
+const updateUserAvatarURL = `-- name: UpdateUserAvatarURL :one
+UPDATE users
+SET updated_at = NOW(),
+    avatar_url = $1
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type UpdateUserAvatarURLParams struct {
+	AvatarUrl sql.NullString
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateUserAvatarURL(ctx context.Context, arg UpdateUserAvatarURLParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserAvatarURL, arg.AvatarUrl, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET updated_at = NOW(),
+    handle = $1,
+    display_name = $2
+WHERE id = $3
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, avatar_url, is_verified, is_deleted, deleted_at, show_sensitive, waitlist_status, token_version, password_change_required
+`
+
+type UpdateUserProfileParams struct {
+	Handle      sql.NullString
+	DisplayName sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile, arg.Handle, arg.DisplayName, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.ShowSensitive,
+		&i.WaitlistStatus,
+		&i.TokenVersion,
+		&i.PasswordChangeRequired,
+	)
+	return i, err
+}
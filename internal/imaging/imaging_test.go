@@ -0,0 +1,88 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h, size   int
+		wantW, wantH int
+		wantResized  bool
+	}{
+		{name: "landscape", w: 400, h: 200, size: 100, wantW: 100, wantH: 50, wantResized: true},
+		{name: "portrait", w: 200, h: 400, size: 100, wantW: 50, wantH: 100, wantResized: true},
+		{name: "already fits", w: 80, h: 60, size: 100},
+		{name: "sliver", w: 1000, h: 2, size: 100, wantW: 100, wantH: 1, wantResized: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			got, resized := Fit(src, tt.size)
+			if resized != tt.wantResized {
+				t.Fatalf("Fit() resized = %v, want %v", resized, tt.wantResized)
+			}
+			if resized && (got.Bounds().Dx() != tt.wantW || got.Bounds().Dy() != tt.wantH) {
+				t.Errorf("Fit() = %v, want %dx%d", got.Bounds(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestFitAverages(t *testing.T) {
+	// Alternating black and white columns average to mid grey.
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+	got, _ := Fit(src, 2)
+	if c := got.RGBAAt(0, 0); c.R != 127 || c.A != 255 {
+		t.Errorf("Fit() pixel = %v, want grey", c)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2)))
+	img, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	if img.Bounds().Dx() != 3 || img.Bounds().Dy() != 2 {
+		t.Errorf("Decode() bounds = %v", img.Bounds())
+	}
+
+	buf.Reset()
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 10000, 5000)))
+	if _, err := Decode(&buf); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Decode() of a huge image error = %v, want ErrTooLarge", err)
+	}
+	if _, err := Decode(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Error("Decode() of garbage succeeded")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	opaque := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := 3; i < len(opaque.Pix); i += 4 {
+		opaque.Pix[i] = 255
+	}
+	if typ, err := Encode(&bytes.Buffer{}, opaque); err != nil || typ != "image/jpeg" {
+		t.Errorf("Encode(opaque) = %q, %v, want image/jpeg", typ, err)
+	}
+	if typ, err := Encode(&bytes.Buffer{}, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil || typ != "image/png" {
+		t.Errorf("Encode(transparent) = %q, %v, want image/png", typ, err)
+	}
+}
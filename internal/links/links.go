@@ -0,0 +1,60 @@
+package links
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const codeAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var urlPattern = regexp.MustCompile(`https?://[^\s]+`)
+
+// Link is a URL found in a chirp together with its short code.
+type Link struct {
+	Code   string
+	Target string
+}
+
+// NewCode returns a random base62 code of the given length.
+func NewCode(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("couldn't generate link code: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// Shorten replaces every URL in body with baseURL + "/l/" + code and returns
+// the rewritten body along with the links that need redirects. Links that
+// already point at baseURL are left alone.
+func Shorten(body, baseURL string, newCode func() (string, error)) (string, []Link, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	var found []Link
+	var genErr error
+	rewritten := urlPattern.ReplaceAllStringFunc(body, func(match string) string {
+		if genErr != nil {
+			return match
+		}
+		target := strings.TrimRight(match, ".,!?;:)'\"")
+		trailing := match[len(target):]
+		if strings.HasPrefix(target, baseURL+"/") {
+			return match
+		}
+		code, err := newCode()
+		if err != nil {
+			genErr = err
+			return match
+		}
+		found = append(found, Link{Code: code, Target: target})
+		return baseURL + "/l/" + code + trailing
+	})
+	if genErr != nil {
+		return "", nil, genErr
+	}
+	return rewritten, found, nil
+}
@@ -0,0 +1,87 @@
+package links
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func sequentialCodes() func() (string, error) {
+	n := 0
+	return func() (string, error) {
+		n++
+		return fmt.Sprintf("c%d", n), nil
+	}
+}
+
+func TestShorten(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantBody  string
+		wantLinks []Link
+	}{
+		{
+			name:     "no links",
+			body:     "hello world",
+			wantBody: "hello world",
+		},
+		{
+			name:      "single link",
+			body:      "read https://example.com/post?id=1 now",
+			wantBody:  "read https://chirpy.test/l/c1 now",
+			wantLinks: []Link{{Code: "c1", Target: "https://example.com/post?id=1"}},
+		},
+		{
+			name:     "trailing punctuation is kept outside the link",
+			body:     "see http://example.com/a, and https://example.org.",
+			wantBody: "see https://chirpy.test/l/c1, and https://chirpy.test/l/c2.",
+			wantLinks: []Link{
+				{Code: "c1", Target: "http://example.com/a"},
+				{Code: "c2", Target: "https://example.org"},
+			},
+		},
+		{
+			name:     "already shortened links are left alone",
+			body:     "old https://chirpy.test/l/abc",
+			wantBody: "old https://chirpy.test/l/abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, found, err := Shorten(tt.body, "https://chirpy.test/", sequentialCodes())
+			if err != nil {
+				t.Fatalf("Shorten() unexpected error: %v", err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("Shorten() body = %q, want %q", body, tt.wantBody)
+			}
+			if len(found) != len(tt.wantLinks) {
+				t.Fatalf("Shorten() returned %d links, want %d", len(found), len(tt.wantLinks))
+			}
+			for i := range found {
+				if found[i] != tt.wantLinks[i] {
+					t.Errorf("Shorten() link[%d] = %+v, want %+v", i, found[i], tt.wantLinks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestShortenCodeError(t *testing.T) {
+	failing := func() (string, error) { return "", errors.New("no entropy") }
+	if _, _, err := Shorten("https://example.com", "https://chirpy.test", failing); err == nil {
+		t.Errorf("Shorten() expected error, got nil")
+	}
+}
+
+func TestNewCode(t *testing.T) {
+	code, err := NewCode(7)
+	if err != nil {
+		t.Fatalf("NewCode() unexpected error: %v", err)
+	}
+	if len(code) != 7 {
+		t.Errorf("NewCode() length = %d, want 7", len(code))
+	}
+}
@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	got := string(Format("Chirpy <noreply@chirpy.example>", Message{
+		To:      "ada@example.com",
+		Subject: "You're in",
+		Body:    "Welcome!\nSign in any time.",
+	}, now))
+	want := "From: Chirpy <noreply@chirpy.example>\r\n" +
+		"To: ada@example.com\r\n" +
+		"Subject: You're in\r\n" +
+		"Date: Tue, 10 Mar 2026 12:00:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Welcome!\r\nSign in any time."
+	if got != want {
+		t.Errorf("Format() =\n%q\nwant\n%q", got, want)
+	}
+}
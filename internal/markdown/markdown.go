@@ -0,0 +1,151 @@
+// Package markdown renders the markdown subset chirps support:
+// [links](https://example.com), **bold**, *italics* or _italics_, `code`
+// and line breaks. Everything else is escaped, so the output can only
+// contain the tags this package writes and links can only be http or https.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+const linkRel = "nofollow noopener noreferrer"
+
+// ToHTML renders src to sanitized HTML. Unclosed markers are left as they
+// are, escaped.
+func ToHTML(src string) string {
+	var b strings.Builder
+	render(&b, src, true)
+	return b.String()
+}
+
+func render(b *strings.Builder, src string, links bool) {
+	plain := 0
+	flush := func(end int) {
+		b.WriteString(html.EscapeString(src[plain:end]))
+	}
+	for i := 0; i < len(src); {
+		var n int
+		switch c := src[i]; {
+		case c == '\\' && i+1 < len(src) && strings.IndexByte("\\`*_[]()", src[i+1]) >= 0:
+			flush(i)
+			b.WriteString(html.EscapeString(src[i+1 : i+2]))
+			n = 2
+		case c == '\n':
+			flush(i)
+			b.WriteString("<br>")
+			n = 1
+		case c == '`':
+			if end := strings.IndexByte(src[i+1:], '`'); end > 0 {
+				flush(i)
+				b.WriteString("<code>" + html.EscapeString(src[i+1:i+1+end]) + "</code>")
+				n = end + 2
+			}
+		case c == '*' && strings.HasPrefix(src[i:], "**"):
+			if end := closingStrong(src[i+2:]); end > 0 {
+				flush(i)
+				b.WriteString("<strong>")
+				render(b, src[i+2:i+2+end], links)
+				b.WriteString("</strong>")
+				n = end + 4
+			}
+		case c == '*' || (c == '_' && !wordByteBefore(src, i)):
+			if end := closingEmphasis(src[i+1:], c); end > 0 {
+				flush(i)
+				b.WriteString("<em>")
+				render(b, src[i+1:i+1+end], links)
+				b.WriteString("</em>")
+				n = end + 2
+			}
+		case c == '[' && links:
+			if text, href, size, ok := parseLink(src[i:]); ok {
+				flush(i)
+				b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">`)
+				render(b, text, false)
+				b.WriteString("</a>")
+				n = size
+			}
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+		i += n
+		plain = i
+	}
+	flush(len(src))
+}
+
+// closingStrong finds the "**" that closes a strong span. In a longer run
+// of asterisks it takes the last two, so "**a *b***" closes after the
+// emphasis.
+func closingStrong(s string) int {
+	if s == "" || s[0] == ' ' {
+		return 0
+	}
+	for j := 1; j+1 < len(s); j++ {
+		if s[j] != '*' || s[j+1] != '*' || s[j-1] == ' ' {
+			continue
+		}
+		for j+2 < len(s) && s[j+2] == '*' {
+			j++
+		}
+		return j
+	}
+	return 0
+}
+
+// closingEmphasis finds the marker that closes an emphasis opened with c.
+// An underscore only closes at the end of a word, so snake_case is left
+// alone.
+func closingEmphasis(s string, c byte) int {
+	if s == "" || s[0] == ' ' {
+		return 0
+	}
+	for j := 1; j < len(s); j++ {
+		if s[j] != c || s[j-1] == ' ' {
+			continue
+		}
+		if c == '_' && j+1 < len(s) && isWordByte(s[j+1]) {
+			continue
+		}
+		if c == '*' && j+1 < len(s) && s[j+1] == '*' {
+			j++
+			continue
+		}
+		return j
+	}
+	return 0
+}
+
+// parseLink parses [text](url) at the start of s. It returns false for
+// anything but an absolute http or https URL.
+func parseLink(s string) (text, href string, size int, ok bool) {
+	closeText := strings.Index(s, "](")
+	if closeText <= 1 {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeText+2:], ')')
+	if closeURL <= 0 {
+		return "", "", 0, false
+	}
+	text = s[1:closeText]
+	href = strings.TrimSpace(s[closeText+2 : closeText+2+closeURL])
+	if strings.ContainsAny(text, "\n") || strings.ContainsAny(href, " \n") {
+		return "", "", 0, false
+	}
+	u, err := url.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", 0, false
+	}
+	return text, u.String(), closeText + 2 + closeURL + 1, true
+}
+
+func wordByteBefore(s string, i int) bool {
+	return i > 0 && isWordByte(s[i-1])
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,48 @@
+package markdown
+
+import "testing"
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "plain", src: "hello world", want: "hello world"},
+		{name: "escapes html", src: `<script>alert("x")</script>`, want: "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"},
+		{name: "bold", src: "a **big** deal", want: "a <strong>big</strong> deal"},
+		{name: "italics", src: "*very* _much_ so", want: "<em>very</em> <em>much</em> so"},
+		{name: "nested", src: "**bold and *italic***", want: "<strong>bold and <em>italic</em></strong>"},
+		{name: "code is literal", src: "run `**x** <b>`", want: "run <code>**x** &lt;b&gt;</code>"},
+		{name: "snake_case", src: "my_var_name stays", want: "my_var_name stays"},
+		{name: "unclosed markers", src: "2 * 3 and **open", want: "2 * 3 and **open"},
+		{name: "escaped marker", src: `\*not italic\*`, want: "*not italic*"},
+		{name: "line break", src: "one\ntwo", want: "one<br>two"},
+		{
+			name: "link",
+			src:  "see [the docs](https://example.com/a?b=1&c=2)",
+			want: `see <a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">the docs</a>`,
+		},
+		{
+			name: "formatted link text",
+			src:  "[**bold** link](http://example.com)",
+			want: `<a href="http://example.com" rel="nofollow noopener noreferrer"><strong>bold</strong> link</a>`,
+		},
+		{name: "javascript link", src: "[click](javascript:alert(1))", want: "[click](javascript:alert(1))"},
+		{name: "data link", src: "[x](data:text/html,hi)", want: "[x](data:text/html,hi)"},
+		{name: "relative link", src: "[x](/admin)", want: "[x](/admin)"},
+		{name: "quote in url", src: `[x](https://example.com/"onmouseover=")`, want: `<a href="https://example.com/%22onmouseover=%22" rel="nofollow noopener noreferrer">x</a>`},
+		{
+			name: "no nested links",
+			src:  "[[a](https://a.example)](https://b.example)",
+			want: `<a href="https://a.example" rel="nofollow noopener noreferrer">[a</a>](https://b.example)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToHTML(tt.src); got != tt.want {
+				t.Errorf("ToHTML(%q) =\n%s\nwant\n%s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
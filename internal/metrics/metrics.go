@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RouteStats holds the counters for one route and status code.
+type RouteStats struct {
+	Route    string
+	Code     int
+	Count    uint64
+	Duration time.Duration
+}
+
+// QueryStats describes the database statements run by one route's
+// requests. P95 is taken over the most recent queryWindowSize requests;
+// the totals cover every request since the last reset.
+type QueryStats struct {
+	Route    string
+	Requests uint64
+	Queries  uint64
+	Duration time.Duration
+	P95      int
+}
+
+// queryWindowSize is how many recent requests per route P95 is taken over.
+const queryWindowSize = 1024
+
+type queryWindow struct {
+	stats   QueryStats
+	samples []int
+	next    int
+}
+
+type routeKey struct {
+	route string
+	code  int
+}
+
+// Registry collects per-route request counts and latencies in memory.
+type Registry struct {
+	mu      sync.Mutex
+	routes  map[routeKey]*RouteStats
+	queries map[string]*queryWindow
+}
+
+func NewRegistry() *Registry {
+	return &Registry{routes: map[routeKey]*RouteStats{}, queries: map[string]*queryWindow{}}
+}
+
+// Observe records a single request.
+func (r *Registry) Observe(route string, code int, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := routeKey{route: route, code: code}
+	stats, ok := r.routes[key]
+	if !ok {
+		stats = &RouteStats{Route: route, Code: code}
+		r.routes[key] = stats
+	}
+	stats.Count++
+	stats.Duration += d
+}
+
+// ObserveQueries records the statements a single request ran and the time
+// spent in them.
+func (r *Registry) ObserveQueries(route string, queries int, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.queries[route]
+	if !ok {
+		w = &queryWindow{stats: QueryStats{Route: route}}
+		r.queries[route] = w
+	}
+	w.stats.Requests++
+	w.stats.Queries += uint64(queries)
+	w.stats.Duration += d
+	if len(w.samples) < queryWindowSize {
+		w.samples = append(w.samples, queries)
+	} else {
+		w.samples[w.next] = queries
+		w.next = (w.next + 1) % queryWindowSize
+	}
+}
+
+// QuerySnapshot returns the per-route statement counts ordered by route.
+func (r *Registry) QuerySnapshot() []QueryStats {
+	r.mu.Lock()
+	stats := make([]QueryStats, 0, len(r.queries))
+	for _, w := range r.queries {
+		s := w.stats
+		s.P95 = percentile(w.samples, 0.95)
+		stats = append(stats, s)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+// percentile returns the nearest-rank p-th percentile of samples.
+func percentile(samples []int, p float64) int {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// Snapshot returns a copy of the current counters ordered by route and code.
+func (r *Registry) Snapshot() []RouteStats {
+	r.mu.Lock()
+	stats := make([]RouteStats, 0, len(r.routes))
+	for _, s := range r.routes {
+		stats = append(stats, *s)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Route != stats[j].Route {
+			return stats[i].Route < stats[j].Route
+		}
+		return stats[i].Code < stats[j].Code
+	})
+	return stats
+}
+
+// Reset clears all counters.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = map[routeKey]*RouteStats{}
+	r.queries = map[string]*queryWindow{}
+}
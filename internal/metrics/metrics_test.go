@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/breaker"
+)
+
+func TestRegistryObserve(t *testing.T) {
+	reg := NewRegistry()
+	reg.Observe("GET /api/chirps", 200, 10*time.Millisecond)
+	reg.Observe("GET /api/chirps", 200, 30*time.Millisecond)
+	reg.Observe("GET /api/chirps", 404, 5*time.Millisecond)
+	reg.Observe("POST /api/login", 200, time.Millisecond)
+
+	got := reg.Snapshot()
+	want := []RouteStats{
+		{Route: "GET /api/chirps", Code: 200, Count: 2, Duration: 40 * time.Millisecond},
+		{Route: "GET /api/chirps", Code: 404, Count: 1, Duration: 5 * time.Millisecond},
+		{Route: "POST /api/login", Code: 200, Count: 1, Duration: time.Millisecond},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() returned %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Snapshot()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	reg.Reset()
+	if got := reg.Snapshot(); len(got) != 0 {
+		t.Errorf("Snapshot() after Reset = %+v, want empty", got)
+	}
+}
+
+func TestRegistryObserveQueries(t *testing.T) {
+	reg := NewRegistry()
+	for i := 1; i <= 100; i++ {
+		reg.ObserveQueries("GET /api/chirps", i, time.Millisecond)
+	}
+	reg.ObserveQueries("POST /api/login", 3, 2*time.Millisecond)
+
+	got := reg.QuerySnapshot()
+	want := []QueryStats{
+		{Route: "GET /api/chirps", Requests: 100, Queries: 5050, Duration: 100 * time.Millisecond, P95: 95},
+		{Route: "POST /api/login", Requests: 1, Queries: 3, Duration: 2 * time.Millisecond, P95: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("QuerySnapshot() returned %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("QuerySnapshot()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Only the most recent requests count towards P95.
+	for range queryWindowSize {
+		reg.ObserveQueries("POST /api/login", 1, 0)
+	}
+	if got := reg.QuerySnapshot()[1].P95; got != 1 {
+		t.Errorf("P95 after the window filled = %d, want 1", got)
+	}
+
+	reg.Reset()
+	if got := reg.QuerySnapshot(); len(got) != 0 {
+		t.Errorf("QuerySnapshot() after Reset = %+v, want empty", got)
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		snap     Snapshot
+		contains []string
+	}{
+		{
+			name: "no routes",
+			snap: Snapshot{FileserverHits: 3},
+			contains: []string{
+				"chirpy_fileserver_hits_total 3\n",
+				"# EOF\n",
+			},
+		},
+		{
+			name: "route stats",
+			snap: Snapshot{Routes: []RouteStats{
+				{Route: "GET /api/chirps", Code: 200, Count: 2, Duration: 1500 * time.Millisecond},
+			}},
+			contains: []string{
+				"chirpy_http_requests_total{route=\"GET /api/chirps\",code=\"200\"} 2\n",
+				"chirpy_http_request_duration_seconds_count{route=\"GET /api/chirps\",code=\"200\"} 2\n",
+				"chirpy_http_request_duration_seconds_sum{route=\"GET /api/chirps\",code=\"200\"} 1.5\n",
+			},
+		},
+		{
+			name: "escapes label values",
+			snap: Snapshot{Routes: []RouteStats{
+				{Route: `GET /a"b\c`, Code: 200, Count: 1},
+			}},
+			contains: []string{
+				`chirpy_http_requests_total{route="GET /a\"b\\c",code="200"} 1`,
+			},
+		},
+		{
+			name: "query stats",
+			snap: Snapshot{Queries: []QueryStats{
+				{Route: "GET /api/chirps", Requests: 4, Queries: 10, Duration: 250 * time.Millisecond, P95: 4},
+			}},
+			contains: []string{
+				"chirpy_http_request_db_queries{route=\"GET /api/chirps\",quantile=\"0.95\"} 4\n",
+				"chirpy_http_request_db_queries_count{route=\"GET /api/chirps\"} 4\n",
+				"chirpy_http_request_db_queries_sum{route=\"GET /api/chirps\"} 10\n",
+				"chirpy_http_request_db_duration_seconds_sum{route=\"GET /api/chirps\"} 0.25\n",
+			},
+		},
+		{
+			name: "circuit breakers",
+			snap: Snapshot{Breakers: []breaker.Stats{
+				{Name: "webhook", State: breaker.StateOpen, Successes: 4, Failures: 5, Rejections: 2},
+			}},
+			contains: []string{
+				"chirpy_circuit_breaker_state{dependency=\"webhook\",chirpy_circuit_breaker_state=\"closed\"} 0\n",
+				"chirpy_circuit_breaker_state{dependency=\"webhook\",chirpy_circuit_breaker_state=\"open\"} 1\n",
+				"chirpy_circuit_breaker_calls_total{dependency=\"webhook\",result=\"failure\"} 5\n",
+				"chirpy_circuit_breaker_calls_total{dependency=\"webhook\",result=\"rejected\"} 2\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteOpenMetrics(&buf, tt.snap); err != nil {
+				t.Fatalf("WriteOpenMetrics() unexpected error: %v", err)
+			}
+			out := buf.String()
+			for _, want := range tt.contains {
+				if !strings.Contains(out, want) {
+					t.Errorf("WriteOpenMetrics() output missing %q:\n%s", want, out)
+				}
+			}
+			if !strings.HasSuffix(out, "# EOF\n") {
+				t.Errorf("WriteOpenMetrics() output must end with # EOF:\n%s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/breaker"
+)
+
+// OpenMetricsContentType is the media type scrapers expect for WriteOpenMetrics.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Snapshot is everything exposed on the metrics endpoint.
+type Snapshot struct {
+	FileserverHits int64
+	Routes         []RouteStats
+	Queries        []QueryStats
+	Breakers       []breaker.Stats
+}
+
+var breakerStates = []breaker.State{breaker.StateClosed, breaker.StateOpen, breaker.StateHalfOpen}
+
+// WriteOpenMetrics writes the snapshot in the OpenMetrics text exposition
+// format.
+func WriteOpenMetrics(w io.Writer, snap Snapshot) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# TYPE chirpy_fileserver_hits counter")
+	fmt.Fprintln(bw, "# HELP chirpy_fileserver_hits Requests served by the /app file server.")
+	fmt.Fprintf(bw, "chirpy_fileserver_hits_total %d\n", snap.FileserverHits)
+
+	fmt.Fprintln(bw, "# TYPE chirpy_http_requests counter")
+	fmt.Fprintln(bw, "# HELP chirpy_http_requests HTTP requests by route and status code.")
+	for _, s := range snap.Routes {
+		fmt.Fprintf(bw, "chirpy_http_requests_total%s %d\n", labels(s), s.Count)
+	}
+
+	fmt.Fprintln(bw, "# TYPE chirpy_http_request_duration_seconds summary")
+	fmt.Fprintln(bw, "# HELP chirpy_http_request_duration_seconds Time spent handling HTTP requests.")
+	for _, s := range snap.Routes {
+		fmt.Fprintf(bw, "chirpy_http_request_duration_seconds_count%s %d\n", labels(s), s.Count)
+		fmt.Fprintf(bw, "chirpy_http_request_duration_seconds_sum%s %s\n", labels(s), strconv.FormatFloat(s.Duration.Seconds(), 'f', -1, 64))
+	}
+
+	fmt.Fprintln(bw, "# TYPE chirpy_http_request_db_queries summary")
+	fmt.Fprintln(bw, "# HELP chirpy_http_request_db_queries Database statements run per HTTP request.")
+	for _, q := range snap.Queries {
+		route := labelEscaper.Replace(q.Route)
+		fmt.Fprintf(bw, "chirpy_http_request_db_queries{route=\"%s\",quantile=\"0.95\"} %d\n", route, q.P95)
+		fmt.Fprintf(bw, "chirpy_http_request_db_queries_count{route=\"%s\"} %d\n", route, q.Requests)
+		fmt.Fprintf(bw, "chirpy_http_request_db_queries_sum{route=\"%s\"} %d\n", route, q.Queries)
+	}
+	fmt.Fprintln(bw, "# TYPE chirpy_http_request_db_duration_seconds summary")
+	fmt.Fprintln(bw, "# HELP chirpy_http_request_db_duration_seconds Time HTTP requests spent waiting on the database.")
+	for _, q := range snap.Queries {
+		route := labelEscaper.Replace(q.Route)
+		fmt.Fprintf(bw, "chirpy_http_request_db_duration_seconds_count{route=\"%s\"} %d\n", route, q.Requests)
+		fmt.Fprintf(bw, "chirpy_http_request_db_duration_seconds_sum{route=\"%s\"} %s\n", route, strconv.FormatFloat(q.Duration.Seconds(), 'f', -1, 64))
+	}
+
+	fmt.Fprintln(bw, "# TYPE chirpy_circuit_breaker_state stateset")
+	fmt.Fprintln(bw, "# HELP chirpy_circuit_breaker_state Current state of each dependency's circuit breaker.")
+	for _, b := range snap.Breakers {
+		for _, state := range breakerStates {
+			value := 0
+			if b.State == state {
+				value = 1
+			}
+			fmt.Fprintf(bw, "chirpy_circuit_breaker_state{dependency=\"%s\",chirpy_circuit_breaker_state=\"%s\"} %d\n", labelEscaper.Replace(b.Name), state, value)
+		}
+	}
+	fmt.Fprintln(bw, "# TYPE chirpy_circuit_breaker_calls counter")
+	fmt.Fprintln(bw, "# HELP chirpy_circuit_breaker_calls Calls to external dependencies by outcome.")
+	for _, b := range snap.Breakers {
+		name := labelEscaper.Replace(b.Name)
+		fmt.Fprintf(bw, "chirpy_circuit_breaker_calls_total{dependency=\"%s\",result=\"success\"} %d\n", name, b.Successes)
+		fmt.Fprintf(bw, "chirpy_circuit_breaker_calls_total{dependency=\"%s\",result=\"failure\"} %d\n", name, b.Failures)
+		fmt.Fprintf(bw, "chirpy_circuit_breaker_calls_total{dependency=\"%s\",result=\"rejected\"} %d\n", name, b.Rejections)
+	}
+
+	fmt.Fprintln(bw, "# EOF")
+	return bw.Flush()
+}
+
+func labels(s RouteStats) string {
+	return fmt.Sprintf(`{route="%s",code="%d"}`, labelEscaper.Replace(s.Route), s.Code)
+}
@@ -0,0 +1,151 @@
+// Package oauthserver holds the protocol rules for Chirpy's OAuth2
+// authorization server: scopes, redirect URI checks, PKCE and the error
+// format from RFC 6749. Storage and HTTP wiring live with the handlers.
+package oauthserver
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	ScopeChirpsRead   = "chirps:read"
+	ScopeChirpsWrite  = "chirps:write"
+	ScopeProfileWrite = "profile:write"
+
+	ResponseTypeCode           = "code"
+	GrantTypeAuthorizationCode = "authorization_code"
+	ChallengeMethodS256        = "S256"
+)
+
+var scopeDescriptions = map[string]string{
+	ScopeChirpsRead:   "Read chirps, including ones only you can see",
+	ScopeChirpsWrite:  "Post and delete chirps as you",
+	ScopeProfileWrite: "Change your handle and display name",
+}
+
+// ParseScope splits a space separated scope string, drops duplicates and
+// returns the scopes in sorted order. Unknown scopes are an error.
+func ParseScope(raw string) ([]string, error) {
+	seen := map[string]bool{}
+	var scopes []string
+	for _, s := range strings.Fields(raw) {
+		if _, ok := scopeDescriptions[s]; !ok {
+			return nil, &Error{Code: ErrInvalidScope, Description: fmt.Sprintf("unknown scope %q", s)}
+		}
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, &Error{Code: ErrInvalidScope, Description: "at least one scope is required"}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+// FormatScope joins scopes into the space separated form used on the wire
+// and in storage.
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// HasScope reports whether a granted scope string includes want.
+func HasScope(granted, want string) bool {
+	for _, s := range strings.Fields(granted) {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+// DescribeScope returns the text shown for a scope on the consent screen.
+func DescribeScope(scope string) string {
+	return scopeDescriptions[scope]
+}
+
+// ValidRedirectURI checks a redirect URI at client registration. It must be
+// absolute, carry no fragment and use https, except for loopback addresses
+// which native apps listen on.
+func ValidRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("redirect URI %q must be an absolute URL", raw)
+	}
+	if u.Fragment != "" || strings.Contains(raw, "#") {
+		return fmt.Errorf("redirect URI %q must not contain a fragment", raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if isLoopback(u.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("redirect URI %q must use https", raw)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// MatchRedirectURI reports whether uri is one of the registered redirect
+// URIs. Matching is exact, as RFC 9700 recommends.
+func MatchRedirectURI(registered []string, uri string) bool {
+	for _, r := range registered {
+		if r == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidCodeVerifier checks the RFC 7636 format of a PKCE code verifier.
+func ValidCodeVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-' || c == '.' || c == '_' || c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// S256Challenge returns the S256 code challenge for a verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE checks a code verifier against the challenge sent with the
+// authorization request. Only S256 is supported.
+func VerifyPKCE(challenge, verifier string) bool {
+	if !ValidCodeVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(S256Challenge(verifier)), []byte(challenge)) == 1
+}
+
+// ValidCodeChallenge checks that a challenge looks like an S256 digest.
+func ValidCodeChallenge(challenge string) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil && len(decoded) == sha256.Size
+}
@@ -0,0 +1,142 @@
+package oauthserver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single", raw: "chirps:read", want: []string{"chirps:read"}},
+		{name: "sorted and deduplicated", raw: "chirps:write chirps:read  chirps:write", want: []string{"chirps:read", "chirps:write"}},
+		{name: "unknown scope", raw: "chirps:read admin", wantErr: true},
+		{name: "empty", raw: "   ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScope(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var oauthErr *Error
+				if !errors.As(err, &oauthErr) || oauthErr.Code != ErrInvalidScope {
+					t.Errorf("ParseScope() error = %v, want %s", err, ErrInvalidScope)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	if !HasScope("chirps:read chirps:write", ScopeChirpsWrite) {
+		t.Error("HasScope() = false for a granted scope")
+	}
+	if HasScope("chirps:read", ScopeChirpsWrite) {
+		t.Error("HasScope() = true for a scope that wasn't granted")
+	}
+	if HasScope("chirps:readwrite", ScopeChirpsRead) {
+		t.Error("HasScope() matched a prefix")
+	}
+}
+
+func TestValidRedirectURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{uri: "https://app.example/callback", wantErr: false},
+		{uri: "https://app.example/callback?team=1", wantErr: false},
+		{uri: "http://127.0.0.1:8765/callback", wantErr: false},
+		{uri: "http://localhost/callback", wantErr: false},
+		{uri: "http://app.example/callback", wantErr: true},
+		{uri: "https://app.example/callback#frag", wantErr: true},
+		{uri: "/callback", wantErr: true},
+		{uri: "javascript:alert(1)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if err := ValidRedirectURI(tt.uri); (err != nil) != tt.wantErr {
+				t.Errorf("ValidRedirectURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchRedirectURI(t *testing.T) {
+	registered := []string{"https://app.example/callback"}
+	if !MatchRedirectURI(registered, "https://app.example/callback") {
+		t.Error("MatchRedirectURI() rejected a registered URI")
+	}
+	for _, uri := range []string{"https://app.example/callback/", "https://app.example/callback?x=1", "https://APP.example/callback"} {
+		if MatchRedirectURI(registered, uri) {
+			t.Errorf("MatchRedirectURI(%q) = true, want exact matching", uri)
+		}
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	// Example from RFC 7636 appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if got := S256Challenge(verifier); got != challenge {
+		t.Fatalf("S256Challenge() = %q, want %q", got, challenge)
+	}
+	if !ValidCodeChallenge(challenge) {
+		t.Error("ValidCodeChallenge() rejected a valid challenge")
+	}
+	if ValidCodeChallenge("short") {
+		t.Error("ValidCodeChallenge() accepted a malformed challenge")
+	}
+	if !VerifyPKCE(challenge, verifier) {
+		t.Error("VerifyPKCE() rejected the matching verifier")
+	}
+	if VerifyPKCE(challenge, verifier[:len(verifier)-1]+"A") {
+		t.Error("VerifyPKCE() accepted a different verifier")
+	}
+	if VerifyPKCE(S256Challenge("too-short"), "too-short") {
+		t.Error("VerifyPKCE() accepted a verifier shorter than 43 characters")
+	}
+}
+
+func TestErrorRedirectURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		redirectURI string
+		state       string
+		err         *Error
+		want        string
+	}{
+		{
+			name:        "with state",
+			redirectURI: "https://app.example/cb",
+			state:       "xyz",
+			err:         &Error{Code: ErrAccessDenied},
+			want:        "https://app.example/cb?error=access_denied&state=xyz",
+		},
+		{
+			name:        "keeps registered query",
+			redirectURI: "https://app.example/cb?team=1",
+			err:         &Error{Code: ErrInvalidScope, Description: "bad scope"},
+			want:        "https://app.example/cb?team=1&error=invalid_scope&error_description=bad+scope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorRedirectURL(tt.redirectURI, tt.state, tt.err); got != tt.want {
+				t.Errorf("ErrorRedirectURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type fakeIdP struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() unexpected error: %v", err)
+	}
+	idp := &fakeIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tenant/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": idp.server.URL})
+	})
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "chirpy" || secret != "s3cret" || r.PostFormValue("code") != "good-code" || r.PostFormValue("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.idToken, "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("SignedString() unexpected error: %v", err)
+	}
+	return signed
+}
+
+func (idp *fakeIdP) provider(t *testing.T) *Provider {
+	t.Helper()
+	p, err := Discover(context.Background(), idp.server.Client(), Config{
+		Issuer:       idp.server.URL,
+		ClientID:     "chirpy",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://chirpy.example/api/sso/callback",
+	})
+	if err != nil {
+		t.Fatalf("Discover() unexpected error: %v", err)
+	}
+	return p
+}
+
+func TestVerify(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := idp.provider(t)
+	base := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            idp.server.URL,
+			"aud":            "chirpy",
+			"sub":            "user-1",
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          "n1",
+			"email":          "ada@corp.example",
+			"email_verified": true,
+			"groups":         []string{"staff", "chirpy-admins"},
+		}
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		c := base()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		claims  jwt.MapClaims
+		nonce   string
+		want    Claims
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			kid:    "k1",
+			claims: base(),
+			nonce:  "n1",
+			want: Claims{
+				Issuer:        idp.server.URL,
+				Subject:       "user-1",
+				Email:         "ada@corp.example",
+				EmailVerified: true,
+				Groups:        []string{"staff", "chirpy-admins"},
+			},
+		},
+		{name: "wrong nonce", kid: "k1", claims: base(), nonce: "other", wantErr: true},
+		{name: "wrong audience", kid: "k1", claims: with("aud", "someone-else"), nonce: "n1", wantErr: true},
+		{name: "wrong issuer", kid: "k1", claims: with("iss", "https://evil.example"), nonce: "n1", wantErr: true},
+		{name: "expired", kid: "k1", claims: with("exp", time.Now().Add(-time.Minute).Unix()), nonce: "n1", wantErr: true},
+		{name: "no expiry", kid: "k1", claims: with("exp", nil), nonce: "n1", wantErr: true},
+		{name: "unknown key", kid: "k2", claims: base(), nonce: "n1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Verify(context.Background(), idp.sign(t, tt.kid, tt.claims), tt.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Verify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsHMAC(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := idp.provider(t)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": idp.server.URL, "aud": "chirpy", "sub": "x", "nonce": "n1",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "k1"
+	signed, _ := token.SignedString([]byte("secret"))
+	if _, err := p.Verify(context.Background(), signed, "n1"); err == nil {
+		t.Error("Verify() accepted an HS256 token")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := idp.provider(t)
+	idp.idToken = "raw-id-token"
+
+	got, err := p.Exchange(context.Background(), "good-code", "verifier")
+	if err != nil {
+		t.Fatalf("Exchange() unexpected error: %v", err)
+	}
+	if got != "raw-id-token" {
+		t.Errorf("Exchange() = %q, want raw-id-token", got)
+	}
+	if _, err := p.Exchange(context.Background(), "bad-code", "verifier"); err == nil {
+		t.Error("Exchange() accepted a bad code")
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := idp.provider(t)
+	u, err := url.Parse(p.AuthCodeURL("st", "no", "ch"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() returned an invalid URL: %v", err)
+	}
+	q := u.Query()
+	for key, want := range map[string]string{
+		"client_id":             "chirpy",
+		"state":                 "st",
+		"nonce":                 "no",
+		"code_challenge":        "ch",
+		"code_challenge_method": "S256",
+		"redirect_uri":          "https://chirpy.example/api/sso/callback",
+	} {
+		if got := q.Get(key); got != want {
+			t.Errorf("AuthCodeURL() %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	_, err := Discover(context.Background(), idp.server.Client(), Config{Issuer: idp.server.URL + "/tenant"})
+	if err == nil {
+		t.Fatal("Discover() accepted a provider with a different issuer")
+	}
+}
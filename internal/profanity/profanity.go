@@ -0,0 +1,171 @@
+package profanity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Action is what happens to text containing a listed word.
+type Action string
+
+const (
+	ActionNone   Action = ""
+	ActionMask   Action = "mask"
+	ActionFlag   Action = "flag"
+	ActionReject Action = "reject"
+)
+
+var severity = map[Action]int{
+	ActionNone:   0,
+	ActionMask:   1,
+	ActionFlag:   2,
+	ActionReject: 3,
+}
+
+// Context identifies where the checked text comes from, so the same word
+// can be masked in a chirp but rejected in a display name.
+type Context string
+
+const (
+	ContextDefault     Context = "default"
+	ContextChirpBody   Context = "chirp_body"
+	ContextDisplayName Context = "display_name"
+)
+
+// Rules maps a lowercase word to the action taken for it per context. The
+// "default" context applies wherever no specific action is configured.
+type Rules map[string]map[Context]Action
+
+var DefaultRules = Rules{
+	"kerfuffle": {ContextDefault: ActionMask},
+	"sharbert":  {ContextDefault: ActionMask},
+	"fornax":    {ContextDefault: ActionMask},
+}
+
+const mask = "****"
+
+type Filter struct {
+	rules Rules
+}
+
+func New(rules Rules) *Filter {
+	normalized := make(Rules, len(rules))
+	for word, actions := range rules {
+		normalized[strings.ToLower(word)] = actions
+	}
+	return &Filter{rules: normalized}
+}
+
+// Parse builds a Filter from its JSON representation, for example
+// {"fornax": {"default": "mask", "display_name": "reject"}}.
+func Parse(raw string) (*Filter, error) {
+	rules := Rules{}
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("couldn't parse profanity rules: %w", err)
+	}
+	for word, actions := range rules {
+		if err := ValidateActions(actions); err != nil {
+			return nil, fmt.Errorf("invalid rules for %q: %w", word, err)
+		}
+	}
+	return New(rules), nil
+}
+
+var contexts = map[Context]bool{
+	ContextDefault:     true,
+	ContextChirpBody:   true,
+	ContextDisplayName: true,
+}
+
+// ValidateActions checks the actions configured for one word.
+func ValidateActions(actions map[Context]Action) error {
+	if len(actions) == 0 {
+		return fmt.Errorf("no actions")
+	}
+	for ctx, action := range actions {
+		if !contexts[ctx] {
+			return fmt.Errorf("unknown context %q", ctx)
+		}
+		if _, ok := severity[action]; !ok || action == ActionNone {
+			return fmt.Errorf("unknown action %q in context %q", action, ctx)
+		}
+	}
+	return nil
+}
+
+// Encode returns the JSON representation of rules accepted by Parse.
+func (r Rules) Encode() string {
+	dat, err := json.Marshal(r)
+	if err != nil {
+		return "{}"
+	}
+	return string(dat)
+}
+
+// Result describes the outcome of checking a piece of text.
+type Result struct {
+	// Text is the input with every masked word replaced.
+	Text string
+	// Action is the most severe action triggered by any word.
+	Action Action
+	// Matches lists the listed words found, in order of appearance.
+	Matches []string
+}
+
+func (f *Filter) actionFor(ctx Context, word string) Action {
+	actions, ok := f.rules[word]
+	if !ok {
+		return ActionNone
+	}
+	if action, ok := actions[ctx]; ok {
+		return action
+	}
+	return actions[ContextDefault]
+}
+
+// Check looks up every word in text. A word is a run of letters and
+// digits, so "Fornax!" and "(fornax)" both match and the punctuation
+// around a masked word is kept. Matching ignores case.
+func (f *Filter) Check(ctx Context, text string) Result {
+	result := Result{Action: ActionNone}
+	var b strings.Builder
+	plain := 0
+	for start := 0; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if !isWordRune(r) {
+			start += size
+			continue
+		}
+		end := start + size
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += size
+		}
+		lower := strings.ToLower(text[start:end])
+		if action := f.actionFor(ctx, lower); action != ActionNone {
+			result.Matches = append(result.Matches, lower)
+			if action == ActionMask {
+				b.WriteString(text[plain:start])
+				b.WriteString(mask)
+				plain = end
+			}
+			if severity[action] > severity[result.Action] {
+				result.Action = action
+			}
+		}
+		start = end
+	}
+	b.WriteString(text[plain:])
+	result.Text = b.String()
+	return result
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
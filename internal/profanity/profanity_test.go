@@ -0,0 +1,193 @@
+package profanity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+func TestFilterCheck(t *testing.T) {
+	filter := New(Rules{
+		"kerfuffle": {ContextDefault: ActionMask},
+		"Fornax":    {ContextDefault: ActionMask, ContextDisplayName: ActionReject},
+		"sharbert":  {ContextChirpBody: ActionFlag},
+	})
+
+	tests := []struct {
+		name       string
+		ctx        Context
+		text       string
+		wantText   string
+		wantAction Action
+	}{
+		{
+			name:       "clean text",
+			ctx:        ContextChirpBody,
+			text:       "hello world",
+			wantText:   "hello world",
+			wantAction: ActionNone,
+		},
+		{
+			name:       "masked word is case insensitive",
+			ctx:        ContextChirpBody,
+			text:       "what a Kerfuffle today",
+			wantText:   "what a **** today",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "context specific action overrides default",
+			ctx:        ContextDisplayName,
+			text:       "fornax fan",
+			wantText:   "fornax fan",
+			wantAction: ActionReject,
+		},
+		{
+			name:       "default action used when context not configured",
+			ctx:        ContextChirpBody,
+			text:       "fornax fan",
+			wantText:   "**** fan",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "flagged word is kept",
+			ctx:        ContextChirpBody,
+			text:       "sharbert kerfuffle",
+			wantText:   "sharbert ****",
+			wantAction: ActionFlag,
+		},
+		{
+			name:       "word without rule for context is ignored",
+			ctx:        ContextDisplayName,
+			text:       "sharbert",
+			wantText:   "sharbert",
+			wantAction: ActionNone,
+		},
+		{
+			name:       "punctuation around a word is kept",
+			ctx:        ContextChirpBody,
+			text:       "what a KERFUFFLE! (fornax), \"kerfuffle\"",
+			wantText:   "what a ****! (****), \"****\"",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "word inside a longer word is ignored",
+			ctx:        ContextChirpBody,
+			text:       "kerfuffles fornax2",
+			wantText:   "kerfuffles fornax2",
+			wantAction: ActionNone,
+		},
+		{
+			name:       "whitespace is preserved",
+			ctx:        ContextChirpBody,
+			text:       "one\nkerfuffle\ttwo",
+			wantText:   "one\n****\ttwo",
+			wantAction: ActionMask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.Check(tt.ctx, tt.text)
+			if got.Text != tt.wantText {
+				t.Errorf("Check() text = %q, want %q", got.Text, tt.wantText)
+			}
+			if got.Action != tt.wantAction {
+				t.Errorf("Check() action = %q, want %q", got.Action, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		wantError bool
+	}{
+		{name: "default rules round trip", raw: DefaultRules.Encode(), wantError: false},
+		{name: "per context actions", raw: `{"fornax": {"default": "mask", "display_name": "reject"}}`, wantError: false},
+		{name: "unknown action", raw: `{"fornax": {"default": "explode"}}`, wantError: true},
+		{name: "empty action", raw: `{"fornax": {"default": ""}}`, wantError: true},
+		{name: "invalid json", raw: `not json`, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.raw)
+			if tt.wantError && err == nil {
+				t.Errorf("Parse() expected error, got nil")
+			}
+			if !tt.wantError && err != nil {
+				t.Errorf("Parse() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+type fakeLoader struct {
+	rows  []database.ProfanityRule
+	err   error
+	calls int
+}
+
+func (f *fakeLoader) ListProfanityRules(ctx context.Context) ([]database.ProfanityRule, error) {
+	f.calls++
+	return f.rows, f.err
+}
+
+func TestStoreFilter(t *testing.T) {
+	ctx := context.Background()
+	loader := &fakeLoader{err: errors.New("database down")}
+	store := NewStore(loader, time.Minute)
+
+	if got := store.Filter(ctx).Check(ContextChirpBody, "kerfuffle").Action; got != ActionMask {
+		t.Errorf("before the first load: action = %q, want the default rules to mask", got)
+	}
+
+	loader.rows = []database.ProfanityRule{
+		{Word: "gadzooks", Context: "default", Action: "mask"},
+		{Word: "gadzooks", Context: "display_name", Action: "reject"},
+	}
+	loader.err = nil
+	store.Filter(ctx)
+	if loader.calls != 1 {
+		t.Fatalf("reloaded within the TTL: %d calls", loader.calls)
+	}
+	store.Invalidate()
+	filter := store.Filter(ctx)
+	if got := filter.Check(ContextChirpBody, "gadzooks kerfuffle").Text; got != "**** kerfuffle" {
+		t.Errorf("after reload: text = %q", got)
+	}
+	if got := filter.Check(ContextDisplayName, "gadzooks").Action; got != ActionReject {
+		t.Errorf("after reload: display name action = %q, want reject", got)
+	}
+
+	loader.err = errors.New("database down")
+	store.Invalidate()
+	if store.Filter(ctx) != filter {
+		t.Error("a failed reload replaced the cached rules")
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions map[Context]Action
+		wantErr bool
+	}{
+		{name: "valid", actions: map[Context]Action{ContextDefault: ActionMask, ContextDisplayName: ActionReject}},
+		{name: "empty", actions: map[Context]Action{}, wantErr: true},
+		{name: "unknown context", actions: map[Context]Action{"bio": ActionMask}, wantErr: true},
+		{name: "unknown action", actions: map[Context]Action{ContextDefault: "explode"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateActions(tt.actions); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateActions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
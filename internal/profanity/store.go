@@ -0,0 +1,71 @@
+package profanity
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+type Loader interface {
+	ListProfanityRules(ctx context.Context) ([]database.ProfanityRule, error)
+}
+
+// Store caches the profanity_rules table as a Filter and rebuilds it once
+// the cached copy is older than the configured TTL, so words added on any
+// instance take effect without a restart. Until the first load succeeds it
+// filters with DefaultRules.
+type Store struct {
+	loader   Loader
+	ttl      time.Duration
+	mu       sync.Mutex
+	filter   *Filter
+	loadedAt time.Time
+}
+
+func NewStore(loader Loader, ttl time.Duration) *Store {
+	return &Store{
+		loader: loader,
+		ttl:    ttl,
+		filter: New(DefaultRules),
+	}
+}
+
+// Invalidate forces the next read to reload the rules from the database.
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Time{}
+}
+
+// Filter returns a filter for the current rules.
+func (s *Store) Filter(ctx context.Context) *Filter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) > s.ttl {
+		rows, err := s.loader.ListProfanityRules(ctx)
+		if err != nil {
+			log.Printf("Error reloading profanity rules, keeping cached rules: %s", err)
+		} else {
+			s.filter = New(RulesFromRows(rows))
+		}
+		s.loadedAt = time.Now()
+	}
+	return s.filter
+}
+
+// RulesFromRows groups profanity_rules rows by word.
+func RulesFromRows(rows []database.ProfanityRule) Rules {
+	rules := Rules{}
+	for _, row := range rows {
+		word := strings.ToLower(row.Word)
+		if rules[word] == nil {
+			rules[word] = map[Context]Action{}
+		}
+		rules[word][Context(row.Context)] = Action(row.Action)
+	}
+	return rules
+}
@@ -0,0 +1,161 @@
+// Package redact masks credentials and personal data so that request
+// bodies, log lines and error messages can be written out safely.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// sensitiveKeys are field names whose values are always masked, compared
+// case-insensitively.
+var sensitiveKeys = map[string]bool{
+	"access_token":  true,
+	"api_key":       true,
+	"authorization": true,
+	"client_secret": true,
+	"code":          true,
+	"code_verifier": true,
+	"csrf_token":    true,
+	"id_token":      true,
+	"key":           true,
+	"password":      true,
+	"refresh_token": true,
+	"secret":        true,
+	"token":         true,
+}
+
+// Sensitive reports whether values of the field name are masked.
+func Sensitive(name string) bool {
+	return sensitiveKeys[strings.ToLower(name)]
+}
+
+// Body returns body with sensitive fields masked. JSON and form bodies are
+// redacted field by field; anything else is omitted because there's no
+// way to tell what it contains.
+func Body(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if s, err := Form(body); err == nil {
+			return s
+		}
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"), mediaType == "":
+		if s, err := JSON(body); err == nil {
+			return s
+		}
+	}
+	return fmt.Sprintf("[%d byte %s body omitted]", len(body), mediaType)
+}
+
+// JSON masks sensitive fields at any depth of a JSON document.
+func JSON(body []byte) (string, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if Sensitive(k) {
+				v[k] = Mask
+				continue
+			}
+			v[k] = redactValue(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return v
+}
+
+// Form masks sensitive fields of a URL-encoded form.
+func Form(body []byte) (string, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return "", err
+	}
+	for k, vs := range values {
+		if Sensitive(k) {
+			for i := range vs {
+				vs[i] = Mask
+			}
+		}
+	}
+	return values.Encode(), nil
+}
+
+var textPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// "password":"...", as found in JSON quoted into an error.
+	{regexp.MustCompile(`(?i)("(?:` + sensitiveKeyPattern + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`), `$1"` + Mask + `"`},
+	// password=... in query strings, forms and DSNs.
+	{regexp.MustCompile(`(?i)\b(` + sensitiveKeyPattern + `)=[^&\s"']+`), `$1=` + Mask},
+	{regexp.MustCompile(`(?i)\b(Bearer|ApiKey)\s+[^\s"']+`), `$1 ` + Mask},
+	// JWTs, including ones embedded in URLs.
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), Mask},
+	{regexp.MustCompile(`\bchrp_rt_[0-9a-f_]+`), Mask},
+	// Legacy refresh tokens and other 256-bit hex secrets.
+	{regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`), Mask},
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`), Mask},
+}
+
+var sensitiveKeyPattern = func() string {
+	keys := make([]string, 0, len(sensitiveKeys))
+	for k := range sensitiveKeys {
+		keys = append(keys, regexp.QuoteMeta(k))
+	}
+	// Longest first, so refresh_token isn't matched as token.
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	return strings.Join(keys, "|")
+}()
+
+// Text masks email addresses, tokens and credential fields in free text
+// such as error messages and log lines.
+func Text(s string) string {
+	for _, p := range textPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
+
+// Writer returns a writer that masks everything written through it with
+// Text. It is meant for log output, where each write is one line.
+func Writer(w io.Writer) io.Writer {
+	return textWriter{w}
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (t textWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(t.w, Text(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
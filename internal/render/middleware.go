@@ -0,0 +1,80 @@
+package render
+
+import (
+	"bytes"
+	"log"
+	"mime"
+	"net/http"
+)
+
+// Middleware reshapes JSON responses according to defaults and the client's
+// Accept header. GET responses can also be re-encoded as MessagePack. Other
+// content types are passed through untouched.
+func Middleware(defaults Options, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		opts := Negotiate(r.Header.Get("Accept"), defaults, r.Method == http.MethodGet)
+		if opts.isDefault() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rw := &writer{ResponseWriter: w, opts: opts}
+		next.ServeHTTP(rw, r)
+		rw.finish()
+	})
+}
+
+// writer buffers JSON bodies so they can be transformed once the handler is
+// done.
+type writer struct {
+	http.ResponseWriter
+	opts      Options
+	status    int
+	decided   bool
+	buffering bool
+	buf       bytes.Buffer
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.status = code
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if mediaType == "application/json" && code != http.StatusNoContent && code != http.StatusNotModified {
+		w.buffering = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *writer) finish() {
+	if !w.buffering {
+		return
+	}
+	body := w.buf.Bytes()
+	if out, err := Transform(body, w.opts, w.status); err != nil {
+		log.Printf("Error rendering JSON response: %s", err)
+	} else {
+		body = out
+		w.Header().Set("Content-Type", w.opts.Format.ContentType())
+	}
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
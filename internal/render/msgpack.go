@@ -0,0 +1,111 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// encodeMessagePack writes a value produced by parseValue in the MessagePack
+// format. Integral numbers use the smallest integer encoding, everything
+// else becomes a float64.
+func encodeMessagePack(out *bytes.Buffer, v any) error {
+	switch t := v.(type) {
+	case nil:
+		out.WriteByte(0xc0)
+	case bool:
+		if t {
+			out.WriteByte(0xc3)
+		} else {
+			out.WriteByte(0xc2)
+		}
+	case string:
+		writeMessagePackString(out, t)
+	case json.Number:
+		if n, err := strconv.ParseInt(string(t), 10, 64); err == nil {
+			writeMessagePackInt(out, n)
+			return nil
+		}
+		f, err := strconv.ParseFloat(string(t), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", t)
+		}
+		out.WriteByte(0xcb)
+		binary.Write(out, binary.BigEndian, math.Float64bits(f))
+	case []any:
+		writeMessagePackHeader(out, len(t), 0x90, 0xdc, 0xdd)
+		for _, item := range t {
+			if err := encodeMessagePack(out, item); err != nil {
+				return err
+			}
+		}
+	case object:
+		writeMessagePackHeader(out, len(t), 0x80, 0xde, 0xdf)
+		for _, m := range t {
+			writeMessagePackString(out, m.key)
+			if err := encodeMessagePack(out, m.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported value of type %T", v)
+	}
+	return nil
+}
+
+// writeMessagePackHeader writes the length prefix of an array or map using
+// the fix, 16-bit or 32-bit form.
+func writeMessagePackHeader(out *bytes.Buffer, n int, fix, b16, b32 byte) {
+	switch {
+	case n < 16:
+		out.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		out.WriteByte(b16)
+		binary.Write(out, binary.BigEndian, uint16(n))
+	default:
+		out.WriteByte(b32)
+		binary.Write(out, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMessagePackString(out *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		out.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		out.WriteByte(0xd9)
+		out.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		out.WriteByte(0xda)
+		binary.Write(out, binary.BigEndian, uint16(n))
+	default:
+		out.WriteByte(0xdb)
+		binary.Write(out, binary.BigEndian, uint32(n))
+	}
+	out.WriteString(s)
+}
+
+func writeMessagePackInt(out *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0 && n <= 0x7f:
+		out.WriteByte(byte(n))
+	case n < 0 && n >= -32:
+		out.WriteByte(byte(int8(n)))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		out.WriteByte(0xd0)
+		out.WriteByte(byte(int8(n)))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		out.WriteByte(0xd1)
+		binary.Write(out, binary.BigEndian, int16(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		out.WriteByte(0xd2)
+		binary.Write(out, binary.BigEndian, int32(n))
+	default:
+		out.WriteByte(0xd3)
+		binary.Write(out, binary.BigEndian, n)
+	}
+}
@@ -0,0 +1,260 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FieldCase is the naming convention applied to JSON object keys.
+type FieldCase string
+
+const (
+	CaseSnake FieldCase = "snake"
+	CaseCamel FieldCase = "camel"
+)
+
+// Format is the wire format JSON responses are re-encoded into.
+type Format string
+
+const (
+	FormatJSON        Format = "json"
+	FormatMessagePack Format = "msgpack"
+)
+
+// ContentType returns the media type sent for responses in format f.
+func (f Format) ContentType() string {
+	if f == FormatMessagePack {
+		return "application/msgpack"
+	}
+	return "application/json"
+}
+
+// Options describe how JSON responses are shaped. The zero value leaves
+// responses exactly as the handlers wrote them.
+type Options struct {
+	Format   Format
+	Case     FieldCase
+	Envelope bool
+}
+
+func (o Options) isDefault() bool {
+	return (o.Format == "" || o.Format == FormatJSON) && (o.Case == "" || o.Case == CaseSnake) && !o.Envelope
+}
+
+var mediaFormats = map[string]Format{
+	"application/json":      FormatJSON,
+	"application/msgpack":   FormatMessagePack,
+	"application/x-msgpack": FormatMessagePack,
+}
+
+// ParseCase validates a field case name; an empty name means snake_case.
+func ParseCase(name string) (FieldCase, error) {
+	switch FieldCase(name) {
+	case "", CaseSnake:
+		return CaseSnake, nil
+	case CaseCamel:
+		return CaseCamel, nil
+	}
+	return "", fmt.Errorf("unknown field case %q", name)
+}
+
+// Negotiate picks the preferred supported media range in the Accept header
+// and applies its parameters on top of defaults, for example
+//
+//	Accept: application/json; case=camel; envelope=data
+//	Accept: application/msgpack, application/json;q=0.5
+//
+// Unknown or invalid parameters are ignored. MessagePack is only chosen when
+// allowMessagePack is set.
+func Negotiate(accept string, defaults Options, allowMessagePack bool) Options {
+	opts := defaults
+	bestQ := 0.0
+	var best map[string]string
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaFormats[mediaType]
+		if !ok || (format == FormatMessagePack && !allowMessagePack) {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			bestQ, best = q, params
+			opts.Format = format
+		}
+	}
+	if best == nil {
+		return opts
+	}
+	if c, err := ParseCase(best["case"]); err == nil && best["case"] != "" {
+		opts.Case = c
+	}
+	switch best["envelope"] {
+	case "data":
+		opts.Envelope = true
+	case "none":
+		opts.Envelope = false
+	}
+	return opts
+}
+
+// Transform rewrites a JSON body according to opts, keeping key order.
+// Bodies of error responses are never enveloped.
+func Transform(body []byte, opts Options, status int) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	v, err := parseValue(dec, opts.Case)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	if opts.Envelope && status < 400 {
+		v = object{{key: "data", value: v}}
+	}
+
+	var out bytes.Buffer
+	switch opts.Format {
+	case FormatMessagePack:
+		err = encodeMessagePack(&out, v)
+	default:
+		err = encodeJSON(&out, v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// object is a JSON object that remembers the order of its keys.
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+// parseValue reads the next JSON value from dec into strings, json.Number,
+// bools, nil, []any and object, renaming object keys as it goes.
+func parseValue(dec *json.Decoder, fieldCase FieldCase) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		obj := object{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			if fieldCase == CaseCamel {
+				key = camelCase(key)
+			}
+			value, err := parseValue(dec, fieldCase)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key, value: value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := []any{}
+		for dec.More() {
+			value, err := parseValue(dec, fieldCase)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+	return nil, fmt.Errorf("unexpected delimiter %q", delim)
+}
+
+func encodeJSON(out *bytes.Buffer, v any) error {
+	switch t := v.(type) {
+	case object:
+		out.WriteByte('{')
+		for i, m := range t {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, m.key); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+			if err := encodeJSON(out, m.value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case []any:
+		out.WriteByte('[')
+		for i, item := range t {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, item); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	default:
+		dat, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		out.Write(dat)
+	}
+	return nil
+}
+
+func camelCase(key string) string {
+	if !strings.Contains(key, "_") {
+		return key
+	}
+	var b strings.Builder
+	upper := false
+	for i, r := range key {
+		if r == '_' && i > 0 {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if upper {
+		b.WriteRune('_')
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		defaults Options
+		msgpack  bool
+		want     Options
+	}{
+		{name: "no accept header", want: Options{}},
+		{name: "camel case", accept: "application/json; case=camel", want: Options{Format: FormatJSON, Case: CaseCamel}},
+		{name: "envelope", accept: "application/json;envelope=data", want: Options{Format: FormatJSON, Envelope: true}},
+		{
+			name:     "client overrides server defaults",
+			accept:   "application/json; case=snake; envelope=none",
+			defaults: Options{Case: CaseCamel, Envelope: true},
+			want:     Options{Format: FormatJSON, Case: CaseSnake},
+		},
+		{
+			name:     "other media types keep defaults",
+			accept:   "text/html; case=camel",
+			defaults: Options{Envelope: true},
+			want:     Options{Envelope: true},
+		},
+		{name: "unknown case ignored", accept: "application/json; case=kebab", want: Options{Format: FormatJSON}},
+		{name: "msgpack", accept: "application/msgpack", msgpack: true, want: Options{Format: FormatMessagePack}},
+		{name: "msgpack not allowed", accept: "application/msgpack", want: Options{}},
+		{
+			name:    "quality values",
+			accept:  "application/msgpack;q=0.5, application/json; case=camel",
+			msgpack: true,
+			want:    Options{Format: FormatJSON, Case: CaseCamel},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Negotiate(tt.accept, tt.defaults, tt.msgpack); got != tt.want {
+				t.Errorf("Negotiate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		opts   Options
+		status int
+		want   string
+	}{
+		{
+			name:   "camel case keeps key order",
+			body:   `{"user_id":"u1","created_at":"2024","body":"hi"}`,
+			opts:   Options{Case: CaseCamel},
+			status: http.StatusOK,
+			want:   `{"userId":"u1","createdAt":"2024","body":"hi"}`,
+		},
+		{
+			name:   "nested objects and arrays",
+			body:   `{"data":[{"is_verified":true,"count":12.5,"parent_id":null}],"next_cursor":"x"}`,
+			opts:   Options{Case: CaseCamel},
+			status: http.StatusOK,
+			want:   `{"data":[{"isVerified":true,"count":12.5,"parentId":null}],"nextCursor":"x"}`,
+		},
+		{
+			name:   "envelope",
+			body:   `{"id":"c1"}`,
+			opts:   Options{Envelope: true},
+			status: http.StatusCreated,
+			want:   `{"data":{"id":"c1"}}`,
+		},
+		{
+			name:   "msgpack",
+			body:   `{"id":"c1","count":300,"score":-1,"ok":true,"tags":[],"parent_id":null}`,
+			opts:   Options{Format: FormatMessagePack},
+			status: http.StatusOK,
+			want: "\x86" +
+				"\xa2id\xa2c1" +
+				"\xa5count\xd1\x01\x2c" +
+				"\xa5score\xff" +
+				"\xa2ok\xc3" +
+				"\xa4tags\x90" +
+				"\xa9parent_id\xc0",
+		},
+		{
+			name:   "errors are not enveloped",
+			body:   `{"error":"nope"}`,
+			opts:   Options{Envelope: true},
+			status: http.StatusBadRequest,
+			want:   `{"error":"nope"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Transform([]byte(tt.body), tt.opts, tt.status)
+			if err != nil {
+				t.Fatalf("Transform() unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Transform() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(Options{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"is_chirpy_red":true}`))
+	}))
+
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	req.Header.Set("Accept", "application/json; case=camel; envelope=data")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got, want := rec.Body.String(), `{"data":{"isChirpyRed":true}}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary = %q, want Accept", got)
+	}
+}
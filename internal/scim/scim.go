@@ -0,0 +1,240 @@
+// Package scim implements the small part of SCIM 2.0 (RFC 7643 and 7644)
+// that Chirpy supports: User resources with an email address and an active
+// flag, simple userName filters and PATCH requests that change them.
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ContentType = "application/scim+json"
+
+	SchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Error types from RFC 7644 section 3.12.
+const (
+	ErrInvalidFilter = "invalidFilter"
+	ErrInvalidSyntax = "invalidSyntax"
+	ErrInvalidPath   = "invalidPath"
+	ErrInvalidValue  = "invalidValue"
+	ErrUniqueness    = "uniqueness"
+	ErrNoTarget      = "noTarget"
+)
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created"`
+	LastModified string `json:"lastModified"`
+	Location     string `json:"location"`
+}
+
+// User is the SCIM view of a Chirpy account. userName is always the
+// account's email address.
+type User struct {
+	Schemas  []string `json:"schemas"`
+	ID       string   `json:"id,omitempty"`
+	UserName string   `json:"userName"`
+	Emails   []Email  `json:"emails,omitempty"`
+	Active   *bool    `json:"active,omitempty"`
+	Password string   `json:"password,omitempty"`
+	Meta     *Meta    `json:"meta,omitempty"`
+}
+
+// PrimaryEmail returns the email a provisioning request asks for: the
+// primary entry in emails, then the first entry, then userName.
+func (u User) PrimaryEmail() string {
+	for _, e := range u.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 && u.Emails[0].Value != "" {
+		return u.Emails[0].Value
+	}
+	return u.UserName
+}
+
+// NewUser builds the resource returned for an account.
+func NewUser(id, email string, active bool, created, modified time.Time, location string) User {
+	return User{
+		Schemas:  []string{SchemaUser},
+		ID:       id,
+		UserName: email,
+		Emails:   []Email{{Value: email, Type: "work", Primary: true}},
+		Active:   &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      created.UTC().Format(time.RFC3339),
+			LastModified: modified.UTC().Format(time.RFC3339),
+			Location:     location,
+		},
+	}
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []User   `json:"Resources"`
+}
+
+func NewListResponse(users []User) ListResponse {
+	if users == nil {
+		users = []User{}
+	}
+	return ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(users),
+		StartIndex:   1,
+		ItemsPerPage: len(users),
+		Resources:    users,
+	}
+}
+
+// Error is a SCIM error response. Status is a string on the wire.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func NewError(status int, scimType, detail string) Error {
+	return Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// Write sends v as a SCIM JSON response.
+func Write(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// ParseUserNameFilter understands the one filter identity providers send
+// before provisioning, `userName eq "value"`. An empty filter matches
+// everything and returns ok false.
+func ParseUserNameFilter(filter string) (value string, ok bool, err error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return "", false, nil
+	}
+	fields := strings.SplitN(filter, " ", 3)
+	if len(fields) != 3 || !strings.EqualFold(fields[0], "userName") || !strings.EqualFold(fields[1], "eq") {
+		return "", false, fmt.Errorf("only userName eq filters are supported")
+	}
+	value, err = strconv.Unquote(strings.TrimSpace(fields[2]))
+	if err != nil {
+		return "", false, fmt.Errorf("filter value must be a quoted string")
+	}
+	return value, true, nil
+}
+
+// Changes are the attributes a PATCH request sets. Nil fields are left
+// alone.
+type Changes struct {
+	Email  *string
+	Active *bool
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ParsePatch turns add and replace operations on active, userName and the
+// email value into Changes. Azure AD sends op names capitalised and active
+// as the string "False", so both are accepted.
+func ParsePatch(req PatchRequest) (Changes, error) {
+	var changes Changes
+	for _, op := range req.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return Changes{}, fmt.Errorf("unsupported operation %q", op.Op)
+		}
+		if op.Path == "" {
+			var attrs struct {
+				UserName *string         `json:"userName"`
+				Emails   []Email         `json:"emails"`
+				Active   json.RawMessage `json:"active"`
+			}
+			if err := json.Unmarshal(op.Value, &attrs); err != nil {
+				return Changes{}, fmt.Errorf("value must be an object of attributes")
+			}
+			if attrs.UserName != nil {
+				changes.Email = attrs.UserName
+			}
+			if len(attrs.Emails) > 0 {
+				email := User{Emails: attrs.Emails}.PrimaryEmail()
+				changes.Email = &email
+			}
+			if attrs.Active != nil {
+				active, err := parseBool(attrs.Active)
+				if err != nil {
+					return Changes{}, err
+				}
+				changes.Active = &active
+			}
+			continue
+		}
+		switch path := strings.ToLower(op.Path); {
+		case path == "active":
+			active, err := parseBool(op.Value)
+			if err != nil {
+				return Changes{}, err
+			}
+			changes.Active = &active
+		case path == "username" || path == "emails.value" || strings.HasPrefix(path, "emails[") && strings.HasSuffix(path, "].value"):
+			var email string
+			if err := json.Unmarshal(op.Value, &email); err != nil {
+				return Changes{}, fmt.Errorf("%s must be a string", op.Path)
+			}
+			changes.Email = &email
+		default:
+			return Changes{}, fmt.Errorf("unsupported path %q", op.Path)
+		}
+	}
+	return changes, nil
+}
+
+func parseBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if parsed, err := strconv.ParseBool(s); err == nil {
+			return parsed, nil
+		}
+	}
+	return false, fmt.Errorf("active must be a boolean")
+}
@@ -0,0 +1,126 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseUserNameFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		want    string
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "empty", filter: "", wantOK: false},
+		{name: "userName eq", filter: `userName eq "ada@corp.example"`, want: "ada@corp.example", wantOK: true},
+		{name: "case insensitive operator", filter: `username EQ "ada@corp.example"`, want: "ada@corp.example", wantOK: true},
+		{name: "unquoted value", filter: `userName eq ada@corp.example`, wantErr: true},
+		{name: "other attribute", filter: `externalId eq "123"`, wantErr: true},
+		{name: "other operator", filter: `userName sw "ada"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := ParseUserNameFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUserNameFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseUserNameFilter() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParsePatch(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name       string
+		body       string
+		wantEmail  string
+		wantActive *bool
+		wantErr    bool
+	}{
+		{
+			name:       "deactivate by path",
+			body:       `{"Operations":[{"op":"replace","path":"active","value":false}]}`,
+			wantActive: &no,
+		},
+		{
+			name:       "azure style",
+			body:       `{"Operations":[{"op":"Replace","path":"active","value":"False"}]}`,
+			wantActive: &no,
+		},
+		{
+			name:      "email by filter path",
+			body:      `{"Operations":[{"op":"replace","path":"emails[type eq \"work\"].value","value":"new@corp.example"}]}`,
+			wantEmail: "new@corp.example",
+		},
+		{
+			name:       "attributes without path",
+			body:       `{"Operations":[{"op":"replace","value":{"userName":"new@corp.example","active":true}}]}`,
+			wantEmail:  "new@corp.example",
+			wantActive: &yes,
+		},
+		{
+			name:    "remove is unsupported",
+			body:    `{"Operations":[{"op":"remove","path":"active"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown path",
+			body:    `{"Operations":[{"op":"replace","path":"name.givenName","value":"Ada"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "active not a boolean",
+			body:    `{"Operations":[{"op":"replace","path":"active","value":"maybe"}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req PatchRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("invalid test body: %v", err)
+			}
+			got, err := ParsePatch(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			gotEmail := ""
+			if got.Email != nil {
+				gotEmail = *got.Email
+			}
+			if gotEmail != tt.wantEmail {
+				t.Errorf("ParsePatch() email = %q, want %q", gotEmail, tt.wantEmail)
+			}
+			if (got.Active == nil) != (tt.wantActive == nil) || got.Active != nil && *got.Active != *tt.wantActive {
+				t.Errorf("ParsePatch() active = %v, want %v", got.Active, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestPrimaryEmail(t *testing.T) {
+	tests := []struct {
+		name string
+		user User
+		want string
+	}{
+		{name: "primary wins", user: User{UserName: "u", Emails: []Email{{Value: "a"}, {Value: "b", Primary: true}}}, want: "b"},
+		{name: "first email", user: User{UserName: "u", Emails: []Email{{Value: "a"}}}, want: "a"},
+		{name: "userName fallback", user: User{UserName: "u"}, want: "u"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.PrimaryEmail(); got != tt.want {
+				t.Errorf("PrimaryEmail() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
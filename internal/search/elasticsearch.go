@@ -0,0 +1,137 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Elasticsearch keeps one index per document kind, named IndexPrefix-kind,
+// in an Elasticsearch or OpenSearch cluster reached over its REST API.
+// Indexes are created with default mappings on first write.
+type Elasticsearch struct {
+	URL         string
+	IndexPrefix string
+	// Username and Password, if set, are sent with basic auth.
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+func (e Elasticsearch) indexURL(kind string) string {
+	return strings.TrimSuffix(e.URL, "/") + "/" + url.PathEscape(e.IndexPrefix+"-"+kind)
+}
+
+func (e Elasticsearch) do(ctx context.Context, method, u string, body any) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		dat, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(dat)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.Username != "" {
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+	return e.Client.Do(req)
+}
+
+func (e Elasticsearch) Put(ctx context.Context, doc Document) error {
+	resp, err := e.do(ctx, http.MethodPut, e.indexURL(doc.Kind)+"/_doc/"+url.PathEscape(doc.ID), doc.Fields)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (e Elasticsearch) Delete(ctx context.Context, kind, id string) error {
+	resp, err := e.do(ctx, http.MethodDelete, e.indexURL(kind)+"/_doc/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// Search runs a multi_match query over every field with AUTO fuzziness and
+// asks for whole fields back as highlights.
+func (e Elasticsearch) Search(ctx context.Context, q Query) ([]Hit, error) {
+	body := map[string]any{
+		"query": map[string]any{
+			"multi_match": map[string]any{
+				"query":     q.Text,
+				"fuzziness": "AUTO",
+				"operator":  "and",
+			},
+		},
+		"highlight": map[string]any{
+			"encoder":   "html",
+			"pre_tags":  []string{"<mark>"},
+			"post_tags": []string{"</mark>"},
+			"fields": map[string]any{
+				"*": map[string]any{"number_of_fragments": 0},
+			},
+		},
+	}
+	if q.Limit > 0 {
+		body["size"] = q.Limit
+	}
+	resp, err := e.do(ctx, http.MethodPost, e.indexURL(q.Kind)+"/_search", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// Nothing has been indexed for this kind yet.
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, responseError(resp)
+	}
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID        string              `json:"_id"`
+				Score     float64             `json:"_score"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, 0, len(result.Hits.Hits))
+	for _, h := range result.Hits.Hits {
+		hit := Hit{ID: h.ID, Score: h.Score, Highlights: map[string]string{}}
+		for field, fragments := range h.Highlight {
+			hit.Highlights[field] = strings.Join(fragments, " … ")
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("elasticsearch responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
@@ -0,0 +1,134 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+)
+
+// Memory is an index held in process, for single instances and
+// development. It starts empty, so it has to be filled again after every
+// restart. Typo tolerance compares query terms with every indexed term, so
+// it suits thousands of documents rather than millions.
+type Memory struct {
+	mu sync.RWMutex
+	// docs and terms are keyed by kind, then by document ID or term.
+	docs  map[string]map[string]Document
+	terms map[string]map[string]map[string]bool
+}
+
+// NewMemory returns an empty index.
+func NewMemory() *Memory {
+	return &Memory{
+		docs:  map[string]map[string]Document{},
+		terms: map[string]map[string]map[string]bool{},
+	}
+}
+
+func (m *Memory) Put(ctx context.Context, doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(doc.Kind, doc.ID)
+	if m.docs[doc.Kind] == nil {
+		m.docs[doc.Kind] = map[string]Document{}
+		m.terms[doc.Kind] = map[string]map[string]bool{}
+	}
+	m.docs[doc.Kind][doc.ID] = doc
+	for _, text := range doc.Fields {
+		for _, t := range tokenize(text) {
+			ids := m.terms[doc.Kind][t.term]
+			if ids == nil {
+				ids = map[string]bool{}
+				m.terms[doc.Kind][t.term] = ids
+			}
+			ids[doc.ID] = true
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, kind, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(kind, id)
+	return nil
+}
+
+func (m *Memory) remove(kind, id string) {
+	doc, ok := m.docs[kind][id]
+	if !ok {
+		return
+	}
+	delete(m.docs[kind], id)
+	for _, text := range doc.Fields {
+		for _, t := range tokenize(text) {
+			delete(m.terms[kind][t.term], id)
+			if len(m.terms[kind][t.term]) == 0 {
+				delete(m.terms[kind], t.term)
+			}
+		}
+	}
+}
+
+// Search scores each document by how closely its terms match the query's:
+// an exact match counts 1, a term one edit away 1/2, two edits 1/3.
+func (m *Memory) Search(ctx context.Context, q Query) ([]Hit, error) {
+	queryTerms := tokenize(q.Text)
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var scores map[string]float64
+	matched := map[string]bool{}
+	for _, qt := range queryTerms {
+		// Each document keeps its best match for this query term.
+		best := map[string]float64{}
+		maxEdits := fuzziness(qt.term)
+		for term, ids := range m.terms[q.Kind] {
+			d := editDistance(qt.term, term, maxEdits)
+			if d > maxEdits {
+				continue
+			}
+			matched[term] = true
+			score := 1 / float64(d+1)
+			for id := range ids {
+				best[id] = max(best[id], score)
+			}
+		}
+		// Every query term has to match.
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id := range scores {
+			if s, ok := best[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	for i := range hits {
+		hits[i].Highlights = map[string]string{}
+		for field, text := range m.docs[q.Kind][hits[i].ID].Fields {
+			if h, ok := highlight(text, matched); ok {
+				hits[i].Highlights[field] = h
+			}
+		}
+	}
+	return hits, nil
+}
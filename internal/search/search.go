@@ -0,0 +1,145 @@
+// Package search keeps a full-text index of chirps and profiles, either in
+// memory or in Elasticsearch.
+package search
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Document kinds.
+const (
+	KindChirp = "chirp"
+	KindUser  = "user"
+)
+
+// Document is one indexed item. Fields maps field names, such as "body" or
+// "handle", to their text.
+type Document struct {
+	Kind   string
+	ID     string
+	Fields map[string]string
+}
+
+// Query looks for documents of Kind matching every word of Text, allowing
+// for typos.
+type Query struct {
+	Kind  string
+	Text  string
+	Limit int
+}
+
+// Hit is a matching document, best first. Highlights holds, for each field
+// that matched, its HTML-escaped text with the matches wrapped in <mark>.
+type Hit struct {
+	ID         string
+	Score      float64
+	Highlights map[string]string
+}
+
+// Index stores documents and searches them. Put replaces any document with
+// the same kind and ID; Delete of a missing document is not an error.
+type Index interface {
+	Put(ctx context.Context, doc Document) error
+	Delete(ctx context.Context, kind, id string) error
+	Search(ctx context.Context, q Query) ([]Hit, error)
+}
+
+// token is a word in a field and where it sits in the original text.
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lowercase words of letters and digits.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// fuzziness is how many edits a query term may be from a match, growing
+// with its length as Elasticsearch's AUTO setting does.
+func fuzziness(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the number of insertions, deletions, substitutions and
+// swaps of adjacent letters that turn a into b, or limit+1 once it is known
+// to exceed limit. Swaps count once, as in Elasticsearch's fuzzy queries.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+	// prev2, prev and cur are the last three rows of the distance table.
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	prevBest := 0
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			best = min(best, cur[j])
+		}
+		// Later rows build on this one and the one before it.
+		if best > limit && prevBest > limit {
+			return limit + 1
+		}
+		prevBest = best
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(rb)], limit+1)
+}
+
+// highlight escapes text for HTML and wraps the words in matched with
+// <mark>. It returns false if none of them are in text.
+func highlight(text string, matched map[string]bool) (string, bool) {
+	var b strings.Builder
+	last, found := 0, false
+	for _, t := range tokenize(text) {
+		if !matched[t.term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:t.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[t.start:t.end]))
+		b.WriteString("</mark>")
+		last, found = t.end, true
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String(), found
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{a: "chirp", b: "chirp", limit: 2, want: 0},
+		{a: "chrip", b: "chirp", limit: 2, want: 1},
+		{a: "ab", b: "ba", limit: 0, want: 1},
+		{a: "chirps", b: "chirp", limit: 2, want: 1},
+		{a: "héllo", b: "hello", limit: 1, want: 1},
+		{a: "kitten", b: "sitting", limit: 2, want: 3},
+		{a: "a", b: "abcdef", limit: 2, want: 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got, ok := highlight("Hello <b>World</b>, hello!", map[string]bool{"hello": true})
+	want := "<mark>Hello</mark> &lt;b&gt;World&lt;/b&gt;, <mark>hello</mark>!"
+	if !ok || got != want {
+		t.Errorf("highlight() = %q, %v, want %q", got, ok, want)
+	}
+	if _, ok := highlight("nothing here", map[string]bool{"hello": true}); ok {
+		t.Error("highlight() without a match reported one")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	m.Put(ctx, Document{Kind: KindChirp, ID: "1", Fields: map[string]string{"body": "Kafka on the shore"}})
+	m.Put(ctx, Document{Kind: KindChirp, ID: "2", Fields: map[string]string{"body": "The trial by Kafka"}})
+	m.Put(ctx, Document{Kind: KindChirp, ID: "3", Fields: map[string]string{"body": "Seaside walk"}})
+	m.Put(ctx, Document{Kind: KindUser, ID: "1", Fields: map[string]string{"handle": "kafka"}})
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{name: "exact", q: Query{Kind: KindChirp, Text: "kafka"}, want: []string{"1", "2"}},
+		{name: "typo", q: Query{Kind: KindChirp, Text: "kafak"}, want: []string{"1", "2"}},
+		{name: "every word has to match", q: Query{Kind: KindChirp, Text: "kafka trial"}, want: []string{"2"}},
+		{name: "single match", q: Query{Kind: KindChirp, Text: "shore"}, want: []string{"1"}},
+		{name: "short words need exact matches", q: Query{Kind: KindChirp, Text: "by"}, want: []string{"2"}},
+		{name: "limit", q: Query{Kind: KindChirp, Text: "kafka", Limit: 1}, want: []string{"1"}},
+		{name: "other kind", q: Query{Kind: KindUser, Text: "kafka"}, want: []string{"1"}},
+		{name: "no words", q: Query{Kind: KindChirp, Text: "!!"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := m.Search(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("Search() failed: %v", err)
+			}
+			var got []string
+			for _, h := range hits {
+				got = append(got, h.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	hits, _ := m.Search(ctx, Query{Kind: KindChirp, Text: "trail"})
+	if len(hits) != 1 || hits[0].Highlights["body"] != "The <mark>trial</mark> by Kafka" {
+		t.Errorf("Search() highlights = %+v", hits)
+	}
+
+	m.Put(ctx, Document{Kind: KindChirp, ID: "1", Fields: map[string]string{"body": "Edited"}})
+	m.Delete(ctx, KindChirp, "2")
+	if hits, _ := m.Search(ctx, Query{Kind: KindChirp, Text: "kafka"}); len(hits) != 0 {
+		t.Errorf("Search() after edit and delete = %+v, want none", hits)
+	}
+	if len(m.terms[KindChirp]["kafka"]) != 0 {
+		t.Error("Delete() left terms behind")
+	}
+}
+
+func TestElasticsearch(t *testing.T) {
+	var gotSearch map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "elastic" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "PUT /chirpy-chirp/_doc/1":
+			w.WriteHeader(http.StatusCreated)
+		case "DELETE /chirpy-chirp/_doc/2":
+			w.WriteHeader(http.StatusNotFound)
+		case "POST /chirpy-chirp/_search":
+			json.NewDecoder(r.Body).Decode(&gotSearch)
+			w.Write([]byte(`{"hits":{"hits":[{"_id":"1","_score":2.5,"highlight":{"body":["<mark>Kafka</mark> on the shore"]}}]}}`))
+		case "POST /chirpy-user/_search":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	e := Elasticsearch{URL: srv.URL, IndexPrefix: "chirpy", Username: "elastic", Password: "secret", Client: srv.Client()}
+	if err := e.Put(ctx, Document{Kind: KindChirp, ID: "1", Fields: map[string]string{"body": "Kafka on the shore"}}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	if err := e.Delete(ctx, KindChirp, "2"); err != nil {
+		t.Fatalf("Delete() of a missing document failed: %v", err)
+	}
+	hits, err := e.Search(ctx, Query{Kind: KindChirp, Text: "kafak", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	want := []Hit{{ID: "1", Score: 2.5, Highlights: map[string]string{"body": "<mark>Kafka</mark> on the shore"}}}
+	if !reflect.DeepEqual(hits, want) {
+		t.Errorf("Search() = %+v, want %+v", hits, want)
+	}
+	match := gotSearch["query"].(map[string]any)["multi_match"].(map[string]any)
+	if match["query"] != "kafak" || match["fuzziness"] != "AUTO" || gotSearch["size"] != float64(10) {
+		t.Errorf("search request = %v", gotSearch)
+	}
+	if hits, err := e.Search(ctx, Query{Kind: KindUser, Text: "kafka"}); err != nil || len(hits) != 0 {
+		t.Errorf("Search() of a missing index = %v, %v, want no hits", hits, err)
+	}
+	e.Password = "wrong"
+	if err := e.Put(ctx, Document{Kind: KindChirp, ID: "1"}); err == nil {
+		t.Error("Put() with bad credentials succeeded")
+	}
+}
@@ -0,0 +1,273 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/slo"
+)
+
+const (
+	BrandingAccentColor         = "branding.accent_color"
+	BrandingLogoURL             = "branding.logo_url"
+	BrandingSiteName            = "branding.site_name"
+	BrandingSupportEmail        = "branding.support_email"
+	CanaryAlertEmail            = "canary.alert_email"
+	CanaryWebhookURL            = "canary.webhook_url"
+	ChirpDuplicateAction        = "chirp.duplicate_action"
+	ChirpDuplicateWindowSeconds = "chirp.duplicate_window_seconds"
+	ChirpFreeDailyQuota         = "chirp.free_daily_quota"
+	ChirpMaxLength              = "chirp.max_length"
+	ChirpMaxLifetimeHours       = "chirp.max_lifetime_hours"
+	ChirpMaxPerMinute           = "chirp.max_per_minute"
+	ChirpRedMaxLifetimeHours    = "chirp.red_max_lifetime_hours"
+	DisplayNameMaxLength        = "profile.display_name_max_length"
+	ProbationHours              = "moderation.probation_hours"
+	RetentionChirpDays          = "retention.chirp_days"
+	RetentionTokenDays          = "retention.token_days"
+	SLOLatencyBudgets           = "slo.latency_budgets"
+	SLOWindowSeconds            = "slo.window_seconds"
+	SLOWebhookURL               = "slo.webhook_url"
+	SignupMode                  = "signup.mode"
+)
+
+// Values of ChirpDuplicateAction.
+const (
+	DuplicateReject = "reject"
+	DuplicateFlag   = "flag"
+)
+
+// Values of SignupMode.
+const (
+	SignupOpen     = "open"
+	SignupWaitlist = "waitlist"
+)
+
+// Definition describes a setting that can be changed at runtime.
+type Definition struct {
+	Default  string
+	Validate func(value string) error
+}
+
+var definitions = map[string]Definition{
+	BrandingAccentColor:         {Default: "#1d9bf0", Validate: hexColor},
+	BrandingLogoURL:             {Default: "", Validate: optionalHTTPURL},
+	BrandingSiteName:            {Default: "Chirpy", Validate: shortText(60)},
+	BrandingSupportEmail:        {Default: "", Validate: optionalEmail},
+	CanaryAlertEmail:            {Default: "", Validate: optionalEmail},
+	CanaryWebhookURL:            {Default: "", Validate: optionalHTTPURL},
+	ChirpDuplicateAction:        {Default: DuplicateReject, Validate: oneOf(DuplicateReject, DuplicateFlag)},
+	ChirpDuplicateWindowSeconds: {Default: "60", Validate: nonNegativeInt},
+	ChirpFreeDailyQuota:         {Default: "100", Validate: nonNegativeInt},
+	ChirpMaxLength:              {Default: "140", Validate: positiveInt},
+	ChirpMaxLifetimeHours:       {Default: "168", Validate: positiveInt},
+	ChirpMaxPerMinute:           {Default: "10", Validate: nonNegativeInt},
+	ChirpRedMaxLifetimeHours:    {Default: "720", Validate: positiveInt},
+	DisplayNameMaxLength:        {Default: "50", Validate: positiveInt},
+	ProbationHours:              {Default: "0", Validate: nonNegativeInt},
+	RetentionChirpDays:          {Default: "0", Validate: nonNegativeInt},
+	RetentionTokenDays:          {Default: "30", Validate: nonNegativeInt},
+	SLOLatencyBudgets:           {Default: "{}", Validate: validLatencyBudgets},
+	SLOWindowSeconds:            {Default: "300", Validate: positiveInt},
+	SLOWebhookURL:               {Default: "", Validate: optionalHTTPURL},
+	SignupMode:                  {Default: SignupOpen, Validate: oneOf(SignupOpen, SignupWaitlist)},
+}
+
+func positiveInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	if n <= 0 {
+		return fmt.Errorf("must be greater than zero")
+	}
+	return nil
+}
+
+func nonNegativeInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	if n < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+func shortText(maxLen int) func(string) error {
+	return func(value string) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("must not be empty")
+		}
+		if utf8.RuneCountInString(value) > maxLen {
+			return fmt.Errorf("must be at most %d characters", maxLen)
+		}
+		return nil
+	}
+}
+
+// hexColor accepts a CSS color in #rrggbb form.
+func hexColor(value string) error {
+	hex, ok := strings.CutPrefix(value, "#")
+	if !ok || len(hex) != 6 {
+		return fmt.Errorf("must be a color like #1d9bf0")
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return fmt.Errorf("must be a color like #1d9bf0")
+	}
+	return nil
+}
+
+func validLatencyBudgets(value string) error {
+	_, err := slo.ParseBudgets(value)
+	return err
+}
+
+func optionalHTTPURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL")
+	}
+	return nil
+}
+
+func optionalEmail(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := mail.ParseAddress(value); err != nil {
+		return fmt.Errorf("must be an email address")
+	}
+	return nil
+}
+
+// Keys returns the names of all known settings in sorted order.
+func Keys() []string {
+	keys := make([]string, 0, len(definitions))
+	for key := range definitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Lookup returns the definition of a known setting.
+func Lookup(key string) (Definition, bool) {
+	def, ok := definitions[key]
+	return def, ok
+}
+
+// Validate reports whether value is acceptable for the given setting.
+func Validate(key, value string) error {
+	def, ok := definitions[key]
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if def.Validate == nil {
+		return nil
+	}
+	if err := def.Validate(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+type Loader interface {
+	ListSettings(ctx context.Context) ([]database.Setting, error)
+}
+
+// Store caches the settings table in memory and reloads it once the cached
+// copy is older than the configured TTL, so changes made on any instance
+// are picked up without a restart.
+type Store struct {
+	loader   Loader
+	ttl      time.Duration
+	mu       sync.Mutex
+	values   map[string]string
+	loadedAt time.Time
+}
+
+func NewStore(loader Loader, ttl time.Duration) *Store {
+	return &Store{
+		loader: loader,
+		ttl:    ttl,
+		values: map[string]string{},
+	}
+}
+
+// Invalidate forces the next read to reload settings from the database.
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Time{}
+}
+
+func (s *Store) snapshot(ctx context.Context) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) > s.ttl {
+		rows, err := s.loader.ListSettings(ctx)
+		if err != nil {
+			log.Printf("Error reloading settings, keeping cached values: %s", err)
+		} else {
+			values := make(map[string]string, len(rows))
+			for _, row := range rows {
+				values[row.Key] = row.Value
+			}
+			s.values = values
+		}
+		s.loadedAt = time.Now()
+	}
+	return s.values
+}
+
+// Get returns the current value of a setting, falling back to its default
+// when it is not overridden or the stored value is no longer valid.
+func (s *Store) Get(ctx context.Context, key string) string {
+	value, ok := s.snapshot(ctx)[key]
+	if !ok || Validate(key, value) != nil {
+		return definitions[key].Default
+	}
+	return value
+}
+
+// Int returns the current value of an integer setting.
+func (s *Store) Int(ctx context.Context, key string) int {
+	n, err := strconv.Atoi(s.Get(ctx, key))
+	if err != nil {
+		n, _ = strconv.Atoi(definitions[key].Default)
+	}
+	return n
+}
+
+// Overridden reports whether a setting has a value stored in the database.
+func (s *Store) Overridden(ctx context.Context, key string) bool {
+	_, ok := s.snapshot(ctx)[key]
+	return ok
+}
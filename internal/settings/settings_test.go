@@ -0,0 +1,127 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+type fakeLoader struct {
+	rows  []database.Setting
+	err   error
+	calls int
+}
+
+func (f *fakeLoader) ListSettings(ctx context.Context) ([]database.Setting, error) {
+	f.calls++
+	return f.rows, f.err
+}
+
+func TestStoreGet(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []database.Setting
+		key  string
+		want int
+	}{
+		{
+			name: "default when not overridden",
+			key:  ChirpMaxLength,
+			want: 140,
+		},
+		{
+			name: "override from database",
+			rows: []database.Setting{{Key: ChirpMaxLength, Value: "280"}},
+			key:  ChirpMaxLength,
+			want: 280,
+		},
+		{
+			name: "invalid stored value falls back to default",
+			rows: []database.Setting{{Key: ChirpMaxLength, Value: "-5"}},
+			key:  ChirpMaxLength,
+			want: 140,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(&fakeLoader{rows: tt.rows}, time.Minute)
+			if got := store.Int(context.Background(), tt.key); got != tt.want {
+				t.Errorf("Store.Int(%q) = %d, want %d", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreCachesUntilInvalidated(t *testing.T) {
+	loader := &fakeLoader{rows: []database.Setting{{Key: ChirpMaxLength, Value: "200"}}}
+	store := NewStore(loader, time.Hour)
+	ctx := context.Background()
+
+	store.Int(ctx, ChirpMaxLength)
+	loader.rows = []database.Setting{{Key: ChirpMaxLength, Value: "300"}}
+	if got := store.Int(ctx, ChirpMaxLength); got != 200 {
+		t.Errorf("Store.Int() = %d, want cached value 200", got)
+	}
+	if loader.calls != 1 {
+		t.Errorf("ListSettings called %d times, want 1", loader.calls)
+	}
+
+	store.Invalidate()
+	if got := store.Int(ctx, ChirpMaxLength); got != 300 {
+		t.Errorf("Store.Int() after Invalidate = %d, want 300", got)
+	}
+}
+
+func TestStoreKeepsValuesOnReloadError(t *testing.T) {
+	loader := &fakeLoader{rows: []database.Setting{{Key: ChirpMaxLength, Value: "200"}}}
+	store := NewStore(loader, 0)
+	ctx := context.Background()
+
+	store.Int(ctx, ChirpMaxLength)
+	loader.err = errors.New("connection refused")
+	if got := store.Int(ctx, ChirpMaxLength); got != 200 {
+		t.Errorf("Store.Int() = %d, want stale value 200", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		value     string
+		wantError bool
+	}{
+		{name: "valid integer", key: ChirpMaxLength, value: "500", wantError: false},
+		{name: "zero", key: ChirpMaxLength, value: "0", wantError: true},
+		{name: "not a number", key: ChirpMaxLength, value: "lots", wantError: true},
+		{name: "unknown key", key: "nope", value: "1", wantError: true},
+		{name: "latency budgets", key: SLOLatencyBudgets, value: `{"GET /api/chirps": 250}`, wantError: false},
+		{name: "zero latency budget", key: SLOLatencyBudgets, value: `{"GET /api/chirps": 0}`, wantError: true},
+		{name: "empty webhook url", key: SLOWebhookURL, value: "", wantError: false},
+		{name: "webhook url without scheme", key: SLOWebhookURL, value: "hooks.example.com", wantError: true},
+		{name: "canary alert email", key: CanaryAlertEmail, value: "security@example.com", wantError: false},
+		{name: "canary alert email without domain", key: CanaryAlertEmail, value: "security", wantError: true},
+		{name: "accent color", key: BrandingAccentColor, value: "#FF6600", wantError: false},
+		{name: "accent color without hash", key: BrandingAccentColor, value: "ff6600", wantError: true},
+		{name: "accent color name", key: BrandingAccentColor, value: "#orange", wantError: true},
+		{name: "blank site name", key: BrandingSiteName, value: "  ", wantError: true},
+		{name: "long site name", key: BrandingSiteName, value: strings.Repeat("a", 61), wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.key, tt.value)
+			if tt.wantError && err == nil {
+				t.Errorf("Validate() expected error, got nil")
+			}
+			if !tt.wantError && err != nil {
+				t.Errorf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
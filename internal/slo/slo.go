@@ -0,0 +1,130 @@
+package slo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSamples bounds the memory used per route within one window. Once a
+// route has this many samples, new ones replace old ones at random so the
+// kept set stays a uniform sample of the window.
+const maxSamples = 10000
+
+// MinSamples is the number of requests a route needs within a window before
+// its p95 is compared against the budget, so a single slow request on a
+// quiet route doesn't raise an alert.
+const MinSamples = 20
+
+// Budgets maps a route pattern such as "GET /api/chirps" to its p95 latency
+// budget.
+type Budgets map[string]time.Duration
+
+// ParseBudgets reads budgets from their JSON representation, a map of route
+// pattern to milliseconds, for example {"GET /api/chirps": 250}.
+func ParseBudgets(raw string) (Budgets, error) {
+	var ms map[string]int
+	if err := json.Unmarshal([]byte(raw), &ms); err != nil {
+		return nil, fmt.Errorf("couldn't parse latency budgets: %w", err)
+	}
+	budgets := make(Budgets, len(ms))
+	for route, n := range ms {
+		if n <= 0 {
+			return nil, fmt.Errorf("budget for %q must be greater than zero", route)
+		}
+		budgets[route] = time.Duration(n) * time.Millisecond
+	}
+	return budgets, nil
+}
+
+// Alert reports a route whose p95 latency exceeded its budget over a window.
+type Alert struct {
+	Route   string
+	P95     time.Duration
+	Budget  time.Duration
+	Samples int
+}
+
+type window struct {
+	samples []time.Duration
+	seen    int
+}
+
+// Monitor collects request latencies per route for the current window.
+type Monitor struct {
+	mu     sync.Mutex
+	routes map[string]*window
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{routes: map[string]*window{}}
+}
+
+// Observe records the latency of a single request.
+func (m *Monitor) Observe(route string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.routes[route]
+	if !ok {
+		w = &window{}
+		m.routes[route] = w
+	}
+	w.seen++
+	if len(w.samples) < maxSamples {
+		w.samples = append(w.samples, d)
+		return
+	}
+	if i := rand.IntN(w.seen); i < maxSamples {
+		w.samples[i] = d
+	}
+}
+
+// Flush closes the current window and returns an alert for every budgeted
+// route whose p95 latency exceeded its budget. Alerts are ordered by route.
+func (m *Monitor) Flush(budgets Budgets) []Alert {
+	m.mu.Lock()
+	routes := m.routes
+	m.routes = map[string]*window{}
+	m.mu.Unlock()
+
+	var alerts []Alert
+	for route, w := range routes {
+		budget, ok := budgets[route]
+		if !ok || len(w.samples) < MinSamples {
+			continue
+		}
+		p95 := Percentile(w.samples, 95)
+		if p95 <= budget {
+			continue
+		}
+		alerts = append(alerts, Alert{
+			Route:   route,
+			P95:     p95,
+			Budget:  budget,
+			Samples: w.seen,
+		})
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Route < alerts[j].Route
+	})
+	return alerts
+}
+
+// Percentile returns the p-th percentile of samples using the nearest-rank
+// method. samples is sorted in place.
+func Percentile(samples []time.Duration, p int) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	rank := (p*len(samples) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return samples[rank-1]
+}
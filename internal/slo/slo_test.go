@@ -0,0 +1,102 @@
+package slo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		p       int
+		want    time.Duration
+	}{
+		{name: "empty", samples: nil, p: 95, want: 0},
+		{name: "single sample", samples: []time.Duration{7}, p: 95, want: 7},
+		{name: "unsorted input", samples: []time.Duration{5, 1, 4, 2, 3}, p: 50, want: 3},
+		{name: "p95 of twenty", samples: durations(20), p: 95, want: 19},
+		{name: "p95 of hundred", samples: durations(100), p: 95, want: 95},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Percentile(tt.samples, tt.p); got != tt.want {
+				t.Errorf("Percentile() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func durations(n int) []time.Duration {
+	d := make([]time.Duration, n)
+	for i := range d {
+		d[i] = time.Duration(i + 1)
+	}
+	return d
+}
+
+func TestMonitorFlush(t *testing.T) {
+	m := NewMonitor()
+	for i := 0; i < MinSamples; i++ {
+		m.Observe("GET /api/chirps", 400*time.Millisecond)
+		m.Observe("POST /api/login", 50*time.Millisecond)
+		m.Observe("GET /api/healthz", time.Second)
+	}
+	m.Observe("GET /api/users", time.Second)
+
+	budgets := Budgets{
+		"GET /api/chirps": 250 * time.Millisecond,
+		"POST /api/login": 100 * time.Millisecond,
+		"GET /api/users":  100 * time.Millisecond,
+	}
+	alerts := m.Flush(budgets)
+	if len(alerts) != 1 {
+		t.Fatalf("Flush() returned %d alerts, want 1: %+v", len(alerts), alerts)
+	}
+	want := Alert{Route: "GET /api/chirps", P95: 400 * time.Millisecond, Budget: 250 * time.Millisecond, Samples: MinSamples}
+	if alerts[0] != want {
+		t.Errorf("Flush() alert = %+v, want %+v", alerts[0], want)
+	}
+
+	if alerts := m.Flush(budgets); len(alerts) != 0 {
+		t.Errorf("Flush() after flush returned %+v, want no alerts", alerts)
+	}
+}
+
+func TestParseBudgets(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		want      Budgets
+		wantError bool
+	}{
+		{name: "empty", raw: "{}", want: Budgets{}},
+		{name: "milliseconds", raw: `{"GET /api/chirps": 250}`, want: Budgets{"GET /api/chirps": 250 * time.Millisecond}},
+		{name: "negative", raw: `{"GET /api/chirps": -1}`, wantError: true},
+		{name: "not json", raw: "fast", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBudgets(tt.raw)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("ParseBudgets() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBudgets() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseBudgets() = %v, want %v", got, tt.want)
+			}
+			for route, budget := range tt.want {
+				if got[route] != budget {
+					t.Errorf("ParseBudgets()[%q] = %s, want %s", route, got[route], budget)
+				}
+			}
+		})
+	}
+}
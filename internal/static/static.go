@@ -0,0 +1,90 @@
+package static
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const indexFile = "index.html"
+
+// Handler serves a frontend from a directory. Unknown paths without a file
+// extension fall back to index.html so client-side routes work, directory
+// listings are never produced, and dotfiles are hidden.
+type Handler struct {
+	root   *os.Root
+	maxAge time.Duration
+}
+
+func New(dir string, maxAge time.Duration) (*Handler, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open static directory %q: %w", dir, err)
+	}
+	return &Handler{root: root, maxAge: maxAge}, nil
+}
+
+func hidden(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) resolve(urlPath string) (string, fs.FileInfo, bool) {
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+	if name == "." || !hidden(name) {
+		target := name
+		info, err := h.root.Stat(target)
+		if err == nil && info.IsDir() {
+			target = path.Join(target, indexFile)
+			info, err = h.root.Stat(target)
+		}
+		if err == nil {
+			return target, info, true
+		}
+	}
+	if path.Ext(name) != "" {
+		return "", nil, false
+	}
+	info, err := h.root.Stat(indexFile)
+	if err != nil {
+		return "", nil, false
+	}
+	return indexFile, info, true
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name, info, ok := h.resolve(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.root.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	if path.Base(name) == indexFile {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
@@ -0,0 +1,79 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":         "<h1>app</h1>",
+		"assets/logo.png":    "png",
+		"docs/index.html":    "<h1>docs</h1>",
+		".env":               "SECRET=1",
+		"assets/.hidden.txt": "hidden",
+	}
+	for name, content := range files {
+		full := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestHandler(t *testing.T) {
+	h, err := New(setupDir(t), time.Hour)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		wantStatus   int
+		wantBody     string
+		wantCacheHdr string
+	}{
+		{name: "root serves index", method: "GET", path: "/", wantStatus: http.StatusOK, wantBody: "<h1>app</h1>", wantCacheHdr: "no-cache"},
+		{name: "asset", method: "GET", path: "/assets/logo.png", wantStatus: http.StatusOK, wantBody: "png", wantCacheHdr: "public, max-age=3600"},
+		{name: "directory index", method: "GET", path: "/docs/", wantStatus: http.StatusOK, wantBody: "<h1>docs</h1>", wantCacheHdr: "no-cache"},
+		{name: "spa route falls back to index", method: "GET", path: "/settings/profile", wantStatus: http.StatusOK, wantBody: "<h1>app</h1>", wantCacheHdr: "no-cache"},
+		{name: "directory without index falls back", method: "GET", path: "/assets", wantStatus: http.StatusOK, wantBody: "<h1>app</h1>", wantCacheHdr: "no-cache"},
+		{name: "missing asset is 404", method: "GET", path: "/assets/missing.js", wantStatus: http.StatusNotFound},
+		{name: "dotfile is hidden", method: "GET", path: "/.env", wantStatus: http.StatusNotFound},
+		{name: "nested dotfile is hidden", method: "GET", path: "/assets/.hidden.txt", wantStatus: http.StatusNotFound},
+		{name: "path traversal stays inside", method: "GET", path: "/../../etc/passwd", wantStatus: http.StatusOK, wantBody: "<h1>app</h1>", wantCacheHdr: "no-cache"},
+		{name: "post not allowed", method: "POST", path: "/", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.URL.Path = tt.path
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantCacheHdr != "" && w.Header().Get("Cache-Control") != tt.wantCacheHdr {
+				t.Errorf("Cache-Control = %q, want %q", w.Header().Get("Cache-Control"), tt.wantCacheHdr)
+			}
+		})
+	}
+}
@@ -2,13 +2,21 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/redact"
 )
 
+// respondWithError writes the JSON error envelope. Some messages are built
+// from error text, so both the message and the logged error are passed
+// through redact.Text in case they quote an email address or a token.
 func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
+	msg = redact.Text(msg)
 	if err != nil {
-		log.Println(err)
+		log.Println(redact.Text(err.Error()))
 	}
 	if code > 499 {
 		log.Printf("Responding with 5XX error: %s", msg)
@@ -21,6 +29,29 @@ func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
 	})
 }
 
+// respondWithDBError maps the database package's domain errors to a status
+// code. Anything else is a 500 with msg.
+func respondWithDBError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, database.ErrUserNotFound):
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+	case errors.Is(err, database.ErrChirpNotFound):
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+	case errors.Is(err, database.ErrDuplicateEmail):
+		respondWithError(w, http.StatusConflict, "Email is already in use", err)
+	case errors.Is(err, database.ErrDuplicateHandle):
+		respondWithError(w, http.StatusConflict, "Handle is already taken", err)
+	case errors.Is(err, database.ErrTokenNotFound):
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+	case errors.Is(err, database.ErrTokenExpired):
+		respondWithError(w, http.StatusUnauthorized, "Token has expired", err)
+	case errors.Is(err, database.ErrTokenRevoked):
+		respondWithError(w, http.StatusUnauthorized, "Token has been revoked", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, msg, err)
+	}
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	dat, err := json.Marshal(payload)
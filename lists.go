@@ -0,0 +1,254 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// Lists are private: only their owner can see them, their members or their
+// timeline. Members don't have to agree to be listed and aren't told.
+const (
+	maxListNameLength = 60
+	maxListsPerUser   = 50
+	maxListMembers    = 500
+)
+
+type listResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	MemberCount int64     `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ownedList loads the list in the listID path value if userID owns it,
+// answering 400 or 404 itself otherwise. Someone else's list is reported
+// as missing so list IDs can't be probed.
+func (cfg *apiConfig) ownedList(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.List, bool) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return database.List{}, false
+	}
+	list, err := cfg.database.GetList(r.Context(), listID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && list.OwnerID != userID) {
+		respondWithError(w, http.StatusNotFound, "List not found", nil)
+		return database.List{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get list", err)
+		return database.List{}, false
+	}
+	return list, true
+}
+
+func (cfg *apiConfig) handlerListLists(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	rows, err := cfg.database.ListListsByOwner(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get lists", err)
+		return
+	}
+	var entries []listResponse
+	for _, row := range rows {
+		entries = append(entries, listResponse{
+			ID:          row.ID,
+			Name:        row.Name,
+			MemberCount: row.MemberCount,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, http.StatusBadRequest, "Name is required", nil)
+		return
+	}
+	if utf8.RuneCountInString(name) > maxListNameLength {
+		respondWithError(w, http.StatusBadRequest, "Name is too long", nil)
+		return
+	}
+	existing, err := cfg.database.ListListsByOwner(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create list", err)
+		return
+	}
+	if len(existing) >= maxListsPerUser {
+		respondWithError(w, http.StatusBadRequest, "You can't have more than 50 lists", nil)
+		return
+	}
+	list, err := cfg.database.CreateList(r.Context(), database.CreateListParams{
+		OwnerID: userID,
+		Name:    name,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create list", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, listResponse{
+		ID:        list.ID,
+		Name:      list.Name,
+		CreatedAt: list.CreatedAt,
+		UpdatedAt: list.UpdatedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+	deleted, err := cfg.database.DeleteList(r.Context(), database.DeleteListParams{
+		ID:      listID,
+		OwnerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete list", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "List not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerListListMembers(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		chirpAuthor
+		AddedAt time.Time `json:"added_at"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+	rows, err := cfg.database.ListListMembers(r.Context(), list.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get list members", err)
+		return
+	}
+	var entries []entry
+	for _, row := range rows {
+		entries = append(entries, entry{
+			chirpAuthor: newChirpAuthor(row.ID, row.Handle, row.DisplayName, row.AvatarUrl, row.IsVerified),
+			AddedAt:     row.AddedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+// handlerAddListMember is idempotent, so adding someone twice is not an
+// error.
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	member, err := cfg.database.GetUserByID(r.Context(), memberID)
+	if err != nil || member.IsDeleted {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	count, err := cfg.database.CountListMembers(r.Context(), list.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't add list member", err)
+		return
+	}
+	if count >= maxListMembers {
+		respondWithError(w, http.StatusBadRequest, "A list can't have more than 500 members", nil)
+		return
+	}
+	err = cfg.database.AddListMember(r.Context(), database.AddListMemberParams{
+		ListID: list.ID,
+		UserID: member.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't add list member", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	err = cfg.database.RemoveListMember(r.Context(), database.RemoveListMemberParams{
+		ListID: list.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove list member", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListChirps is the timeline of a list's members, newest first.
+func (cfg *apiConfig) handlerListChirps(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	list, ok := cfg.ownedList(w, r, userID)
+	if !ok {
+		return
+	}
+	page, ok := parseTimelinePage(w, r)
+	if !ok {
+		return
+	}
+	rows, err := cfg.database.GetMessagesByList(r.Context(), database.GetMessagesByListParams{
+		ListID:          list.ID,
+		BeforeCreatedAt: page.beforeCreatedAt,
+		BeforeID:        page.beforeID,
+		RowLimit:        page.limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get messages", err)
+		return
+	}
+	messages := make([]database.GetMessagesWithAuthorRow, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, database.GetMessagesWithAuthorRow(row))
+	}
+	cfg.respondWithTimeline(w, r, messages, page.limit)
+}
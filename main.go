@@ -1,13 +1,32 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
+	"errors"
+	"html/template"
 	"log"
 	"net/http"
 	"os"
-	"text/template"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/breaker"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/coalesce"
 	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/mail"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/metrics"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/redact"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/render"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/slo"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/static"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -17,19 +36,48 @@ func NewApiConfig(db *sql.DB, secret, apikey string) *apiConfig {
 	if err != nil {
 		log.Fatal("Error loading admin template:", err)
 	}
-	dbQueries := database.New(db)
+	loginTmpl, err := template.ParseFiles("./admin/login.html")
+	if err != nil {
+		log.Fatal("Error loading admin login template:", err)
+	}
+	chirpTmpl, err := template.ParseFiles("./templates/chirp.html")
+	if err != nil {
+		log.Fatal("Error loading chirp template:", err)
+	}
+	consentTmpl, err := template.ParseFiles("./templates/oauth_consent.html")
+	if err != nil {
+		log.Fatal("Error loading OAuth consent template:", err)
+	}
+	failover := newDBFailover(db)
+	dbQueries := database.NewStore(countingDB{failover})
 	return &apiConfig{
-		adminTemplate: tmpl,
-		database:      dbQueries,
-		tokenSecret:   secret,
-		apiKey:        apikey,
+		adminTemplate:   tmpl,
+		loginTemplate:   loginTmpl,
+		chirpTemplate:   chirpTmpl,
+		consentTemplate: consentTmpl,
+		db:              db,
+		dbFailover:      failover,
+		database:        dbQueries,
+		settings:        settings.NewStore(dbQueries, 30*time.Second),
+		profanityRules:  profanity.NewStore(dbQueries, 30*time.Second),
+		tokenSecret:     secret,
+		apiKey:          apikey,
+		requestStats:    metrics.NewRegistry(),
+		sloMonitor:      slo.NewMonitor(),
+		breakers:        breaker.NewRegistry(breaker.DefaultSettings),
 	}
 }
 
 func main() {
-	mux := http.NewServeMux()
-
+	// Errors from the driver and from upstream services can quote emails
+	// and tokens, so every log line is redacted on the way out.
+	log.SetOutput(redact.Writer(os.Stderr))
 	godotenv.Load(".env")
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
+	mux := http.NewServeMux()
 	dbURL := os.Getenv("DB_URL")
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
@@ -39,26 +87,154 @@ func main() {
 		log.Fatal("Error pinging the database:", err)
 	}
 	apiCfg := NewApiConfig(db, os.Getenv("SIG_SECRET"), os.Getenv("POLKA_KEY"))
+	apiCfg.baseURL = strings.TrimSuffix(os.Getenv("BASE_URL"), "/")
+	apiCfg.polkaWebhookSecret = os.Getenv("POLKA_WEBHOOK_SECRET")
+	if apiCfg.polkaWebhookSecret == "" {
+		log.Print("POLKA_WEBHOOK_SECRET is not set, so Polka webhooks will be rejected")
+	}
+	if apiCfg.baseURL == "" {
+		log.Print("BASE_URL is not set, so links in chirps won't be shortened")
+	}
+	apiCfg.databaseURL = dbURL
+	apiCfg.backupDir = os.Getenv("BACKUP_DIR")
+	apiCfg.accessTokenCookie = os.Getenv("ACCESS_TOKEN_COOKIE") == "true"
+	apiCfg.scimToken = os.Getenv("SCIM_TOKEN")
+	// DELETION_REPORT_SECRET signs the reports the purge job writes. Without
+	// it they are signed with the JWT secret.
+	apiCfg.reportSecret = cmp.Or(os.Getenv("DELETION_REPORT_SECRET"), apiCfg.tokenSecret)
+	// JWT_LEEWAY_SECONDS tolerates clock skew between servers when checking
+	// token expiry, so a token minted on one node isn't rejected by another.
+	if raw := os.Getenv("JWT_LEEWAY_SECONDS"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		leeway := time.Duration(seconds) * time.Second
+		if err != nil || leeway < 0 || leeway > auth.MaxJWTLeeway {
+			log.Fatalf("Invalid JWT_LEEWAY_SECONDS: must be between 0 and %d", int(auth.MaxJWTLeeway.Seconds()))
+		}
+		apiCfg.jwtLeeway = leeway
+	}
+	apiCfg.mailer = mail.Log{}
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		apiCfg.mailer = mail.SMTP{
+			Addr:     addr,
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+	if err := apiCfg.configureCounters(context.Background()); err != nil {
+		log.Fatal("Error configuring counters:", err)
+	}
+	if err := apiCfg.configureMedia(); err != nil {
+		log.Fatal("Error configuring media storage:", err)
+	}
+	if err := apiCfg.configureSearch(); err != nil {
+		log.Fatal("Error configuring search:", err)
+	}
+	if err := apiCfg.loadSSOConfig(context.Background()); err != nil {
+		log.Fatal("Error loading SSO config:", err)
+	}
+	apiCfg.securityHeaders, err = loadSecurityHeadersConfig()
+	if err != nil {
+		log.Fatal("Error loading security headers config:", err)
+	}
+
+	appDir := os.Getenv("APP_DIR")
+	if appDir == "" {
+		appDir = "."
+	}
+	appCacheMaxAge := time.Hour
+	if raw := os.Getenv("APP_CACHE_MAX_AGE"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil {
+			log.Fatal("Invalid APP_CACHE_MAX_AGE:", err)
+		}
+		appCacheMaxAge = time.Duration(seconds) * time.Second
+	}
+	appHandler, err := static.New(appDir, appCacheMaxAge)
+	if err != nil {
+		log.Fatal("Error loading app directory:", err)
+	}
 
-	mux.Handle("/app/", http.StripPrefix("/app/", apiCfg.middlewareMetricsInc(http.FileServer(http.Dir("./")))))
-	mux.HandleFunc("GET /api/healthz", endpointHealt)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.middlewareMetricsGet)
-	mux.HandleFunc("POST /admin/reset", apiCfg.middlewareMetricsReset)
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerChirpsValidate)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerChirpsGetAll)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerChirpsGetByID)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirps)
-	mux.HandleFunc("POST /api/users", apiCfg.apiCreateUser)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
-	mux.HandleFunc("POST /api/login", apiCfg.handlerChirpsLogin)
-	mux.Handle("POST /api/refresh", apiCfg.middlewareNoBody(http.HandlerFunc(apiCfg.handlerRefreshTokens)))
-	mux.Handle("POST /api/revoke", apiCfg.middlewareNoBody(http.HandlerFunc(apiCfg.handlerRevokRefreshToken)))
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerAddSubscription)
+	// COALESCE_GETS shares one execution between identical concurrent reads
+	// of the timeline, so a burst of refreshes only hits the database once.
+	var coalesceReads func(http.Handler) http.Handler
+	if os.Getenv("COALESCE_GETS") == "true" {
+		coalesceReads = coalesce.New().Middleware
+	}
+	routes := apiCfg.routes(appHandler)
+	apiCfg.debugCapture, err = loadDebugCaptureConfig(routes)
+	if err != nil {
+		log.Fatal("Error loading debug capture config:", err)
+	}
+	apiCfg.chaos, err = loadChaosConfig(routes)
+	if err != nil {
+		log.Fatal("Error loading chaos config:", err)
+	}
+	if err := apiCfg.registerRoutes(mux, routes, coalesceReads); err != nil {
+		log.Fatal("Error registering routes:", err)
+	}
+	responseCase, err := render.ParseCase(os.Getenv("RESPONSE_FIELD_CASE"))
+	if err != nil {
+		log.Fatal("Invalid RESPONSE_FIELD_CASE:", err)
+	}
+	responseOptions := render.Options{
+		Case:     responseCase,
+		Envelope: os.Getenv("RESPONSE_ENVELOPE") == "true",
+	}
+
+	go apiCfg.runSLOMonitor(context.Background())
+	go apiCfg.runUserPurge(context.Background())
+	go apiCfg.runChirpExpiryPurge(context.Background())
+	go apiCfg.runRedExpiry(context.Background())
+	go apiCfg.runRetention(context.Background())
+	go apiCfg.runWebhookNoncePurge(context.Background())
+	go apiCfg.runProfanityBackfill(context.Background())
+	go apiCfg.runMediaPurge(context.Background())
+	go apiCfg.runMediaVariants(context.Background())
+	go apiCfg.runOutboxRelay(context.Background())
+	go apiCfg.runSearchIndexer(context.Background())
+	apiCfg.checkSchema(context.Background())
+	// WARM_CACHES holds /api/readyz at 503 after boot until the caches the
+	// first requests need are loaded, to avoid a latency spike on deploy.
+	if os.Getenv("WARM_CACHES") == "true" {
+		apiCfg.warming.Store(true)
+		go apiCfg.warmCaches(context.Background())
+	}
+	go apiCfg.runSchemaCheck(context.Background())
+	// OAuth responses have a shape fixed by RFC 6749, so they skip the
+	// response field case and envelope options.
+	root := http.NewServeMux()
+	gated := apiCfg.middlewareDBAvailable(apiCfg.middlewareSchemaCompatible(mux))
+	root.Handle("/oauth/", gated)
+	root.Handle("/", render.Middleware(responseOptions, gated))
 	server := &http.Server{
 		Addr:    ":8080",
-		Handler: mux,
+		Handler: apiCfg.middlewareRequestStats(root),
+	}
+	// DRAIN_DELAY_SECONDS is how long /api/readyz fails after SIGTERM before
+	// the server stops, so the load balancer has moved traffic away first.
+	var drainDelay time.Duration
+	if raw := os.Getenv("DRAIN_DELAY_SECONDS"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			log.Fatal("Invalid DRAIN_DELAY_SECONDS: must be a non-negative integer")
+		}
+		drainDelay = time.Duration(seconds) * time.Second
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	stopped := make(chan struct{})
+	go func() {
+		<-stop
+		apiCfg.shutdown(server, drainDelay)
+		close(stopped)
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
 	}
-	server.ListenAndServe()
+	// ListenAndServe returns as soon as Shutdown starts; wait for in-flight
+	// requests.
+	<-stopped
 }
 
 func endpointHealt(w http.ResponseWriter, r *http.Request) {
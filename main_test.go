@@ -1,9 +1,47 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/breaker"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/counter"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/mail"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/metrics"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/search"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/slo"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/storage"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 func TestEndpointHealth(t *testing.T) {
@@ -129,3 +167,2285 @@ func TestApiCreateUserReturn(t *testing.T) {
 		})
 	}
 }
+
+func TestValidCSRFToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     string
+		header   string
+		expected string
+		want     bool
+	}{
+		{
+			name:     "matching form token",
+			form:     "csrf_token=abc123",
+			expected: "abc123",
+			want:     true,
+		},
+		{
+			name:     "matching header token",
+			header:   "abc123",
+			expected: "abc123",
+			want:     true,
+		},
+		{
+			name:     "mismatched token",
+			form:     "csrf_token=wrong",
+			expected: "abc123",
+			want:     false,
+		},
+		{
+			name:     "missing token",
+			expected: "abc123",
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/admin/logout", strings.NewReader(tt.form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+
+			if got := validCSRFToken(req, tt.expected); got != tt.want {
+				t.Errorf("validCSRFToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitialChirpStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		accountAge time.Duration
+		probation  time.Duration
+		body       string
+		action     profanity.Action
+		want       string
+	}{
+		{
+			name:       "probation disabled",
+			accountAge: time.Minute,
+			probation:  0,
+			body:       "visit https://example.com",
+			want:       chirpStatusPublished,
+		},
+		{
+			name:       "account older than probation",
+			accountAge: 48 * time.Hour,
+			probation:  24 * time.Hour,
+			body:       "visit https://example.com",
+			want:       chirpStatusPublished,
+		},
+		{
+			name:       "new account with clean chirp is cleared automatically",
+			accountAge: time.Hour,
+			probation:  24 * time.Hour,
+			body:       "hello world",
+			want:       chirpStatusPublished,
+		},
+		{
+			name:       "new account with link is held",
+			accountAge: time.Hour,
+			probation:  24 * time.Hour,
+			body:       "visit https://example.com",
+			want:       chirpStatusPending,
+		},
+		{
+			name:       "new account with profanity is held",
+			accountAge: time.Hour,
+			probation:  24 * time.Hour,
+			body:       "what a kerfuffle",
+			action:     profanity.ActionMask,
+			want:       chirpStatusPending,
+		},
+		{
+			name:       "flagged chirp is held even for old accounts",
+			accountAge: 48 * time.Hour,
+			probation:  0,
+			body:       "what a sharbert",
+			action:     profanity.ActionFlag,
+			want:       chirpStatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := initialChirpStatus(time.Now().Add(-tt.accountAge), tt.probation, tt.body, tt.action)
+			if got != tt.want {
+				t.Errorf("initialChirpStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareSecurityHeaders(t *testing.T) {
+	cfg := &apiConfig{securityHeaders: securityHeadersConfig{
+		ContentSecurityPolicy: "default-src 'self'",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		HSTSMaxAge:            time.Hour,
+	}}
+	handler := cfg.middlewareSecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		https    bool
+		wantHSTS string
+	}{
+		{name: "plain http omits HSTS", https: false, wantHSTS: ""},
+		{name: "https sends HSTS", https: true, wantHSTS: "max-age=3600; includeSubDomains"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/app/", nil)
+			if tt.https {
+				req.Header.Set("X-Forwarded-Proto", "https")
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			want := map[string]string{
+				"Content-Security-Policy":   "default-src 'self'",
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "no-referrer",
+				"Strict-Transport-Security": tt.wantHSTS,
+			}
+			for header, value := range want {
+				if got := w.Header().Get(header); got != value {
+					t.Errorf("%s = %q, want %q", header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		cookieMode  bool
+		header      string
+		cookie      string
+		want        string
+		expectError bool
+	}{
+		{
+			name:   "bearer header",
+			header: "Bearer header-token",
+			want:   "header-token",
+		},
+		{
+			name:        "cookie ignored when cookie mode is off",
+			cookie:      "cookie-token",
+			expectError: true,
+		},
+		{
+			name:       "cookie accepted in cookie mode",
+			cookieMode: true,
+			cookie:     "cookie-token",
+			want:       "cookie-token",
+		},
+		{
+			name:       "header takes precedence over cookie",
+			cookieMode: true,
+			header:     "Bearer header-token",
+			cookie:     "cookie-token",
+			want:       "header-token",
+		},
+		{
+			name:        "neither header nor cookie",
+			cookieMode:  true,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &apiConfig{accessTokenCookie: tt.cookieMode}
+			req := httptest.NewRequest("GET", "/api/chirps", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: accessTokenCookie, Value: tt.cookie})
+			}
+
+			got, err := cfg.accessToken(req)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("accessToken() expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("accessToken() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("accessToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestStats(t *testing.T) {
+	cfg := &apiConfig{requestStats: metrics.NewRegistry(), sloMonitor: slo.NewMonitor()}
+	mux := http.NewServeMux()
+	db := countingDB{execOnlyDB{}}
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		db.ExecContext(r.Context(), "SELECT 1")
+		db.ExecContext(r.Context(), "SELECT 2")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := cfg.middlewareRequestStats(mux)
+
+	for _, path := range []string{"/api/chirps/a", "/api/chirps/b", "/nope"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	got := cfg.requestStats.Snapshot()
+	want := []struct {
+		route string
+		code  int
+		count uint64
+	}{
+		{route: "GET /api/chirps/{chirpID}", code: http.StatusNotFound, count: 2},
+		{route: "unmatched", code: http.StatusNotFound, count: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() = %+v, want %d entries", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Route != w.route || got[i].Code != w.code || got[i].Count != w.count {
+			t.Errorf("Snapshot()[%d] = %+v, want route %q code %d count %d", i, got[i], w.route, w.code, w.count)
+		}
+	}
+
+	queries := cfg.requestStats.QuerySnapshot()
+	if len(queries) != 2 || queries[0].Requests != 2 || queries[0].Queries != 4 || queries[0].P95 != 2 || queries[1].Queries != 0 {
+		t.Errorf("QuerySnapshot() = %+v, want 2 queries per chirp request and none unmatched", queries)
+	}
+}
+
+// execOnlyDB is a database.DBTX whose statements all succeed without doing
+// anything. Only ExecContext may be called.
+type execOnlyDB struct {
+	database.DBTX
+}
+
+func (execOnlyDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func TestChirpTemplate(t *testing.T) {
+	tmpl, err := template.ParseFiles("./templates/chirp.html")
+	if err != nil {
+		t.Fatalf("ParseFiles() unexpected error: %v", err)
+	}
+	data := chirpPageData{
+		SiteName:    "Birdhouse",
+		Title:       "Ada on Birdhouse",
+		Body:        `<script>alert("hi")</script> & more`,
+		AuthorName:  "Ada",
+		URL:         "https://chirpy.example/chirps/abc",
+		OEmbedURL:   "https://chirpy.example/api/oembed?format=json&url=x",
+		PublishedAt: "2024-05-01T12:00:00Z",
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<meta property="og:site_name" content="Birdhouse" />`,
+		`<meta property="og:title" content="Ada on Birdhouse" />`,
+		`<meta property="og:url" content="https://chirpy.example/chirps/abc" />`,
+		`<link rel="alternate" type="application/json+oembed" href="https://chirpy.example/api/oembed?format=json&amp;url=x"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered page missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("rendered page contains unescaped chirp body:\n%s", out)
+	}
+	if strings.Contains(out, "og:image") {
+		t.Errorf("rendered page has og:image without an avatar")
+	}
+}
+
+func TestEmailDomainAllowed(t *testing.T) {
+	deny := func(domain string) database.EmailDomainRule {
+		return database.EmailDomainRule{Domain: domain, Rule: emailDomainDeny}
+	}
+	allow := func(domain string) database.EmailDomainRule {
+		return database.EmailDomainRule{Domain: domain, Rule: emailDomainAllow}
+	}
+	tests := []struct {
+		name  string
+		email string
+		rules []database.EmailDomainRule
+		want  bool
+	}{
+		{name: "no rules", email: "a@example.com", want: true},
+		{name: "denied domain", email: "a@mailinator.com", rules: []database.EmailDomainRule{deny("mailinator.com")}, want: false},
+		{name: "denied subdomain", email: "a@x.mailinator.com", rules: []database.EmailDomainRule{deny("mailinator.com")}, want: false},
+		{name: "suffix is not a subdomain", email: "a@notmailinator.com", rules: []database.EmailDomainRule{deny("mailinator.com")}, want: true},
+		{name: "case insensitive", email: "a@Corp.Example", rules: []database.EmailDomainRule{allow("corp.example")}, want: true},
+		{name: "not on allowlist", email: "a@gmail.com", rules: []database.EmailDomainRule{allow("corp.example")}, want: false},
+		{name: "deny beats allow", email: "a@contractors.corp.example", rules: []database.EmailDomainRule{allow("corp.example"), deny("contractors.corp.example")}, want: false},
+		{name: "missing at sign", email: "corp.example", rules: nil, want: false},
+		{name: "second at sign", email: "a@allowed.com@denied.com", rules: []database.EmailDomainRule{allow("allowed.com"), deny("denied.com")}, want: false},
+		{name: "trailing dot", email: "a@denied.com.", rules: []database.EmailDomainRule{deny("denied.com")}, want: false},
+		{name: "quoted at sign in local part", email: `"a@allowed.com"@denied.com`, rules: []database.EmailDomainRule{allow("allowed.com")}, want: false},
+		{name: "display name", email: "A <a@allowed.com>", rules: []database.EmailDomainRule{allow("allowed.com")}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emailDomainAllowed(tt.email, tt.rules); got != tt.want {
+				t.Errorf("emailDomainAllowed(%q) = %v, want %v", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChirpVisible(t *testing.T) {
+	author := uuid.New()
+	other := uuid.New()
+	follower := uuid.New()
+	followsAuthor := followedAuthors{author: true}
+	tests := []struct {
+		name       string
+		visibility string
+		viewer     uuid.UUID
+		followed   followedAuthors
+		listing    bool
+		want       bool
+	}{
+		{"public listed anonymous", chirpVisibilityPublic, uuid.Nil, nil, true, true},
+		{"unlisted direct anonymous", chirpVisibilityUnlisted, uuid.Nil, nil, false, true},
+		{"unlisted hidden from listings", chirpVisibilityUnlisted, other, nil, true, false},
+		{"unlisted listed for author", chirpVisibilityUnlisted, author, nil, true, true},
+		{"followers hidden from others", chirpVisibilityFollowers, other, nil, false, false},
+		{"followers hidden from non-followers", chirpVisibilityFollowers, other, followedAuthors{uuid.New(): true}, true, false},
+		{"followers hidden from anonymous", chirpVisibilityFollowers, uuid.Nil, followsAuthor, true, false},
+		{"followers shown to author", chirpVisibilityFollowers, author, nil, true, true},
+		{"followers listed for follower", chirpVisibilityFollowers, follower, followsAuthor, true, true},
+		{"followers direct for follower", chirpVisibilityFollowers, follower, followsAuthor, false, true},
+		{"unknown value hidden", "secret", other, followsAuthor, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chirpVisible(tt.visibility, author, tt.viewer, tt.followed, tt.listing); got != tt.want {
+				t.Errorf("chirpVisible() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	cfg := &apiConfig{database: database.NewStore(unqueriedDB{t: t})}
+	if followed, err := cfg.viewerFollows(context.Background(), uuid.Nil, []uuid.UUID{author}); err != nil || followed != nil {
+		t.Errorf("viewerFollows() for an anonymous viewer = %v, %v, want nobody", followed, err)
+	}
+	if visible, err := cfg.chirpVisibleTo(context.Background(), chirpVisibilityPublic, author, other); err != nil || !visible {
+		t.Errorf("chirpVisibleTo() of a public chirp = %v, %v, want visible", visible, err)
+	}
+}
+
+func TestChirpExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(d)
+		return &ts
+	}
+	tests := []struct {
+		name      string
+		requested *time.Time
+		wantValid bool
+		wantErr   error
+	}{
+		{name: "no expiry", requested: nil, wantValid: false},
+		{name: "within lifetime", requested: at(time.Hour), wantValid: true},
+		{name: "exactly the maximum", requested: at(24 * time.Hour), wantValid: true},
+		{name: "past the maximum", requested: at(25 * time.Hour), wantErr: errChirpExpiryTooLate},
+		{name: "in the past", requested: at(-time.Minute), wantErr: errChirpExpiryPast},
+		{name: "now", requested: at(0), wantErr: errChirpExpiryPast},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := chirpExpiry(now, tt.requested, 24*time.Hour)
+			if err != tt.wantErr {
+				t.Fatalf("chirpExpiry() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Valid != tt.wantValid {
+				t.Errorf("chirpExpiry() valid = %v, want %v", got.Valid, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestDeveloperQuotaWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantDay   time.Time
+		wantReset time.Time
+	}{
+		{
+			name:      "midday utc",
+			now:       time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC),
+			wantDay:   time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "offset zone counts against the utc day",
+			now:       time.Date(2026, 3, 10, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+			wantDay:   time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "end of month",
+			now:       time.Date(2026, 2, 28, 23, 59, 59, 0, time.UTC),
+			wantDay:   time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := developerQuotaDay(tt.now); !got.Equal(tt.wantDay) {
+				t.Errorf("developerQuotaDay() = %v, want %v", got, tt.wantDay)
+			}
+			if got := developerQuotaReset(tt.now); !got.Equal(tt.wantReset) {
+				t.Errorf("developerQuotaReset() = %v, want %v", got, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestOAuthConsentTemplate(t *testing.T) {
+	tmpl, err := template.ParseFiles("./templates/oauth_consent.html")
+	if err != nil {
+		t.Fatalf("ParseFiles() unexpected error: %v", err)
+	}
+	tests := []struct {
+		name    string
+		data    oauthConsentData
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "signed out",
+			data: oauthConsentData{
+				ClientName:    `<b>Evil</b> App`,
+				ClientID:      "c1",
+				RedirectURI:   "https://app.example/cb?a=1&b=2",
+				Scope:         "chirps:read",
+				Scopes:        []string{"Read chirps"},
+				State:         `"><script>`,
+				CSRFToken:     "csrf",
+				PasswordLogin: true,
+			},
+			want: []string{
+				`&lt;b&gt;Evil&lt;/b&gt; App wants to use your Chirpy account`,
+				`name="redirect_uri" value="https://app.example/cb?a=1&amp;b=2"`,
+				`name="password"`,
+				`<li>Read chirps</li>`,
+			},
+			notWant: []string{"<script>", "<b>Evil"},
+		},
+		{
+			name:    "signed in",
+			data:    oauthConsentData{ClientName: "App"},
+			notWant: []string{`name="password"`},
+		},
+		{
+			name:    "invalid request",
+			data:    oauthConsentData{Error: "unknown client"},
+			want:    []string{"Authorization failed", "unknown client"},
+			notWant: []string{"<form"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := tmpl.Execute(&buf, tt.data); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("rendered page missing %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out, notWant) {
+					t.Errorf("rendered page contains %q", notWant)
+				}
+			}
+		})
+	}
+}
+
+// unqueriedDB fails the test if anything is read from it.
+type unqueriedDB struct {
+	database.DBTX
+	t *testing.T
+}
+
+func (d unqueriedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	d.t.Fatalf("unexpected query %q", query)
+	return nil
+}
+
+func TestConsentingUserIgnoresBearerTokens(t *testing.T) {
+	cfg := &apiConfig{
+		accessTokenCookie: true,
+		tokenSecret:       "secret",
+		database:          database.NewStore(unqueriedDB{t: t}),
+	}
+	readToken, err := auth.MakeSessionToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherJWT, err := auth.MakeJWT(uuid.New(), "other secret", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+	}{
+		// A client holding a chirps:read token must not be able to post
+		// the consent form itself and approve chirps:write.
+		{name: "oauth token", header: "Bearer " + readToken},
+		{name: "jwt in header", header: "Bearer " + otherJWT},
+		{name: "foreign cookie", cookie: otherJWT},
+		{name: "anonymous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"scope": {"chirps:read chirps:write profile:write"}}
+			r := httptest.NewRequest(http.MethodPost, "/oauth/authorize", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: accessTokenCookie, Value: tt.cookie})
+			}
+			if got := cfg.consentingUser(r); got != uuid.Nil {
+				t.Errorf("consentingUser() = %s, want nobody", got)
+			}
+		})
+	}
+}
+
+func TestParseGroupRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: map[string]string{}},
+		{
+			name: "several groups",
+			raw:  " chirpy-admins=admin, ops = admin ,",
+			want: map[string]string{"chirpy-admins": "admin", "ops": "admin"},
+		},
+		{name: "missing role", raw: "chirpy-admins", wantErr: true},
+		{name: "missing group", raw: "=admin", wantErr: true},
+		{name: "unknown role", raw: "staff=moderator", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGroupRoles(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGroupRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGroupRoles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheHitRatio(t *testing.T) {
+	tests := []struct {
+		name string
+		hit  int64
+		read int64
+		want float64
+	}{
+		{name: "no blocks touched", want: 1},
+		{name: "all cached", hit: 40, want: 1},
+		{name: "mixed", hit: 75, read: 25, want: 0.75},
+		{name: "all from disk", read: 10, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheHitRatio(tt.hit, tt.read); got != tt.want {
+				t.Errorf("cacheHitRatio(%d, %d) = %v, want %v", tt.hit, tt.read, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "no rows", err: sql.ErrNoRows, want: false},
+		{name: "bad conn", err: fmt.Errorf("query: %w", driver.ErrBadConn), want: true},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, want: true},
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, want: true},
+		{name: "connection failure class", err: &pq.Error{Code: "08006"}, want: true},
+		{name: "read only after failover", err: &pq.Error{Code: "25006"}, want: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, want: false},
+		{name: "cancelled", err: context.Canceled, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareDBAvailable(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	failover := &dbFailover{now: func() time.Time { return now }}
+	cfg := &apiConfig{dbFailover: failover}
+	handler := cfg.middlewareDBAvailable(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name           string
+		down           bool
+		retryAt        time.Time
+		path           string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "up", path: "/api/chirps", wantStatus: http.StatusOK},
+		{name: "down", down: true, retryAt: now.Add(2500 * time.Millisecond), path: "/api/chirps", wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "3"},
+		{name: "probe overdue", down: true, retryAt: now.Add(-time.Second), path: "/api/chirps", wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "1"},
+		{name: "health check while down", down: true, retryAt: now, path: "/api/healthz", wantStatus: http.StatusOK},
+		{name: "static app while down", down: true, retryAt: now, path: "/app/index.html", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failover.down, failover.retryAt = tt.down, tt.retryAt
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestPIIChanges(t *testing.T) {
+	base := database.User{
+		Email:       "ada@example.com",
+		Handle:      sql.NullString{String: "ada", Valid: true},
+		DisplayName: sql.NullString{String: "Ada", Valid: true},
+	}
+	tests := []struct {
+		name   string
+		modify func(u *database.User)
+		want   []piiChange
+	}{
+		{name: "unchanged", modify: func(u *database.User) {}},
+		{
+			name:   "email",
+			modify: func(u *database.User) { u.Email = "ada@corp.example" },
+			want: []piiChange{{
+				Field:    "email",
+				OldValue: sql.NullString{String: "ada@example.com", Valid: true},
+				NewValue: sql.NullString{String: "ada@corp.example", Valid: true},
+			}},
+		},
+		{
+			name: "display name cleared and avatar set",
+			modify: func(u *database.User) {
+				u.DisplayName = sql.NullString{}
+				u.AvatarUrl = sql.NullString{String: "https://cdn.example/a.png", Valid: true}
+			},
+			want: []piiChange{
+				{Field: "display_name", OldValue: sql.NullString{String: "Ada", Valid: true}},
+				{Field: "avatar_url", NewValue: sql.NullString{String: "https://cdn.example/a.png", Valid: true}},
+			},
+		},
+		{name: "non personal fields", modify: func(u *database.User) { u.IsChirpyRed = true; u.UpdatedAt = time.Now() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			tt.modify(&after)
+			if got := piiChanges(base, after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("piiChanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRespondWithPendingPolicy(t *testing.T) {
+	rec := httptest.NewRecorder()
+	respondWithPendingPolicy(rec, database.PolicyVersion{
+		Version:     "2026-03",
+		Url:         "https://chirpy.example/terms",
+		PublishedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if rec.Code != http.StatusUnavailableForLegalReasons {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnavailableForLegalReasons)
+	}
+	var body struct {
+		Error  string `json:"error"`
+		Policy struct {
+			Version string `json:"version"`
+			URL     string `json:"url"`
+		} `json:"policy"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if body.Error == "" || body.Policy.Version != "2026-03" || body.Policy.URL != "https://chirpy.example/terms" {
+		t.Errorf("body = %+v, want the pending policy and an error", body)
+	}
+}
+
+func TestSensitiveBody(t *testing.T) {
+	author, viewer := uuid.New(), uuid.New()
+	warning := sql.NullString{String: "spoilers", Valid: true}
+	tests := []struct {
+		name       string
+		warning    sql.NullString
+		viewer     uuid.UUID
+		reveal     bool
+		wantBody   string
+		wantHidden bool
+	}{
+		{name: "not sensitive", viewer: uuid.Nil, wantBody: "hello"},
+		{name: "anonymous", warning: warning, viewer: uuid.Nil, wantHidden: true},
+		{name: "viewer without opt in", warning: warning, viewer: viewer, wantHidden: true},
+		{name: "viewer confirmed", warning: warning, viewer: viewer, reveal: true, wantBody: "hello"},
+		{name: "author", warning: warning, viewer: author, wantBody: "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, hidden := sensitiveBody("hello", tt.warning, author, tt.viewer, tt.reveal)
+			if body != tt.wantBody || hidden != tt.wantHidden {
+				t.Errorf("sensitiveBody() = (%q, %v), want (%q, %v)", body, hidden, tt.wantBody, tt.wantHidden)
+			}
+		})
+	}
+}
+
+func TestParseContentWarning(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		want   sql.NullString
+		wantOK bool
+	}{
+		{name: "empty", raw: "  ", wantOK: true},
+		{name: "trimmed", raw: " spoilers ", want: sql.NullString{String: "spoilers", Valid: true}, wantOK: true},
+		{name: "too long", raw: strings.Repeat("é", maxContentWarningLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseContentWarning(tt.raw)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseContentWarning(%q) = (%+v, %v), want (%+v, %v)", tt.raw, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckSchemaCompat(t *testing.T) {
+	tests := []struct {
+		name             string
+		dbVersion        int64
+		oldestCompatible int64
+		wantErr          bool
+	}{
+		{name: "matching schema", dbVersion: schemaVersion, oldestCompatible: schemaVersion - 1},
+		{name: "database behind", dbVersion: schemaMinVersion - 1, oldestCompatible: 0, wantErr: true},
+		{name: "database ahead after expand", dbVersion: schemaVersion + 2, oldestCompatible: schemaVersion},
+		{name: "database ahead after contract", dbVersion: schemaVersion + 2, oldestCompatible: schemaVersion + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSchemaCompat(tt.dbVersion, tt.oldestCompatible)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSchemaCompat(%d, %d) error = %v, wantErr %v", tt.dbVersion, tt.oldestCompatible, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMiddlewareSchemaCompatible(t *testing.T) {
+	cfg := &apiConfig{}
+	handler := cfg.middlewareSchemaCompatible(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name         string
+		incompatible bool
+		path         string
+		wantStatus   int
+	}{
+		{name: "compatible", path: "/api/chirps", wantStatus: http.StatusOK},
+		{name: "incompatible", incompatible: true, path: "/api/chirps", wantStatus: http.StatusServiceUnavailable},
+		{name: "health check while incompatible", incompatible: true, path: "/api/healthz", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.incompatible {
+				err = checkSchemaCompat(0, 0)
+			}
+			cfg.schemaGate.set(err)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRespondWithDBError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantError  string
+	}{
+		{name: "user not found", err: fmt.Errorf("%w: %w", database.ErrUserNotFound, sql.ErrNoRows), wantStatus: http.StatusNotFound, wantError: "User not found"},
+		{name: "duplicate handle", err: database.ErrDuplicateHandle, wantStatus: http.StatusConflict, wantError: "Handle is already taken"},
+		{name: "unknown token", err: database.ErrTokenNotFound, wantStatus: http.StatusUnauthorized, wantError: "Invalid token"},
+		{name: "expired token", err: database.ErrTokenExpired, wantStatus: http.StatusUnauthorized, wantError: "Token has expired"},
+		{name: "revoked token", err: database.ErrTokenRevoked, wantStatus: http.StatusUnauthorized, wantError: "Token has been revoked"},
+		{name: "other error", err: sql.ErrConnDone, wantStatus: http.StatusInternalServerError, wantError: "Couldn't do it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			respondWithDBError(rec, "Couldn't do it", tt.err)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestChirpIDTime(t *testing.T) {
+	id, createdAt, err := newChirpID()
+	if err != nil {
+		t.Fatalf("newChirpID() error = %v", err)
+	}
+	if id.Version() != 7 {
+		t.Errorf("newChirpID() version = %d, want 7", id.Version())
+	}
+	if d := time.Since(createdAt); d < 0 || d > time.Minute {
+		t.Errorf("newChirpID() time = %v, want about now", createdAt)
+	}
+	if got, ok := chirpIDTime(id); !ok || !got.Equal(createdAt) {
+		t.Errorf("chirpIDTime() = %v, %v, want %v, true", got, ok, createdAt)
+	}
+
+	next, nextCreatedAt, _ := newChirpID()
+	if nextCreatedAt.Before(createdAt) || (nextCreatedAt.Equal(createdAt) && next.String() <= id.String()) {
+		t.Errorf("newChirpID() = %s after %s, want time-ordered IDs", next, id)
+	}
+
+	if _, ok := chirpIDTime(uuid.New()); ok {
+		t.Error("chirpIDTime() of a v4 ID reported a time")
+	}
+}
+
+func TestParseChirpCursor(t *testing.T) {
+	cfg := &apiConfig{}
+	id, createdAt, _ := newChirpID()
+	opaque := api.Cursor{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ID: uuid.New()}
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    api.Cursor
+		wantErr bool
+	}{
+		{name: "v7 chirp ID", raw: id.String(), want: api.Cursor{CreatedAt: createdAt, ID: id}},
+		{name: "next_cursor", raw: opaque.Encode(), want: opaque},
+		{name: "garbage", raw: "not a cursor", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.parseChirpCursor(context.Background(), tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChirpCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (!got.CreatedAt.Equal(tt.want.CreatedAt) || got.ID != tt.want.ID) {
+				t.Errorf("parseChirpCursor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlePolkaGiftValidation(t *testing.T) {
+	cfg := &apiConfig{}
+	alice, bob := uuid.New().String(), uuid.New().String()
+	tests := []struct {
+		name string
+		data EventData
+	}{
+		{name: "invalid recipient", data: EventData{UserID: "nope", GifterID: alice}},
+		{name: "invalid gifter", data: EventData{UserID: bob, GifterID: ""}},
+		{name: "gift to self", data: EventData{UserID: alice, GifterID: alice}},
+		{name: "negative months", data: EventData{UserID: bob, GifterID: alice, Months: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			cfg.handlePolkaGift(rec, httptest.NewRequest(http.MethodPost, "/api/polka/webhooks", nil), tt.data, "nonce")
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestWaitlistLoginError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      sql.NullString
+		wantWaiting bool
+	}{
+		{name: "approved", status: sql.NullString{}, wantWaiting: false},
+		{name: "pending", status: sql.NullString{String: waitlistPending, Valid: true}, wantWaiting: true},
+		{name: "rejected", status: sql.NullString{String: waitlistRejected, Valid: true}, wantWaiting: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, waiting := waitlistLoginError(database.User{WaitlistStatus: tt.status})
+			if waiting != tt.wantWaiting {
+				t.Errorf("waitlistLoginError() waiting = %v, want %v", waiting, tt.wantWaiting)
+			}
+			if waiting && msg == "" {
+				t.Error("waitlistLoginError() gave no message")
+			}
+		})
+	}
+}
+
+func TestMiddlewareAuthenticateRejects(t *testing.T) {
+	cfg := &apiConfig{tokenSecret: "secret"}
+	handler := cfg.middlewareAuthenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called without a valid token")
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{name: "missing token", authorization: ""},
+		{name: "malformed token", authorization: "Bearer not-a-jwt"},
+		{name: "wrong scheme", authorization: "Basic abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/users/me/history", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+
+	if _, ok := userFromContext(context.Background()); ok {
+		t.Error("userFromContext() found a user in an empty context")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	cfg := &apiConfig{}
+	seen := map[string]bool{}
+	for _, rt := range cfg.routes(http.NotFoundHandler()) {
+		if seen[rt.pattern] {
+			t.Errorf("%s registered twice", rt.pattern)
+		}
+		seen[rt.pattern] = true
+		if rt.handler == nil {
+			t.Errorf("%s has no handler", rt.pattern)
+		}
+		if _, err := cfg.routeHandler(rt, nil); err != nil {
+			t.Error(err)
+		}
+		if strings.Contains(rt.pattern, "/api/users/me/") && rt.access == accessOpen {
+			t.Errorf("%s is open to anonymous callers", rt.pattern)
+		}
+	}
+}
+
+// offlineConnector fails every connection, so each query returns an error.
+type offlineConnector struct{}
+
+func (offlineConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("database offline")
+}
+
+func (offlineConnector) Driver() driver.Driver { return nil }
+
+func TestHandlerRoutesCheckCredentials(t *testing.T) {
+	t.Setenv("PLATFORM", "dev")
+	db := sql.OpenDB(offlineConnector{})
+	defer db.Close()
+	cfg := &apiConfig{
+		tokenSecret:     "secret",
+		db:              db,
+		database:        database.NewStore(db),
+		counters:        counter.NewMemory(),
+		requestStats:    metrics.NewRegistry(),
+		consentTemplate: template.Must(template.New("consent").Parse("")),
+	}
+	placeholders := regexp.MustCompile(`\{[^}]+\}`)
+	for _, rt := range cfg.routes(http.NotFoundHandler()) {
+		if rt.access != accessHandler {
+			continue
+		}
+		t.Run(rt.pattern, func(t *testing.T) {
+			h, err := cfg.routeHandler(rt, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mux := http.NewServeMux()
+			mux.Handle(rt.pattern, h)
+			method, path, _ := strings.Cut(rt.pattern, " ")
+			path = placeholders.ReplaceAllString(path, uuid.NewString())
+			rec := httptest.NewRecorder()
+			defer func() {
+				if p := recover(); p != nil {
+					t.Errorf("%s without credentials got past the handler's checks: %v", rt.pattern, p)
+				}
+			}()
+			mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader("{}")))
+			if rec.Code < 300 || rec.Code >= 500 {
+				t.Errorf("%s without credentials = %d, want a rejection; label it with the access it needs", rt.pattern, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRouteHandler(t *testing.T) {
+	cfg := &apiConfig{tokenSecret: "secret"}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	tests := []struct {
+		name       string
+		rt         route
+		body       string
+		wantErr    bool
+		wantStatus int
+	}{
+		{name: "open", rt: route{pattern: "GET /a", handler: ok, access: accessOpen}, wantStatus: http.StatusOK},
+		{name: "user without token", rt: route{pattern: "GET /a", handler: ok, access: accessUser}, wantStatus: http.StatusUnauthorized},
+		{name: "no body", rt: route{pattern: "POST /a", handler: ok, access: accessHandler, middleware: withNoBody}, body: "{}", wantStatus: http.StatusBadRequest},
+		{name: "missing access", rt: route{pattern: "GET /a", handler: ok}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := cfg.routeHandler(tt.rt, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("routeHandler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/a", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMiddlewareDebugCapture(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &apiConfig{debugCapture: debugCaptureConfig{secret: "secret", routes: map[string]bool{}}}
+	handler := cfg.middlewareDebugCapture("POST /api/login", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "hunter2") {
+			t.Errorf("handler got body %q, want the original", body)
+		}
+		respondWithJSON(w, http.StatusOK, map[string]string{"token": "jwt", "email": "ada@example.com"})
+	}))
+
+	tests := []struct {
+		name    string
+		header  string
+		wantLog bool
+	}{
+		{name: "no header", wantLog: false},
+		{name: "invalid header", header: "123.abc", wantLog: false},
+		{name: "signed header", header: auth.SignDebugHeader("secret", time.Now().Add(time.Minute)), wantLog: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"email":"ada@example.com","password":"hunter2"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set(debugCaptureHeader, tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			got := logs.String()
+			if logged := strings.Contains(got, "debug capture"); logged != tt.wantLog {
+				t.Fatalf("logged = %v, want %v: %s", logged, tt.wantLog, got)
+			}
+			if strings.Contains(got, "hunter2") || strings.Contains(got, `\"token\":\"jwt\"`) {
+				t.Errorf("capture leaked a credential: %s", got)
+			}
+			if tt.wantLog && !strings.Contains(got, "ada@example.com") {
+				t.Errorf("capture is missing the body: %s", got)
+			}
+		})
+	}
+}
+
+func TestRespondWithErrorRedacts(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	err := errors.New(`pq: duplicate key value violates unique constraint "users_email_key": Key (email)=(ada@example.com) already exists`)
+	rec := httptest.NewRecorder()
+	respondWithError(rec, http.StatusBadRequest, "Couldn't sign up ada@example.com", err)
+
+	if strings.Contains(rec.Body.String(), "ada@example.com") {
+		t.Errorf("response leaked the email: %s", rec.Body.String())
+	}
+	if strings.Contains(logs.String(), "ada@example.com") {
+		t.Errorf("log leaked the email: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "users_email_key") {
+		t.Errorf("log lost the rest of the error: %s", logs.String())
+	}
+}
+
+func TestHandlerAPIFallback(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	routes := []route{
+		{pattern: "GET /api/chirps", handler: noop, access: accessOpen},
+		{pattern: "POST /api/chirps", handler: noop, access: accessHandler},
+		{pattern: "GET /api/chirps/{chirpID}", handler: noop, access: accessOpen},
+		{pattern: "DELETE /api/chirps/{chirpID}", handler: noop, access: accessHandler},
+		{pattern: "POST /api/users", handler: noop, access: accessOpen},
+		{pattern: "GET /admin/users", handler: noop, access: accessAdmin},
+	}
+	mux := http.NewServeMux()
+	if err := (&apiConfig{}).registerRoutes(mux, routes, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+		wantHints  []string
+	}{
+		{name: "matched route", method: http.MethodGet, path: "/api/chirps", wantStatus: http.StatusOK},
+		{name: "wrong method", method: http.MethodPut, path: "/api/chirps", wantStatus: http.StatusMethodNotAllowed, wantAllow: "GET, POST"},
+		{name: "wrong method with wildcard", method: http.MethodPost, path: "/api/chirps/123", wantStatus: http.StatusMethodNotAllowed, wantAllow: "DELETE, GET"},
+		{name: "typo", method: http.MethodGet, path: "/api/chrips", wantStatus: http.StatusNotFound, wantHints: []string{"GET /api/chirps", "POST /api/chirps"}},
+		{name: "singular", method: http.MethodPost, path: "/api/user", wantStatus: http.StatusNotFound, wantHints: []string{"POST /api/users"}},
+		{name: "nothing similar", method: http.MethodGet, path: "/api/completely/different", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantStatus != http.StatusNotFound {
+				return
+			}
+			var body struct {
+				Error      string   `json:"error"`
+				DidYouMean []string `json:"did_you_mean"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("404 body isn't JSON: %v", err)
+			}
+			if body.Error == "" || !reflect.DeepEqual(body.DidYouMean, tt.wantHints) {
+				t.Errorf("body = %+v, want hints %v", body, tt.wantHints)
+			}
+		})
+	}
+}
+
+func TestEditedChirpStatus(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	tests := []struct {
+		name    string
+		current string
+		body    string
+		action  profanity.Action
+		want    string
+	}{
+		{name: "clean edit stays published", current: chirpStatusPublished, body: "hello", want: chirpStatusPublished},
+		{name: "flagged edit is held again", current: chirpStatusPublished, body: "hello", action: profanity.ActionFlag, want: chirpStatusPending},
+		{name: "pending chirp keeps waiting", current: chirpStatusPending, body: "hello", want: chirpStatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := editedChirpStatus(tt.current, old, 24*time.Hour, tt.body, tt.action); got != tt.want {
+				t.Errorf("editedChirpStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenFromRequest(t *testing.T) {
+	header, err := auth.MakeRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := auth.MakeRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		authorization string
+		body          string
+		want          string
+		wantErr       bool
+	}{
+		{name: "header", authorization: "Bearer " + header, want: header},
+		{name: "body", body: `{"refresh_token": "` + body + `"}`, want: body},
+		{name: "header wins", authorization: "Bearer " + header, body: `{"refresh_token": "` + body + `"}`, want: header},
+		{name: "malformed", authorization: "Bearer chrp_rt_guess", wantErr: true},
+		{name: "neither", wantErr: true},
+		{name: "empty field", body: `{"refresh_token": ""}`, wantErr: true},
+		{name: "bad json", body: `{"refresh_token":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/refresh", strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			got, err := refreshTokenFromRequest(httptest.NewRecorder(), req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("refreshTokenFromRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("refreshTokenFromRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanaryWebhook(t *testing.T) {
+	canary := database.CanaryToken{ID: uuid.New(), Kind: canaryKindAPIKey, Label: "backup bucket"}
+	use := canaryUse{Path: "/api/chirps", RemoteAddr: "203.0.113.7:4242", UserAgent: "curl/8", At: time.Now().UTC()}
+
+	var got struct {
+		CanaryID uuid.UUID `json:"canary_id"`
+		Label    string    `json:"label"`
+		Use      canaryUse `json:"use"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("webhook body isn't JSON: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	body, err := canaryWebhookBody(canary, use)
+	if err != nil {
+		t.Fatalf("canaryWebhookBody() error = %v", err)
+	}
+	b := breaker.NewRegistry(breaker.DefaultSettings).Get(dependencyCanaryWebhook)
+	if err := postWebhook(context.Background(), b, srv.URL, body); err != nil {
+		t.Fatalf("postWebhook() error = %v", err)
+	}
+	if got.CanaryID != canary.ID || got.Label != canary.Label || got.Use.RemoteAddr != use.RemoteAddr {
+		t.Errorf("webhook payload = %+v", got)
+	}
+}
+
+func TestHandlerReadiness(t *testing.T) {
+	tests := []struct {
+		name         string
+		draining     bool
+		warming      bool
+		incompatible bool
+		wantStatus   int
+	}{
+		{name: "ready", wantStatus: http.StatusOK},
+		{name: "draining", draining: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "warming", warming: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "schema incompatible", incompatible: true, wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &apiConfig{}
+			cfg.draining.Store(tt.draining)
+			cfg.warming.Store(tt.warming)
+			if tt.incompatible {
+				cfg.schemaGate.set(checkSchemaCompat(0, 0))
+			}
+			rec := httptest.NewRecorder()
+			cfg.handlerReadiness(rec, httptest.NewRequest(http.MethodGet, "/api/readyz", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUsageWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantMonth time.Time
+		wantReset time.Time
+	}{
+		{
+			name:      "mid month",
+			now:       time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC),
+			wantMonth: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "offset zone counts against the utc month",
+			now:       time.Date(2026, 3, 31, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+			wantMonth: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "end of year",
+			now:       time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC),
+			wantMonth: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usageMonth(tt.now); !got.Equal(tt.wantMonth) {
+				t.Errorf("usageMonth() = %v, want %v", got, tt.wantMonth)
+			}
+			if got := usageReset(tt.now); !got.Equal(tt.wantReset) {
+				t.Errorf("usageReset() = %v, want %v", got, tt.wantReset)
+			}
+		})
+	}
+
+	if got := usageExceededStatus(userTier(false)); got != http.StatusPaymentRequired {
+		t.Errorf("free tier over quota status = %d, want %d", got, http.StatusPaymentRequired)
+	}
+	if got := usageExceededStatus(userTier(true)); got != http.StatusTooManyRequests {
+		t.Errorf("red tier over quota status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestNewDeletionReport(t *testing.T) {
+	userID := uuid.New()
+	deletedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	purgedAt := deletedAt.Add(userRestoreWindow)
+	before := []database.CountUserReferencesRow{
+		{Reference: "users.id", RowCount: 1},
+		{Reference: "messages.user_id", RowCount: 12},
+		{Reference: "likes.user_id", RowCount: 0},
+	}
+
+	tests := []struct {
+		name          string
+		after         []database.CountUserReferencesRow
+		wantVerified  bool
+		wantRemoved   map[string]int64
+		wantRemaining map[string]int64
+	}{
+		{
+			name:          "nothing left",
+			after:         []database.CountUserReferencesRow{{Reference: "users.id"}, {Reference: "messages.user_id"}, {Reference: "likes.user_id"}},
+			wantVerified:  true,
+			wantRemoved:   map[string]int64{"users.id": 1, "messages.user_id": 12},
+			wantRemaining: map[string]int64{},
+		},
+		{
+			name:          "rows left behind",
+			after:         []database.CountUserReferencesRow{{Reference: "users.id"}, {Reference: "messages.user_id", RowCount: 2}},
+			wantVerified:  false,
+			wantRemoved:   map[string]int64{"users.id": 1, "messages.user_id": 10},
+			wantRemaining: map[string]int64{"messages.user_id": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newDeletionReport(userID, deletedAt, purgedAt, before, tt.after)
+			if got.Verified != tt.wantVerified {
+				t.Errorf("Verified = %v, want %v", got.Verified, tt.wantVerified)
+			}
+			if !reflect.DeepEqual(got.Removed, tt.wantRemoved) {
+				t.Errorf("Removed = %v, want %v", got.Removed, tt.wantRemoved)
+			}
+			if !reflect.DeepEqual(got.Remaining, tt.wantRemaining) {
+				t.Errorf("Remaining = %v, want %v", got.Remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestChirpBodyHash(t *testing.T) {
+	base := chirpBodyHash("hello world")
+	tests := []struct {
+		name     string
+		body     string
+		wantSame bool
+	}{
+		{name: "identical", body: "hello world", wantSame: true},
+		{name: "extra whitespace", body: "  hello \n world ", wantSame: true},
+		{name: "different case", body: "Hello world", wantSame: false},
+		{name: "different text", body: "hello there", wantSame: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chirpBodyHash(tt.body)
+			if (got == base) != tt.wantSame {
+				t.Errorf("chirpBodyHash(%q) == chirpBodyHash(%q) is %v, want %v", tt.body, "hello world", got == base, tt.wantSame)
+			}
+		})
+	}
+	if got := chirpBodyHash("   "); got.Valid {
+		t.Errorf("chirpBodyHash of blank body = %v, want NULL", got)
+	}
+}
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "none", body: "just a chirp", want: nil},
+		{name: "lowercased and deduplicated", body: "#Go is great, #go #Gophers", want: []string{"go", "gophers"}},
+		{name: "punctuation ends a tag", body: "loving #chirpy! (#beta)", want: []string{"chirpy", "beta"}},
+		{name: "unicode", body: "#café time", want: []string{"café"}},
+		{name: "numbers only ignored", body: "we're #1 and #2024_", want: nil},
+		{name: "mid-word and url fragments ignored", body: "a#b http://x.io/#top &#39;", want: nil},
+		{name: "too long ignored", body: "#" + strings.Repeat("a", maxHashtagLength+1), want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractHashtags(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractHashtags(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestChirpReferencesCascade keeps rows about a chirp from outliving it:
+// every foreign key to messages must say what happens on delete.
+func TestChirpReferencesCascade(t *testing.T) {
+	files, err := filepath.Glob("sql/schema/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("couldn't find migrations: %v", err)
+	}
+	ref := regexp.MustCompile(`(?i)REFERENCES\s+messages\s*\(\s*id\s*\)([^,\n]*)`)
+	onDelete := regexp.MustCompile(`(?i)ON\s+DELETE\s+(CASCADE|SET\s+NULL)`)
+	found := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(data), "-- +goose Down")
+		for _, m := range ref.FindAllStringSubmatch(up, -1) {
+			found++
+			if !onDelete.MatchString(m[1]) {
+				t.Errorf("%s: %q has no ON DELETE CASCADE or SET NULL", filepath.Base(file), strings.TrimSpace(m[0]))
+			}
+		}
+	}
+	if found == 0 {
+		t.Error("found no references to messages; the pattern is out of date")
+	}
+}
+
+func TestMutedWordsMatches(t *testing.T) {
+	muted := mutedWords{normalizeMutedPhrase("Cat"), normalizeMutedPhrase("  season   finale ")}
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "whole word", body: "look at my cat!", want: true},
+		{name: "different case", body: "CAT pictures", want: true},
+		{name: "hashtag", body: "#cat", want: true},
+		{name: "inside another word", body: "a new category", want: false},
+		{name: "phrase across extra whitespace", body: "the Season\n finale was great", want: true},
+		{name: "partial phrase", body: "season two", want: false},
+		{name: "no match", body: "dogs only", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := muted.matches(tt.body); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+	if (mutedWords{}).matches("anything") {
+		t.Error("empty mute list matched")
+	}
+}
+
+func TestBotOwnersLabel(t *testing.T) {
+	bot, owner, human := uuid.New(), uuid.New(), uuid.New()
+	bots := botOwners{bot: owner}
+
+	got := bots.label(chirpAuthor{ID: bot})
+	if !got.IsAutomated || got.AutomatedBy == nil || *got.AutomatedBy != owner {
+		t.Errorf("label(bot) = %+v, want automated by %s", got, owner)
+	}
+	got = bots.label(chirpAuthor{ID: human})
+	if got.IsAutomated || got.AutomatedBy != nil {
+		t.Errorf("label(human) = %+v, want not automated", got)
+	}
+}
+
+func TestRetentionCutoff(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		days   int
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "disabled", days: 0, wantOK: false},
+		{name: "negative is disabled", days: -5, wantOK: false},
+		{name: "one day", days: 1, want: time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC), wantOK: true},
+		{name: "across a month", days: 30, want: time.Date(2025, 2, 8, 12, 0, 0, 0, time.UTC), wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retentionCutoff(now, tt.days)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("retentionCutoff(%d) = %v, %v; want %v, %v", tt.days, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoadChaosConfig(t *testing.T) {
+	routes := []route{{pattern: "GET /api/chirps"}}
+	tests := []struct {
+		name      string
+		platform  string
+		rules     string
+		wantRules int
+		wantErr   bool
+	}{
+		{name: "unset", platform: "dev", rules: "", wantRules: 0},
+		{name: "dev", platform: "dev", rules: `{"GET /api/chirps": {"latency_ms": 100}}`, wantRules: 1},
+		{name: "staging with default", platform: "staging", rules: `{"*": {"error_rate": 0.5}, "GET /api/chirps": {}}`, wantRules: 2},
+		{name: "ignored in production", platform: "", rules: `{"GET /api/chirps": {"error_rate": 1}}`, wantRules: 0},
+		{name: "unknown route", platform: "dev", rules: `{"GET /nope": {}}`, wantErr: true},
+		{name: "rate out of range", platform: "dev", rules: `{"*": {"error_rate": 1.5}}`, wantErr: true},
+		{name: "success status", platform: "dev", rules: `{"*": {"status": 200}}`, wantErr: true},
+		{name: "bad json", platform: "dev", rules: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PLATFORM", tt.platform)
+			t.Setenv("CHAOS_RULES", tt.rules)
+			cfg, err := loadChaosConfig(routes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(cfg.rules) != tt.wantRules {
+				t.Errorf("got %d rules, want %d", len(cfg.rules), tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestMiddlewareChaos(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	tests := []struct {
+		name       string
+		rules      map[string]chaosRule
+		random     float64
+		wantStatus int
+		wantHeader string
+	}{
+		{name: "no rule", rules: map[string]chaosRule{}, random: 0, wantStatus: http.StatusOK},
+		{name: "error injected", rules: map[string]chaosRule{"GET /x": {ErrorRate: 0.5, Status: http.StatusBadGateway}}, random: 0.2, wantStatus: http.StatusBadGateway, wantHeader: "error"},
+		{name: "error not drawn", rules: map[string]chaosRule{"GET /x": {ErrorRate: 0.5, Status: http.StatusBadGateway}}, random: 0.8, wantStatus: http.StatusOK},
+		{name: "default rule", rules: map[string]chaosRule{"*": {LatencyMS: 1}}, random: 0, wantStatus: http.StatusOK, wantHeader: "latency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &apiConfig{chaos: chaosConfig{rules: tt.rules, random: func() float64 { return tt.random }}}
+			rec := httptest.NewRecorder()
+			cfg.middlewareChaos("GET /x", ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get(chaosHeader); got != tt.wantHeader {
+				t.Errorf("%s = %q, want %q", chaosHeader, got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestParseChirpRender(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantHTML   bool
+		wantOK     bool
+		wantStatus int
+	}{
+		{query: "", wantHTML: false, wantOK: true},
+		{query: "?render=html", wantHTML: true, wantOK: true},
+		{query: "?render=markdown", wantOK: false, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			renderHTML, ok := parseChirpRender(rec, httptest.NewRequest(http.MethodGet, "/api/chirps"+tt.query, nil))
+			if renderHTML != tt.wantHTML || ok != tt.wantOK {
+				t.Errorf("parseChirpRender() = %v, %v; want %v, %v", renderHTML, ok, tt.wantHTML, tt.wantOK)
+			}
+			if !ok && rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+	if got := renderedBody(true, ""); got != nil {
+		t.Errorf("renderedBody of a hidden body = %q, want nil", *got)
+	}
+	if got := renderedBody(true, "**hi**"); got == nil || *got != "<strong>hi</strong>" {
+		t.Errorf("renderedBody(**hi**) = %v", got)
+	}
+}
+
+func TestProfanityWords(t *testing.T) {
+	older := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	got := profanityWords([]database.ProfanityRule{
+		{Word: "fornax", Context: "default", Action: "mask", UpdatedAt: older},
+		{Word: "fornax", Context: "display_name", Action: "reject", UpdatedAt: newer},
+		{Word: "kerfuffle", Context: "default", Action: "flag", UpdatedAt: older},
+	})
+	want := []profanityWordResponse{
+		{Word: "fornax", Actions: map[profanity.Context]profanity.Action{"default": "mask", "display_name": "reject"}, UpdatedAt: newer},
+		{Word: "kerfuffle", Actions: map[profanity.Context]profanity.Action{"default": "flag"}, UpdatedAt: older},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("profanityWords() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCustomEmojisIn(t *testing.T) {
+	emojis := customEmojis{"blobcat": "https://cdn.example/blobcat.png", "party_parrot": "https://cdn.example/parrot.gif"}
+	tests := []struct {
+		name string
+		body string
+		want map[string]string
+	}{
+		{name: "none", body: "plain text", want: nil},
+		{name: "known", body: "hi :blobcat:", want: map[string]string{"blobcat": "https://cdn.example/blobcat.png"}},
+		{name: "adjacent and repeated", body: ":blobcat::party_parrot: :blobcat:", want: map[string]string{
+			"blobcat":      "https://cdn.example/blobcat.png",
+			"party_parrot": "https://cdn.example/parrot.gif",
+		}},
+		{name: "unknown", body: ":nope: at 10:30:00", want: nil},
+		{name: "case sensitive", body: ":BlobCat:", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emojis.in(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("in(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+type staticProfanityRules []database.ProfanityRule
+
+func (s staticProfanityRules) ListProfanityRules(context.Context) ([]database.ProfanityRule, error) {
+	return s, nil
+}
+
+func TestReadableBody(t *testing.T) {
+	cfg := &apiConfig{profanityRules: profanity.NewStore(staticProfanityRules{
+		{Word: "fornax", Context: "default", Action: "mask"},
+	}, time.Minute)}
+	tests := []struct {
+		name     string
+		body     string
+		filtered bool
+		want     string
+	}{
+		{name: "unfiltered is cleaned", body: "Fornax!", want: "****!"},
+		{name: "filtered is stored as is", body: "fornax", filtered: true, want: "fornax"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.readableBody(context.Background(), tt.body, tt.filtered); got != tt.want {
+				t.Errorf("readableBody(%q, %v) = %q, want %q", tt.body, tt.filtered, got, tt.want)
+			}
+		})
+	}
+}
+
+type staticSettings []database.Setting
+
+func (s staticSettings) ListSettings(context.Context) ([]database.Setting, error) {
+	return s, nil
+}
+
+func TestAllowChirpPost(t *testing.T) {
+	tests := []struct {
+		name        string
+		perMinute   string
+		posts       int
+		wantAllowed int
+	}{
+		{name: "under the limit", perMinute: "3", posts: 3, wantAllowed: 3},
+		{name: "over the limit", perMinute: "2", posts: 5, wantAllowed: 2},
+		{name: "disabled", perMinute: "0", posts: 20, wantAllowed: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &apiConfig{
+				settings: settings.NewStore(staticSettings{{Key: settings.ChirpMaxPerMinute, Value: tt.perMinute}}, time.Minute),
+				counters: counter.NewMemory(),
+			}
+			userID := uuid.New()
+			allowed := 0
+			for range tt.posts {
+				rec := httptest.NewRecorder()
+				if cfg.allowChirpPost(rec, httptest.NewRequest("POST", "/api/chirps", nil), userID) {
+					allowed++
+				} else if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+					t.Errorf("rejected post: status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+				}
+			}
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed %d posts, want %d", allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestChirpQuota(t *testing.T) {
+	reset := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		quota         chirpQuota
+		wantExceeded  bool
+		wantRemaining string
+	}{
+		{name: "unused", quota: chirpQuota{limit: 10, used: 0, resetAt: reset}, wantRemaining: "10"},
+		{name: "last one", quota: chirpQuota{limit: 10, used: 9, resetAt: reset}, wantRemaining: "1"},
+		{name: "used up", quota: chirpQuota{limit: 10, used: 10, resetAt: reset}, wantExceeded: true, wantRemaining: "0"},
+		{name: "over after lowering", quota: chirpQuota{limit: 5, used: 8, resetAt: reset}, wantExceeded: true, wantRemaining: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quota.exceeded(); got != tt.wantExceeded {
+				t.Errorf("exceeded() = %v, want %v", got, tt.wantExceeded)
+			}
+			rec := httptest.NewRecorder()
+			tt.quota.setHeaders(rec)
+			if got := rec.Header().Get("X-Chirp-Quota-Remaining"); got != tt.wantRemaining {
+				t.Errorf("X-Chirp-Quota-Remaining = %q, want %q", got, tt.wantRemaining)
+			}
+			if got := rec.Header().Get("X-Chirp-Quota-Reset"); got != "1748779200" {
+				t.Errorf("X-Chirp-Quota-Reset = %q, want %q", got, "1748779200")
+			}
+		})
+	}
+}
+
+func TestSniffMediaType(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 600)
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "png", body: png, want: "image/png"},
+		{name: "gif", body: "GIF89a......", want: "image/gif"},
+		{name: "html", body: "<html><script>alert(1)</script></html>", want: "text/html"},
+		{name: "empty", body: "", want: "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, body, err := sniffMediaType(strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("sniffMediaType() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("content type = %q, want %q", got, tt.want)
+			}
+			rest, _ := io.ReadAll(body)
+			if string(rest) != tt.body {
+				t.Errorf("body after sniffing has %d bytes, want %d", len(rest), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestHandlerServeMedia(t *testing.T) {
+	cfg := &apiConfig{media: storage.Disk{Dir: t.TempDir()}}
+	ctx := context.Background()
+	cfg.media.Put(ctx, "chirps/1/a.png", strings.NewReader("png"), 3, "image/png")
+	cfg.media.Put(ctx, "chirps/1/b.html", strings.NewReader("<p>"), 3, "text/html")
+	tests := []struct {
+		key      string
+		wantCode int
+	}{
+		{key: "chirps/1/a.png", wantCode: http.StatusOK},
+		{key: "chirps/1/missing.png", wantCode: http.StatusNotFound},
+		{key: "chirps/1/b.html", wantCode: http.StatusNotFound},
+		{key: "chirps/../1/a.png", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/media/x", nil)
+			req.SetPathValue("key", tt.key)
+			rec := httptest.NewRecorder()
+			cfg.handlerServeMedia(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusOK {
+				if got := rec.Header().Get("Content-Type"); got != "image/png" {
+					t.Errorf("Content-Type = %q, want image/png", got)
+				}
+				if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+					t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerServeSignedMedia(t *testing.T) {
+	cfg := &apiConfig{
+		media:          storage.Disk{Dir: t.TempDir()},
+		mediaURLSecret: "secret",
+		mediaURLTTL:    time.Hour,
+		baseURL:        "https://chirpy.example",
+	}
+	ctx := context.Background()
+	cfg.media.Put(ctx, "chirps/1/a.png", strings.NewReader("png"), 3, "image/png")
+	cfg.media.Put(ctx, "avatars/1/a.png", strings.NewReader("png"), 3, "image/png")
+	req := httptest.NewRequest("GET", "/", nil)
+	signed, err := url.Parse(cfg.mediaURL(req, "chirps/1/a.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-time.Minute)
+	expiredQuery := url.Values{
+		"expires": {strconv.FormatInt(expired.Unix(), 10)},
+		"sig":     {auth.SignMediaURL("secret", "chirps/1/a.png", expired)},
+	}
+	if got := cfg.mediaURL(req, "avatars/1/a.png"); got != "https://chirpy.example/media/avatars/1/a.png" {
+		t.Errorf("mediaURL(avatar) = %q, want an unsigned URL", got)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		query    string
+		wantCode int
+	}{
+		{name: "signed", key: "chirps/1/a.png", query: signed.RawQuery, wantCode: http.StatusOK},
+		{name: "unsigned", key: "chirps/1/a.png", wantCode: http.StatusForbidden},
+		{name: "expired", key: "chirps/1/a.png", query: expiredQuery.Encode(), wantCode: http.StatusForbidden},
+		{name: "signed for another key", key: "chirps/1/b.png", query: signed.RawQuery, wantCode: http.StatusForbidden},
+		{name: "public avatar", key: "avatars/1/a.png", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/media/x?"+tt.query, nil)
+			req.SetPathValue("key", tt.key)
+			rec := httptest.NewRecorder()
+			cfg.handlerServeMedia(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.name == "signed" && !strings.HasPrefix(rec.Header().Get("Cache-Control"), "private, max-age=") {
+				t.Errorf("Cache-Control = %q, want a private one", rec.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestMayApproveAdminAction(t *testing.T) {
+	requester := uuid.New()
+	action := database.PendingAdminAction{RequestedBy: uuid.NullUUID{UUID: requester, Valid: true}}
+	tests := []struct {
+		name     string
+		platform string
+		action   database.PendingAdminAction
+		admin    uuid.UUID
+		want     bool
+	}{
+		{name: "second admin", platform: "prod", action: action, admin: uuid.New(), want: true},
+		{name: "requester", platform: "prod", action: action, admin: requester, want: false},
+		{name: "requester in dev", platform: "dev", action: action, admin: requester, want: true},
+		{name: "requester since purged", platform: "prod", action: database.PendingAdminAction{}, admin: requester, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PLATFORM", tt.platform)
+			if got := mayApproveAdminAction(tt.action, tt.admin); got != tt.want {
+				t.Errorf("mayApproveAdminAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMediaUpload(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100)
+	mp4 := "\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"
+	tests := []struct {
+		name     string
+		field    string
+		body     string
+		maxBytes int64
+		wantCode int
+		wantType string
+	}{
+		{name: "png avatar", field: "file", body: png, maxBytes: 1 << 20, wantType: "image/png"},
+		{name: "video avatar", field: "file", body: mp4, maxBytes: 1 << 20, wantCode: http.StatusUnsupportedMediaType},
+		{name: "too large", field: "file", body: strings.Repeat("x", 2<<20), maxBytes: 1 << 20, wantCode: http.StatusRequestEntityTooLarge},
+		{name: "wrong field", field: "image", body: png, maxBytes: 1 << 20, wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			part, _ := mw.CreateFormFile(tt.field, "upload.bin")
+			io.WriteString(part, tt.body)
+			mw.Close()
+			req := httptest.NewRequest("PUT", "/api/users/me/avatar", &buf)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			rec := httptest.NewRecorder()
+
+			upload, ok := readMediaUpload(rec, req, tt.maxBytes, avatarTypes)
+			if tt.wantCode != 0 {
+				if ok || rec.Code != tt.wantCode {
+					t.Fatalf("readMediaUpload() = %v with status %d, want status %d", ok, rec.Code, tt.wantCode)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("readMediaUpload() rejected the upload: %d %s", rec.Code, rec.Body)
+			}
+			defer upload.file.Close()
+			if upload.contentType != tt.wantType || upload.ext != avatarTypes[tt.wantType] {
+				t.Errorf("upload type = %q %q, want %q", upload.contentType, upload.ext, tt.wantType)
+			}
+			got, _ := io.ReadAll(upload.body)
+			if string(got) != tt.body || upload.size != int64(len(tt.body)) {
+				t.Errorf("upload body has %d bytes (size %d), want %d", len(got), upload.size, len(tt.body))
+			}
+		})
+	}
+}
+
+// recordingDB is an execOnlyDB that keeps the arguments of the last
+// statement, and reports rowsAffected for it.
+type recordingDB struct {
+	execOnlyDB
+	rowsAffected int64
+	args         []interface{}
+}
+
+func (d *recordingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.args = args
+	return driver.RowsAffected(d.rowsAffected), nil
+}
+
+func TestAppendEvent(t *testing.T) {
+	db := &recordingDB{rowsAffected: 1}
+	chirpID, userID := uuid.New(), uuid.New()
+	err := appendEvent(context.Background(), database.NewStore(db), eventChirpDeleted, aggregateChirp, chirpID, map[string]any{
+		"user_id": userID,
+		"reason":  "author",
+	})
+	if err != nil {
+		t.Fatalf("appendEvent() failed: %v", err)
+	}
+	if len(db.args) != 4 || db.args[0] != eventChirpDeleted || db.args[1] != aggregateChirp || db.args[2] != chirpID {
+		t.Fatalf("AppendEvent args = %v", db.args)
+	}
+	want := `{"reason":"author","user_id":"` + userID.String() + `"}`
+	if got := string(db.args[3].(json.RawMessage)); got != want {
+		t.Errorf("payload = %s, want %s", got, want)
+	}
+}
+
+func TestUpgradeUnknownUser(t *testing.T) {
+	db := &recordingDB{rowsAffected: 0}
+	err := upgradeUser(context.Background(), database.NewStore(db), uuid.New(), nil)
+	if !errors.Is(err, database.ErrUserNotFound) {
+		t.Errorf("upgradeUser() error = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestMakeVariants(t *testing.T) {
+	db := &recordingDB{rowsAffected: 1}
+	cfg := &apiConfig{media: storage.Disk{Dir: t.TempDir()}, database: database.NewStore(db)}
+	ctx := context.Background()
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 640, 480)))
+	cfg.media.Put(ctx, "chirps/1/a.png", bytes.NewReader(buf.Bytes()), int64(buf.Len()), "image/png")
+	cfg.media.Put(ctx, "chirps/1/b.png", strings.NewReader("not a png"), 9, "image/png")
+
+	tests := []struct {
+		name        string
+		media       database.ChirpMedium
+		wantStatus  string
+		wantVariant string
+	}{
+		{name: "image", media: database.ChirpMedium{StorageKey: "chirps/1/a.png", ContentType: "image/png"}, wantStatus: variantsDone, wantVariant: "chirps/1/a_thumb.jpg"},
+		{name: "undecodable", media: database.ChirpMedium{StorageKey: "chirps/1/b.png", ContentType: "image/png"}, wantStatus: variantsFailed},
+		{name: "missing", media: database.ChirpMedium{StorageKey: "chirps/1/c.png", ContentType: "image/png"}, wantStatus: variantsFailed},
+		{name: "video", media: database.ChirpMedium{StorageKey: "chirps/1/d.mp4", ContentType: "video/mp4"}, wantStatus: variantsSkipped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.args = nil
+			status, err := cfg.makeVariants(ctx, tt.media)
+			if err != nil {
+				t.Fatalf("makeVariants() failed: %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("makeVariants() = %q, want %q", status, tt.wantStatus)
+			}
+			if tt.wantVariant == "" {
+				if db.args != nil {
+					t.Errorf("makeVariants() saved a variant: %v", db.args)
+				}
+				return
+			}
+			// The web size is larger than the image, so only a thumbnail
+			// is made.
+			if len(db.args) != 7 || db.args[1] != "thumb" || db.args[2] != tt.wantVariant || db.args[4] != int32(320) || db.args[5] != int32(240) {
+				t.Fatalf("CreateChirpMediaVariant args = %v", db.args)
+			}
+			if _, err := cfg.media.Get(ctx, tt.wantVariant); err != nil {
+				t.Errorf("variant file: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 11, want: outboxMaxBackoff},
+		{attempts: 64, want: outboxMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempts); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+type recordingMailer struct {
+	sent []mail.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestDeliverOutbox(t *testing.T) {
+	var hookBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		hookBody = string(b)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	mailer := &recordingMailer{}
+	cfg := &apiConfig{mailer: mailer, breakers: breaker.NewRegistry(breaker.DefaultSettings)}
+
+	// Messages are queued through the same helpers handlers use, then
+	// read back the way the relay claims them.
+	db := &recordingDB{rowsAffected: 1}
+	queued := func(enqueue func(q *database.Store) error) database.Outbox {
+		if err := enqueue(database.NewStore(db)); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+		return database.Outbox{Kind: db.args[1].(string), Payload: db.args[2].(json.RawMessage)}
+	}
+	ctx := context.Background()
+	email := queued(func(q *database.Store) error {
+		return enqueueEmail(ctx, q, mail.Message{To: "a@example.com", Subject: "Hi", Body: "Hello"})
+	})
+	hook := queued(func(q *database.Store) error {
+		return enqueueWebhook(ctx, q, "test_webhook", srv.URL+"/ok", []byte(`{"a":1}`))
+	})
+	failing := queued(func(q *database.Store) error {
+		return enqueueWebhook(ctx, q, "test_webhook", srv.URL+"/fail", []byte(`{}`))
+	})
+
+	if err := cfg.deliverOutbox(ctx, email); err != nil {
+		t.Fatalf("deliverOutbox(email) failed: %v", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "a@example.com" || mailer.sent[0].Body != "Hello" {
+		t.Errorf("sent = %+v", mailer.sent)
+	}
+	if err := cfg.deliverOutbox(ctx, hook); err != nil {
+		t.Fatalf("deliverOutbox(webhook) failed: %v", err)
+	}
+	if hookBody != `{"a":1}` {
+		t.Errorf("webhook body = %q", hookBody)
+	}
+	if err := cfg.deliverOutbox(ctx, failing); err == nil {
+		t.Error("deliverOutbox() of a rejected webhook succeeded")
+	}
+	if err := cfg.deliverOutbox(ctx, database.Outbox{Kind: "push", Payload: json.RawMessage(`{}`)}); err == nil {
+		t.Error("deliverOutbox() of an unknown kind succeeded")
+	}
+}
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		want       search.Query
+	}{
+		{name: "default limit", url: "/api/search/chirps?q=+kafka+", want: search.Query{Kind: search.KindChirp, Text: "kafka", Limit: 20}},
+		{name: "limit", url: "/api/search/chirps?q=kafka&limit=100", want: search.Query{Kind: search.KindChirp, Text: "kafka", Limit: 100}},
+		{name: "missing q", url: "/api/search/chirps?q=+", wantStatus: http.StatusBadRequest},
+		{name: "limit too high", url: "/api/search/chirps?q=kafka&limit=101", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			got, ok := parseSearch(w, httptest.NewRequest(http.MethodGet, tt.url, nil), search.KindChirp)
+			if tt.wantStatus != 0 {
+				if ok || w.Code != tt.wantStatus {
+					t.Errorf("parseSearch() = %v, status %d, want status %d", ok, w.Code, tt.wantStatus)
+				}
+				return
+			}
+			if !ok || got != tt.want {
+				t.Errorf("parseSearch() = %+v, %v, want %+v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentHighlight(t *testing.T) {
+	tests := []struct {
+		highlighted, text string
+		want              bool
+	}{
+		{highlighted: "<mark>Kafka</mark> &amp; co", text: "Kafka & co", want: true},
+		{highlighted: "<mark>Kafka</mark> on the shore", text: "Edited", want: false},
+		{highlighted: "", text: "", want: false},
+	}
+	for _, tt := range tests {
+		if _, got := currentHighlight(tt.highlighted, tt.text); got != tt.want {
+			t.Errorf("currentHighlight(%q, %q) = %v, want %v", tt.highlighted, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestShortenLinks(t *testing.T) {
+	body := "see https://example.com/a"
+	cfg := &apiConfig{}
+	got, shortLinks, err := cfg.shortenLinks(body)
+	if err != nil || got != body || len(shortLinks) != 0 {
+		t.Errorf("shortenLinks() without BASE_URL = %q, %v, %v, want the body unchanged", got, shortLinks, err)
+	}
+
+	cfg.baseURL = "https://chirpy.example"
+	got, shortLinks, err = cfg.shortenLinks(body)
+	if err != nil || len(shortLinks) != 1 || !strings.HasPrefix(got, "see https://chirpy.example/l/") {
+		t.Errorf("shortenLinks() = %q, %v, %v", got, shortLinks, err)
+	}
+}
+
+func TestCredentialRotationEmail(t *testing.T) {
+	cfg := &apiConfig{settings: settings.NewStore(staticSettings{}, time.Minute)}
+	user := database.User{Email: "a@example.com"}
+	rotated := rotateCredentialsResponse{RefreshTokensRevoked: 2, RotatedAt: time.Now()}
+
+	msg := cfg.credentialRotationEmail(context.Background(), user, rotated)
+	if strings.Contains(msg.Body, "Apps") {
+		t.Errorf("email without revoked app tokens mentions apps: %q", msg.Body)
+	}
+	rotated.OAuthTokensRevoked = 1
+	msg = cfg.credentialRotationEmail(context.Background(), user, rotated)
+	if !strings.Contains(msg.Body, "approved again") {
+		t.Errorf("email doesn't say connected apps were signed out: %q", msg.Body)
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaBytes      = 8 << 20
+	maxMediaPerChirp   = 4
+	mediaPurgeInterval = 10 * time.Minute
+	mediaPurgeBatch    = 100
+	// defaultMediaURLTTL is how long signed media URLs work unless
+	// MEDIA_URL_TTL_SECONDS says otherwise.
+	defaultMediaURLTTL = time.Hour
+)
+
+// mediaTypes are the content types chirps can carry, with the extension
+// stored files get. The type is sniffed from the file rather than trusted
+// from the upload.
+var mediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"video/mp4":  ".mp4",
+}
+
+// configureMedia picks where uploaded files are kept. The default is the
+// MEDIA_DIR directory (./media) on local disk, which only suits a single
+// instance; MEDIA_STORE=s3 uses an S3-compatible bucket.
+//
+// With MEDIA_URL_SECRET set, chirp media is only served through signed
+// URLs that expire after MEDIA_URL_TTL_SECONDS. The bucket should then be
+// private, since the app serves those files itself.
+func (cfg *apiConfig) configureMedia() error {
+	cfg.mediaURLSecret = os.Getenv("MEDIA_URL_SECRET")
+	cfg.mediaURLTTL = defaultMediaURLTTL
+	if raw := os.Getenv("MEDIA_URL_TTL_SECONDS"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		// URLs expire on whole minutes, so shorter lifetimes could be
+		// over before they're used.
+		if err != nil || seconds < 120 {
+			return fmt.Errorf("MEDIA_URL_TTL_SECONDS must be a number of seconds, at least 120")
+		}
+		cfg.mediaURLTTL = time.Duration(seconds) * time.Second
+	}
+
+	switch store := os.Getenv("MEDIA_STORE"); store {
+	case "", "disk":
+		cfg.media = storage.Disk{Dir: cmp.Or(os.Getenv("MEDIA_DIR"), "media")}
+	case "s3":
+		s3 := storage.S3{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          cmp.Or(os.Getenv("S3_REGION"), "us-east-1"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("MEDIA_PUBLIC_URL"),
+			Client:          &http.Client{Timeout: time.Minute},
+		}
+		if s3.Endpoint == "" || s3.Bucket == "" {
+			return fmt.Errorf("MEDIA_STORE=s3 needs S3_ENDPOINT and S3_BUCKET")
+		}
+		cfg.media = s3
+	default:
+		return fmt.Errorf("unknown MEDIA_STORE %q", store)
+	}
+	return nil
+}
+
+type mediaResponse struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	// Variants are smaller renditions by name, added once the variants
+	// job has processed the file.
+	Variants map[string]mediaVariantResponse `json:"variants,omitempty"`
+}
+
+// signedMediaKey reports whether key is only served through signed URLs
+// once MEDIA_URL_SECRET is set. Avatars stay public: their URLs are stored
+// with the user and shown on profiles.
+func signedMediaKey(key string) bool {
+	return strings.HasPrefix(key, "chirps/")
+}
+
+// mediaURL is where clients fetch a stored file: the store's own URL if it
+// has one, otherwise the app's /media/ route. Files that need signed URLs
+// always go through the app.
+func (cfg *apiConfig) mediaURL(r *http.Request, key string) string {
+	if cfg.mediaURLSecret != "" && signedMediaKey(key) {
+		// Expiring on whole minutes keeps URLs stable between requests,
+		// so clients can cache them.
+		expires := time.Now().Add(cfg.mediaURLTTL).Truncate(time.Minute)
+		query := url.Values{
+			"expires": {strconv.FormatInt(expires.Unix(), 10)},
+			"sig":     {auth.SignMediaURL(cfg.mediaURLSecret, key, expires)},
+		}
+		return cfg.publicBaseURL(r) + "/media/" + key + "?" + query.Encode()
+	}
+	if u := cfg.media.URL(key); u != "" {
+		return u
+	}
+	return cfg.publicBaseURL(r) + "/media/" + key
+}
+
+func (cfg *apiConfig) newMediaResponse(r *http.Request, m database.ChirpMedium) mediaResponse {
+	return mediaResponse{
+		ID:          m.ID,
+		URL:         cfg.mediaURL(r, m.StorageKey),
+		ContentType: m.ContentType,
+		SizeBytes:   m.SizeBytes,
+	}
+}
+
+// chirpMedia maps chirp IDs to their attachments.
+type chirpMedia map[uuid.UUID][]mediaResponse
+
+// chirpMedia loads the attachments of chirpIDs in one query.
+func (cfg *apiConfig) chirpMedia(r *http.Request, chirpIDs []uuid.UUID) (chirpMedia, error) {
+	media := chirpMedia{}
+	if len(chirpIDs) == 0 {
+		return media, nil
+	}
+	rows, err := cfg.database.ListChirpMediaByChirps(r.Context(), chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	mediaIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		mediaIDs = append(mediaIDs, row.ID)
+	}
+	variants, err := cfg.mediaVariantsByID(r.Context(), mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		resp := cfg.newMediaResponse(r, row)
+		for _, v := range variants[row.ID] {
+			if resp.Variants == nil {
+				resp.Variants = map[string]mediaVariantResponse{}
+			}
+			resp.Variants[v.Name] = mediaVariantResponse{
+				URL:    cfg.mediaURL(r, v.StorageKey),
+				Width:  v.Width,
+				Height: v.Height,
+			}
+		}
+		media[row.ChirpID.UUID] = append(media[row.ChirpID.UUID], resp)
+	}
+	return media, nil
+}
+
+// sniffMediaType reads the start of body to find its content type. It
+// returns the reader to use from then on, with the sniffed bytes put back.
+func sniffMediaType(body io.Reader) (string, io.Reader, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return contentType, io.MultiReader(bytes.NewReader(head), body), nil
+}
+
+// mediaUpload is a file sent as the "file" field of a multipart form.
+type mediaUpload struct {
+	file multipart.File
+	// body reads the whole file, including the bytes sniffed for its type.
+	body        io.Reader
+	size        int64
+	contentType string
+	ext         string
+}
+
+// readMediaUpload reads an upload of at most maxBytes whose sniffed type is
+// one of types, which maps content types to file extensions. Otherwise it
+// answers the request itself and returns false. The caller closes file.
+func readMediaUpload(w http.ResponseWriter, r *http.Request, maxBytes int64, types map[string]string) (mediaUpload, bool) {
+	tooLargeMsg := fmt.Sprintf("Files can be at most %d MiB", maxBytes>>20)
+	// The limit leaves room for the multipart headers around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+	file, header, err := r.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, tooLargeMsg, err)
+		return mediaUpload{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Send the file as the \"file\" field of a multipart form", err)
+		return mediaUpload{}, false
+	}
+	if header.Size > maxBytes {
+		file.Close()
+		respondWithError(w, http.StatusRequestEntityTooLarge, tooLargeMsg, nil)
+		return mediaUpload{}, false
+	}
+	contentType, body, err := sniffMediaType(file)
+	if err != nil {
+		file.Close()
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", err)
+		return mediaUpload{}, false
+	}
+	ext, ok := types[contentType]
+	if !ok {
+		file.Close()
+		respondWithError(w, http.StatusUnsupportedMediaType, "Files of type "+contentType+" aren't accepted here", nil)
+		return mediaUpload{}, false
+	}
+	return mediaUpload{file: file, body: body, size: header.Size, contentType: contentType, ext: ext}, true
+}
+
+// handlerUploadChirpMedia attaches a file, sent as the "file" field of a
+// multipart form, to one of the caller's chirps.
+func (cfg *apiConfig) handlerUploadChirpMedia(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	token, err := cfg.accessToken(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
+		return
+	}
+	userID, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeChirpsWrite)
+	if err != nil {
+		respondWithTokenError(w, err)
+		return
+	}
+	message, err := cfg.database.GetMessageByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithDBError(w, "Couldn't get chirp", err)
+		return
+	}
+	if message.Status == chirpStatusRejected {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+	if message.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can only attach media to your own chirps", nil)
+		return
+	}
+	chirp := uuid.NullUUID{UUID: message.ID, Valid: true}
+	count, err := cfg.database.CountChirpMedia(r.Context(), chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't attach media", err)
+		return
+	}
+	if count >= maxMediaPerChirp {
+		respondWithError(w, http.StatusBadRequest, "A chirp can't have more than 4 attachments", nil)
+		return
+	}
+
+	upload, ok := readMediaUpload(w, r, maxMediaBytes, mediaTypes)
+	if !ok {
+		return
+	}
+	defer upload.file.Close()
+
+	mediaID := uuid.New()
+	key := "chirps/" + message.ID.String() + "/" + mediaID.String() + upload.ext
+	if err := cfg.media.Put(r.Context(), key, upload.body, upload.size, upload.contentType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store file", err)
+		return
+	}
+	media, err := cfg.database.CreateChirpMedia(r.Context(), database.CreateChirpMediaParams{
+		ID:          mediaID,
+		ChirpID:     chirp,
+		StorageKey:  key,
+		ContentType: upload.contentType,
+		SizeBytes:   upload.size,
+	})
+	if err != nil {
+		if err := cfg.media.Delete(context.Background(), key); err != nil {
+			log.Printf("Error removing unsaved media %s: %s", key, err)
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't attach media", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, cfg.newMediaResponse(r, media))
+}
+
+// handlerServeMedia serves stored files for stores without URLs of their
+// own, and files that need signed URLs. Keys are never reused, so public
+// responses can be cached for good; signed ones until the URL expires.
+func (cfg *apiConfig) handlerServeMedia(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	contentType, _, _ := mime.ParseMediaType(mime.TypeByExtension(path.Ext(key)))
+	if _, ok := mediaTypes[contentType]; !ok || !storage.ValidKey(key) {
+		respondWithError(w, http.StatusNotFound, "Media not found", nil)
+		return
+	}
+	cacheControl := "public, max-age=31536000, immutable"
+	if cfg.mediaURLSecret != "" && signedMediaKey(key) {
+		query := r.URL.Query()
+		now := time.Now()
+		if !auth.VerifyMediaURL(cfg.mediaURLSecret, key, query.Get("expires"), query.Get("sig"), now) {
+			respondWithError(w, http.StatusForbidden, "Invalid or expired media URL", nil)
+			return
+		}
+		expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+		cacheControl = "private, max-age=" + strconv.FormatInt(expires-now.Unix(), 10)
+	}
+	body, err := cfg.media.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Media not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
+		return
+	}
+	defer body.Close()
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Error serving media %s: %s", key, err)
+	}
+}
+
+// runMediaPurge removes the files of deleted chirps and of replaced or
+// purged avatars. Their rows stay behind with no owner until the file is
+// gone, so a failed delete is retried on the next run.
+func (cfg *apiConfig) runMediaPurge(ctx context.Context) {
+	ticker := time.NewTicker(mediaPurgeInterval)
+	defer ticker.Stop()
+	for {
+		cfg.purgeOrphanedMedia(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) purgeOrphanedMedia(ctx context.Context) {
+	purged := 0
+	media, err := cfg.database.ListOrphanedChirpMedia(ctx, mediaPurgeBatch)
+	if err != nil {
+		log.Printf("Error listing orphaned media: %s", err)
+	}
+	mediaIDs := make([]uuid.UUID, 0, len(media))
+	for _, m := range media {
+		mediaIDs = append(mediaIDs, m.ID)
+	}
+	variants, err := cfg.mediaVariantsByID(ctx, mediaIDs)
+	if err != nil {
+		log.Printf("Error listing orphaned media variants: %s", err)
+		media = nil
+	}
+	for _, m := range media {
+		// Variant rows go with the media row, so their files go first.
+		if !cfg.purgeVariantFiles(ctx, variants[m.ID]) {
+			continue
+		}
+		if cfg.purgeStoredFile(ctx, m.StorageKey, func() error { return cfg.database.DeleteChirpMedia(ctx, m.ID) }) {
+			purged++
+		}
+	}
+	avatars, err := cfg.database.ListOrphanedUserAvatars(ctx, mediaPurgeBatch)
+	if err != nil {
+		log.Printf("Error listing orphaned avatars: %s", err)
+	}
+	for _, a := range avatars {
+		if cfg.purgeStoredFile(ctx, a.StorageKey, func() error { return cfg.database.DeleteUserAvatar(ctx, a.ID) }) {
+			purged++
+		}
+	}
+	if purged > 0 {
+		log.Printf("Purged %d orphaned media files", purged)
+	}
+}
+
+// purgeStoredFile deletes the file at key, then its row with deleteRow.
+func (cfg *apiConfig) purgeStoredFile(ctx context.Context, key string, deleteRow func() error) bool {
+	if err := cfg.media.Delete(ctx, key); err != nil {
+		log.Printf("Error deleting media %s: %s", key, err)
+		return false
+	}
+	if err := deleteRow(); err != nil {
+		log.Printf("Error deleting media row for %s: %s", key, err)
+		return false
+	}
+	return true
+}
+
+// purgeVariantFiles deletes the files of variants, reporting whether all
+// of them are gone.
+func (cfg *apiConfig) purgeVariantFiles(ctx context.Context, variants []database.ChirpMediaVariant) bool {
+	for _, v := range variants {
+		if err := cfg.media.Delete(ctx, v.StorageKey); err != nil {
+			log.Printf("Error deleting media %s: %s", v.StorageKey, err)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/imaging"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	mediaVariantsInterval = 15 * time.Second
+	mediaVariantsBatch    = 20
+
+	variantsDone    = "done"
+	variantsSkipped = "skipped"
+	variantsFailed  = "failed"
+)
+
+// mediaVariants are the renditions made of each chirp image, by the size
+// of their longest side. Images already that small don't get one.
+var mediaVariants = []struct {
+	name string
+	size int
+}{
+	{"thumb", 320},
+	{"web", 1280},
+}
+
+// resizableTypes are the media types imaging can decode. Other types keep
+// only their original.
+var resizableTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+type mediaVariantResponse struct {
+	URL    string `json:"url"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+}
+
+// variantKey is where the named rendition of the file at key is stored,
+// next to the original.
+func variantKey(key, name, ext string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + name + ext
+}
+
+// runMediaVariants makes renditions of newly uploaded images. Uploads
+// answer before their variants exist; clients fall back to the original
+// until they appear.
+func (cfg *apiConfig) runMediaVariants(ctx context.Context) {
+	ticker := time.NewTicker(mediaVariantsInterval)
+	defer ticker.Stop()
+	for {
+		cfg.makePendingVariants(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) makePendingVariants(ctx context.Context) {
+	media, err := cfg.database.ListPendingChirpMedia(ctx, mediaVariantsBatch)
+	if err != nil {
+		log.Printf("Error listing media waiting for variants: %s", err)
+		return
+	}
+	for _, m := range media {
+		status, err := cfg.makeVariants(ctx, m)
+		if err != nil {
+			// Storage and database errors are retried on the next run.
+			log.Printf("Error making variants of media %s: %s", m.ID, err)
+			continue
+		}
+		err = cfg.database.SetChirpMediaVariantsStatus(ctx, database.SetChirpMediaVariantsStatusParams{
+			ID:             m.ID,
+			VariantsStatus: status,
+		})
+		if err != nil {
+			log.Printf("Error updating variants status of media %s: %s", m.ID, err)
+		}
+	}
+}
+
+// makeVariants stores the renditions of m and returns its new variants
+// status. Files that don't decode are marked failed rather than retried.
+func (cfg *apiConfig) makeVariants(ctx context.Context, m database.ChirpMedium) (string, error) {
+	if !resizableTypes[m.ContentType] {
+		return variantsSkipped, nil
+	}
+	body, err := cfg.media.Get(ctx, m.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return variantsFailed, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	src, err := imaging.Decode(body)
+	body.Close()
+	if err != nil {
+		log.Printf("Couldn't decode media %s: %s", m.ID, err)
+		return variantsFailed, nil
+	}
+	for _, v := range mediaVariants {
+		img, resized := imaging.Fit(src, v.size)
+		if !resized {
+			continue
+		}
+		var buf bytes.Buffer
+		contentType, err := imaging.Encode(&buf, img)
+		if err != nil {
+			return "", err
+		}
+		key := variantKey(m.StorageKey, v.name, mediaTypes[contentType])
+		if err := cfg.media.Put(ctx, key, bytes.NewReader(buf.Bytes()), int64(buf.Len()), contentType); err != nil {
+			return "", err
+		}
+		err = cfg.database.CreateChirpMediaVariant(ctx, database.CreateChirpMediaVariantParams{
+			MediaID:     m.ID,
+			Name:        v.name,
+			StorageKey:  key,
+			ContentType: contentType,
+			Width:       int32(img.Bounds().Dx()),
+			Height:      int32(img.Bounds().Dy()),
+			SizeBytes:   int64(buf.Len()),
+		})
+		if err != nil {
+			// Most likely the media was purged meanwhile.
+			if err := cfg.media.Delete(context.Background(), key); err != nil {
+				log.Printf("Error removing unsaved variant %s: %s", key, err)
+			}
+			return "", err
+		}
+	}
+	return variantsDone, nil
+}
+
+// mediaVariantsByID loads the renditions of mediaIDs in one query.
+func (cfg *apiConfig) mediaVariantsByID(ctx context.Context, mediaIDs []uuid.UUID) (map[uuid.UUID][]database.ChirpMediaVariant, error) {
+	variants := map[uuid.UUID][]database.ChirpMediaVariant{}
+	if len(mediaIDs) == 0 {
+		return variants, nil
+	}
+	rows, err := cfg.database.ListChirpMediaVariants(ctx, mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		variants[row.MediaID] = append(variants[row.MediaID], row)
+	}
+	return variants, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+)
+
+const (
+	chirpStatusPending   = "pending"
+	chirpStatusPublished = "published"
+	chirpStatusRejected  = "rejected"
+)
+
+// initialChirpStatus decides whether a new chirp goes live immediately.
+// Chirps flagged by the profanity filter always wait for a moderator, and
+// chirps from accounts still in probation are held unless the automated
+// check clears them.
+func initialChirpStatus(accountCreatedAt time.Time, probation time.Duration, body string, profanityAction profanity.Action) string {
+	if profanityAction == profanity.ActionFlag {
+		return chirpStatusPending
+	}
+	if probation <= 0 || time.Since(accountCreatedAt) >= probation {
+		return chirpStatusPublished
+	}
+	if autoModerationClears(body, profanityAction) {
+		return chirpStatusPublished
+	}
+	return chirpStatusPending
+}
+
+// autoModerationClears is the automated check for chirps from accounts in
+// probation: anything with profanity or links waits for a moderator.
+func autoModerationClears(body string, profanityAction profanity.Action) bool {
+	if profanityAction != profanity.ActionNone {
+		return false
+	}
+	lower := strings.ToLower(body)
+	return !strings.Contains(lower, "http://") && !strings.Contains(lower, "https://") && !strings.Contains(lower, "www.")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMutedWords        = 100
+	maxMutedPhraseLength = 100
+)
+
+type mutedWordResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Phrase    string    `json:"phrase"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// normalizeMutedPhrase lowercases a phrase and collapses its whitespace, so
+// "Spoilers" and " spoilers " are the same mute.
+func normalizeMutedPhrase(phrase string) string {
+	return strings.ToLower(strings.Join(strings.Fields(phrase), " "))
+}
+
+// mutedWords are a user's normalized muted phrases.
+type mutedWords []string
+
+// matches reports whether body contains any of the phrases as whole words,
+// ignoring case, so muting "cat" hides "my cat!" and "#cat" but not
+// "category".
+func (m mutedWords) matches(body string) bool {
+	if len(m) == 0 {
+		return false
+	}
+	body = normalizeMutedPhrase(body)
+	for _, phrase := range m {
+		for i := 0; i < len(body); {
+			j := strings.Index(body[i:], phrase)
+			if j < 0 {
+				break
+			}
+			start, end := i+j, i+j+len(phrase)
+			before, _ := utf8.DecodeLastRuneInString(body[:start])
+			after, _ := utf8.DecodeRuneInString(body[end:])
+			if !isWordRune(before) && !isWordRune(after) {
+				return true
+			}
+			_, size := utf8.DecodeRuneInString(body[start:])
+			i = start + size
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// viewerMutes loads the phrases viewer has muted. Anonymous viewers have
+// none.
+func (cfg *apiConfig) viewerMutes(ctx context.Context, viewer uuid.UUID) (mutedWords, error) {
+	if viewer == uuid.Nil {
+		return nil, nil
+	}
+	rows, err := cfg.database.ListMutedWords(ctx, viewer)
+	if err != nil {
+		return nil, err
+	}
+	muted := make(mutedWords, 0, len(rows))
+	for _, row := range rows {
+		muted = append(muted, row.Phrase)
+	}
+	return muted, nil
+}
+
+func (cfg *apiConfig) handlerListMutes(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	rows, err := cfg.database.ListMutedWords(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get muted words", err)
+		return
+	}
+	var entries []mutedWordResponse
+	for _, row := range rows {
+		entries = append(entries, mutedWordResponse{ID: row.ID, Phrase: row.Phrase, CreatedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerCreateMute(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Phrase string `json:"phrase"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	phrase := normalizeMutedPhrase(params.Phrase)
+	if phrase == "" {
+		respondWithError(w, http.StatusBadRequest, "Phrase is required", nil)
+		return
+	}
+	if utf8.RuneCountInString(phrase) > maxMutedPhraseLength {
+		respondWithError(w, http.StatusBadRequest, "Phrase is too long", nil)
+		return
+	}
+	existing, err := cfg.database.ListMutedWords(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute phrase", err)
+		return
+	}
+	if len(existing) >= maxMutedWords {
+		respondWithError(w, http.StatusBadRequest, "You can't mute more than 100 phrases", nil)
+		return
+	}
+	muted, err := cfg.database.CreateMutedWord(r.Context(), database.CreateMutedWordParams{
+		UserID: userID,
+		Phrase: phrase,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute phrase", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, mutedWordResponse{ID: muted.ID, Phrase: muted.Phrase, CreatedAt: muted.CreatedAt})
+}
+
+func (cfg *apiConfig) handlerDeleteMute(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	muteID, err := uuid.Parse(r.PathValue("muteID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid mute ID", err)
+		return
+	}
+	deleted, err := cfg.database.DeleteMutedWord(r.Context(), database.DeleteMutedWordParams{
+		ID:     muteID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete muted word", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Muted word not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
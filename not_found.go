@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	apiPrefix = "/api/"
+	// maxRouteHints is how many similar routes a 404 suggests.
+	maxRouteHints = 3
+	// maxRouteHintDistance is the largest edit distance between the
+	// requested path and a route that is still worth suggesting.
+	maxRouteHintDistance = 3
+)
+
+// handlerAPIFallback answers /api requests that no route matched. The
+// mux's own 404 and 405 are plain text, and a catch-all pattern also
+// shadows the mux's 405, so both cases are worked out here from the route
+// table.
+func handlerAPIFallback(routes []route) http.HandlerFunc {
+	type apiRoute struct {
+		method string
+		path   string
+	}
+	var api []apiRoute
+	for _, rt := range routes {
+		method, path, ok := strings.Cut(rt.pattern, " ")
+		if !ok || !strings.HasPrefix(path, apiPrefix) {
+			continue
+		}
+		api = append(api, apiRoute{method: method, path: path})
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		type methodNotAllowed struct {
+			Error          string   `json:"error"`
+			AllowedMethods []string `json:"allowed_methods"`
+		}
+		type notFound struct {
+			Error      string   `json:"error"`
+			DidYouMean []string `json:"did_you_mean,omitempty"`
+		}
+
+		var allowed []string
+		for _, rt := range api {
+			if matchRoutePath(rt.path, r.URL.Path) && !slices.Contains(allowed, rt.method) {
+				allowed = append(allowed, rt.method)
+			}
+		}
+		if len(allowed) > 0 {
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			respondWithJSON(w, http.StatusMethodNotAllowed, methodNotAllowed{
+				Error:          "Method " + r.Method + " is not allowed on this route",
+				AllowedMethods: allowed,
+			})
+			return
+		}
+
+		type hint struct {
+			route    string
+			distance int
+		}
+		var hints []hint
+		for _, rt := range api {
+			d := routePathDistance(rt.path, r.URL.Path)
+			if d > maxRouteHintDistance {
+				continue
+			}
+			route := rt.method + " " + rt.path
+			if !slices.ContainsFunc(hints, func(h hint) bool { return h.route == route }) {
+				hints = append(hints, hint{route: route, distance: d})
+			}
+		}
+		sort.SliceStable(hints, func(i, j int) bool { return hints[i].distance < hints[j].distance })
+		resp := notFound{Error: "No route matches " + r.URL.Path}
+		for _, h := range hints[:min(len(hints), maxRouteHints)] {
+			resp.DidYouMean = append(resp.DidYouMean, h.route)
+		}
+		respondWithJSON(w, http.StatusNotFound, resp)
+	}
+}
+
+// matchRoutePath reports whether path matches a route path, where a
+// {name} segment matches any one segment.
+func matchRoutePath(pattern, path string) bool {
+	ps := strings.Split(pattern, "/")
+	segs := strings.Split(path, "/")
+	if len(ps) != len(segs) {
+		return false
+	}
+	for i, p := range ps {
+		if !isWildcardSegment(p) && p != segs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// routePathDistance is the edit distance between path and a route path.
+// When both have the same number of segments, wildcards take the value
+// of the corresponding path segment, so IDs don't count as differences.
+func routePathDistance(pattern, path string) int {
+	ps := strings.Split(pattern, "/")
+	segs := strings.Split(path, "/")
+	if len(ps) == len(segs) {
+		for i, p := range ps {
+			if isWildcardSegment(p) {
+				ps[i] = segs[i]
+			}
+		}
+	}
+	return levenshtein(strings.Join(ps, "/"), path)
+}
+
+func isWildcardSegment(s string) bool {
+	return strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/google/uuid"
+)
+
+// Third-party apps get access through the authorization code flow with
+// PKCE. Their access tokens are opaque, stored hashed and limited to the
+// scopes the user agreed to; first-party JWTs keep full access. Only the
+// user's first-party session can approve a request on the consent screen:
+// the access token cookie or a password typed into the form, never a
+// bearer token.
+const (
+	oauthCSRFCookie          = "chirpy_oauth_csrf"
+	oauthCodeLifetime        = 10 * time.Minute
+	oauthAccessTokenLifetime = time.Hour
+	oauthMaxRedirectURIs     = 10
+)
+
+var errInsufficientScope = errors.New("token doesn't grant the required scope")
+
+// userForToken resolves the user behind a bearer token. OAuth tokens must
+// carry scope; Chirpy's own JWTs are not scoped.
+func (cfg *apiConfig) userForToken(ctx context.Context, token, scope string) (uuid.UUID, error) {
+	user, err := cfg.authenticateJWT(ctx, token)
+	if err == nil {
+		if user.PasswordChangeRequired {
+			return uuid.Nil, errPasswordChangeRequired
+		}
+		return user.ID, nil
+	}
+	if !errors.Is(err, errInvalidAccessToken) {
+		return uuid.Nil, err
+	}
+	grant, err := cfg.database.GetOAuthAccessToken(ctx, auth.HashToken(token))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if !oauthserver.HasScope(grant.Scope, scope) {
+		return uuid.Nil, fmt.Errorf("%w %s", errInsufficientScope, scope)
+	}
+	return grant.UserID, nil
+}
+
+func respondWithTokenError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInsufficientScope) {
+		respondWithError(w, http.StatusForbidden, "Token doesn't allow this action", err)
+		return
+	}
+	if errors.Is(err, errPasswordChangeRequired) {
+		respondWithError(w, http.StatusForbidden, "You must change your password first", err)
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+}
+
+type oauthClientResponse struct {
+	ID           uuid.UUID `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+	Secret       string    `json:"client_secret,omitempty"`
+}
+
+func newOAuthClientResponse(c database.OauthClient) oauthClientResponse {
+	return oauthClientResponse{
+		ID:           c.ID,
+		Name:         c.Name,
+		RedirectURIs: c.RedirectUris,
+		Confidential: c.SecretHash.Valid,
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+func (cfg *apiConfig) handlerCreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Confidential bool     `json:"confidential"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Name is required", nil)
+		return
+	}
+	if len(params.RedirectURIs) == 0 || len(params.RedirectURIs) > oauthMaxRedirectURIs {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Between 1 and %d redirect URIs are required", oauthMaxRedirectURIs), nil)
+		return
+	}
+	for _, uri := range params.RedirectURIs {
+		if err := oauthserver.ValidRedirectURI(uri); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	var secret string
+	secretHash := sql.NullString{}
+	if params.Confidential {
+		secret, err = auth.MakeSessionToken()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create client secret", err)
+			return
+		}
+		secretHash = sql.NullString{String: auth.HashToken(secret), Valid: true}
+	}
+	client, err := cfg.database.CreateOAuthClient(r.Context(), database.CreateOAuthClientParams{
+		OwnerID:      userID,
+		Name:         params.Name,
+		SecretHash:   secretHash,
+		RedirectUris: params.RedirectURIs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create client", err)
+		return
+	}
+	resp := newOAuthClientResponse(client)
+	resp.Secret = secret
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) handlerListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	clients, err := cfg.database.ListOAuthClientsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get clients", err)
+		return
+	}
+	var entries []oauthClientResponse
+	for _, client := range clients {
+		entries = append(entries, newOAuthClientResponse(client))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerDeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	clientID, err := uuid.Parse(r.PathValue("clientID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid client ID", err)
+		return
+	}
+	n, err := cfg.database.DeleteOAuthClient(r.Context(), database.DeleteOAuthClientParams{
+		ID:      clientID,
+		OwnerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete client", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Client not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type oauthAuthorizeRequest struct {
+	ClientID      uuid.UUID
+	RedirectURI   string
+	Scope         string
+	State         string
+	CodeChallenge string
+}
+
+type oauthConsentData struct {
+	ClientName    string
+	ClientID      string
+	RedirectURI   string
+	Scope         string
+	Scopes        []string
+	State         string
+	CodeChallenge string
+	CSRFToken     string
+	PasswordLogin bool
+	Error         string
+}
+
+// parseAuthorizeRequest validates an authorization request. Problems with
+// the client or redirect URI come back as plain errors and must be shown to
+// the user; anything else is an *oauthserver.Error to send to the client.
+func (cfg *apiConfig) parseAuthorizeRequest(ctx context.Context, form url.Values) (oauthAuthorizeRequest, database.OauthClient, error) {
+	req := oauthAuthorizeRequest{
+		RedirectURI:   form.Get("redirect_uri"),
+		State:         form.Get("state"),
+		CodeChallenge: form.Get("code_challenge"),
+	}
+	clientID, err := uuid.Parse(form.Get("client_id"))
+	if err != nil {
+		return req, database.OauthClient{}, errors.New("unknown client")
+	}
+	req.ClientID = clientID
+	client, err := cfg.database.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return req, client, errors.New("unknown client")
+	}
+	if !oauthserver.MatchRedirectURI(client.RedirectUris, req.RedirectURI) {
+		return req, client, errors.New("the redirect URI isn't registered for this client")
+	}
+
+	if form.Get("response_type") != oauthserver.ResponseTypeCode {
+		return req, client, &oauthserver.Error{Code: oauthserver.ErrUnsupportedResponseType}
+	}
+	scopes, err := oauthserver.ParseScope(form.Get("scope"))
+	if err != nil {
+		return req, client, err
+	}
+	req.Scope = oauthserver.FormatScope(scopes)
+	if form.Get("code_challenge_method") != oauthserver.ChallengeMethodS256 || !oauthserver.ValidCodeChallenge(req.CodeChallenge) {
+		return req, client, &oauthserver.Error{Code: oauthserver.ErrInvalidRequest, Description: "an S256 code_challenge is required"}
+	}
+	return req, client, nil
+}
+
+func setOAuthCookie(w http.ResponseWriter, name, value string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/oauth",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   os.Getenv("PLATFORM") != "dev",
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// consentingUser returns the user signed in to Chirpy's own frontend in
+// this browser, from the access token cookie, or uuid.Nil. The
+// Authorization header is ignored: the CSRF check can't tell a client
+// replaying its own token from the user, and an OAuth token must never be
+// able to grant itself more scopes.
+func (cfg *apiConfig) consentingUser(r *http.Request) uuid.UUID {
+	if !cfg.accessTokenCookie {
+		return uuid.Nil
+	}
+	cookie, err := r.Cookie(accessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return uuid.Nil
+	}
+	user, err := cfg.authenticateJWT(r.Context(), cookie.Value)
+	if err != nil || user.PasswordChangeRequired {
+		return uuid.Nil
+	}
+	if _, waiting := waitlistLoginError(user); waiting {
+		return uuid.Nil
+	}
+	return user.ID
+}
+
+func (cfg *apiConfig) renderOAuthConsent(w http.ResponseWriter, r *http.Request, code int, req oauthAuthorizeRequest, client database.OauthClient, msg string) {
+	data := oauthConsentData{Error: msg}
+	if client.ID != uuid.Nil {
+		csrfToken, err := auth.MakeSessionToken()
+		if err != nil {
+			http.Error(w, "Couldn't create CSRF token", http.StatusInternalServerError)
+			return
+		}
+		setOAuthCookie(w, oauthCSRFCookie, csrfToken, time.Now().Add(time.Hour))
+		data = oauthConsentData{
+			ClientName:    client.Name,
+			ClientID:      client.ID.String(),
+			RedirectURI:   req.RedirectURI,
+			Scope:         req.Scope,
+			State:         req.State,
+			CodeChallenge: req.CodeChallenge,
+			CSRFToken:     csrfToken,
+			PasswordLogin: cfg.consentingUser(r) == uuid.Nil && !cfg.passwordLoginDisabled(),
+			Error:         msg,
+		}
+		for _, scope := range strings.Fields(req.Scope) {
+			data.Scopes = append(data.Scopes, oauthserver.DescribeScope(scope))
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	cfg.consentTemplate.Execute(w, data)
+}
+
+func (cfg *apiConfig) handlerOAuthAuthorizePage(w http.ResponseWriter, r *http.Request) {
+	req, client, err := cfg.parseAuthorizeRequest(r.Context(), r.URL.Query())
+	var oauthErr *oauthserver.Error
+	if errors.As(err, &oauthErr) {
+		http.Redirect(w, r, oauthserver.ErrorRedirectURL(req.RedirectURI, req.State, oauthErr), http.StatusFound)
+		return
+	}
+	if err != nil {
+		cfg.renderOAuthConsent(w, r, http.StatusBadRequest, req, database.OauthClient{}, "This app sent an invalid request: "+err.Error())
+		return
+	}
+	cfg.renderOAuthConsent(w, r, http.StatusOK, req, client, "")
+}
+
+func (cfg *apiConfig) handlerOAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		cfg.renderOAuthConsent(w, r, http.StatusBadRequest, oauthAuthorizeRequest{}, database.OauthClient{}, "Couldn't read the form")
+		return
+	}
+	req, client, err := cfg.parseAuthorizeRequest(r.Context(), r.PostForm)
+	var oauthErr *oauthserver.Error
+	if errors.As(err, &oauthErr) {
+		http.Redirect(w, r, oauthserver.ErrorRedirectURL(req.RedirectURI, req.State, oauthErr), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		cfg.renderOAuthConsent(w, r, http.StatusBadRequest, req, database.OauthClient{}, "This app sent an invalid request: "+err.Error())
+		return
+	}
+	csrfCookie, err := r.Cookie(oauthCSRFCookie)
+	if err != nil || !validCSRFToken(r, csrfCookie.Value) {
+		cfg.renderOAuthConsent(w, r, http.StatusForbidden, req, client, "Your form expired, please try again")
+		return
+	}
+	setOAuthCookie(w, oauthCSRFCookie, "", time.Unix(0, 0))
+	if r.PostFormValue("decision") != "allow" {
+		http.Redirect(w, r, oauthserver.ErrorRedirectURL(req.RedirectURI, req.State, &oauthserver.Error{Code: oauthserver.ErrAccessDenied}), http.StatusSeeOther)
+		return
+	}
+
+	userID := cfg.consentingUser(r)
+	if userID == uuid.Nil && cfg.passwordLoginDisabled() {
+		cfg.renderOAuthConsent(w, r, http.StatusUnauthorized, req, client, "Sign in to Chirpy with SSO first, then try again")
+		return
+	}
+	if userID == uuid.Nil {
+		user, err := cfg.database.GetUserByEmail(r.Context(), r.PostFormValue("email"))
+		if err != nil || auth.CheckPasswordHash(r.PostFormValue("password"), user.HashedPassword) != nil || user.IsDeleted {
+			cfg.renderOAuthConsent(w, r, http.StatusUnauthorized, req, client, "Incorrect email or password")
+			return
+		}
+		if user.PasswordChangeRequired {
+			cfg.renderOAuthConsent(w, r, http.StatusForbidden, req, client, "You must change your password before approving apps")
+			return
+		}
+		if msg, waiting := waitlistLoginError(user); waiting {
+			cfg.renderOAuthConsent(w, r, http.StatusForbidden, req, client, msg)
+			return
+		}
+		userID = user.ID
+	}
+
+	code, err := auth.MakeSessionToken()
+	if err != nil {
+		http.Error(w, "Couldn't create authorization code", http.StatusInternalServerError)
+		return
+	}
+	err = cfg.database.CreateOAuthAuthorizationCode(r.Context(), database.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectUri:   req.RedirectURI,
+		Scope:         req.Scope,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(oauthCodeLifetime),
+	})
+	if err != nil {
+		http.Error(w, "Couldn't create authorization code", http.StatusInternalServerError)
+		return
+	}
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	http.Redirect(w, r, oauthserver.RedirectURL(req.RedirectURI, params), http.StatusSeeOther)
+}
+
+func respondWithOAuthError(w http.ResponseWriter, code int, err *oauthserver.Error) {
+	if code == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="chirpy"`)
+	}
+	respondWithJSON(w, code, err)
+}
+
+// authenticateOAuthClient checks the client credentials on a token or
+// revocation request. Public clients only send their ID and rely on PKCE.
+func (cfg *apiConfig) authenticateOAuthClient(r *http.Request) (database.OauthClient, bool) {
+	rawID, secret, hasBasic := r.BasicAuth()
+	if !hasBasic {
+		rawID = r.PostFormValue("client_id")
+		secret = r.PostFormValue("client_secret")
+	}
+	clientID, err := uuid.Parse(rawID)
+	if err != nil {
+		return database.OauthClient{}, false
+	}
+	client, err := cfg.database.GetOAuthClient(r.Context(), clientID)
+	if err != nil {
+		return database.OauthClient{}, false
+	}
+	if client.SecretHash.Valid && subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(client.SecretHash.String)) != 1 {
+		return database.OauthClient{}, false
+	}
+	return client, true
+}
+
+func (cfg *apiConfig) handlerOAuthToken(w http.ResponseWriter, r *http.Request) {
+	type returnVals struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, &oauthserver.Error{Code: oauthserver.ErrInvalidRequest})
+		return
+	}
+	if r.PostFormValue("grant_type") != oauthserver.GrantTypeAuthorizationCode {
+		respondWithOAuthError(w, http.StatusBadRequest, &oauthserver.Error{Code: oauthserver.ErrUnsupportedGrantType})
+		return
+	}
+	client, ok := cfg.authenticateOAuthClient(r)
+	if !ok {
+		respondWithOAuthError(w, http.StatusUnauthorized, &oauthserver.Error{Code: oauthserver.ErrInvalidClient})
+		return
+	}
+
+	// Codes are deleted as they are read, so a replayed code always fails.
+	grant, err := cfg.database.ConsumeOAuthAuthorizationCode(r.Context(), auth.HashToken(r.PostFormValue("code")))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithOAuthError(w, http.StatusBadRequest, &oauthserver.Error{Code: oauthserver.ErrInvalidGrant})
+		return
+	}
+	if err != nil {
+		respondWithOAuthError(w, http.StatusInternalServerError, &oauthserver.Error{Code: oauthserver.ErrServerError})
+		return
+	}
+	if grant.ClientID != client.ID || grant.RedirectUri != r.PostFormValue("redirect_uri") {
+		respondWithOAuthError(w, http.StatusBadRequest, &oauthserver.Error{Code: oauthserver.ErrInvalidGrant})
+		return
+	}
+	if !oauthserver.VerifyPKCE(grant.CodeChallenge, r.PostFormValue("code_verifier")) {
+		respondWithOAuthError(w, http.StatusBadRequest, &oauthserver.Error{Code: oauthserver.ErrInvalidGrant, Description: "code_verifier doesn't match"})
+		return
+	}
+
+	accessToken, err := auth.MakeSessionToken()
+	if err != nil {
+		respondWithOAuthError(w, http.StatusInternalServerError, &oauthserver.Error{Code: oauthserver.ErrServerError})
+		return
+	}
+	err = cfg.database.CreateOAuthAccessToken(r.Context(), database.CreateOAuthAccessTokenParams{
+		TokenHash: auth.HashToken(accessToken),
+		ClientID:  client.ID,
+		UserID:    grant.UserID,
+		Scope:     grant.Scope,
+		ExpiresAt: time.Now().Add(oauthAccessTokenLifetime),
+	})
+	if err != nil {
+		respondWithOAuthError(w, http.StatusInternalServerError, &oauthserver.Error{Code: oauthserver.ErrServerError})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnVals{
+		AccessToken: accessToken,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int(oauthAccessTokenLifetime.Seconds()),
+		Scope:       grant.Scope,
+	})
+}
+
+// handlerOAuthRevoke implements RFC 7009. Unknown tokens are not an error,
+// so the response doesn't reveal whether a token existed.
+func (cfg *apiConfig) handlerOAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, &oauthserver.Error{Code: oauthserver.ErrInvalidRequest})
+		return
+	}
+	client, ok := cfg.authenticateOAuthClient(r)
+	if !ok {
+		respondWithOAuthError(w, http.StatusUnauthorized, &oauthserver.Error{Code: oauthserver.ErrInvalidClient})
+		return
+	}
+	_, err := cfg.database.RevokeOAuthAccessToken(r.Context(), database.RevokeOAuthAccessTokenParams{
+		TokenHash: auth.HashToken(r.PostFormValue("token")),
+		ClientID:  client.ID,
+	})
+	if err != nil {
+		respondWithOAuthError(w, http.StatusInternalServerError, &oauthserver.Error{Code: oauthserver.ErrServerError})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/breaker"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/mail"
+	"github.com/google/uuid"
+)
+
+const (
+	outboxEmail   = "email"
+	outboxWebhook = "webhook"
+
+	outboxPending = "pending"
+	outboxDead    = "dead"
+
+	outboxInterval = 5 * time.Second
+	outboxBatch    = 50
+	// outboxLease is how long a claimed message is left to one relay
+	// before another may try it.
+	outboxLease = 5 * time.Minute
+	// outboxMaxAttempts is how many deliveries a message gets before it is
+	// marked dead, about fifteen hours with the backoff below.
+	outboxMaxAttempts = 12
+	outboxMaxBackoff  = 6 * time.Hour
+	// outboxDeadRetention is how long dead messages are kept. They can
+	// hold email addresses, so not for long.
+	outboxDeadRetention = 7 * 24 * time.Hour
+)
+
+// outboxWebhookPayload is a queued POST of a JSON body. Dependency names
+// the circuit breaker guarding the receiver.
+type outboxWebhookPayload struct {
+	Dependency string          `json:"dependency"`
+	URL        string          `json:"url"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// enqueueEmail queues msg through q, which should be the transaction
+// making the change the email is about, so the two commit together.
+func enqueueEmail(ctx context.Context, q *database.Store, msg mail.Message) error {
+	return enqueueOutbox(ctx, q, outboxEmail, msg)
+}
+
+// enqueueWebhook queues a POST of body to url through q, the caller's
+// transaction.
+func enqueueWebhook(ctx context.Context, q *database.Store, dependency, url string, body []byte) error {
+	return enqueueOutbox(ctx, q, outboxWebhook, outboxWebhookPayload{
+		Dependency: dependency,
+		URL:        url,
+		Body:       body,
+	})
+}
+
+func enqueueOutbox(ctx context.Context, q *database.Store, kind string, payload any) error {
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return q.EnqueueOutboxMessage(ctx, database.EnqueueOutboxMessageParams{
+		ID:      uuid.New(),
+		Kind:    kind,
+		Payload: dat,
+	})
+}
+
+// outboxBackoff is how long to wait after the given number of failed
+// attempts: 30s doubling up to outboxMaxBackoff.
+func outboxBackoff(attempts int32) time.Duration {
+	if attempts > 20 {
+		return outboxMaxBackoff
+	}
+	return min(30*time.Second<<max(attempts-1, 0), outboxMaxBackoff)
+}
+
+// runOutboxRelay delivers queued emails and webhooks. A message is deleted
+// once delivered, so it is sent at least once; receivers may see repeats if
+// an instance stops between delivering and deleting.
+func (cfg *apiConfig) runOutboxRelay(ctx context.Context) {
+	ticker := time.NewTicker(outboxInterval)
+	defer ticker.Stop()
+	for {
+		cfg.relayOutbox(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) relayOutbox(ctx context.Context) {
+	now := time.Now()
+	messages, err := cfg.database.ClaimOutboxMessages(ctx, database.ClaimOutboxMessagesParams{
+		LeaseUntil: now.Add(outboxLease),
+		RowLimit:   outboxBatch,
+	})
+	if err != nil {
+		log.Printf("Error claiming outbox messages: %s", err)
+		return
+	}
+	for _, m := range messages {
+		if err := cfg.deliverOutbox(ctx, m); err != nil {
+			cfg.failOutbox(ctx, m, err)
+			continue
+		}
+		if err := cfg.database.DeleteOutboxMessage(ctx, m.ID); err != nil {
+			log.Printf("Error deleting delivered outbox message %s: %s", m.ID, err)
+		}
+	}
+	if n, err := cfg.database.DeleteDeadOutboxMessages(ctx, now.Add(-outboxDeadRetention)); err != nil {
+		log.Printf("Error deleting dead outbox messages: %s", err)
+	} else if n > 0 {
+		log.Printf("Deleted %d dead outbox messages", n)
+	}
+}
+
+// failOutbox schedules m for another attempt, or marks it dead once it has
+// used them all.
+func (cfg *apiConfig) failOutbox(ctx context.Context, m database.Outbox, deliveryErr error) {
+	params := database.FailOutboxMessageParams{
+		ID:            m.ID,
+		Status:        outboxPending,
+		NextAttemptAt: time.Now().Add(outboxBackoff(m.Attempts)),
+		LastError:     sql.NullString{String: deliveryErr.Error(), Valid: true},
+	}
+	if m.Attempts >= outboxMaxAttempts {
+		params.Status = outboxDead
+		log.Printf("Giving up on %s outbox message %s after %d attempts: %s", m.Kind, m.ID, m.Attempts, deliveryErr)
+	} else {
+		log.Printf("Error delivering %s outbox message %s (attempt %d): %s", m.Kind, m.ID, m.Attempts, deliveryErr)
+	}
+	if err := cfg.database.FailOutboxMessage(ctx, params); err != nil {
+		log.Printf("Error updating outbox message %s: %s", m.ID, err)
+	}
+}
+
+func (cfg *apiConfig) deliverOutbox(ctx context.Context, m database.Outbox) error {
+	switch m.Kind {
+	case outboxEmail:
+		var msg mail.Message
+		if err := json.Unmarshal(m.Payload, &msg); err != nil {
+			return err
+		}
+		if cfg.mailer == nil {
+			return errors.New("no mailer configured")
+		}
+		return cfg.mailer.Send(ctx, msg)
+	case outboxWebhook:
+		var hook outboxWebhookPayload
+		if err := json.Unmarshal(m.Payload, &hook); err != nil {
+			return err
+		}
+		return postWebhook(ctx, cfg.breakers.Get(hook.Dependency), hook.URL, hook.Body)
+	default:
+		return fmt.Errorf("unknown outbox message kind %q", m.Kind)
+	}
+}
+
+// postWebhook POSTs a JSON body to url through b, treating anything but a
+// 2xx answer as a failure.
+func postWebhook(ctx context.Context, b *breaker.Breaker, url string, body []byte) error {
+	return b.Do(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with %s", resp.Status)
+		}
+		return nil
+	})
+}
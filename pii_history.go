@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// Sources recorded with each PII change.
+const (
+	piiSourceUser = "user"
+	piiSourceSCIM = "scim"
+)
+
+type piiChange struct {
+	Field    string
+	OldValue sql.NullString
+	NewValue sql.NullString
+}
+
+// piiChanges lists the personal data fields that differ between two
+// versions of a user.
+func piiChanges(before, after database.User) []piiChange {
+	var changes []piiChange
+	add := func(field string, old, new sql.NullString) {
+		if old != new {
+			changes = append(changes, piiChange{Field: field, OldValue: old, NewValue: new})
+		}
+	}
+	add("email", sql.NullString{String: before.Email, Valid: true}, sql.NullString{String: after.Email, Valid: true})
+	add("handle", before.Handle, after.Handle)
+	add("display_name", before.DisplayName, after.DisplayName)
+	add("avatar_url", before.AvatarUrl, after.AvatarUrl)
+	return changes
+}
+
+// recordPIIChanges writes a history entry for each personal data field that
+// changed. Like recordAudit it logs failures instead of returning them.
+func (cfg *apiConfig) recordPIIChanges(ctx context.Context, before, after database.User, source string) {
+	for _, change := range piiChanges(before, after) {
+		err := cfg.database.CreateUserPIIChange(ctx, database.CreateUserPIIChangeParams{
+			UserID:   after.ID,
+			Field:    change.Field,
+			OldValue: change.OldValue,
+			NewValue: change.NewValue,
+			Source:   source,
+		})
+		if err != nil {
+			log.Printf("Error recording %s change for user %s: %s", change.Field, after.ID, err)
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerUserHistory(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+	cfg.respondWithPIIHistory(w, r, userID)
+}
+
+func (cfg *apiConfig) handlerAdminUserHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	cfg.respondWithPIIHistory(w, r, userID)
+}
+
+func (cfg *apiConfig) respondWithPIIHistory(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	type returnVals struct {
+		Field     string    `json:"field"`
+		OldValue  *string   `json:"old_value"`
+		NewValue  *string   `json:"new_value"`
+		Source    string    `json:"source"`
+		ChangedAt time.Time `json:"changed_at"`
+	}
+
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return
+		}
+		limit = n
+	}
+	history, err := cfg.database.ListUserPIIHistory(r.Context(), database.ListUserPIIHistoryParams{
+		UserID: userID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get history", err)
+		return
+	}
+	var entries []returnVals
+	for _, entry := range history {
+		entries = append(entries, returnVals{
+			Field:     entry.Field,
+			OldValue:  nullStringPtr(entry.OldValue),
+			NewValue:  nullStringPtr(entry.NewValue),
+			Source:    entry.Source,
+			ChangedAt: entry.ChangedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
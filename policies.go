@@ -0,0 +1,195 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/lib/pq"
+)
+
+type policyResponse struct {
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+func newPolicyResponse(p database.PolicyVersion) policyResponse {
+	return policyResponse{
+		Version:     p.Version,
+		URL:         p.Url,
+		PublishedAt: p.PublishedAt,
+	}
+}
+
+// respondWithPendingPolicy tells the client which terms of service version
+// the user still has to accept. 451 keeps it apart from the other 409s the
+// API returns, so clients can send the user to the policy page.
+func respondWithPendingPolicy(w http.ResponseWriter, policy database.PolicyVersion) {
+	type returnVals struct {
+		Error  string         `json:"error"`
+		Policy policyResponse `json:"policy"`
+	}
+	respondWithJSON(w, http.StatusUnavailableForLegalReasons, returnVals{
+		Error:  "You need to accept the latest terms of service",
+		Policy: newPolicyResponse(policy),
+	})
+}
+
+// middlewarePolicyAccepted holds back requests from users who haven't
+// accepted the latest terms of service. Only routes that create content or
+// credentials use it, so users can still read, export and delete their data
+// before accepting. Requests without a valid token pass through and are
+// rejected by the handler.
+func (cfg *apiConfig) middlewarePolicyAccepted(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := cfg.accessToken(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, err := cfg.validateJWT(token)
+		if err != nil {
+			grant, err := cfg.database.GetOAuthAccessToken(r.Context(), auth.HashToken(token))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID = grant.UserID
+		}
+		pending, err := cfg.database.GetPendingPolicyVersion(r.Context(), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check policy acceptance", err)
+			return
+		}
+		respondWithPendingPolicy(w, pending)
+	})
+}
+
+func (cfg *apiConfig) handlerCurrentPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := cfg.database.GetLatestPolicyVersion(r.Context())
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No policy has been published", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get policy", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newPolicyResponse(policy))
+}
+
+func (cfg *apiConfig) handlerAcceptPolicy(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Version string `json:"version"`
+	}
+	type returnVals struct {
+		Version    string    `json:"version"`
+		AcceptedAt time.Time `json:"accepted_at"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	latest, err := cfg.database.GetLatestPolicyVersion(r.Context())
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No policy has been published", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get policy", err)
+		return
+	}
+	// Only the version the user was shown can be accepted, so a client
+	// holding a stale page can't accept terms the user never saw.
+	if params.Version != latest.Version {
+		respondWithPendingPolicy(w, latest)
+		return
+	}
+	acceptedAt, err := cfg.database.AcceptPolicyVersion(r.Context(), database.AcceptPolicyVersionParams{
+		UserID:          userID,
+		PolicyVersionID: latest.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record acceptance", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnVals{
+		Version:    latest.Version,
+		AcceptedAt: acceptedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerAdminListPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := cfg.database.ListPolicyVersions(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get policies", err)
+		return
+	}
+	var entries []policyResponse
+	for _, p := range policies {
+		entries = append(entries, newPolicyResponse(p))
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+// handlerAdminPublishPolicy publishes a new terms of service version. Every
+// user has to accept it before they can post again.
+func (cfg *apiConfig) handlerAdminPublishPolicy(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Version string `json:"version"`
+		URL     string `json:"url"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	params.Version = strings.TrimSpace(params.Version)
+	if params.Version == "" {
+		respondWithError(w, http.StatusBadRequest, "Version is required", nil)
+		return
+	}
+	if u, err := url.Parse(params.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		respondWithError(w, http.StatusBadRequest, "URL must be an absolute http(s) URL", nil)
+		return
+	}
+	policy, err := cfg.database.CreatePolicyVersion(r.Context(), database.CreatePolicyVersionParams{
+		Version: params.Version,
+		Url:     params.URL,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, "Version already exists", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish policy", err)
+		return
+	}
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "policy.published", "policy", policy.Version, map[string]any{
+		"url": policy.Url,
+	})
+	respondWithJSON(w, http.StatusCreated, newPolicyResponse(policy))
+}
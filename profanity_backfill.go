@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+const (
+	profanityBackfillInterval  = 10 * time.Minute
+	profanityBackfillBatchSize = 500
+)
+
+// runProfanityBackfill cleans chirps stored before bodies were filtered on
+// write. Reads clean those on the fly in the meantime, so this only saves
+// the work; once every chirp is filtered each run is a single query.
+func (cfg *apiConfig) runProfanityBackfill(ctx context.Context) {
+	ticker := time.NewTicker(profanityBackfillInterval)
+	defer ticker.Stop()
+	for {
+		cfg.backfillProfanity(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) backfillProfanity(ctx context.Context) {
+	total := int64(0)
+	for {
+		rows, err := cfg.database.ListUnfilteredMessages(ctx, profanityBackfillBatchSize)
+		if err != nil {
+			log.Printf("Error listing chirps to filter: %s", err)
+			return
+		}
+		for _, row := range rows {
+			n, err := cfg.database.FilterMessageBody(ctx, database.FilterMessageBodyParams{
+				ID:   row.ID,
+				Body: cfg.cleanProfanity(ctx, row.Body),
+			})
+			if err != nil {
+				log.Printf("Error filtering chirp %s: %s", row.ID, err)
+				return
+			}
+			total += n
+		}
+		if len(rows) < profanityBackfillBatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("Filtered profanity in %d stored chirps", total)
+	}
+}
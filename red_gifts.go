@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// polkaEventGifted is sent when one user pays for another's Chirpy Red.
+const polkaEventGifted = "user.gifted"
+
+const redExpiryInterval = 10 * time.Minute
+
+// handlePolkaGift upgrades the recipient of a gift and records it so the
+// recipient is told who sent it the next time they check their gifts.
+// Gifted Red lasts for the gift's months, added on to any gifted Red the
+// recipient still has. A member already paying for Red keeps it
+// indefinitely.
+func (cfg *apiConfig) handlePolkaGift(w http.ResponseWriter, r *http.Request, data EventData, nonce string) {
+	recipientID, err := uuid.Parse(data.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user_id", err)
+		return
+	}
+	gifterID, err := uuid.Parse(data.GifterID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid gifter_id", err)
+		return
+	}
+	if gifterID == recipientID {
+		respondWithError(w, http.StatusBadRequest, "A gift needs a different recipient", nil)
+		return
+	}
+	months := data.Months
+	if months == 0 {
+		months = 1
+	}
+	if months < 0 {
+		respondWithError(w, http.StatusBadRequest, "Months must be positive", nil)
+		return
+	}
+	for _, id := range []uuid.UUID{recipientID, gifterID} {
+		user, err := cfg.database.GetUserByID(r.Context(), id)
+		if err == nil && user.IsDeleted {
+			err = database.ErrUserNotFound
+		}
+		if err != nil {
+			respondWithDBError(w, "Couldn't get user", err)
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record gift", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	if !cfg.claimWebhookNonce(w, r, qtx, webhookSourcePolka, nonce) {
+		return
+	}
+	var redExpiresAt *time.Time
+	expiresAt, err := qtx.ExtendChirpyRed(r.Context(), database.ExtendChirpyRedParams{
+		Months: months,
+		UserID: recipientID,
+	})
+	if err == nil {
+		redExpiresAt = &expiresAt
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't extend Chirpy Red", err)
+		return
+	}
+	err = upgradeUser(r.Context(), qtx, recipientID, map[string]any{
+		"source":         "gift",
+		"gifter_id":      gifterID,
+		"months":         months,
+		"red_expires_at": redExpiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't upgrade user", err)
+		return
+	}
+	gift, err := qtx.CreateRedGift(r.Context(), database.CreateRedGiftParams{
+		GifterID:    uuid.NullUUID{UUID: gifterID, Valid: true},
+		RecipientID: recipientID,
+		Months:      months,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record gift", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record gift", err)
+		return
+	}
+	cfg.recordAudit(r.Context(), uuid.Nil, "red.gifted", "user", recipientID.String(), map[string]any{
+		"gift_id":        gift.ID,
+		"gifter_id":      gifterID,
+		"months":         months,
+		"red_expires_at": redExpiresAt,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runRedExpiry takes Chirpy Red away from members whose gifted Red has run
+// out.
+func (cfg *apiConfig) runRedExpiry(ctx context.Context) {
+	ticker := time.NewTicker(redExpiryInterval)
+	defer ticker.Stop()
+	for {
+		cfg.expireRed(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) expireRed(ctx context.Context) {
+	n, err := cfg.expireGiftedRed(ctx)
+	if err != nil {
+		log.Printf("Error expiring gifted Chirpy Red: %s", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Gifted Chirpy Red ran out for %d users", n)
+	}
+}
+
+// expireGiftedRed ends lapsed gifted Red and records a UserRedExpired event
+// for each member in the same transaction.
+func (cfg *apiConfig) expireGiftedRed(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	expired, err := qtx.ExpireChirpyRed(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, userID := range expired {
+		if err := appendEvent(ctx, qtx, eventUserRedExpired, aggregateUser, userID, nil); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), tx.Commit()
+}
+
+// handlerUserGifts lists the Red gifts the user has received. Gifts not
+// listed before are marked new, then marked seen, so clients can show a
+// notification once.
+func (cfg *apiConfig) handlerUserGifts(w http.ResponseWriter, r *http.Request) {
+	type giftResponse struct {
+		ID        uuid.UUID    `json:"id"`
+		From      *chirpAuthor `json:"from"`
+		Months    int32        `json:"months"`
+		CreatedAt time.Time    `json:"created_at"`
+		New       bool         `json:"new"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	gifts, err := cfg.database.ListRedGiftsForRecipient(r.Context(), database.ListRedGiftsForRecipientParams{
+		RecipientID: userID,
+		Limit:       100,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get gifts", err)
+		return
+	}
+	var entries []giftResponse
+	for _, g := range gifts {
+		entry := giftResponse{
+			ID:        g.ID,
+			Months:    g.Months,
+			CreatedAt: g.CreatedAt,
+			New:       !g.SeenAt.Valid,
+		}
+		// Gifts from deleted accounts are shown without a sender.
+		if g.GifterID.Valid {
+			from := newChirpAuthor(g.GifterID.UUID, g.GifterHandle, g.GifterDisplayName, g.GifterAvatarUrl, g.GifterIsVerified)
+			entry.From = &from
+		}
+		entries = append(entries, entry)
+	}
+	if err := cfg.database.MarkRedGiftsSeen(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update gifts", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
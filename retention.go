@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/google/uuid"
+)
+
+const (
+	retentionInterval  = time.Hour
+	retentionBatchSize = 1000
+)
+
+// A retentionRule deletes one kind of row once it is older than the number
+// of days in its setting. Zero days turns the rule off.
+type retentionRule struct {
+	name    string
+	setting string
+	count   func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error)
+	purge   func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error)
+}
+
+var retentionRules = []retentionRule{
+	{
+		name:    "chirps",
+		setting: settings.RetentionChirpDays,
+		count: func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error) {
+			return q.CountChirpsOlderThan(ctx, cutoff)
+		},
+		purge: func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error) {
+			var total int64
+			for {
+				n, err := q.DeleteChirpsOlderThan(ctx, database.DeleteChirpsOlderThanParams{
+					Cutoff:    cutoff,
+					BatchSize: retentionBatchSize,
+				})
+				total += n
+				if err != nil || n < retentionBatchSize {
+					return total, err
+				}
+			}
+		},
+	},
+	{
+		name:    "refresh_tokens",
+		setting: settings.RetentionTokenDays,
+		count: func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error) {
+			return q.CountStaleRefreshTokens(ctx, cutoff)
+		},
+		purge: func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error) {
+			return q.DeleteStaleRefreshTokens(ctx, cutoff)
+		},
+	},
+	{
+		name:    "oauth_access_tokens",
+		setting: settings.RetentionTokenDays,
+		count: func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error) {
+			return q.CountStaleOAuthAccessTokens(ctx, cutoff)
+		},
+		purge: func(ctx context.Context, q *database.Store, cutoff time.Time) (int64, error) {
+			return q.DeleteStaleOAuthAccessTokens(ctx, cutoff)
+		},
+	},
+}
+
+// retentionCutoff is the time before which rows are deleted, or false if
+// the rule is off.
+func retentionCutoff(now time.Time, days int) (time.Time, bool) {
+	if days <= 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -days), true
+}
+
+// runRetention applies the retention rules every hour. Settings are read
+// on each pass, so changing a rule needs no restart.
+func (cfg *apiConfig) runRetention(ctx context.Context) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		cfg.applyRetention(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) applyRetention(ctx context.Context) {
+	now := time.Now().UTC()
+	for _, rule := range retentionRules {
+		cutoff, ok := retentionCutoff(now, cfg.settings.Int(ctx, rule.setting))
+		if !ok {
+			continue
+		}
+		deleted, err := rule.purge(ctx, cfg.database, cutoff)
+		if err != nil {
+			log.Printf("Error applying %s retention: %s", rule.name, err)
+		}
+		if deleted > 0 {
+			log.Printf("Retention removed %d %s", deleted, rule.name)
+			cfg.recordAudit(ctx, uuid.Nil, "retention.purged", "retention_rule", rule.name, map[string]any{
+				"deleted": deleted,
+				"cutoff":  cutoff,
+			})
+		}
+	}
+}
+
+// handlerAdminRetentionReport is a dry run: it counts what the next
+// retention pass would delete without deleting anything.
+func (cfg *apiConfig) handlerAdminRetentionReport(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		Rule        string     `json:"rule"`
+		Setting     string     `json:"setting"`
+		Days        int        `json:"days"`
+		Enabled     bool       `json:"enabled"`
+		Cutoff      *time.Time `json:"cutoff,omitempty"`
+		WouldDelete int64      `json:"would_delete"`
+	}
+
+	now := time.Now().UTC()
+	entries := make([]entry, 0, len(retentionRules))
+	for _, rule := range retentionRules {
+		days := cfg.settings.Int(r.Context(), rule.setting)
+		e := entry{Rule: rule.name, Setting: rule.setting, Days: days}
+		if cutoff, ok := retentionCutoff(now, days); ok {
+			count, err := rule.count(r.Context(), cfg.database, cutoff)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't build retention report", err)
+				return
+			}
+			e.Enabled = true
+			e.Cutoff = &cutoff
+			e.WouldDelete = count
+		}
+		entries = append(entries, e)
+	}
+	respondWithJSON(w, http.StatusOK, map[string]any{
+		"generated_at": now,
+		"rules":        entries,
+	})
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// routeAccess says who may call a route. It has no zero value so that a
+// route added without one fails at startup instead of being left open.
+type routeAccess int
+
+const (
+	// accessOpen routes need no credentials.
+	accessOpen routeAccess = iota + 1
+	// accessHandler routes check credentials themselves because they take
+	// something other than a Chirpy JWT: OAuth tokens, refresh tokens,
+	// client secrets, developer keys or webhook keys.
+	accessHandler
+	accessUser
+	accessAdmin
+	accessAdminSession
+	accessSCIM
+)
+
+// routeMiddleware is a set of optional middleware for a route.
+type routeMiddleware uint
+
+const (
+	withPolicy routeMiddleware = 1 << iota
+	withNoBody
+	withQuota
+	withCoalesce
+	withSecurityHeaders
+	withUsageWrite
+	withUsageRead
+)
+
+type route struct {
+	pattern    string
+	handler    http.HandlerFunc
+	access     routeAccess
+	middleware routeMiddleware
+}
+
+func (cfg *apiConfig) routes(appHandler http.Handler) []route {
+	return []route{
+		{"/app/", http.StripPrefix("/app", cfg.middlewareMetricsInc(appHandler)).ServeHTTP, accessOpen, withSecurityHeaders},
+		{"GET /api/healthz", endpointHealt, accessOpen, 0},
+		{"GET /api/readyz", cfg.handlerReadiness, accessOpen, 0},
+		{"GET /api/branding", cfg.handlerBranding, accessOpen, 0},
+		{"GET /api/emojis", cfg.handlerListEmojis, accessOpen, 0},
+		{"GET /admin/login", cfg.handlerAdminLoginPage, accessOpen, withSecurityHeaders},
+		{"POST /admin/login", cfg.handlerAdminLogin, accessOpen, withSecurityHeaders},
+		{"POST /admin/logout", cfg.handlerAdminLogout, accessAdminSession, withSecurityHeaders},
+		{"GET /admin/metrics", cfg.handlerAdminMetrics().ServeHTTP, accessHandler, withSecurityHeaders},
+		{"POST /admin/reset", cfg.middlewareMetricsReset, accessOpen, 0},
+		{"GET /admin/settings", cfg.handlerAdminListSettings, accessAdmin, 0},
+		{"PUT /admin/settings/{key}", cfg.handlerAdminUpdateSetting, accessAdmin, 0},
+		{"DELETE /admin/settings/{key}", cfg.handlerAdminResetSetting, accessAdmin, 0},
+		{"GET /admin/moderation/chirps", cfg.handlerAdminPendingChirps, accessAdmin, 0},
+		{"POST /admin/moderation/chirps/{chirpID}/approve", cfg.handlerAdminApproveChirp, accessAdmin, 0},
+		{"POST /admin/moderation/chirps/{chirpID}/reject", cfg.handlerAdminRejectChirp, accessAdmin, 0},
+		{"POST /admin/users/{userID}/verify", cfg.handlerAdminVerifyUser, accessAdmin, 0},
+		{"DELETE /admin/users/{userID}/verify", cfg.handlerAdminUnverifyUser, accessAdmin, 0},
+		{"DELETE /admin/users/{userID}", cfg.handlerAdminDeleteUser, accessAdmin, 0},
+		{"GET /admin/users/{userID}/history", cfg.handlerAdminUserHistory, accessAdmin, 0},
+		{"POST /admin/users/{userID}/restore", cfg.handlerAdminRestoreUser, accessAdmin, 0},
+		{"POST /admin/users/{userID}/purge", cfg.handlerAdminPurgeUser, accessAdmin, 0},
+		{"GET /admin/pending-actions", cfg.handlerAdminListPendingActions, accessAdmin, 0},
+		{"POST /admin/pending-actions/{actionID}/approve", cfg.handlerAdminApproveAction, accessAdmin, 0},
+		{"POST /admin/pending-actions/{actionID}/reject", cfg.handlerAdminRejectAction, accessAdmin, 0},
+		{"GET /admin/waitlist", cfg.handlerAdminListWaitlist, accessAdmin, 0},
+		{"POST /admin/waitlist/approve", cfg.handlerAdminApproveWaitlist, accessAdmin, 0},
+		{"POST /admin/waitlist/reject", cfg.handlerAdminRejectWaitlist, accessAdmin, 0},
+		{"GET /admin/email-domains", cfg.handlerAdminListEmailDomains, accessAdmin, 0},
+		{"PUT /admin/email-domains/{domain}", cfg.handlerAdminSetEmailDomain, accessAdmin, 0},
+		{"DELETE /admin/email-domains/{domain}", cfg.handlerAdminDeleteEmailDomain, accessAdmin, 0},
+		{"GET /admin/profanity", cfg.handlerAdminListProfanity, accessAdmin, 0},
+		{"PUT /admin/profanity/{word}", cfg.handlerAdminSetProfanityWord, accessAdmin, 0},
+		{"DELETE /admin/profanity/{word}", cfg.handlerAdminDeleteProfanityWord, accessAdmin, 0},
+		{"PUT /admin/emojis/{shortcode}", cfg.handlerAdminSetEmoji, accessAdmin, 0},
+		{"DELETE /admin/emojis/{shortcode}", cfg.handlerAdminDeleteEmoji, accessAdmin, 0},
+		{"PUT /admin/developer-keys/{keyID}/tier", cfg.handlerAdminSetDeveloperKeyTier, accessAdmin, 0},
+		{"GET /admin/policies", cfg.handlerAdminListPolicies, accessAdmin, 0},
+		{"POST /admin/policies", cfg.handlerAdminPublishPolicy, accessAdmin, 0},
+		{"GET /admin/backups", cfg.handlerAdminListBackups, accessAdmin, 0},
+		{"POST /admin/backups", cfg.handlerAdminStartBackup, accessAdmin, 0},
+		{"GET /admin/backups/{backupID}", cfg.handlerAdminGetBackup, accessAdmin, 0},
+		{"GET /admin/audit-logs", cfg.handlerAdminAuditLogs, accessAdmin, 0},
+		{"GET /admin/events", cfg.handlerAdminListEvents, accessAdmin, 0},
+		{"GET /admin/deletion-reports", cfg.handlerAdminListDeletionReports, accessAdmin, 0},
+		{"GET /admin/deletion-reports/{reportID}", cfg.handlerAdminDownloadDeletionReport, accessAdmin, 0},
+		{"GET /admin/retention/report", cfg.handlerAdminRetentionReport, accessAdmin, 0},
+		{"GET /admin/export/chirps", cfg.handlerAdminExportChirps, accessAdmin, 0},
+		{"GET /admin/db/slow-queries", cfg.handlerAdminSlowQueries, accessAdmin, 0},
+		{"POST /admin/debug-capture", cfg.handlerAdminDebugCapture, accessAdmin, 0},
+		{"POST /admin/drain", cfg.handlerAdminDrain, accessAdmin, 0},
+		{"DELETE /admin/drain", cfg.handlerAdminUndrain, accessAdmin, 0},
+		{"GET /admin/canaries", cfg.handlerAdminListCanaries, accessAdmin, 0},
+		{"POST /admin/canaries", cfg.handlerAdminCreateCanary, accessAdmin, 0},
+		{"DELETE /admin/canaries/{canaryID}", cfg.handlerAdminDeleteCanary, accessAdmin, 0},
+		{"POST /api/chirps", cfg.handlerChirpsValidate, accessHandler, withPolicy | withUsageWrite},
+		{"GET /api/chirps", cfg.handlerChirpsGetAll, accessOpen, withQuota | withUsageRead | withCoalesce},
+		{"GET /api/chirps/{chirpID}", cfg.handlerChirpsGetByID, accessOpen, withQuota | withCoalesce},
+		{"GET /api/chirps/{chirpID}/thread", cfg.handlerChirpsThread, accessOpen, withQuota | withUsageRead | withCoalesce},
+		{"GET /api/chirps/{chirpID}/links", cfg.handlerChirpLinkStats, accessUser, 0},
+		{"GET /l/{code}", cfg.handlerLinkRedirect, accessOpen, 0},
+		{"GET /chirps/{chirpID}", cfg.handlerChirpPage, accessOpen, withSecurityHeaders},
+		{"GET /media/{key...}", cfg.handlerServeMedia, accessOpen, 0},
+		{"GET /api/oembed", cfg.handlerOEmbed, accessOpen, withQuota},
+		{"PUT /api/chirps/{chirpID}", cfg.handlerChirpsUpdate, accessHandler, withPolicy | withUsageWrite},
+		{"POST /api/chirps/{chirpID}/like", cfg.handlerChirpLike, accessHandler, withPolicy | withUsageWrite},
+		{"DELETE /api/chirps/{chirpID}/like", cfg.handlerChirpUnlike, accessHandler, withUsageWrite},
+		{"POST /api/chirps/{chirpID}/media", cfg.handlerUploadChirpMedia, accessHandler, withPolicy | withUsageWrite},
+		{"DELETE /api/chirps/{chirpID}", cfg.handlerDeleteChirps, accessHandler, 0},
+		{"GET /api/hashtags/{tag}/chirps", cfg.handlerHashtagChirps, accessOpen, withQuota | withUsageRead | withCoalesce},
+		{"GET /api/search/chirps", cfg.handlerSearchChirps, accessOpen, withQuota | withUsageRead},
+		{"GET /api/search/users", cfg.handlerSearchUsers, accessOpen, withQuota | withUsageRead},
+		{"GET /api/lists", cfg.handlerListLists, accessUser, 0},
+		{"POST /api/lists", cfg.handlerCreateList, accessUser, 0},
+		{"DELETE /api/lists/{listID}", cfg.handlerDeleteList, accessUser, 0},
+		{"GET /api/lists/{listID}/members", cfg.handlerListListMembers, accessUser, 0},
+		{"PUT /api/lists/{listID}/members/{userID}", cfg.handlerAddListMember, accessUser, 0},
+		{"DELETE /api/lists/{listID}/members/{userID}", cfg.handlerRemoveListMember, accessUser, 0},
+		{"GET /api/lists/{listID}/chirps", cfg.handlerListChirps, accessUser, withUsageRead},
+		{"GET /api/delegations", cfg.handlerListDelegations, accessUser, 0},
+		{"POST /api/delegations", cfg.handlerCreateDelegation, accessUser, withPolicy},
+		{"POST /api/delegations/{ownerID}/accept", cfg.handlerAcceptDelegation, accessUser, withPolicy},
+		{"DELETE /api/delegations/{ownerID}/{delegateID}", cfg.handlerDeleteDelegation, accessUser, 0},
+		{"GET /api/developer/keys", cfg.handlerListDeveloperKeys, accessUser, 0},
+		{"POST /api/developer/keys", cfg.handlerCreateDeveloperKey, accessUser, withPolicy},
+		{"DELETE /api/developer/keys/{keyID}", cfg.handlerRevokeDeveloperKey, accessUser, 0},
+		{"GET /api/developer/usage", cfg.handlerDeveloperUsage, accessHandler, 0},
+		{"GET /api/oauth/clients", cfg.handlerListOAuthClients, accessUser, 0},
+		{"POST /api/oauth/clients", cfg.handlerCreateOAuthClient, accessUser, withPolicy},
+		{"DELETE /api/oauth/clients/{clientID}", cfg.handlerDeleteOAuthClient, accessUser, 0},
+		{"GET /oauth/authorize", cfg.handlerOAuthAuthorizePage, accessOpen, withSecurityHeaders},
+		{"POST /oauth/authorize", cfg.handlerOAuthAuthorize, accessHandler, withSecurityHeaders},
+		{"POST /oauth/token", cfg.handlerOAuthToken, accessHandler, 0},
+		{"POST /oauth/revoke", cfg.handlerOAuthRevoke, accessHandler, 0},
+		{"GET /scim/v2/Users", cfg.handlerSCIMListUsers, accessSCIM, 0},
+		{"POST /scim/v2/Users", cfg.handlerSCIMCreateUser, accessSCIM, 0},
+		{"GET /scim/v2/Users/{userID}", cfg.handlerSCIMGetUser, accessSCIM, 0},
+		{"PUT /scim/v2/Users/{userID}", cfg.handlerSCIMReplaceUser, accessSCIM, 0},
+		{"PATCH /scim/v2/Users/{userID}", cfg.handlerSCIMPatchUser, accessSCIM, 0},
+		{"DELETE /scim/v2/Users/{userID}", cfg.handlerSCIMDeleteUser, accessSCIM, 0},
+		{"POST /api/users", cfg.apiCreateUser, accessOpen, 0},
+		{"PUT /api/users", cfg.handlerUpdateUser, accessUser, 0},
+		{"PUT /api/users/me/profile", cfg.handlerUpdateProfile, accessHandler, withPolicy},
+		{"PUT /api/users/me/avatar", cfg.handlerUploadAvatar, accessHandler, withPolicy},
+		{"GET /api/users/me/history", cfg.handlerUserHistory, accessUser, 0},
+		{"GET /api/users/me/gifts", cfg.handlerUserGifts, accessUser, 0},
+		{"GET /api/users/me/usage", cfg.handlerUserUsage, accessUser, 0},
+		{"PUT /api/users/me/preferences", cfg.handlerUpdatePreferences, accessUser, 0},
+		{"PUT /api/users/me/automated", cfg.handlerSetAutomated, accessUser, 0},
+		{"DELETE /api/users/me/automated", cfg.handlerClearAutomated, accessUser, 0},
+		{"GET /api/users/me/mutes", cfg.handlerListMutes, accessUser, 0},
+		{"POST /api/users/me/mutes", cfg.handlerCreateMute, accessUser, 0},
+		{"DELETE /api/users/me/mutes/{muteID}", cfg.handlerDeleteMute, accessUser, 0},
+		{"POST /api/users/me/accept-policy", cfg.handlerAcceptPolicy, accessUser, 0},
+		{"POST /api/users/me/rotate-credentials", cfg.handlerRotateCredentials, accessUser, 0},
+		{"POST /api/users/{userID}/follow", cfg.handlerFollowUser, accessUser, withPolicy | withUsageWrite},
+		{"DELETE /api/users/{userID}/follow", cfg.handlerUnfollowUser, accessUser, withUsageWrite},
+		{"GET /api/users/{userID}/followers", cfg.handlerListFollowers, accessOpen, withQuota},
+		{"GET /api/users/{userID}/following", cfg.handlerListFollowing, accessOpen, withQuota},
+		{"GET /api/policies/current", cfg.handlerCurrentPolicy, accessOpen, 0},
+		{"POST /api/login", cfg.handlerChirpsLogin, accessOpen, 0},
+		{"GET /api/sso/login", cfg.handlerSSOLogin, accessOpen, 0},
+		{"GET /api/sso/callback", cfg.handlerSSOCallback, accessOpen, 0},
+		{"POST /api/refresh", cfg.handlerRefreshTokens, accessHandler, 0},
+		{"POST /api/revoke", cfg.handlerRevokRefreshToken, accessHandler, 0},
+		{"POST /api/polka/webhooks", cfg.handlerAddSubscription, accessHandler, 0},
+	}
+}
+
+// registerRoutes adds routes to mux. coalesceReads may be nil, in which
+// case withCoalesce has no effect.
+func (cfg *apiConfig) registerRoutes(mux *http.ServeMux, routes []route, coalesceReads func(http.Handler) http.Handler) error {
+	for _, rt := range routes {
+		h, err := cfg.routeHandler(rt, coalesceReads)
+		if err != nil {
+			return err
+		}
+		mux.Handle(rt.pattern, h)
+	}
+	mux.Handle(apiPrefix, handlerAPIFallback(routes))
+	return nil
+}
+
+func (cfg *apiConfig) routeHandler(rt route, coalesceReads func(http.Handler) http.Handler) (http.Handler, error) {
+	var h http.Handler = rt.handler
+	if rt.middleware&withCoalesce != 0 && coalesceReads != nil {
+		h = coalesceReads(h)
+	}
+	// Developer keys are metered ahead of coalescing so that every caller
+	// is charged for its own request.
+	if rt.middleware&withQuota != 0 {
+		h = cfg.middlewareDeveloperQuota(h)
+	}
+	// Users are metered ahead of coalescing too, for the same reason.
+	if rt.middleware&withUsageWrite != 0 {
+		h = cfg.middlewareUsage(usageKindWrite, h)
+	}
+	if rt.middleware&withUsageRead != 0 {
+		h = cfg.middlewareUsage(usageKindRead, h)
+	}
+	if rt.middleware&withNoBody != 0 {
+		h = cfg.middlewareNoBody(h)
+	}
+	if rt.middleware&withPolicy != 0 {
+		h = cfg.middlewarePolicyAccepted(h)
+	}
+	switch rt.access {
+	case accessOpen, accessHandler:
+	case accessUser:
+		h = cfg.middlewareAuthenticate(h)
+	case accessAdmin:
+		h = cfg.middlewareAdminAPI(h)
+	case accessAdminSession:
+		h = cfg.middlewareAdminSession(h)
+	case accessSCIM:
+		h = cfg.middlewareSCIM(h)
+	default:
+		return nil, fmt.Errorf("route %q has no access level", rt.pattern)
+	}
+	if rt.middleware&withSecurityHeaders != 0 {
+		h = cfg.middlewareSecurityHeaders(h)
+	}
+	h = cfg.middlewareChaos(rt.pattern, h)
+	return cfg.middlewareDebugCapture(rt.pattern, h), nil
+}
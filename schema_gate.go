@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A breaking schema change is split into an expand migration that only
+// adds, releases that move writes and then reads to the new shape, and a
+// contract migration that drops the old one and raises
+// schema_compatibility.oldest_app_schema. The gate below lets builds from
+// either side of each step serve at the same time.
+const (
+	// schemaVersion is the latest migration this build was written against.
+	schemaVersion int64 = 50
+	// schemaMinVersion is the oldest schema this build can run on: the last
+	// migration that added something its queries use.
+	schemaMinVersion int64 = 50
+
+	schemaCheckInterval = 30 * time.Second
+)
+
+// checkSchemaCompat decides whether this build can serve against a database
+// at dbVersion whose applied migrations need builds expecting at least
+// oldestCompatible. A database ahead of the build is fine as long as only
+// expand migrations were applied past schemaVersion.
+func checkSchemaCompat(dbVersion, oldestCompatible int64) error {
+	if dbVersion < schemaMinVersion {
+		return fmt.Errorf("database schema is at version %d but this build needs at least %d; apply the migrations first", dbVersion, schemaMinVersion)
+	}
+	if oldestCompatible > schemaVersion {
+		return fmt.Errorf("database schema version %d no longer supports builds older than %d and this build expects %d; deploy a newer build", dbVersion, oldestCompatible, schemaVersion)
+	}
+	return nil
+}
+
+// schemaGate holds the outcome of the last schema version check.
+type schemaGate struct {
+	mu  sync.RWMutex
+	err error
+}
+
+func (g *schemaGate) set(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.err = err
+}
+
+func (g *schemaGate) incompatible() error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.err
+}
+
+// runSchemaCheck compares the database schema with this build every
+// schemaCheckInterval, so an instance starts serving once the migrations it
+// needs are applied and stops if a contract migration lands while it is
+// still running. main checks once before listening.
+func (cfg *apiConfig) runSchemaCheck(ctx context.Context) {
+	ticker := time.NewTicker(schemaCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cfg.checkSchema(ctx)
+	}
+}
+
+func (cfg *apiConfig) checkSchema(ctx context.Context) {
+	dbVersion, err := cfg.database.GetSchemaVersion(ctx)
+	if err != nil {
+		// Keep the last outcome; a lost connection is handled by the
+		// failover middleware.
+		log.Printf("Error reading schema version: %s", err)
+		return
+	}
+	oldest, err := cfg.database.GetOldestCompatibleSchema(ctx)
+	if err != nil {
+		log.Printf("Error reading schema compatibility: %s", err)
+		return
+	}
+	err = checkSchemaCompat(dbVersion, oldest)
+	prev := cfg.schemaGate.incompatible()
+	cfg.schemaGate.set(err)
+	if err != nil && prev == nil {
+		log.Printf("Refusing requests: %s", err)
+	} else if err == nil && prev != nil {
+		log.Printf("Database schema is at version %d, serving requests", dbVersion)
+	}
+}
+
+// middlewareSchemaCompatible answers 503 while the database schema is
+// incompatible with this build. Probes and the static app still answer so
+// the instance isn't restarted in a loop during a deploy.
+func (cfg *apiConfig) middlewareSchemaCompatible(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isProbePath(r.URL.Path) || strings.HasPrefix(r.URL.Path, "/app/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := cfg.schemaGate.incompatible(); err != nil {
+			respondWithError(w, http.StatusServiceUnavailable, "Service unavailable during a database upgrade", err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/search"
+	"github.com/google/uuid"
+)
+
+const (
+	searchIndexInterval = 5 * time.Second
+	searchIndexBatch    = 500
+)
+
+// configureSearch picks the search index from SEARCH_INDEX: memory, the
+// default, or elasticsearch. The in-memory index is rebuilt from the event
+// log on every start; Elasticsearch keeps its place in
+// search_index_cursors.
+func (cfg *apiConfig) configureSearch() error {
+	switch index := os.Getenv("SEARCH_INDEX"); index {
+	case "", "memory":
+		cfg.search = search.NewMemory()
+	case "elasticsearch":
+		es := search.Elasticsearch{
+			URL:         os.Getenv("ELASTICSEARCH_URL"),
+			IndexPrefix: cmp.Or(os.Getenv("ELASTICSEARCH_INDEX_PREFIX"), "chirpy"),
+			Username:    os.Getenv("ELASTICSEARCH_USERNAME"),
+			Password:    os.Getenv("ELASTICSEARCH_PASSWORD"),
+			Client:      &http.Client{Timeout: 10 * time.Second},
+		}
+		if es.URL == "" {
+			return fmt.Errorf("SEARCH_INDEX=elasticsearch needs ELASTICSEARCH_URL")
+		}
+		cfg.search = es
+		cfg.searchCursorName = "elasticsearch:" + es.IndexPrefix
+	default:
+		return fmt.Errorf("unknown SEARCH_INDEX %q", index)
+	}
+	return nil
+}
+
+// runSearchIndexer follows the event log, putting each chirp and user it
+// mentions into the search index as they now stand.
+func (cfg *apiConfig) runSearchIndexer(ctx context.Context) {
+	var cursor int64
+	if cfg.searchCursorName != "" {
+		for {
+			var err error
+			cursor, err = cfg.database.GetSearchIndexCursor(ctx, cfg.searchCursorName)
+			if err == nil || errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			log.Printf("Error getting search index cursor: %s", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(searchIndexInterval):
+			}
+		}
+	}
+
+	ticker := time.NewTicker(searchIndexInterval)
+	defer ticker.Stop()
+	for {
+		next, err := cfg.syncSearchIndex(ctx, cursor)
+		if err != nil {
+			log.Printf("Error updating search index: %s", err)
+		}
+		if next != cursor && cfg.searchCursorName != "" {
+			err := cfg.database.SetSearchIndexCursor(ctx, database.SetSearchIndexCursorParams{
+				IndexName:   cfg.searchCursorName,
+				LastEventID: next,
+			})
+			if err != nil {
+				log.Printf("Error saving search index cursor: %s", err)
+			}
+		}
+		cursor = next
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncSearchIndex indexes the aggregates of every event after the one with
+// id after and returns the id of the last event handled. It stops at the
+// first failure so that event is tried again next time.
+func (cfg *apiConfig) syncSearchIndex(ctx context.Context, after int64) (int64, error) {
+	for {
+		events, err := cfg.database.ListEvents(ctx, database.ListEventsParams{
+			AfterID:  after,
+			RowLimit: searchIndexBatch,
+		})
+		if err != nil {
+			return after, err
+		}
+		// An aggregate changed several times in a batch is indexed once.
+		done := map[string]bool{}
+		for _, e := range events {
+			key := e.AggregateType + "/" + e.AggregateID.String()
+			if !done[key] {
+				if err := cfg.indexAggregate(ctx, e.AggregateType, e.AggregateID); err != nil {
+					return after, fmt.Errorf("indexing %s: %w", key, err)
+				}
+				done[key] = true
+			}
+			after = e.ID
+		}
+		if len(events) < searchIndexBatch {
+			return after, nil
+		}
+	}
+}
+
+// indexAggregate puts a chirp or user into the index, or takes it out if
+// it should no longer be found.
+func (cfg *apiConfig) indexAggregate(ctx context.Context, aggregateType string, id uuid.UUID) error {
+	switch aggregateType {
+	case aggregateChirp:
+		msg, err := cfg.database.GetMessageWithAuthorByID(ctx, id)
+		if errors.Is(err, database.ErrChirpNotFound) || (err == nil && msg.Visibility != chirpVisibilityPublic) {
+			return cfg.search.Delete(ctx, search.KindChirp, id.String())
+		}
+		if err != nil {
+			return err
+		}
+		return cfg.search.Put(ctx, search.Document{
+			Kind:   search.KindChirp,
+			ID:     id.String(),
+			Fields: map[string]string{"body": cfg.readableBody(ctx, msg.Body, msg.BodyFiltered)},
+		})
+	case aggregateUser:
+		user, err := cfg.database.GetUserByID(ctx, id)
+		if errors.Is(err, database.ErrUserNotFound) || (err == nil && !userSearchable(user)) {
+			return cfg.search.Delete(ctx, search.KindUser, id.String())
+		}
+		if err != nil {
+			return err
+		}
+		return cfg.search.Put(ctx, search.Document{
+			Kind: search.KindUser,
+			ID:   id.String(),
+			Fields: map[string]string{
+				"handle":       user.Handle.String,
+				"display_name": user.DisplayName.String,
+			},
+		})
+	}
+	return nil
+}
+
+// userSearchable reports whether user can be found by name: a live,
+// approved account with a handle or display name.
+func userSearchable(user database.User) bool {
+	return !user.IsDeleted && !user.WaitlistStatus.Valid && (user.Handle.Valid || user.DisplayName.Valid)
+}
+
+// parseSearch reads q and limit, answering 400 itself if either is
+// missing or invalid.
+func parseSearch(w http.ResponseWriter, r *http.Request, kind string) (search.Query, bool) {
+	query := r.URL.Query()
+	q := search.Query{Kind: kind, Text: strings.TrimSpace(query.Get("q")), Limit: 20}
+	if q.Text == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing q parameter", nil)
+		return search.Query{}, false
+	}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return search.Query{}, false
+		}
+		q.Limit = n
+	}
+	return q, true
+}
+
+// searchHits runs q and returns its hits with their IDs parsed, best
+// first.
+func (cfg *apiConfig) searchHits(ctx context.Context, q search.Query) ([]uuid.UUID, map[uuid.UUID]search.Hit, error) {
+	hits, err := cfg.search.Search(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(hits))
+	byID := make(map[uuid.UUID]search.Hit, len(hits))
+	for _, h := range hits {
+		id, err := uuid.Parse(h.ID)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		byID[id] = h
+	}
+	return ids, byID, nil
+}
+
+// currentHighlight returns highlighted if it marks up text as it is now.
+// The index can lag behind edits, and its copy must not be shown in place
+// of the real one.
+func currentHighlight(highlighted, text string) (string, bool) {
+	plain := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(highlighted)
+	if highlighted == "" || html.UnescapeString(plain) != text {
+		return "", false
+	}
+	return highlighted, true
+}
+
+// handlerSearchChirps finds public chirps matching q, allowing for typos.
+// Results come from the database, so the usual visibility and muting rules
+// apply and anything removed since it was indexed is left out.
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	q, ok := parseSearch(w, r, search.KindChirp)
+	if !ok {
+		return
+	}
+	ids, hits, err := cfg.searchHits(r.Context(), q)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+	rows, err := cfg.database.GetMessagesWithAuthorByIDs(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps", err)
+		return
+	}
+	messages := make([]database.GetMessagesWithAuthorRow, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, database.GetMessagesWithAuthorRow(row))
+	}
+	slices.SortFunc(messages, func(a, b database.GetMessagesWithAuthorRow) int {
+		return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+	})
+	chirps, ok := cfg.timelineChirps(w, r, messages)
+	if !ok {
+		return
+	}
+	for i, c := range chirps {
+		if c.BodyHidden {
+			continue
+		}
+		if h, ok := currentHighlight(hits[c.Id].Highlights["body"], c.Body); ok {
+			chirps[i].Highlight = &h
+		}
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(chirps))
+}
+
+// handlerSearchUsers finds users whose handle or display name matches q,
+// allowing for typos.
+func (cfg *apiConfig) handlerSearchUsers(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		chirpAuthor
+		// Highlights holds the fields that matched, HTML-escaped, with the
+		// matching words wrapped in <mark>.
+		Highlights map[string]string `json:"highlights,omitempty"`
+	}
+
+	q, ok := parseSearch(w, r, search.KindUser)
+	if !ok {
+		return
+	}
+	ids, hits, err := cfg.searchHits(r.Context(), q)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search users", err)
+		return
+	}
+	users, err := cfg.database.ListSearchableUsersByIDs(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get users", err)
+		return
+	}
+	slices.SortFunc(users, func(a, b database.ListSearchableUsersByIDsRow) int {
+		return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+	})
+	bots, err := cfg.botOwners(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get authors", err)
+		return
+	}
+	var entries []entry
+	for _, u := range users {
+		e := entry{chirpAuthor: bots.label(newChirpAuthor(u.ID, u.Handle, u.DisplayName, u.AvatarUrl, u.IsVerified))}
+		current := map[string]string{"handle": u.Handle.String, "display_name": u.DisplayName.String}
+		for field, highlighted := range hits[u.ID].Highlights {
+			if h, ok := currentHighlight(highlighted, current[field]); ok {
+				if e.Highlights == nil {
+					e.Highlights = map[string]string{}
+				}
+				e.Highlights[field] = h
+			}
+		}
+		entries = append(entries, e)
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxContentWarningLength = 100
+
+// parseContentWarning trims the warning an author attached to a chirp. An
+// empty warning means the chirp isn't sensitive.
+func parseContentWarning(raw string) (sql.NullString, bool) {
+	warning := strings.TrimSpace(raw)
+	if utf8.RuneCountInString(warning) > maxContentWarningLength {
+		return sql.NullString{}, false
+	}
+	return sql.NullString{String: warning, Valid: warning != ""}, true
+}
+
+// sensitiveBody decides whether a chirp's body goes into a response. Bodies
+// of sensitive chirps are withheld unless the viewer wrote the chirp or has
+// chosen to see sensitive content; clients then show the warning and ask
+// again with ?show_sensitive=true once the user confirms.
+func sensitiveBody(body string, warning sql.NullString, authorID, viewerID uuid.UUID, reveal bool) (string, bool) {
+	if !warning.Valid || reveal || (viewerID != uuid.Nil && viewerID == authorID) {
+		return body, false
+	}
+	return "", true
+}
+
+// publicChirpBody is the text shown for a chirp on pages and embeds that
+// are rendered for anyone. Sensitive chirps show only their warning.
+func publicChirpBody(body string, warning sql.NullString) string {
+	if warning.Valid {
+		return "Content warning: " + warning.String
+	}
+	return body
+}
+
+// revealSensitive reports whether sensitive bodies should be included for
+// this request. An explicit show_sensitive query parameter wins over the
+// viewer's saved preference.
+func (cfg *apiConfig) revealSensitive(r *http.Request, viewerID uuid.UUID) bool {
+	if raw := r.URL.Query().Get("show_sensitive"); raw != "" {
+		return raw == "true"
+	}
+	if viewerID == uuid.Nil {
+		return false
+	}
+	user, err := cfg.database.GetUserByID(r.Context(), viewerID)
+	return err == nil && user.ShowSensitive
+}
+
+func (cfg *apiConfig) handlerUpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ShowSensitive *bool `json:"show_sensitive"`
+	}
+	type returnVals struct {
+		ShowSensitive bool `json:"show_sensitive"`
+	}
+
+	userID, _ := userIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if params.ShowSensitive == nil {
+		respondWithError(w, http.StatusBadRequest, "show_sensitive is required", nil)
+		return
+	}
+	user, err := cfg.database.SetUserShowSensitive(r.Context(), database.SetUserShowSensitiveParams{
+		ShowSensitive: *params.ShowSensitive,
+		ID:            userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update preferences", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, returnVals{
+		ShowSensitive: user.ShowSensitive,
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+const (
+	emailDomainAllow = "allow"
+	emailDomainDeny  = "deny"
+)
+
+var emailDomainPattern = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)+$`)
+
+// normalizeEmailDomain lowercases a domain and strips the "@" or "*." that
+// admins tend to paste in front of it.
+func normalizeEmailDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "@")
+	return strings.TrimPrefix(domain, "*.")
+}
+
+// emailDomainAllowed applies the signup domain rules to an address. A rule
+// covers its domain and every subdomain. A matching deny rule always wins;
+// once any allow rule exists, the address must match one of them.
+// Addresses that don't parse are refused, and the domain is whatever
+// follows the last "@", without a trailing ".", so neither a second "@"
+// nor a fully qualified domain can slip past a rule.
+func emailDomainAllowed(email string, rules []database.EmailDomainRule) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return false
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.TrimSuffix(normalizeEmailDomain(addr.Address[at+1:]), ".")
+
+	hasAllowRules, allowed := false, false
+	for _, rule := range rules {
+		matches := domain == rule.Domain || strings.HasSuffix(domain, "."+rule.Domain)
+		switch rule.Rule {
+		case emailDomainDeny:
+			if matches {
+				return false
+			}
+		case emailDomainAllow:
+			hasAllowRules = true
+			allowed = allowed || matches
+		}
+	}
+	return !hasAllowRules || allowed
+}
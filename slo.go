@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/breaker"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/slo"
+)
+
+// dependencySLOWebhook names the circuit breaker guarding the latency alert
+// webhook.
+const dependencySLOWebhook = "slo_webhook"
+
+// runSLOMonitor closes a latency window every slo.window_seconds and reports
+// routes whose p95 went over budget. It runs until ctx is cancelled.
+func (cfg *apiConfig) runSLOMonitor(ctx context.Context) {
+	for {
+		window := time.Duration(cfg.settings.Int(ctx, settings.SLOWindowSeconds)) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(window):
+		}
+
+		budgets, err := slo.ParseBudgets(cfg.settings.Get(ctx, settings.SLOLatencyBudgets))
+		if err != nil {
+			log.Printf("Error loading latency budgets: %s", err)
+			continue
+		}
+		alerts := cfg.sloMonitor.Flush(budgets)
+		for _, alert := range alerts {
+			log.Printf("SLO warning: %s p95 %s over budget %s (%d requests in %s)", alert.Route, alert.P95, alert.Budget, alert.Samples, window)
+		}
+		if webhookURL := cfg.settings.Get(ctx, settings.SLOWebhookURL); webhookURL != "" && len(alerts) > 0 {
+			if err := sendSLOWebhook(ctx, cfg.breakers.Get(dependencySLOWebhook), webhookURL, window, alerts); err != nil {
+				log.Printf("Error sending SLO webhook: %s", err)
+			}
+		}
+	}
+}
+
+func sendSLOWebhook(ctx context.Context, b *breaker.Breaker, webhookURL string, window time.Duration, alerts []slo.Alert) error {
+	type alertVals struct {
+		Route    string `json:"route"`
+		P95Ms    int64  `json:"p95_ms"`
+		BudgetMs int64  `json:"budget_ms"`
+		Requests int    `json:"requests"`
+	}
+	type payload struct {
+		WindowSeconds int         `json:"window_seconds"`
+		Alerts        []alertVals `json:"alerts"`
+	}
+	body := payload{WindowSeconds: int(window.Seconds())}
+	for _, alert := range alerts {
+		body.Alerts = append(body.Alerts, alertVals{
+			Route:    alert.Route,
+			P95Ms:    alert.P95.Milliseconds(),
+			BudgetMs: alert.Budget.Milliseconds(),
+			Requests: alert.Samples,
+		})
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return postWebhook(ctx, b, webhookURL, data)
+}
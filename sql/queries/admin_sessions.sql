@@ -0,0 +1,18 @@
+-- name: CreateAdminSession :one
+INSERT INTO admin_sessions (token, user_id, csrf_token, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetAdminSession :one
+SELECT s.token, s.user_id, s.csrf_token, s.expires_at, u.email
+FROM admin_sessions s
+JOIN users u ON s.user_id = u.id
+WHERE s.token = $1 AND s.expires_at > NOW() AND u.is_admin = TRUE AND NOT u.is_deleted;
+
+-- name: DeleteAdminSession :exec
+DELETE FROM admin_sessions WHERE token = $1;
@@ -0,0 +1,15 @@
+-- name: CreateAuditLog :exec
+INSERT INTO audit_logs (id, actor_id, action, target_type, target_id, metadata)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_logs
+ORDER BY created_at DESC
+LIMIT $1;
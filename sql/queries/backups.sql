@@ -0,0 +1,22 @@
+-- name: CreateBackup :one
+INSERT INTO backups (file_name, requested_by)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: FinishBackup :one
+UPDATE backups
+SET status = $2,
+    size_bytes = $3,
+    error = $4,
+    finished_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetBackup :one
+SELECT * FROM backups
+WHERE id = $1;
+
+-- name: ListBackups :many
+SELECT * FROM backups
+ORDER BY started_at DESC
+LIMIT $1;
@@ -0,0 +1,20 @@
+-- name: SetBotAccount :one
+INSERT INTO bot_accounts (user_id, owner_id, developer_key_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET owner_id = EXCLUDED.owner_id,
+    developer_key_id = EXCLUDED.developer_key_id
+RETURNING *;
+
+-- name: DeleteBotAccount :execrows
+DELETE FROM bot_accounts WHERE user_id = $1;
+
+-- name: ListBotOwners :many
+SELECT user_id, owner_id FROM bot_accounts WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[]);
+
+-- name: GetBotQuotaTier :one
+-- The tier of the developer key a bot was linked with, if it is still live.
+SELECT k.tier
+FROM bot_accounts b
+JOIN developer_api_keys k ON k.id = b.developer_key_id
+WHERE b.user_id = $1 AND k.revoked_at IS NULL;
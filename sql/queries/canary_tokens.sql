@@ -0,0 +1,22 @@
+-- name: CreateCanaryToken :one
+INSERT INTO canary_tokens (kind, label, token_hash, created_by)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: ListCanaryTokens :many
+SELECT * FROM canary_tokens ORDER BY created_at;
+
+-- name: DeleteCanaryToken :execrows
+DELETE FROM canary_tokens WHERE id = $1;
+
+-- name: TripCanaryToken :one
+UPDATE canary_tokens
+SET trigger_count = trigger_count + 1,
+    last_triggered_at = NOW()
+WHERE token_hash = $1 AND kind = $2
+RETURNING *;
@@ -0,0 +1,30 @@
+-- name: CreateChirpMedia :one
+INSERT INTO chirp_media (id, chirp_id, storage_key, content_type, size_bytes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CountChirpMedia :one
+SELECT COUNT(*) FROM chirp_media WHERE chirp_id = $1;
+
+-- name: ListChirpMediaByChirps :many
+SELECT * FROM chirp_media
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY created_at, id;
+
+-- name: ListOrphanedChirpMedia :many
+SELECT * FROM chirp_media
+WHERE chirp_id IS NULL
+ORDER BY created_at
+LIMIT $1;
+
+-- name: DeleteChirpMedia :exec
+DELETE FROM chirp_media WHERE id = $1;
+
+-- name: ListPendingChirpMedia :many
+SELECT * FROM chirp_media
+WHERE variants_status = 'pending' AND chirp_id IS NOT NULL
+ORDER BY created_at
+LIMIT $1;
+
+-- name: SetChirpMediaVariantsStatus :exec
+UPDATE chirp_media SET variants_status = $2 WHERE id = $1;
@@ -0,0 +1,9 @@
+-- name: CreateChirpMediaVariant :exec
+INSERT INTO chirp_media_variants (media_id, name, storage_key, content_type, width, height, size_bytes)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (media_id, name) DO NOTHING;
+
+-- name: ListChirpMediaVariants :many
+SELECT * FROM chirp_media_variants
+WHERE media_id = ANY(sqlc.arg(media_ids)::uuid[])
+ORDER BY media_id, name;
@@ -0,0 +1,15 @@
+-- name: ListCustomEmojis :many
+SELECT * FROM custom_emojis ORDER BY shortcode;
+
+-- name: ListCustomEmojisByShortcode :many
+SELECT * FROM custom_emojis WHERE shortcode = ANY(sqlc.arg(shortcodes)::text[]);
+
+-- name: UpsertCustomEmoji :one
+INSERT INTO custom_emojis (shortcode, image_url)
+VALUES ($1, $2)
+ON CONFLICT (shortcode) DO UPDATE
+SET image_url = EXCLUDED.image_url, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteCustomEmoji :execrows
+DELETE FROM custom_emojis WHERE shortcode = $1;
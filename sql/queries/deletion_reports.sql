@@ -0,0 +1,59 @@
+-- name: CountUserReferences :many
+-- Every column that refers to a user is listed here, so that the purge
+-- job can show nothing is left once the user is deleted.
+SELECT 'users.id' AS reference, COUNT(*) AS row_count FROM users WHERE id = sqlc.arg(user_id)
+UNION ALL SELECT 'messages.user_id', COUNT(*) FROM messages WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'messages.posted_by_id', COUNT(*) FROM messages WHERE posted_by_id = sqlc.arg(user_id)
+UNION ALL SELECT 'refresh_tokens.user_id', COUNT(*) FROM refresh_tokens WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'admin_sessions.user_id', COUNT(*) FROM admin_sessions WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'audit_logs.actor_id', COUNT(*) FROM audit_logs WHERE actor_id = sqlc.arg(user_id)
+UNION ALL SELECT 'audit_logs.metadata', COUNT(*) FROM audit_logs WHERE target_type = 'user' AND target_id = (sqlc.arg(user_id)::uuid)::text AND metadata <> '{}'
+UNION ALL SELECT 'link_redirects.user_id', COUNT(*) FROM link_redirects WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'post_delegations.owner_id', COUNT(*) FROM post_delegations WHERE owner_id = sqlc.arg(user_id)
+UNION ALL SELECT 'post_delegations.delegate_id', COUNT(*) FROM post_delegations WHERE delegate_id = sqlc.arg(user_id)
+UNION ALL SELECT 'developer_api_keys.user_id', COUNT(*) FROM developer_api_keys WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'oauth_clients.owner_id', COUNT(*) FROM oauth_clients WHERE owner_id = sqlc.arg(user_id)
+UNION ALL SELECT 'oauth_authorization_codes.user_id', COUNT(*) FROM oauth_authorization_codes WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'oauth_access_tokens.user_id', COUNT(*) FROM oauth_access_tokens WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'user_identities.user_id', COUNT(*) FROM user_identities WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'user_pii_history.user_id', COUNT(*) FROM user_pii_history WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'policy_acceptances.user_id', COUNT(*) FROM policy_acceptances WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'backups.requested_by', COUNT(*) FROM backups WHERE requested_by = sqlc.arg(user_id)
+UNION ALL SELECT 'red_gifts.gifter_id', COUNT(*) FROM red_gifts WHERE gifter_id = sqlc.arg(user_id)
+UNION ALL SELECT 'red_gifts.recipient_id', COUNT(*) FROM red_gifts WHERE recipient_id = sqlc.arg(user_id)
+UNION ALL SELECT 'follows.follower_id', COUNT(*) FROM follows WHERE follower_id = sqlc.arg(user_id)
+UNION ALL SELECT 'follows.followee_id', COUNT(*) FROM follows WHERE followee_id = sqlc.arg(user_id)
+UNION ALL SELECT 'likes.user_id', COUNT(*) FROM likes WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'muted_words.user_id', COUNT(*) FROM muted_words WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'lists.owner_id', COUNT(*) FROM lists WHERE owner_id = sqlc.arg(user_id)
+UNION ALL SELECT 'list_members.user_id', COUNT(*) FROM list_members WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'bot_accounts.user_id', COUNT(*) FROM bot_accounts WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'bot_accounts.owner_id', COUNT(*) FROM bot_accounts WHERE owner_id = sqlc.arg(user_id)
+UNION ALL SELECT 'canary_tokens.created_by', COUNT(*) FROM canary_tokens WHERE created_by = sqlc.arg(user_id)
+UNION ALL SELECT 'pending_admin_actions.requested_by', COUNT(*) FROM pending_admin_actions WHERE requested_by = sqlc.arg(user_id)
+UNION ALL SELECT 'pending_admin_actions.decided_by', COUNT(*) FROM pending_admin_actions WHERE decided_by = sqlc.arg(user_id)
+UNION ALL SELECT 'user_api_usage.user_id', COUNT(*) FROM user_api_usage WHERE user_id = sqlc.arg(user_id)
+UNION ALL SELECT 'user_avatars.user_id', COUNT(*) FROM user_avatars WHERE user_id = sqlc.arg(user_id);
+
+-- name: AnonymizeUserAuditLogs :execrows
+UPDATE audit_logs
+SET metadata = '{}'
+WHERE target_type = 'user' AND target_id = $1 AND metadata <> '{}';
+
+-- name: CreateDeletionReport :one
+INSERT INTO deletion_reports (user_id, verified, report, signature)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: ListDeletionReports :many
+SELECT id, user_id, created_at, verified FROM deletion_reports
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: GetDeletionReport :one
+SELECT * FROM deletion_reports WHERE id = $1;
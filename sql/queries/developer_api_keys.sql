@@ -0,0 +1,46 @@
+-- name: CreateDeveloperAPIKey :one
+INSERT INTO developer_api_keys (user_id, name, key_hash)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetDeveloperAPIKeyByHash :one
+SELECT k.*
+FROM developer_api_keys k
+JOIN users u ON k.user_id = u.id
+WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND NOT u.is_deleted;
+
+-- name: ListDeveloperAPIKeysForUser :many
+SELECT * FROM developer_api_keys
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: RevokeDeveloperAPIKey :execrows
+UPDATE developer_api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: SetDeveloperAPIKeyTier :one
+UPDATE developer_api_keys
+SET tier = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: IncrementDeveloperAPIUsage :one
+INSERT INTO developer_api_usage (key_id, day, requests)
+VALUES (
+    $1,
+    $2,
+    1
+)
+ON CONFLICT (key_id, day) DO UPDATE
+SET requests = developer_api_usage.requests + 1
+RETURNING requests;
+
+-- name: ListDeveloperAPIUsage :many
+SELECT day, requests FROM developer_api_usage
+WHERE key_id = $1 AND day >= $2
+ORDER BY day DESC;
@@ -0,0 +1,16 @@
+-- name: ListEmailDomainRules :many
+SELECT * FROM email_domain_rules ORDER BY domain;
+
+-- name: UpsertEmailDomainRule :one
+INSERT INTO email_domain_rules (domain, rule)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (domain) DO UPDATE
+SET rule = EXCLUDED.rule,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteEmailDomainRule :execrows
+DELETE FROM email_domain_rules WHERE domain = $1;
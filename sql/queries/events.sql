@@ -0,0 +1,15 @@
+-- name: AppendEvent :exec
+INSERT INTO events (event_type, aggregate_type, aggregate_id, payload)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: ListEvents :many
+SELECT * FROM events
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(event_type)::text IS NULL OR event_type = sqlc.narg(event_type))
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
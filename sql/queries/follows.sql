@@ -0,0 +1,35 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: ListFollowedAmong :many
+SELECT followee_id FROM follows
+WHERE follower_id = sqlc.arg(follower_id) AND followee_id = ANY(sqlc.arg(followee_ids)::uuid[]);
+
+-- name: ListFollowers :many
+SELECT u.id, u.handle, u.display_name, u.avatar_url, u.is_verified, f.created_at AS followed_at,
+    EXISTS (SELECT 1 FROM follows y WHERE y.follower_id = u.id AND y.followee_id = sqlc.arg(viewer_id)) AS follows_viewer,
+    EXISTS (SELECT 1 FROM follows v WHERE v.follower_id = sqlc.arg(viewer_id) AND v.followee_id = u.id) AS followed_by_viewer
+FROM follows f
+JOIN users u ON u.id = f.follower_id
+WHERE f.followee_id = sqlc.arg(user_id) AND NOT u.is_deleted
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (f.created_at, f.follower_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY f.created_at DESC, f.follower_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListFollowing :many
+SELECT u.id, u.handle, u.display_name, u.avatar_url, u.is_verified, f.created_at AS followed_at,
+    EXISTS (SELECT 1 FROM follows y WHERE y.follower_id = u.id AND y.followee_id = sqlc.arg(viewer_id)) AS follows_viewer,
+    EXISTS (SELECT 1 FROM follows v WHERE v.follower_id = sqlc.arg(viewer_id) AND v.followee_id = u.id) AS followed_by_viewer
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = sqlc.arg(user_id) AND NOT u.is_deleted
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (f.created_at, f.followee_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY f.created_at DESC, f.followee_id DESC
+LIMIT sqlc.arg(row_limit);
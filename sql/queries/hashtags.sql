@@ -0,0 +1,27 @@
+-- name: AddChirpHashtags :exec
+WITH tags AS (
+    INSERT INTO hashtags (tag)
+    SELECT unnest(sqlc.arg(tags)::text[])
+    ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+    RETURNING id
+)
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id)
+SELECT sqlc.arg(chirp_id), id FROM tags
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags WHERE chirp_id = $1;
+
+-- name: GetMessagesByHashtag :many
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM hashtags h
+JOIN chirp_hashtags ch ON ch.hashtag_id = h.id
+JOIN messages m ON m.id = ch.chirp_id
+JOIN users u ON m.user_id = u.id
+WHERE h.tag = sqlc.arg(tag)
+  AND m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,14 @@
+-- name: CountChirpLikes :many
+SELECT chirp_id, COUNT(*) AS like_count, BOOL_OR(user_id = sqlc.arg(viewer_id)) AS liked_by_me
+FROM likes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: LikeChirp :exec
+INSERT INTO likes (user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM likes
+WHERE user_id = $1 AND chirp_id = $2;
@@ -0,0 +1,20 @@
+-- name: CreateLinkRedirect :exec
+INSERT INTO link_redirects (code, target_url, message_id, user_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: RecordLinkClick :one
+UPDATE link_redirects
+SET click_count = click_count + 1,
+    last_clicked_at = NOW()
+WHERE code = $1
+RETURNING target_url;
+
+-- name: ListLinkRedirectsForMessage :many
+SELECT * FROM link_redirects
+WHERE message_id = $1 AND user_id = $2
+ORDER BY created_at;
@@ -0,0 +1,49 @@
+-- name: CreateList :one
+INSERT INTO lists (owner_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: ListListsByOwner :many
+SELECT l.id, l.owner_id, l.name, l.created_at, l.updated_at, COUNT(lm.user_id) AS member_count
+FROM lists l
+LEFT JOIN list_members lm ON lm.list_id = l.id
+WHERE l.owner_id = $1
+GROUP BY l.id
+ORDER BY l.created_at;
+
+-- name: GetList :one
+SELECT * FROM lists WHERE id = $1;
+
+-- name: DeleteList :execrows
+DELETE FROM lists WHERE id = $1 AND owner_id = $2;
+
+-- name: CountListMembers :one
+SELECT COUNT(*) FROM list_members WHERE list_id = $1;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :exec
+DELETE FROM list_members WHERE list_id = $1 AND user_id = $2;
+
+-- name: ListListMembers :many
+SELECT u.id, u.handle, u.display_name, u.avatar_url, u.is_verified, lm.added_at
+FROM list_members lm
+JOIN users u ON u.id = lm.user_id
+WHERE lm.list_id = $1 AND NOT u.is_deleted
+ORDER BY lm.added_at, u.id;
+
+-- name: GetMessagesByList :many
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM list_members lm
+JOIN messages m ON m.user_id = lm.user_id
+JOIN users u ON m.user_id = u.id
+WHERE lm.list_id = sqlc.arg(list_id)
+  AND m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT sqlc.arg(row_limit);
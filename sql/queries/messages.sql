@@ -0,0 +1,145 @@
+-- name: GetMessagesByAuthor :many
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM messages m
+JOIN users u ON m.user_id = u.id
+WHERE m.user_id = sqlc.arg(author_id)
+  AND m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY m.created_at, m.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetMessagesByAuthorDesc :many
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM messages m
+JOIN users u ON m.user_id = u.id
+WHERE m.user_id = sqlc.arg(author_id)
+  AND m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetMessagesWithAuthor :many
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM messages m
+JOIN users u ON m.user_id = u.id
+WHERE m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY m.created_at, m.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetMessagesWithAuthorDesc :many
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM messages m
+JOIN users u ON m.user_id = u.id
+WHERE m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (m.created_at, m.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY m.created_at DESC, m.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetMessageWithAuthorByID :one
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM messages m
+JOIN users u ON m.user_id = u.id
+WHERE m.id = $1 AND m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW());
+
+-- name: ListPendingMessages :many
+SELECT * FROM messages
+WHERE status = 'pending'
+ORDER BY created_at;
+
+-- name: SetPendingMessageStatus :one
+UPDATE messages
+SET status = $1,
+    updated_at = NOW()
+WHERE id = $2 AND status = 'pending'
+RETURNING *;
+
+-- name: GetMessageThread :many
+WITH RECURSIVE ancestors AS (
+    SELECT m.id, m.parent_id, 0 AS depth
+    FROM messages m
+    WHERE m.id = $1
+    UNION ALL
+    SELECT p.id, p.parent_id, a.depth - 1
+    FROM messages p
+    JOIN ancestors a ON p.id = a.parent_id
+), descendants AS (
+    SELECT m.id, 0 AS depth, ARRAY[m.created_at::text || m.id::text] AS path
+    FROM messages m
+    WHERE m.id = $1
+    UNION ALL
+    SELECT c.id, d.depth + 1, d.path || (c.created_at::text || c.id::text)
+    FROM messages c
+    JOIN descendants d ON c.parent_id = d.id
+), thread AS (
+    SELECT id, depth, ARRAY[]::text[] AS path FROM ancestors WHERE depth < 0
+    UNION ALL
+    SELECT id, depth, path FROM descendants
+)
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified, t.depth::int AS depth
+FROM thread t
+JOIN messages m ON m.id = t.id
+JOIN users u ON m.user_id = u.id
+WHERE m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW())
+ORDER BY t.depth < 0 DESC, LEAST(t.depth, 0), t.path;
+
+-- name: ExportPublishedMessages :many
+SELECT * FROM messages
+WHERE status = 'published'
+  AND visibility = 'public'
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND user_id NOT IN (SELECT id FROM users WHERE is_deleted)
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamp, sqlc.arg(after_id)::uuid)
+  AND created_at < sqlc.arg(until)
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteExpiredMessages :many
+DELETE FROM messages
+WHERE expires_at <= $1
+RETURNING id, user_id;
+
+-- name: FindRecentDuplicateMessage :one
+SELECT id FROM messages
+WHERE user_id = $1
+  AND body_hash = $2
+  AND created_at > $3
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListUnfilteredMessages :many
+SELECT id, body FROM messages
+WHERE NOT body_filtered
+ORDER BY created_at, id
+LIMIT $1;
+
+-- name: FilterMessageBody :execrows
+-- FilterMessageBody stores the cleaned body unless the chirp was edited
+-- since it was read, in which case the edit already cleaned it.
+UPDATE messages
+SET body = $2,
+    body_filtered = TRUE
+WHERE id = $1 AND NOT body_filtered;
+
+-- name: CountRecentMessagesByUser :one
+SELECT COUNT(*)::int AS count, COALESCE(MIN(created_at), NOW())::timestamp AS oldest_created_at
+FROM messages
+WHERE user_id = $1 AND created_at > $2;
@@ -0,0 +1,11 @@
+-- name: CreateMutedWord :one
+INSERT INTO muted_words (user_id, phrase)
+VALUES ($1, $2)
+ON CONFLICT (user_id, phrase) DO UPDATE SET phrase = EXCLUDED.phrase
+RETURNING *;
+
+-- name: ListMutedWords :many
+SELECT * FROM muted_words WHERE user_id = $1 ORDER BY created_at;
+
+-- name: DeleteMutedWord :execrows
+DELETE FROM muted_words WHERE id = $1 AND user_id = $2;
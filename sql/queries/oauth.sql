@@ -0,0 +1,65 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (owner_id, name, secret_hash, redirect_uris)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients WHERE id = $1;
+
+-- name: ListOAuthClientsForUser :many
+SELECT * FROM oauth_clients
+WHERE owner_id = $1
+ORDER BY created_at;
+
+-- name: DeleteOAuthClient :execrows
+DELETE FROM oauth_clients
+WHERE id = $1 AND owner_id = $2;
+
+-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scope, code_challenge, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: ConsumeOAuthAuthorizationCode :one
+DELETE FROM oauth_authorization_codes
+WHERE code_hash = $1 AND expires_at > NOW()
+RETURNING *;
+
+-- name: CreateOAuthAccessToken :exec
+INSERT INTO oauth_access_tokens (token_hash, client_id, user_id, scope, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: GetOAuthAccessToken :one
+SELECT t.*
+FROM oauth_access_tokens t
+JOIN users u ON t.user_id = u.id
+WHERE t.token_hash = $1 AND t.revoked_at IS NULL AND t.expires_at > NOW()
+    AND NOT u.is_deleted AND NOT u.password_change_required;
+
+-- name: RevokeOAuthAccessToken :execrows
+UPDATE oauth_access_tokens
+SET revoked_at = NOW()
+WHERE token_hash = $1 AND client_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserOAuthAccessTokens :execrows
+UPDATE oauth_access_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,32 @@
+-- name: EnqueueOutboxMessage :exec
+INSERT INTO outbox (id, kind, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimOutboxMessages :many
+-- Leases due messages to one relay until lease_until, so instances running
+-- the relay side by side don't deliver the same message twice.
+UPDATE outbox
+SET attempts = attempts + 1,
+    next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id FROM outbox
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeleteOutboxMessage :exec
+DELETE FROM outbox WHERE id = $1;
+
+-- name: FailOutboxMessage :exec
+UPDATE outbox
+SET status = $2,
+    next_attempt_at = $3,
+    last_error = $4
+WHERE id = $1;
+
+-- name: DeleteDeadOutboxMessages :execrows
+DELETE FROM outbox
+WHERE status = 'dead' AND created_at < sqlc.arg(cutoff)::timestamp;
@@ -0,0 +1,34 @@
+-- name: CreatePendingAdminAction :one
+INSERT INTO pending_admin_actions (action, target_id, requested_by, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: ListPendingAdminActions :many
+SELECT * FROM pending_admin_actions
+WHERE status = 'pending' AND expires_at > NOW()
+ORDER BY created_at;
+
+-- name: GetPendingAdminAction :one
+SELECT * FROM pending_admin_actions WHERE id = $1;
+
+-- name: DecidePendingAdminAction :one
+-- Only an open, unexpired request can be decided, and only once.
+UPDATE pending_admin_actions
+SET status = $2,
+    decided_by = $3,
+    decided_at = NOW()
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+RETURNING *;
+
+-- name: ExpirePendingAdminActions :execrows
+-- Lapsed requests are rejected by nobody, freeing the action and target
+-- for a new request.
+UPDATE pending_admin_actions
+SET status = 'rejected',
+    decided_at = NOW()
+WHERE status = 'pending' AND expires_at <= NOW();
@@ -0,0 +1,31 @@
+-- name: AcceptPolicyVersion :one
+INSERT INTO policy_acceptances (user_id, policy_version_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, policy_version_id) DO UPDATE SET accepted_at = policy_acceptances.accepted_at
+RETURNING accepted_at;
+
+-- name: CreatePolicyVersion :one
+INSERT INTO policy_versions (version, url)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetLatestPolicyVersion :one
+SELECT * FROM policy_versions
+ORDER BY published_at DESC, id DESC
+LIMIT 1;
+
+-- name: GetPendingPolicyVersion :one
+SELECT p.id, p.version, p.url, p.published_at FROM policy_versions p
+WHERE p.id = (
+    SELECT latest.id FROM policy_versions latest
+    ORDER BY latest.published_at DESC, latest.id DESC
+    LIMIT 1
+)
+AND NOT EXISTS (
+    SELECT 1 FROM policy_acceptances a
+    WHERE a.policy_version_id = p.id AND a.user_id = $1
+);
+
+-- name: ListPolicyVersions :many
+SELECT * FROM policy_versions
+ORDER BY published_at DESC, id DESC;
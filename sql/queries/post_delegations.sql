@@ -0,0 +1,30 @@
+-- name: CreatePostDelegation :one
+INSERT INTO post_delegations (owner_id, delegate_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (owner_id, delegate_id) DO UPDATE
+SET owner_id = EXCLUDED.owner_id
+RETURNING *;
+
+-- name: AcceptPostDelegation :one
+UPDATE post_delegations
+SET accepted_at = NOW()
+WHERE owner_id = $1 AND delegate_id = $2 AND accepted_at IS NULL
+RETURNING *;
+
+-- name: DeletePostDelegation :execrows
+DELETE FROM post_delegations
+WHERE owner_id = $1 AND delegate_id = $2;
+
+-- name: HasAcceptedPostDelegation :one
+SELECT EXISTS (
+    SELECT 1 FROM post_delegations
+    WHERE owner_id = $1 AND delegate_id = $2 AND accepted_at IS NOT NULL
+);
+
+-- name: ListPostDelegationsForUser :many
+SELECT * FROM post_delegations
+WHERE owner_id = $1 OR delegate_id = $1
+ORDER BY created_at;
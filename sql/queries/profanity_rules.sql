@@ -0,0 +1,11 @@
+-- name: ListProfanityRules :many
+SELECT * FROM profanity_rules ORDER BY word, context;
+
+-- name: SetProfanityRule :exec
+INSERT INTO profanity_rules (word, context, action)
+VALUES ($1, $2, $3)
+ON CONFLICT (word, context) DO UPDATE
+SET action = EXCLUDED.action, updated_at = NOW();
+
+-- name: DeleteProfanityWord :execrows
+DELETE FROM profanity_rules WHERE word = $1;
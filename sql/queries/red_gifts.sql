@@ -0,0 +1,47 @@
+-- name: CreateRedGift :one
+INSERT INTO red_gifts (gifter_id, recipient_id, months)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListRedGiftsForRecipient :many
+SELECT g.id, g.months, g.created_at, g.seen_at,
+    u.id AS gifter_id, u.handle AS gifter_handle, u.display_name AS gifter_display_name,
+    u.avatar_url AS gifter_avatar_url, COALESCE(u.is_verified, FALSE) AS gifter_is_verified
+FROM red_gifts g
+LEFT JOIN users u ON u.id = g.gifter_id AND NOT u.is_deleted
+WHERE g.recipient_id = $1
+ORDER BY g.created_at DESC
+LIMIT $2;
+
+-- name: MarkRedGiftsSeen :exec
+UPDATE red_gifts
+SET seen_at = NOW()
+WHERE recipient_id = $1 AND seen_at IS NULL;
+
+-- name: ExtendChirpyRed :one
+-- ExtendChirpyRed starts or extends gifted Red by months. Members already
+-- on Red without an expiry get no row back, since their Red doesn't end.
+INSERT INTO chirpy_red_expirations (user_id, expires_at)
+SELECT u.id, NOW() + make_interval(months => sqlc.arg(months)::int)
+FROM users u
+WHERE u.id = sqlc.arg(user_id)
+    AND (NOT u.is_chirpy_red OR EXISTS (SELECT 1 FROM chirpy_red_expirations e WHERE e.user_id = u.id))
+ON CONFLICT (user_id) DO UPDATE
+SET expires_at = GREATEST(chirpy_red_expirations.expires_at, NOW()) + make_interval(months => sqlc.arg(months)::int)
+RETURNING expires_at;
+
+-- name: ClearChirpyRedExpiry :exec
+DELETE FROM chirpy_red_expirations
+WHERE user_id = $1;
+
+-- name: ExpireChirpyRed :many
+WITH expired AS (
+    DELETE FROM chirpy_red_expirations
+    WHERE expires_at <= NOW()
+    RETURNING user_id
+)
+UPDATE users
+SET is_chirpy_red = FALSE,
+    updated_at = NOW()
+WHERE id IN (SELECT user_id FROM expired)
+RETURNING id;
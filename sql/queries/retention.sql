@@ -0,0 +1,28 @@
+-- name: CountChirpsOlderThan :one
+SELECT COUNT(*) FROM messages WHERE created_at < sqlc.arg(cutoff)::timestamp;
+
+-- name: DeleteChirpsOlderThan :execrows
+-- Deletes in batches so a newly enabled rule doesn't hold locks on the
+-- whole table.
+DELETE FROM messages
+WHERE id IN (
+    SELECT id FROM messages
+    WHERE created_at < sqlc.arg(cutoff)::timestamp
+    LIMIT sqlc.arg(batch_size)
+);
+
+-- name: CountStaleRefreshTokens :one
+SELECT COUNT(*) FROM refresh_tokens
+WHERE revoked_at < sqlc.arg(cutoff)::timestamp OR expires_at < sqlc.arg(cutoff)::timestamp;
+
+-- name: DeleteStaleRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE revoked_at < sqlc.arg(cutoff)::timestamp OR expires_at < sqlc.arg(cutoff)::timestamp;
+
+-- name: CountStaleOAuthAccessTokens :one
+SELECT COUNT(*) FROM oauth_access_tokens
+WHERE revoked_at < sqlc.arg(cutoff)::timestamp OR expires_at < sqlc.arg(cutoff)::timestamp;
+
+-- name: DeleteStaleOAuthAccessTokens :execrows
+DELETE FROM oauth_access_tokens
+WHERE revoked_at < sqlc.arg(cutoff)::timestamp OR expires_at < sqlc.arg(cutoff)::timestamp;
@@ -0,0 +1,3 @@
+-- name: GetOldestCompatibleSchema :one
+SELECT COALESCE(MAX(oldest_app_schema), 0)::bigint AS oldest_app_schema
+FROM schema_compatibility;
@@ -0,0 +1,22 @@
+-- name: GetSearchIndexCursor :one
+SELECT last_event_id FROM search_index_cursors WHERE index_name = $1;
+
+-- name: SetSearchIndexCursor :exec
+INSERT INTO search_index_cursors (index_name, last_event_id)
+VALUES ($1, $2)
+ON CONFLICT (index_name) DO UPDATE
+SET last_event_id = EXCLUDED.last_event_id,
+    updated_at = NOW();
+
+-- name: GetMessagesWithAuthorByIDs :many
+SELECT m.*, u.handle AS author_handle, u.display_name AS author_display_name, u.avatar_url AS author_avatar_url, u.is_verified AS author_is_verified
+FROM messages m
+JOIN users u ON m.user_id = u.id
+WHERE m.id = ANY(sqlc.arg(ids)::uuid[])
+  AND m.status = 'published' AND NOT u.is_deleted
+  AND (m.expires_at IS NULL OR m.expires_at > NOW());
+
+-- name: ListSearchableUsersByIDs :many
+SELECT id, handle, display_name, avatar_url, is_verified FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+  AND NOT is_deleted AND waitlist_status IS NULL;
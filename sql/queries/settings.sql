@@ -0,0 +1,17 @@
+-- name: ListSettings :many
+SELECT * FROM settings ORDER BY key;
+
+-- name: UpsertSetting :one
+INSERT INTO settings (key, value, updated_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (key) DO UPDATE
+SET value = EXCLUDED.value,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteSetting :exec
+DELETE FROM settings WHERE key = $1;
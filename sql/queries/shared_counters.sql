@@ -0,0 +1,22 @@
+-- name: AddSharedCounter :one
+INSERT INTO shared_counters (key, value, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (key) DO UPDATE SET
+    value = CASE
+        WHEN shared_counters.expires_at <= NOW() THEN EXCLUDED.value
+        ELSE shared_counters.value + EXCLUDED.value
+    END,
+    expires_at = EXCLUDED.expires_at
+RETURNING value;
+
+-- name: DeleteExpiredSharedCounters :execrows
+DELETE FROM shared_counters
+WHERE expires_at <= $1;
+
+-- name: DeleteSharedCounter :exec
+DELETE FROM shared_counters
+WHERE key = $1;
+
+-- name: GetSharedCounter :one
+SELECT value FROM shared_counters
+WHERE key = $1 AND (expires_at IS NULL OR expires_at > NOW());
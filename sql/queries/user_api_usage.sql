@@ -0,0 +1,20 @@
+-- name: IncrementUserAPIUsage :one
+WITH usage AS (
+    INSERT INTO user_api_usage (user_id, month, kind, requests)
+    VALUES (
+        $1,
+        $2,
+        $3,
+        1
+    )
+    ON CONFLICT (user_id, month, kind) DO UPDATE
+    SET requests = user_api_usage.requests + 1
+    RETURNING requests
+)
+SELECT usage.requests, u.is_chirpy_red
+FROM usage
+JOIN users u ON u.id = $1;
+
+-- name: ListUserAPIUsage :many
+SELECT kind, requests FROM user_api_usage
+WHERE user_id = $1 AND month = $2;
@@ -0,0 +1,16 @@
+-- name: CreateUserAvatar :one
+INSERT INTO user_avatars (id, user_id, storage_key, content_type, size_bytes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: DetachUserAvatar :exec
+UPDATE user_avatars SET user_id = NULL WHERE user_id = $1;
+
+-- name: ListOrphanedUserAvatars :many
+SELECT * FROM user_avatars
+WHERE user_id IS NULL
+ORDER BY created_at
+LIMIT $1;
+
+-- name: DeleteUserAvatar :exec
+DELETE FROM user_avatars WHERE id = $1;
@@ -0,0 +1,13 @@
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (issuer, subject, user_id)
+VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetUserByIdentity :one
+SELECT u.*
+FROM user_identities i
+JOIN users u ON i.user_id = u.id
+WHERE i.issuer = $1 AND i.subject = $2;
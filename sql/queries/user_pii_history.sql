@@ -0,0 +1,15 @@
+-- name: CreateUserPIIChange :exec
+INSERT INTO user_pii_history (user_id, field, old_value, new_value, source)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: ListUserPIIHistory :many
+SELECT * FROM user_pii_history
+WHERE user_id = $1
+ORDER BY changed_at DESC
+LIMIT $2;
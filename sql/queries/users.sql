@@ -1,21 +1,32 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, waitlist_status)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
 -- name: CreateMessage :one
 
-INSERT INTO messages (id, body, user_id) 
+INSERT INTO messages (id, created_at, updated_at, body, user_id, status, parent_id, posted_by_id, visibility, expires_at, content_warning, body_hash, body_filtered)
 VALUES (
-    gen_random_uuid(),
     $1,
-    $2
+    $2,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    TRUE
 )
 RETURNING *;
 
@@ -35,10 +46,10 @@ VALUES (
 RETURNING token;
 
 -- name: GetUserFromRefreshToken :one
-SELECT u.*
+SELECT u.*, rt.expires_at AS refresh_token_expires_at, rt.revoked_at AS refresh_token_revoked_at
 FROM refresh_tokens rt
 JOIN users u ON rt.user_id = u.id
-WHERE rt.token = $1 AND rt.revoked_at IS NULL AND rt.expires_at > NOW();
+WHERE rt.token = $1 AND NOT u.is_deleted;
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
@@ -50,20 +61,131 @@ WHERE token = $1;
 UPDATE users
 SET updated_at = NOW(),
     email = $1,
-    hashed_password = $2
+    hashed_password = $2,
+    password_change_required = FALSE
 WHERE id = $3
 RETURNING email;
 
--- name: DeleteChirpsByID :exec
+-- name: DeleteChirpsByID :execrows
 DELETE FROM messages WHERE id = $1 AND user_id = $2;
 
+-- name: UpdateMessageBody :one
+UPDATE messages
+SET body = $1,
+    status = $2,
+    body_hash = $3,
+    body_filtered = TRUE,
+    updated_at = NOW()
+WHERE id = $4 AND user_id = $5
+RETURNING *;
+
 -- name: GetMessageByID :one
 SELECT * FROM messages WHERE id = $1;
 
 -- name: GetMessages :many
 SELECT * FROM messages ORDER BY created_at;
 
--- name: AddUserChirpyRed :exec
+-- name: AddUserChirpyRed :execrows
 UPDATE users
 SET is_chirpy_red = TRUE
 WHERE id = $1;
+
+-- name: UpdateUserEmail :one
+UPDATE users
+SET updated_at = NOW(),
+    email = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET updated_at = NOW(),
+    handle = $1,
+    display_name = $2
+WHERE id = $3
+RETURNING *;
+
+-- name: UpdateUserAvatarURL :one
+UPDATE users
+SET updated_at = NOW(),
+    avatar_url = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: SetUserVerified :one
+UPDATE users
+SET is_verified = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: SetUserAdmin :one
+UPDATE users
+SET is_admin = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: SetUserShowSensitive :one
+UPDATE users
+SET show_sensitive = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: SoftDeleteUser :one
+UPDATE users
+SET is_deleted = TRUE,
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND is_deleted = FALSE
+RETURNING *;
+
+-- name: RestoreUser :one
+UPDATE users
+SET is_deleted = FALSE,
+    deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND is_deleted AND deleted_at > sqlc.arg(deleted_after)::timestamp
+RETURNING *;
+
+-- name: ListPurgeableUsers :many
+SELECT id, deleted_at FROM users
+WHERE is_deleted AND deleted_at <= sqlc.arg(deleted_before)::timestamp
+ORDER BY deleted_at;
+
+-- name: PurgeDeletedUser :execrows
+DELETE FROM users
+WHERE id = sqlc.arg(id) AND is_deleted AND deleted_at <= sqlc.arg(deleted_before)::timestamp;
+
+-- name: ListWaitlistedUsers :many
+SELECT * FROM users
+WHERE waitlist_status = 'pending' AND NOT is_deleted
+ORDER BY created_at
+LIMIT $1;
+
+-- name: SetWaitlistStatus :many
+UPDATE users
+SET waitlist_status = sqlc.narg(waitlist_status),
+    updated_at = NOW()
+WHERE id = ANY(sqlc.arg(ids)::uuid[]) AND waitlist_status = 'pending'
+RETURNING *;
+
+-- name: RotateUserCredentials :one
+-- RotateUserCredentials invalidates every access token issued to the user
+-- so far. A password change already required stays required.
+UPDATE users
+SET token_version = token_version + 1,
+    password_change_required = password_change_required OR sqlc.arg(require_password_change)::boolean,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING token_version;
+
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
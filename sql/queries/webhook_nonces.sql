@@ -0,0 +1,8 @@
+-- name: ClaimWebhookNonce :execrows
+INSERT INTO webhook_nonces (source, nonce)
+VALUES ($1, $2)
+ON CONFLICT (source, nonce) DO NOTHING;
+
+-- name: DeleteWebhookNoncesBefore :execrows
+DELETE FROM webhook_nonces
+WHERE received_at < $1;
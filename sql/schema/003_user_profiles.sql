@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN handle TEXT UNIQUE;
+ALTER TABLE users ADD COLUMN display_name TEXT;
+ALTER TABLE users ADD COLUMN avatar_url TEXT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN avatar_url;
+ALTER TABLE users DROP COLUMN display_name;
+ALTER TABLE users DROP COLUMN handle;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE messages ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('pending', 'published', 'rejected'));
+
+CREATE INDEX messages_pending_idx ON messages (created_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX messages_pending_idx;
+ALTER TABLE messages DROP COLUMN status;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN is_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_logs_created_at_idx ON audit_logs (created_at DESC);
+
+-- +goose Down
+DROP TABLE audit_logs;
+ALTER TABLE users DROP COLUMN is_verified;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE messages ADD COLUMN parent_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+
+CREATE INDEX messages_parent_id_idx ON messages (parent_id);
+
+-- +goose Down
+DROP INDEX messages_parent_id_idx;
+ALTER TABLE messages DROP COLUMN parent_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE link_redirects (
+    code TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    target_url TEXT NOT NULL,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    click_count BIGINT NOT NULL DEFAULT 0,
+    last_clicked_at TIMESTAMP NULL
+);
+
+CREATE INDEX link_redirects_message_id_idx ON link_redirects (message_id);
+
+-- +goose Down
+DROP TABLE link_redirects;
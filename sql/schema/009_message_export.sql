@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX messages_published_created_at_id_idx ON messages (created_at, id) WHERE status = 'published';
+
+-- +goose Down
+DROP INDEX messages_published_created_at_id_idx;
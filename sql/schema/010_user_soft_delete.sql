@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN is_deleted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE is_deleted;
+
+-- +goose Down
+DROP INDEX users_deleted_at_idx;
+ALTER TABLE users DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN is_deleted;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE email_domain_rules (
+    domain TEXT PRIMARY KEY,
+    rule TEXT NOT NULL CHECK (rule IN ('allow', 'deny')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE email_domain_rules;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE post_delegations (
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMP NULL,
+    PRIMARY KEY (owner_id, delegate_id),
+    CHECK (owner_id <> delegate_id)
+);
+
+CREATE INDEX post_delegations_delegate_id_idx ON post_delegations (delegate_id);
+
+ALTER TABLE messages ADD COLUMN posted_by_id UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE messages DROP COLUMN posted_by_id;
+DROP INDEX post_delegations_delegate_id_idx;
+DROP TABLE post_delegations;
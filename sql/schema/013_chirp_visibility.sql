@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE messages ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'unlisted'));
+
+-- +goose Down
+ALTER TABLE messages DROP COLUMN visibility;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE messages ADD COLUMN expires_at TIMESTAMP;
+CREATE INDEX messages_expires_at_idx ON messages (expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX messages_expires_at_idx;
+ALTER TABLE messages DROP COLUMN expires_at;
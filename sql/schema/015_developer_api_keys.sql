@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE developer_api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    tier TEXT NOT NULL DEFAULT 'free' CHECK (tier IN ('free', 'standard', 'partner')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP NULL
+);
+
+CREATE INDEX developer_api_keys_user_id_idx ON developer_api_keys (user_id);
+
+CREATE TABLE developer_api_usage (
+    key_id UUID NOT NULL REFERENCES developer_api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
+
+-- +goose Down
+DROP TABLE developer_api_usage;
+DROP INDEX developer_api_keys_user_id_idx;
+DROP TABLE developer_api_keys;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE webhook_nonces (
+    source TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, nonce)
+);
+
+CREATE INDEX webhook_nonces_received_at_idx ON webhook_nonces (received_at);
+
+-- +goose Down
+DROP INDEX webhook_nonces_received_at_idx;
+DROP TABLE webhook_nonces;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE user_pii_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    old_value TEXT NULL,
+    new_value TEXT NULL,
+    source TEXT NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX user_pii_history_user_id_idx ON user_pii_history (user_id, changed_at DESC);
+
+-- +goose Down
+DROP INDEX user_pii_history_user_id_idx;
+DROP TABLE user_pii_history;
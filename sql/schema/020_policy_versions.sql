@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE policy_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    version TEXT NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    published_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE policy_acceptances (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    policy_version_id UUID NOT NULL REFERENCES policy_versions(id) ON DELETE CASCADE,
+    accepted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, policy_version_id)
+);
+
+-- +goose Down
+DROP TABLE policy_acceptances;
+DROP TABLE policy_versions;
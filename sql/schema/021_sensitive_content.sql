@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE messages ADD COLUMN content_warning TEXT NULL;
+ALTER TABLE users ADD COLUMN show_sensitive BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN show_sensitive;
+ALTER TABLE messages DROP COLUMN content_warning;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE backups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    file_name TEXT NOT NULL,
+    size_bytes BIGINT NULL,
+    error TEXT NULL,
+    requested_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP NULL
+);
+
+-- +goose Down
+DROP TABLE backups;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Each migration records the oldest build it still works with, as the
+-- schema version that build expects. Expand migrations keep the previous
+-- value; contract migrations that drop or rename something raise it, and a
+-- build older than the maximum stops serving instead of failing mid-query.
+CREATE TABLE schema_compatibility (
+    version BIGINT PRIMARY KEY,
+    oldest_app_schema BIGINT NOT NULL
+);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (23, 22);
+
+-- +goose Down
+DROP TABLE schema_compatibility;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE shared_counters (
+    key TEXT PRIMARY KEY,
+    value BIGINT NOT NULL,
+    expires_at TIMESTAMP NULL
+);
+
+CREATE INDEX shared_counters_expires_at_idx ON shared_counters (expires_at) WHERE expires_at IS NOT NULL;
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (24, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 24;
+DROP TABLE shared_counters;
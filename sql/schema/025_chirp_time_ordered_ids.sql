@@ -0,0 +1,11 @@
+-- +goose Up
+-- New chirps get UUIDv7 IDs minted by the app, with created_at set to the
+-- time embedded in the ID. Existing chirps keep their random v4 IDs, so
+-- keyset pagination orders by (created_at, id), which works for both.
+CREATE INDEX messages_created_at_id_idx ON messages (created_at, id);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (25, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 25;
+DROP INDEX messages_created_at_id_idx;
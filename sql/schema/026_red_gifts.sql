@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE red_gifts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    gifter_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    recipient_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    months INTEGER NOT NULL CHECK (months > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    seen_at TIMESTAMP NULL
+);
+
+CREATE INDEX red_gifts_recipient_id_idx ON red_gifts (recipient_id, created_at);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (26, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 26;
+DROP TABLE red_gifts;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- NULL means the account can sign in. Accounts created while signups are
+-- in waitlist mode start out pending until an admin decides.
+ALTER TABLE users ADD COLUMN waitlist_status TEXT NULL CHECK (waitlist_status IN ('pending', 'rejected'));
+
+CREATE INDEX users_waitlist_pending_idx ON users (created_at) WHERE waitlist_status = 'pending';
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (27, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 27;
+ALTER TABLE users DROP COLUMN waitlist_status;
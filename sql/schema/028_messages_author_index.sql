@@ -0,0 +1,10 @@
+-- +goose Up
+-- GET /api/chirps?author_id= pages through one author's chirps in
+-- (created_at, id) order.
+CREATE INDEX messages_user_id_created_at_id_idx ON messages (user_id, created_at, id);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (28, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 28;
+DROP INDEX messages_user_id_created_at_id_idx;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE likes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+-- Counting a page of chirps' likes looks them up by chirp.
+CREATE INDEX likes_chirp_id_idx ON likes (chirp_id);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (29, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 29;
+DROP TABLE likes;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Canary tokens look like real credentials but belong to nobody. They are
+-- planted in backups, logs and config so that any use of one reveals a
+-- leak. Only the hash is kept, as for API keys.
+CREATE TABLE canary_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL CHECK (kind IN ('refresh_token', 'api_key')),
+    label TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    trigger_count INTEGER NOT NULL DEFAULT 0,
+    last_triggered_at TIMESTAMP NULL
+);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (30, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 30;
+DROP TABLE canary_tokens;
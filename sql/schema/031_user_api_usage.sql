@@ -0,0 +1,16 @@
+-- +goose Up
+-- Monthly request counts per user, metered separately for writes and heavy
+-- reads so each can have its own quota.
+CREATE TABLE user_api_usage (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('write', 'read')),
+    requests INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, month, kind)
+);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (31, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 31;
+DROP TABLE user_api_usage;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- One report per purged account, kept after the user row is gone so there
+-- is no foreign key. The report is stored as the exact bytes that were
+-- signed, hence TEXT rather than JSONB.
+CREATE TABLE deletion_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    verified BOOLEAN NOT NULL,
+    report TEXT NOT NULL,
+    signature TEXT NOT NULL
+);
+
+CREATE INDEX deletion_reports_created_at_idx ON deletion_reports (created_at DESC);
+
+-- The purge job anonymizes audit entries about the user it deletes.
+CREATE INDEX audit_logs_target_idx ON audit_logs (target_type, target_id);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (32, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 32;
+DROP INDEX audit_logs_target_idx;
+DROP TABLE deletion_reports;
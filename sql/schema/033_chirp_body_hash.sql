@@ -0,0 +1,13 @@
+-- +goose Up
+-- Hash of the body as the author typed it, before links are shortened, so
+-- an accidental double post can be caught. Older chirps have none.
+ALTER TABLE messages ADD COLUMN body_hash TEXT NULL;
+
+CREATE INDEX messages_user_body_hash_idx ON messages (user_id, body_hash, created_at DESC);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (33, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 33;
+DROP INDEX messages_user_body_hash_idx;
+ALTER TABLE messages DROP COLUMN body_hash;
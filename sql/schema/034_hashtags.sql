@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE hashtags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tag TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    hashtag_id UUID NOT NULL REFERENCES hashtags(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, hashtag_id)
+);
+
+-- Hashtag timelines look chirps up by tag.
+CREATE INDEX chirp_hashtags_hashtag_id_idx ON chirp_hashtags (hashtag_id);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (34, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 34;
+DROP TABLE chirp_hashtags;
+DROP TABLE hashtags;
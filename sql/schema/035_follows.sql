@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+-- Follower and following pages are read newest first.
+CREATE INDEX follows_followee_created_at_idx ON follows (followee_id, created_at DESC, follower_id DESC);
+CREATE INDEX follows_follower_created_at_idx ON follows (follower_id, created_at DESC, followee_id DESC);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (35, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 35;
+DROP TABLE follows;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Phrases are stored lowercased, so the unique constraint is
+-- case-insensitive.
+CREATE TABLE muted_words (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phrase TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, phrase)
+);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (36, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 36;
+DROP TABLE muted_words;
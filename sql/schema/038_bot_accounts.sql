@@ -0,0 +1,19 @@
+-- +goose Up
+-- An automated account, linked to the user who runs it through one of that
+-- user's developer keys. The key's tier sets the bot's write quota while
+-- the key is live.
+CREATE TABLE bot_accounts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    developer_key_id UUID NULL REFERENCES developer_api_keys(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (user_id <> owner_id)
+);
+
+CREATE INDEX bot_accounts_owner_id_idx ON bot_accounts (owner_id);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (38, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 38;
+DROP TABLE bot_accounts;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- The action taken for a banned word in each context where text is
+-- checked, as in profanity.Rules. Replaces the profanity.rules setting.
+CREATE TABLE profanity_rules (
+    word TEXT NOT NULL CHECK (word <> '' AND word = lower(word)),
+    context TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'flag', 'reject')),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (word, context)
+);
+
+-- Carry over rules saved in the setting, or seed the built-in defaults if
+-- it was never changed.
+INSERT INTO profanity_rules (word, context, action)
+SELECT lower(w.key), a.key, a.value
+FROM settings s, jsonb_each(s.value::jsonb) w, jsonb_each_text(w.value) a
+WHERE s.key = 'profanity.rules'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO profanity_rules (word, context, action)
+SELECT d.word, 'default', 'mask'
+FROM (VALUES ('kerfuffle'), ('sharbert'), ('fornax')) AS d(word)
+WHERE NOT EXISTS (SELECT 1 FROM settings WHERE key = 'profanity.rules');
+
+DELETE FROM settings WHERE key = 'profanity.rules';
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (39, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 39;
+INSERT INTO settings (key, value)
+SELECT 'profanity.rules', jsonb_object_agg(word, actions)::text
+FROM (
+    SELECT word, jsonb_object_agg(context, action) AS actions
+    FROM profanity_rules
+    GROUP BY word
+) r
+HAVING COUNT(*) > 0;
+DROP TABLE profanity_rules;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- A deployment's custom emoji, written in chirps as :shortcode:.
+CREATE TABLE custom_emojis (
+    shortcode TEXT PRIMARY KEY CHECK (shortcode ~ '^[a-z0-9_]{2,32}$'),
+    image_url TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (40, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 40;
+DROP TABLE custom_emojis;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Chirp bodies are now cleaned of profanity when they are written. Rows
+-- from before this, or inserted by an older app during a deploy, are
+-- FALSE until the background backfill cleans them.
+ALTER TABLE messages ADD COLUMN body_filtered BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (41, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 41;
+ALTER TABLE messages DROP COLUMN body_filtered;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- token_version is embedded in access tokens; bumping it invalidates every
+-- access token issued before. password_change_required limits the account
+-- to changing its password until it does.
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN password_change_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (42, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 42;
+ALTER TABLE users DROP COLUMN password_change_required;
+ALTER TABLE users DROP COLUMN token_version;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Files attached to chirps. The file itself lives in the media store under
+-- storage_key. Deleting the chirp keeps the row with a NULL chirp_id until
+-- the purge job has removed the file too.
+CREATE TABLE chirp_media (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NULL REFERENCES messages(id) ON DELETE SET NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX chirp_media_chirp_id_idx ON chirp_media (chirp_id, created_at);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (43, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 43;
+DROP TABLE chirp_media;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Irreversible admin actions wait here until a second admin approves or
+-- rejects them. Requests lapse at expires_at if nobody decides.
+CREATE TABLE pending_admin_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    requested_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    decided_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- One open request per action and target.
+CREATE UNIQUE INDEX pending_admin_actions_open_idx ON pending_admin_actions (action, target_id)
+WHERE status = 'pending';
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (44, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 44;
+DROP TABLE pending_admin_actions;
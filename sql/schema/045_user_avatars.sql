@@ -0,0 +1,20 @@
+-- +goose Up
+-- Uploaded avatar files. users.avatar_url points at the current one. A
+-- replaced avatar, or one whose user was purged, keeps its row with a NULL
+-- user_id until the purge job has removed the file.
+CREATE TABLE user_avatars (
+    id UUID PRIMARY KEY,
+    user_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX user_avatars_user_id_idx ON user_avatars (user_id);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (45, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 45;
+DROP TABLE user_avatars;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Domain events, appended in the same transaction as the change they
+-- describe. Rows are never updated or deleted; consumers read them in id
+-- order. Payloads carry IDs only, so a purged user leaves nothing personal
+-- behind.
+CREATE TABLE events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX events_aggregate_idx ON events (aggregate_type, aggregate_id, id);
+
+-- +goose StatementBegin
+CREATE FUNCTION events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER events_append_only
+BEFORE UPDATE OR DELETE ON events
+FOR EACH ROW EXECUTE FUNCTION events_append_only();
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (46, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 46;
+DROP TABLE events;
+DROP FUNCTION events_append_only();
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Smaller renditions of chirp images, made in the background after upload.
+-- variants_status says whether the worker has got to a file yet: skipped
+-- is for types it can't decode and failed for files that didn't decode.
+ALTER TABLE chirp_media
+    ADD COLUMN variants_status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (variants_status IN ('pending', 'done', 'skipped', 'failed'));
+
+CREATE INDEX chirp_media_pending_variants_idx ON chirp_media (created_at)
+    WHERE variants_status = 'pending';
+
+CREATE TABLE chirp_media_variants (
+    media_id UUID NOT NULL REFERENCES chirp_media(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (media_id, name)
+);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (47, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 47;
+DROP TABLE chirp_media_variants;
+ALTER TABLE chirp_media DROP COLUMN variants_status;
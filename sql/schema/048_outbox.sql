@@ -0,0 +1,23 @@
+-- +goose Up
+-- Side effects waiting to be delivered: emails and webhooks queued in the
+-- same transaction as the change that causes them. The relay deletes each
+-- message once delivered. Messages that keep failing are marked dead and
+-- kept a while for inspection.
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('email', 'webhook')),
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE status = 'pending';
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (48, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 48;
+DROP TABLE outbox;
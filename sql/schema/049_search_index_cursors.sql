@@ -0,0 +1,14 @@
+-- +goose Up
+-- How far through the event log each external search index has been
+-- brought. The in-memory index keeps its own and replays the log on start.
+CREATE TABLE search_index_cursors (
+    index_name TEXT PRIMARY KEY,
+    last_event_id BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (49, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 49;
+DROP TABLE search_index_cursors;
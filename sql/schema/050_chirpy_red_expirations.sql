@@ -0,0 +1,15 @@
+-- +goose Up
+-- When Chirpy Red granted by gifts runs out. Members who pay through Polka
+-- have no row and keep Red until they cancel.
+CREATE TABLE chirpy_red_expirations (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX chirpy_red_expirations_expires_at_idx ON chirpy_red_expirations (expires_at);
+
+INSERT INTO schema_compatibility (version, oldest_app_schema) VALUES (50, 22);
+
+-- +goose Down
+DELETE FROM schema_compatibility WHERE version = 50;
+DROP TABLE chirpy_red_expirations;
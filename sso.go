@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oidc"
+	"github.com/google/uuid"
+)
+
+// AUTH_MODE=sso turns off local passwords: sign-up, password login and
+// password changes are refused and users sign in through the OpenID
+// Connect provider instead. Accounts are created the first time someone
+// signs in, and SSO_GROUP_ROLES keeps their role in step with their groups.
+const (
+	authModeLocal    = "local"
+	authModeSSO      = "sso"
+	ssoStateCookie   = "chirpy_sso_state"
+	ssoStateLifetime = 10 * time.Minute
+	ssoReturnAdmin   = "admin"
+	roleAdmin        = "admin"
+)
+
+func (cfg *apiConfig) passwordLoginDisabled() bool {
+	return cfg.authMode == authModeSSO
+}
+
+// parseGroupRoles reads SSO_GROUP_ROLES, a comma separated list of
+// group=role pairs such as "chirpy-admins=admin".
+func parseGroupRoles(raw string) (map[string]string, error) {
+	roles := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("%q must look like group=role", pair)
+		}
+		if role != roleAdmin {
+			return nil, fmt.Errorf("unknown role %q for group %q", role, group)
+		}
+		roles[group] = role
+	}
+	return roles, nil
+}
+
+// groupsGrantRole reports whether any of the groups maps to role.
+func groupsGrantRole(groups []string, groupRoles map[string]string, role string) bool {
+	for _, g := range groups {
+		if groupRoles[g] == role {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSSOConfig sets up the identity provider from the environment. It is
+// a no-op unless OIDC_ISSUER is set.
+func (cfg *apiConfig) loadSSOConfig(ctx context.Context) error {
+	cfg.authMode = os.Getenv("AUTH_MODE")
+	if cfg.authMode == "" {
+		cfg.authMode = authModeLocal
+	}
+	if cfg.authMode != authModeLocal && cfg.authMode != authModeSSO {
+		return fmt.Errorf("AUTH_MODE must be %q or %q", authModeLocal, authModeSSO)
+	}
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		if cfg.authMode == authModeSSO {
+			return errors.New("AUTH_MODE=sso needs OIDC_ISSUER")
+		}
+		return nil
+	}
+	if cfg.baseURL == "" {
+		return errors.New("OIDC_ISSUER needs BASE_URL for the callback URL")
+	}
+	groupRoles, err := parseGroupRoles(os.Getenv("SSO_GROUP_ROLES"))
+	if err != nil {
+		return fmt.Errorf("invalid SSO_GROUP_ROLES: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	provider, err := oidc.Discover(ctx, &http.Client{Timeout: 10 * time.Second}, oidc.Config{
+		Issuer:       issuer,
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  cfg.baseURL + "/api/sso/callback",
+		GroupsClaim:  os.Getenv("OIDC_GROUPS_CLAIM"),
+	})
+	if err != nil {
+		return err
+	}
+	cfg.sso = provider
+	cfg.ssoGroupRoles = groupRoles
+	return nil
+}
+
+func setSSOCookie(w http.ResponseWriter, value string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    value,
+		Path:     "/api/sso",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   os.Getenv("PLATFORM") != "dev",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// handlerSSOLogin sends the browser to the identity provider. The state,
+// nonce and PKCE verifier wait in a cookie until the callback.
+// ?return_to=admin signs into the admin console instead of returning tokens.
+func (cfg *apiConfig) handlerSSOLogin(w http.ResponseWriter, r *http.Request) {
+	if cfg.sso == nil {
+		respondWithError(w, http.StatusNotFound, "SSO is not configured", nil)
+		return
+	}
+	var values [3]string
+	for i := range values {
+		v, err := auth.MakeSessionToken()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't start sign in", err)
+			return
+		}
+		values[i] = v
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+	returnTo := ""
+	if r.URL.Query().Get("return_to") == ssoReturnAdmin {
+		returnTo = ssoReturnAdmin
+	}
+	setSSOCookie(w, strings.Join([]string{state, nonce, verifier, returnTo}, "."), time.Now().Add(ssoStateLifetime))
+	http.Redirect(w, r, cfg.sso.AuthCodeURL(state, nonce, oauthserver.S256Challenge(verifier)), http.StatusFound)
+}
+
+func (cfg *apiConfig) handlerSSOCallback(w http.ResponseWriter, r *http.Request) {
+	if cfg.sso == nil {
+		respondWithError(w, http.StatusNotFound, "SSO is not configured", nil)
+		return
+	}
+	cookie, err := r.Cookie(ssoStateCookie)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Sign in expired, please try again", err)
+		return
+	}
+	setSSOCookie(w, "", time.Unix(0, 0))
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 4 || r.URL.Query().Get("state") != parts[0] {
+		respondWithError(w, http.StatusBadRequest, "Sign in expired, please try again", nil)
+		return
+	}
+	nonce, verifier, returnTo := parts[1], parts[2], parts[3]
+	if idpErr := r.URL.Query().Get("error"); idpErr != "" {
+		respondWithError(w, http.StatusUnauthorized, "Identity provider refused sign in: "+idpErr, nil)
+		return
+	}
+
+	rawIDToken, err := cfg.sso.Exchange(r.Context(), r.URL.Query().Get("code"), verifier)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Couldn't complete sign in with the identity provider", err)
+		return
+	}
+	claims, err := cfg.sso.Verify(r.Context(), rawIDToken, nonce)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Identity provider returned an invalid ID token", err)
+		return
+	}
+	user, err := cfg.ssoUser(r.Context(), claims)
+	var ssoErr *ssoUserError
+	if errors.As(err, &ssoErr) {
+		respondWithError(w, ssoErr.status, ssoErr.msg, nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't sign in", err)
+		return
+	}
+
+	if returnTo == ssoReturnAdmin {
+		if !user.IsAdmin {
+			respondWithError(w, http.StatusForbidden, "Admin access required", nil)
+			return
+		}
+		if err := cfg.startAdminSession(w, r, user.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create session", err)
+			return
+		}
+		http.Redirect(w, r, "/admin/metrics", http.StatusSeeOther)
+		return
+	}
+	tokens, err := cfg.CreateTokenAndRefreshToken(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create tokens", err)
+		return
+	}
+	cfg.setAccessTokenCookie(w, tokens.AccessToken, tokens.AccessTokenExpiresAt)
+	respondWithJSON(w, http.StatusOK, newLoginResponse(user, tokens))
+}
+
+type ssoUserError struct {
+	status int
+	msg    string
+}
+
+func (e *ssoUserError) Error() string {
+	return e.msg
+}
+
+// ssoUser finds or provisions the account for a signed-in identity. An
+// existing account is only linked by email when the provider has verified
+// that address. When group roles are configured the admin flag follows the
+// user's groups on every sign in.
+func (cfg *apiConfig) ssoUser(ctx context.Context, claims oidc.Claims) (database.User, error) {
+	user, err := cfg.database.GetUserByIdentity(ctx, database.GetUserByIdentityParams{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = cfg.provisionSSOUser(ctx, claims)
+	}
+	if err != nil {
+		return user, err
+	}
+	if user.IsDeleted {
+		return user, &ssoUserError{http.StatusForbidden, "This account has been deleted"}
+	}
+
+	if len(cfg.ssoGroupRoles) > 0 {
+		isAdmin := groupsGrantRole(claims.Groups, cfg.ssoGroupRoles, roleAdmin)
+		if isAdmin != user.IsAdmin {
+			user, err = cfg.database.SetUserAdmin(ctx, database.SetUserAdminParams{
+				IsAdmin: isAdmin,
+				ID:      user.ID,
+			})
+			if err != nil {
+				return user, err
+			}
+			cfg.recordAudit(ctx, uuid.Nil, "user.role_changed", "user", user.ID.String(), map[string]any{
+				"source":   "sso",
+				"is_admin": isAdmin,
+			})
+		}
+	}
+	return user, nil
+}
+
+func (cfg *apiConfig) provisionSSOUser(ctx context.Context, claims oidc.Claims) (database.User, error) {
+	email := strings.TrimSpace(claims.Email)
+	if email == "" {
+		return database.User{}, &ssoUserError{http.StatusForbidden, "Your identity provider didn't share an email address"}
+	}
+	user, err := cfg.database.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil && !claims.EmailVerified:
+		return user, &ssoUserError{http.StatusConflict, "An account with this email already exists"}
+	case errors.Is(err, sql.ErrNoRows):
+		rules, err := cfg.database.ListEmailDomainRules(ctx)
+		if err != nil {
+			return user, err
+		}
+		if !emailDomainAllowed(email, rules) {
+			return user, &ssoUserError{http.StatusForbidden, "Registration is not open to this email domain"}
+		}
+		user, err = cfg.createUser(ctx, database.CreateUserParams{
+			Email:          email,
+			HashedPassword: unsetPassword,
+		}, "sso")
+		if err != nil {
+			return user, err
+		}
+		cfg.recordAudit(ctx, uuid.Nil, "user.provisioned", "user", user.ID.String(), map[string]any{
+			"source": "sso",
+		})
+	case err != nil:
+		return user, err
+	}
+
+	err = cfg.database.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		UserID:  user.ID,
+	})
+	return user, err
+}
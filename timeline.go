@@ -0,0 +1,164 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// timelinePage is the paging requested for a newest-first timeline such as
+// a hashtag or a list. next_cursor goes back in before.
+type timelinePage struct {
+	limit           int32
+	beforeCreatedAt sql.NullTime
+	beforeID        uuid.NullUUID
+}
+
+// parseTimelinePage reads limit and before, answering 400 itself if either
+// is invalid.
+func parseTimelinePage(w http.ResponseWriter, r *http.Request) (timelinePage, bool) {
+	query := r.URL.Query()
+	page := timelinePage{limit: 100}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return timelinePage{}, false
+		}
+		page.limit = int32(n)
+	}
+	if raw := query.Get("before"); raw != "" {
+		cursor, err := api.DecodeCursor(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before parameter", err)
+			return timelinePage{}, false
+		}
+		page.beforeCreatedAt, page.beforeID = nullCursor(&cursor)
+	}
+	return page, true
+}
+
+// timelineChirp is a chirp as listed in a timeline.
+type timelineChirp struct {
+	Id             uuid.UUID         `json:"id"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+	Body           string            `json:"body"`
+	RenderedBody   *string           `json:"rendered_body,omitempty"`
+	Emojis         map[string]string `json:"emojis,omitempty"`
+	Media          []mediaResponse   `json:"media,omitempty"`
+	UserID         uuid.UUID         `json:"user_id"`
+	Author         chirpAuthor       `json:"author"`
+	PostedBy       *uuid.UUID        `json:"posted_by,omitempty"`
+	Visibility     string            `json:"visibility"`
+	ExpiresAt      *string           `json:"expires_at,omitempty"`
+	ContentWarning *string           `json:"content_warning"`
+	BodyHidden     bool              `json:"body_hidden"`
+	LikeCount      int64             `json:"like_count"`
+	LikedByMe      bool              `json:"liked_by_me"`
+	// Highlight is set by search: the body, HTML-escaped, with the words
+	// that matched wrapped in <mark>.
+	Highlight *string `json:"highlight,omitempty"`
+}
+
+// respondWithTimeline writes a page of chirps read newest first, applying
+// the same visibility, muting and sensitive content rules as the main
+// timeline.
+func (cfg *apiConfig) respondWithTimeline(w http.ResponseWriter, r *http.Request, messages []database.GetMessagesWithAuthorRow, limit int32) {
+	chirps, ok := cfg.timelineChirps(w, r, messages)
+	if !ok {
+		return
+	}
+	var next string
+	if len(messages) == int(limit) {
+		last := messages[len(messages)-1]
+		next = api.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(chirps).WithCursors(next, ""))
+}
+
+// timelineChirps turns messages into timeline entries, in order, leaving
+// out those the viewer can't see or has muted. It answers the request
+// itself if that fails.
+func (cfg *apiConfig) timelineChirps(w http.ResponseWriter, r *http.Request, messages []database.GetMessagesWithAuthorRow) ([]timelineChirp, bool) {
+	renderHTML, ok := parseChirpRender(w, r)
+	if !ok {
+		return nil, false
+	}
+	viewer := cfg.optionalViewer(r)
+	reveal := cfg.revealSensitive(r, viewer)
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	likes, err := cfg.chirpLikes(r.Context(), viewer, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get likes", err)
+		return nil, false
+	}
+	muted, err := cfg.viewerMutes(r.Context(), viewer)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get muted words", err)
+		return nil, false
+	}
+	authorIDs := make([]uuid.UUID, 0, len(messages))
+	bodies := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		authorIDs = append(authorIDs, msg.UserID)
+		bodies = append(bodies, msg.Body)
+	}
+	bots, err := cfg.botOwners(r.Context(), authorIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get authors", err)
+		return nil, false
+	}
+	followed, err := cfg.viewerFollows(r.Context(), viewer, authorIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follows", err)
+		return nil, false
+	}
+	emojis, err := cfg.customEmojis(r.Context(), bodies)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get emojis", err)
+		return nil, false
+	}
+	media, err := cfg.chirpMedia(r, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
+		return nil, false
+	}
+	var chirps []timelineChirp
+	for _, msg := range messages {
+		if !chirpVisible(msg.Visibility, msg.UserID, viewer, followed, true) {
+			continue
+		}
+		if msg.UserID != viewer && muted.matches(msg.Body) {
+			continue
+		}
+		body, hidden := sensitiveBody(msg.Body, msg.ContentWarning, msg.UserID, viewer, reveal)
+		body = cfg.readableBody(r.Context(), body, msg.BodyFiltered)
+		chirps = append(chirps, timelineChirp{
+			Id:             msg.ID,
+			CreatedAt:      msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:      msg.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Body:           body,
+			RenderedBody:   renderedBody(renderHTML, body),
+			Emojis:         emojis.in(body),
+			Media:          media[msg.ID],
+			UserID:         msg.UserID,
+			Author:         bots.label(newChirpAuthor(msg.UserID, msg.AuthorHandle, msg.AuthorDisplayName, msg.AuthorAvatarUrl, msg.AuthorIsVerified)),
+			PostedBy:       nullUUIDPtr(msg.PostedByID),
+			Visibility:     msg.Visibility,
+			ExpiresAt:      nullTimeString(msg.ExpiresAt),
+			ContentWarning: nullStringPtr(msg.ContentWarning),
+			BodyHidden:     hidden,
+			LikeCount:      likes[msg.ID].count,
+			LikedByMe:      likes[msg.ID].likedByMe,
+		})
+	}
+	return chirps, true
+}
@@ -2,21 +2,37 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
+	"io"
+	"log"
 	"net/http"
 	"os"
 	"sync/atomic"
-	"text/template"
 	"time"
 
 	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/breaker"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/counter"
 	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/mail"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/metrics"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oidc"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/profanity"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/search"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/slo"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/storage"
 	"github.com/google/uuid"
 )
 
 type adminData struct {
-	Count int
+	Count     int
+	CSRFToken string
 }
 
 type apiCreateUserReturn struct {
@@ -25,14 +41,51 @@ type apiCreateUserReturn struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	Email       string    `json:"email"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	Waitlisted  bool      `json:"waitlisted,omitempty"`
 }
 
 type apiConfig struct {
-	TotalReq      atomic.Int32
-	adminTemplate *template.Template
-	database      *database.Queries
-	tokenSecret   string
-	apiKey        string
+	adminTemplate     *template.Template
+	loginTemplate     *template.Template
+	chirpTemplate     *template.Template
+	consentTemplate   *template.Template
+	db                *sql.DB
+	dbFailover        *dbFailover
+	databaseURL       string
+	backupDir         string
+	backupRunning     atomic.Bool
+	draining          atomic.Bool
+	warming           atomic.Bool
+	schemaGate        schemaGate
+	database          *database.Store
+	settings          *settings.Store
+	profanityRules    *profanity.Store
+	tokenSecret       string
+	reportSecret      string
+	jwtLeeway         time.Duration
+	apiKey            string
+	scimToken         string
+	authMode          string
+	sso               *oidc.Provider
+	ssoGroupRoles     map[string]string
+	baseURL           string
+	securityHeaders   securityHeadersConfig
+	debugCapture      debugCaptureConfig
+	chaos             chaosConfig
+	accessTokenCookie bool
+	requestStats      *metrics.Registry
+	sloMonitor        *slo.Monitor
+	breakers          *breaker.Registry
+	mailer            mail.Sender
+	counters          counter.Store
+	media             storage.Store
+	mediaURLSecret    string
+	mediaURLTTL       time.Duration
+	// polkaWebhookSecret signs Polka webhooks. Unlike the API key it is
+	// never sent, so a captured request can't be re-signed.
+	polkaWebhookSecret string
+	search             search.Index
+	searchCursorName   string
 }
 
 type ChirpRequest struct {
@@ -47,7 +100,9 @@ type ChirpResponse struct {
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg.TotalReq.Add(1)
+		if _, err := cfg.counters.Add(r.Context(), fileserverHitsKey, 1, time.Time{}); err != nil {
+			log.Printf("Error counting fileserver hit: %s", err)
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -56,23 +111,33 @@ func (cfg *apiConfig) middlewareMetricsGet(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 
-	count := int(cfg.TotalReq.Load())
-	data := adminData{Count: count}
+	count, err := cfg.counters.Get(r.Context(), fileserverHitsKey)
+	if err != nil {
+		log.Printf("Error reading fileserver hits: %s", err)
+	}
+	data := adminData{Count: int(count)}
+	if session, ok := adminSessionFromContext(r.Context()); ok {
+		data.CSRFToken = session.CsrfToken
+	}
 
-	err := cfg.adminTemplate.Execute(w, data)
+	err = cfg.adminTemplate.Execute(w, data)
 	if err != nil {
 		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// middlewareMetricsReset deletes every user and resets the metrics. It
+// needs no credentials, since it would delete the caller too, and instead
+// refuses to run outside PLATFORM=dev.
 func (cfg *apiConfig) middlewareMetricsReset(w http.ResponseWriter, r *http.Request) {
 	if os.Getenv("PLATFORM") != "dev" {
 		http.Error(w, "Resetting metrics is not allowed on Heroku", http.StatusForbidden)
 		return
 	}
 	cfg.database.DeleteUser(r.Context())
-	cfg.TotalReq.Store(0)
+	cfg.counters.Delete(r.Context(), fileserverHitsKey)
+	cfg.requestStats.Reset()
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 }
@@ -93,6 +158,10 @@ func (cfg *apiConfig) apiCreateUser(w http.ResponseWriter, r *http.Request) {
 		Email    string `json:"email"`
 	}
 
+	if cfg.passwordLoginDisabled() {
+		respondWithError(w, http.StatusForbidden, "Sign up is handled by your identity provider", nil)
+		return
+	}
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
 	err := decoder.Decode(&params)
@@ -104,42 +173,89 @@ func (cfg *apiConfig) apiCreateUser(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Email is required", nil)
 		return
 	}
+	domainRules, err := cfg.database.ListEmailDomainRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check email domain", err)
+		return
+	}
+	if !emailDomainAllowed(params.Email, domainRules) {
+		respondWithError(w, http.StatusForbidden, "Registration is not open to this email domain", nil)
+		return
+	}
 	hashPass, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
 		return
 	}
-	user, err := cfg.database.CreateUser(r.Context(), database.CreateUserParams{
+	waitlisted := cfg.settings.Get(r.Context(), settings.SignupMode) == settings.SignupWaitlist
+	user, err := cfg.createUser(r.Context(), database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashPass,
-	})
+		WaitlistStatus: sql.NullString{String: waitlistPending, Valid: waitlisted},
+	}, "signup")
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
+		respondWithDBError(w, "Couldn't create user", err)
 		return
 	}
-	respondWithJSON(w, http.StatusCreated, apiCreateUserReturn{
+	status := http.StatusCreated
+	if waitlisted {
+		status = http.StatusAccepted
+	}
+	respondWithJSON(w, status, apiCreateUserReturn{
 		ID:          user.ID,
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
 		Email:       user.Email,
 		IsChirpyRed: user.IsChirpyRed,
+		Waitlisted:  waitlisted,
 	})
 
 }
 
+type loginResponse struct {
+	Id                     uuid.UUID `json:"id"`
+	CreatedAt              string    `json:"created_at"`
+	UpdatedAt              string    `json:"updated_at"`
+	Email                  string    `json:"email"`
+	Token                  string    `json:"token,omitempty"`
+	TokenType              string    `json:"token_type,omitempty"`
+	ExpiresIn              int       `json:"expires_in,omitempty"`
+	ExpiresAt              string    `json:"expires_at,omitempty"`
+	RefreshToken           string    `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresIn  int       `json:"refresh_token_expires_in,omitempty"`
+	RefreshTokenExpiresAt  string    `json:"refresh_token_expires_at,omitempty"`
+	IsChirpyRed            bool      `json:"is_chirpy_red,omitempty"`
+	AvatarURL              string    `json:"avatar_url,omitempty"`
+	PasswordChangeRequired bool      `json:"password_change_required,omitempty"`
+}
+
+func newLoginResponse(user database.User, tokens tokenPair) loginResponse {
+	return loginResponse{
+		Id:                     user.ID,
+		CreatedAt:              user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:              user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Email:                  user.Email,
+		Token:                  tokens.AccessToken,
+		TokenType:              tokenTypeBearer,
+		ExpiresIn:              int(accessTokenDuration.Seconds()),
+		ExpiresAt:              tokens.AccessTokenExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+		RefreshToken:           tokens.RefreshToken,
+		RefreshTokenExpiresIn:  int(refreshTokenDuration.Seconds()),
+		RefreshTokenExpiresAt:  tokens.RefreshTokenExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+		IsChirpyRed:            user.IsChirpyRed,
+		AvatarURL:              user.AvatarUrl.String,
+		PasswordChangeRequired: user.PasswordChangeRequired,
+	}
+}
+
 func (cfg *apiConfig) handlerChirpsLogin(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
 	}
-	type returnVals struct {
-		Id           uuid.UUID `json:"id"`
-		CreatedAt    string    `json:"created_at"`
-		UpdatedAt    string    `json:"updated_at"`
-		Email        string    `json:"email"`
-		Token        string    `json:"token,omitempty"`
-		RefreshToken string    `json:"refresh_token,omitempty"`
-		IsChirpyRed  bool      `json:"is_chirpy_red,omitempty"`
+	if cfg.passwordLoginDisabled() {
+		respondWithError(w, http.StatusForbidden, "Password login is disabled, sign in with SSO", nil)
+		return
 	}
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
@@ -153,87 +269,158 @@ func (cfg *apiConfig) handlerChirpsLogin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	user, err := cfg.database.GetUserByEmail(r.Context(), params.Email)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't get user by email", err)
+	if errors.Is(err, database.ErrUserNotFound) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password", nil)
 		return
 	}
-	if user.ID == uuid.Nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid email or password", nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user by email", err)
 		return
 	}
 	sda := auth.CheckPasswordHash(params.Password, user.HashedPassword)
-	if sda != nil {
+	if sda != nil || user.IsDeleted {
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
 		return
 	}
+	if msg, waiting := waitlistLoginError(user); waiting {
+		respondWithError(w, http.StatusForbidden, msg, nil)
+		return
+	}
 
-	refreshToken, jwtToken, err := cfg.CreateTokenAndRefreshToken(r.Context(), user)
+	tokens, err := cfg.CreateTokenAndRefreshToken(r.Context(), user)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create tokens", err)
 		return
 	}
+	cfg.setAccessTokenCookie(w, tokens.AccessToken, tokens.AccessTokenExpiresAt)
 
-	respondWithJSON(w, http.StatusOK, returnVals{
-		Id:           user.ID,
-		CreatedAt:    user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:    user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		Email:        user.Email,
-		Token:        jwtToken,
-		RefreshToken: refreshToken,
-		IsChirpyRed:  user.IsChirpyRed,
-	})
+	respondWithJSON(w, http.StatusOK, newLoginResponse(user, tokens))
+
+}
+
+const (
+	accessTokenDuration  = time.Hour
+	refreshTokenDuration = 60 * 24 * time.Hour // 60 days
+	tokenTypeBearer      = "Bearer"
+)
 
+type tokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
 }
 
-func (cfg *apiConfig) CreateTokenAndRefreshToken(ctx context.Context, user database.User) (refreshToken, jwtToken string, err error) {
-	jwtToken, err = auth.MakeJWT(user.ID, os.Getenv("SIG_SECRET"), time.Hour)
+func (cfg *apiConfig) CreateTokenAndRefreshToken(ctx context.Context, user database.User) (tokenPair, error) {
+	now := time.Now()
+	jwtToken, err := auth.MakeVersionedJWT(user.ID, user.TokenVersion, os.Getenv("SIG_SECRET"), accessTokenDuration)
 	if err != nil {
-		return "", "", fmt.Errorf("couldn't create JWT token: %w", err)
+		return tokenPair{}, fmt.Errorf("couldn't create JWT token: %w", err)
 	}
 
-	refreshToken, err = auth.MakeRefreshToken()
+	refreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		return "", "", fmt.Errorf("couldn't create refresh token: %w", err)
+		return tokenPair{}, fmt.Errorf("couldn't create refresh token: %w", err)
 	}
 
-	expiresAt := time.Now().Add(60 * 24 * time.Hour) // 60 days
+	expiresAt := now.Add(refreshTokenDuration)
 	_, err = cfg.database.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
 		Token:     refreshToken,
 		UserID:    user.ID,
 		ExpiresAt: expiresAt,
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("couldn't save refresh token to database: %w", err)
+		return tokenPair{}, fmt.Errorf("couldn't save refresh token to database: %w", err)
+	}
+
+	return tokenPair{
+		AccessToken:           jwtToken,
+		AccessTokenExpiresAt:  now.Add(accessTokenDuration),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: expiresAt,
+	}, nil
+}
+
+// maxRefreshBodyBytes is plenty for {"refresh_token": "..."}.
+const maxRefreshBodyBytes = 4 << 10
+
+// refreshTokenFromRequest reads the refresh token from the Authorization
+// header or, for clients that can't set headers on background calls, from
+// a {"refresh_token": "..."} body. The header wins when both are sent.
+// Malformed tokens are rejected here, before they reach the database.
+func refreshTokenFromRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		token, err = refreshTokenFromBody(w, r, err)
+		if err != nil {
+			return "", err
+		}
 	}
+	if err := auth.CheckRefreshToken(token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
 
-	return refreshToken, jwtToken, nil
+func refreshTokenFromBody(w http.ResponseWriter, r *http.Request, headerErr error) (string, error) {
+	if r.Body == nil || r.ContentLength == 0 {
+		return "", headerErr
+	}
+	var params struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRefreshBodyBytes)).Decode(&params); err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", headerErr
+		}
+		return "", fmt.Errorf("couldn't decode body: %w", err)
+	}
+	if params.RefreshToken == "" {
+		return "", fmt.Errorf("%w and no refresh_token in the body", headerErr)
+	}
+	return params.RefreshToken, nil
 }
 
 func (cfg *apiConfig) handlerRefreshTokens(w http.ResponseWriter, r *http.Request) {
 	type respondVals struct {
-		Token string `json:"token"`
+		Token                 string `json:"token"`
+		TokenType             string `json:"token_type"`
+		ExpiresIn             int    `json:"expires_in"`
+		ExpiresAt             string `json:"expires_at"`
+		RefreshTokenExpiresIn int    `json:"refresh_token_expires_in"`
+		RefreshTokenExpiresAt string `json:"refresh_token_expires_at"`
 	}
 	if r.Method != http.MethodPost {
 		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
-	token, err := auth.GetBearerToken(r.Header)
+	token, err := refreshTokenFromRequest(w, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
 		return
 	}
 	auths, err := cfg.database.GetUserFromRefreshToken(r.Context(), token)
+	if errors.Is(err, database.ErrTokenNotFound) {
+		cfg.checkCanary(r, canaryKindRefreshToken, token)
+	}
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't get user from refresh token", err)
+		respondWithDBError(w, "Couldn't get user from refresh token", err)
 		return
 	}
-	jwtToekn, err := auth.MakeJWT(auths.ID, os.Getenv("SIG_SECRET"), time.Hour)
+	expiresAt := time.Now().Add(accessTokenDuration)
+	jwtToekn, err := auth.MakeVersionedJWT(auths.ID, auths.TokenVersion, os.Getenv("SIG_SECRET"), accessTokenDuration)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create JWT token", err)
 		return
 	}
+	cfg.setAccessTokenCookie(w, jwtToekn, expiresAt)
 	respondWithJSON(w, http.StatusOK, respondVals{
-		Token: jwtToekn,
+		Token:                 jwtToekn,
+		TokenType:             tokenTypeBearer,
+		ExpiresIn:             int(accessTokenDuration.Seconds()),
+		ExpiresAt:             expiresAt.Format("2006-01-02T15:04:05Z07:00"),
+		RefreshTokenExpiresIn: int(time.Until(auths.RefreshTokenExpiresAt).Seconds()),
+		RefreshTokenExpiresAt: auths.RefreshTokenExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
 	})
 
 }
@@ -243,16 +430,20 @@ func (cfg *apiConfig) handlerRevokRefreshToken(w http.ResponseWriter, r *http.Re
 		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
-	token, err := auth.GetBearerToken(r.Header)
+	token, err := refreshTokenFromRequest(w, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
 		return
 	}
+	// Revoking an unknown token succeeds, so a canary is checked for here
+	// rather than on a failed lookup.
+	cfg.checkCanary(r, canaryKindRefreshToken, token)
 	err = cfg.database.RevokeRefreshToken(r.Context(), token)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke refresh token", err)
 		return
 	}
+	cfg.setAccessTokenCookie(w, "", time.Unix(0, 0))
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -264,6 +455,10 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 	type respondVals struct {
 		Email string `json:"email"`
 	}
+	if cfg.passwordLoginDisabled() {
+		respondWithError(w, http.StatusForbidden, "Email and password are managed by your identity provider", nil)
+		return
+	}
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
 	err := decoder.Decode(&params)
@@ -275,30 +470,20 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusBadRequest, "Email and password is required", nil)
 		return
 	}
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
-		return
-	}
-	auths, err := auth.ValidateJWT(token, cfg.tokenSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
-		return
-	}
-	if auths == uuid.Nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid user ID in token", nil)
-		return
-	}
+	before, _ := userFromContext(r.Context())
 	hashedPass, _ := auth.HashPassword(params.Password)
-	_, err = cfg.database.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:             auths,
+	email, err := cfg.database.UpdateUser(r.Context(), database.UpdateUserParams{
+		ID:             before.ID,
 		Email:          params.Email,
 		HashedPassword: hashedPass,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		respondWithDBError(w, "Couldn't update user", err)
 		return
 	}
+	after := before
+	after.Email = email
+	cfg.recordPIIChanges(r.Context(), before, after, piiSourceUser)
 	respondWithJSON(w, http.StatusOK, respondVals{
 		Email: params.Email,
 	})
@@ -310,14 +495,14 @@ func (cfg *apiConfig) handlerDeleteChirps(w http.ResponseWriter, r *http.Request
 		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
-	token, err := auth.GetBearerToken(r.Header)
+	token, err := cfg.accessToken(r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token", err)
 		return
 	}
-	auths, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	auths, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeChirpsWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		respondWithTokenError(w, err)
 		return
 	}
 	if auths == uuid.Nil {
@@ -325,23 +510,53 @@ func (cfg *apiConfig) handlerDeleteChirps(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	idStrg := r.PathValue("chirpID")
-	if idStrg == "" {
-		respondWithError(w, http.StatusBadRequest, "Chirp ID is required", nil)
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	message, err := cfg.database.GetMessageByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithDBError(w, "Couldn't get chirp", err)
 		return
 	}
-	message, err := cfg.database.GetMessageByID(r.Context(), uuid.MustParse(idStrg))
 	if message.UserID != auths {
 		respondWithError(w, http.StatusForbidden, "You are not allowed to delete this chirp", nil)
 		return
 	}
+	// Likes, hashtags and short links go with the chirp through ON DELETE
+	// CASCADE in the same statement; replies are kept and lose their parent.
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
 	}
-	cfg.database.DeleteChirpsByID(r.Context(), database.DeleteChirpsByIDParams{
-		ID:     uuid.MustParse(idStrg),
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	deleted, err := qtx.DeleteChirpsByID(r.Context(), database.DeleteChirpsByIDParams{
+		ID:     chirpID,
 		UserID: auths,
 	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+	err = appendEvent(r.Context(), qtx, eventChirpDeleted, aggregateChirp, chirpID, map[string]any{
+		"user_id": auths,
+		"reason":  "author",
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -351,7 +566,9 @@ type Event struct {
 }
 
 type EventData struct {
-	UserID string `json:"user_id"`
+	UserID   string `json:"user_id"`
+	GifterID string `json:"gifter_id,omitempty"`
+	Months   int32  `json:"months,omitempty"`
 }
 
 func (cfg *apiConfig) handlerAddSubscription(w http.ResponseWriter, r *http.Request) {
@@ -365,17 +582,50 @@ func (cfg *apiConfig) handlerAddSubscription(w http.ResponseWriter, r *http.Requ
 		respondWithError(w, http.StatusUnauthorized, "Invalid API key", nil)
 		return
 	}
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&event)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read webhook", err)
+		return
+	}
+	nonce, err := auth.VerifyWebhook(r.Header, body, cfg.polkaWebhookSecret, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		return
+	}
+	err = json.Unmarshal(body, &event)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
 		return
 	}
+	if event.Event == polkaEventGifted {
+		cfg.handlePolkaGift(w, r, event.Data, nonce)
+		return
+	}
 	if event.Event != "user.upgraded" {
 		respondWithJSON(w, http.StatusNoContent, nil)
 		return
 	}
-	err = cfg.database.AddUserChirpyRed(r.Context(), uuid.MustParse(event.Data.UserID))
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't upgrade user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	if !cfg.claimWebhookNonce(w, r, qtx, webhookSourcePolka, nonce) {
+		return
+	}
+	userID := uuid.MustParse(event.Data.UserID)
+	err = upgradeUser(r.Context(), qtx, userID, map[string]any{
+		"source": "polka",
+	})
+	// A paying member keeps Red, even if a gift would have run out.
+	if err == nil {
+		err = qtx.ClearChirpyRedExpiry(r.Context(), userID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		respondWithJSON(w, http.StatusNotFound, nil)
 		return
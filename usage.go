@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+// Signed-in users' writes and heavy reads are counted per calendar month
+// (UTC) and limited by their tier. Anonymous requests aren't metered here;
+// developer keys have their own daily quota.
+const (
+	usageKindWrite = "write"
+	usageKindRead  = "read"
+	userTierFree   = "free"
+	userTierRed    = "red"
+)
+
+var userUsageQuotas = map[string]map[string]int32{
+	userTierFree: {usageKindWrite: 3000, usageKindRead: 50000},
+	userTierRed:  {usageKindWrite: 30000, usageKindRead: 500000},
+}
+
+func userTier(isChirpyRed bool) string {
+	if isChirpyRed {
+		return userTierRed
+	}
+	return userTierFree
+}
+
+// usageMonth is the first day of the UTC month a request is counted in.
+func usageMonth(now time.Time) time.Time {
+	y, m, _ := now.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// usageReset is when the quota for now's month starts over.
+func usageReset(now time.Time) time.Time {
+	return usageMonth(now).AddDate(0, 1, 0)
+}
+
+// usageExceededStatus is the status for a request over quota: free users
+// can lift the limit by upgrading, so they get 402; Chirpy Red users and
+// bots can't, so they get 429.
+func usageExceededStatus(tier string) int {
+	if tier == userTierFree {
+		return http.StatusPaymentRequired
+	}
+	return http.StatusTooManyRequests
+}
+
+// meteredUser identifies the user to charge for a request, or returns
+// uuid.Nil if it carries no valid credentials; the handler then decides
+// whether to reject it. Scope doesn't matter here, only whose token it is.
+func (cfg *apiConfig) meteredUser(r *http.Request) uuid.UUID {
+	if userID, ok := userIDFromContext(r.Context()); ok {
+		return userID
+	}
+	token, err := cfg.accessToken(r)
+	if err != nil {
+		return uuid.Nil
+	}
+	if userID, err := cfg.validateJWT(token); err == nil {
+		return userID
+	}
+	grant, err := cfg.database.GetOAuthAccessToken(r.Context(), auth.HashToken(token))
+	if err != nil {
+		return uuid.Nil
+	}
+	return grant.UserID
+}
+
+// middlewareUsage counts a request of the given kind against its user's
+// monthly quota and rejects it once the quota is used up.
+func (cfg *apiConfig) middlewareUsage(kind string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := cfg.meteredUser(r)
+		if userID == uuid.Nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := time.Now()
+		usage, err := cfg.database.IncrementUserAPIUsage(r.Context(), database.IncrementUserAPIUsageParams{
+			UserID: userID,
+			Month:  usageMonth(now),
+			Kind:   kind,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't record usage", err)
+			return
+		}
+
+		tier, quotas := cfg.usageLimits(r.Context(), userID, usage.IsChirpyRed)
+		quota := quotas[kind]
+		reset := usageReset(now)
+		w.Header().Set("X-Usage-Limit", strconv.Itoa(int(quota)))
+		w.Header().Set("X-Usage-Remaining", strconv.Itoa(int(max(quota-usage.Requests, 0))))
+		w.Header().Set("X-Usage-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if usage.Requests > quota {
+			w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+			msg := "Monthly " + kind + " quota exceeded"
+			if tier == userTierFree {
+				msg += "; upgrade to Chirpy Red for a higher quota"
+			}
+			respondWithError(w, usageExceededStatus(tier), msg, nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) handlerUserUsage(w http.ResponseWriter, r *http.Request) {
+	type quotaVals struct {
+		Used  int32 `json:"used"`
+		Limit int32 `json:"limit"`
+	}
+	type returnVals struct {
+		Tier        string    `json:"tier"`
+		PeriodStart time.Time `json:"period_start"`
+		ResetsAt    time.Time `json:"resets_at"`
+		Writes      quotaVals `json:"writes"`
+		Reads       quotaVals `json:"reads"`
+	}
+
+	user, _ := userFromContext(r.Context())
+	now := time.Now()
+	rows, err := cfg.database.ListUserAPIUsage(r.Context(), database.ListUserAPIUsageParams{
+		UserID: user.ID,
+		Month:  usageMonth(now),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get usage", err)
+		return
+	}
+	used := map[string]int32{}
+	for _, row := range rows {
+		used[row.Kind] = row.Requests
+	}
+	tier, quotas := cfg.usageLimits(r.Context(), user.ID, user.IsChirpyRed)
+	respondWithJSON(w, http.StatusOK, returnVals{
+		Tier:        tier,
+		PeriodStart: usageMonth(now),
+		ResetsAt:    usageReset(now),
+		Writes:      quotaVals{Used: used[usageKindWrite], Limit: quotas[usageKindWrite]},
+		Reads:       quotaVals{Used: used[usageKindRead], Limit: quotas[usageKindRead]},
+	})
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// userRestoreWindow is how long a soft-deleted user can be restored
+	// before the purge job removes them and their content for good.
+	userRestoreWindow = 30 * 24 * time.Hour
+	userPurgeInterval = time.Hour
+	// deletionReportType is deliberately not application/json, so the
+	// response renderer passes the signed bytes through untouched.
+	deletionReportType = "application/vnd.chirpy.deletion-report+json"
+)
+
+// runUserPurge permanently deletes users whose restore window has passed.
+// Their chirps, tokens and sessions go with them through ON DELETE CASCADE.
+func (cfg *apiConfig) runUserPurge(ctx context.Context) {
+	ticker := time.NewTicker(userPurgeInterval)
+	defer ticker.Stop()
+	for {
+		cfg.purgeDeletedUsers(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) purgeDeletedUsers(ctx context.Context) {
+	cutoff := time.Now().Add(-userRestoreWindow)
+	users, err := cfg.database.ListPurgeableUsers(ctx, cutoff)
+	if err != nil {
+		log.Printf("Error listing deleted users: %s", err)
+		return
+	}
+	purged := 0
+	for _, u := range users {
+		report, err := cfg.purgeUser(ctx, u, cutoff)
+		if err != nil {
+			log.Printf("Error purging user %s: %s", u.ID, err)
+			continue
+		}
+		if report.ID == uuid.Nil {
+			continue
+		}
+		purged++
+		cfg.recordAudit(ctx, uuid.Nil, "user.purged", "user", u.ID.String(), map[string]any{
+			"deletion_report_id": report.ID,
+			"verified":           report.Verified,
+		})
+		if !report.Verified {
+			log.Printf("Purged user %s but references remain; see deletion report %s", u.ID, report.ID)
+		}
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted users", purged)
+	}
+}
+
+// purgeUser deletes one user, anonymizes the audit entries about them and
+// stores a signed report of what was removed. Counting before and after in
+// the same transaction is what lets the report vouch that nothing is left.
+// It returns a zero report if the user was restored in the meantime.
+func (cfg *apiConfig) purgeUser(ctx context.Context, u database.ListPurgeableUsersRow, cutoff time.Time) (database.DeletionReport, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.DeletionReport{}, err
+	}
+	defer tx.Rollback()
+	report, err := cfg.purgeUserTx(ctx, cfg.database.WithTx(tx), u, cutoff)
+	if err != nil || report.ID == uuid.Nil {
+		return report, err
+	}
+	return report, tx.Commit()
+}
+
+// purgeUserTx is purgeUser within a transaction the caller commits.
+func (cfg *apiConfig) purgeUserTx(ctx context.Context, qtx *database.Store, u database.ListPurgeableUsersRow, cutoff time.Time) (database.DeletionReport, error) {
+	before, err := qtx.CountUserReferences(ctx, u.ID)
+	if err != nil {
+		return database.DeletionReport{}, fmt.Errorf("couldn't count references: %w", err)
+	}
+	if _, err := qtx.AnonymizeUserAuditLogs(ctx, u.ID.String()); err != nil {
+		return database.DeletionReport{}, fmt.Errorf("couldn't anonymize audit logs: %w", err)
+	}
+	deleted, err := qtx.PurgeDeletedUser(ctx, database.PurgeDeletedUserParams{ID: u.ID, DeletedBefore: cutoff})
+	if err != nil {
+		return database.DeletionReport{}, fmt.Errorf("couldn't delete user: %w", err)
+	}
+	if deleted == 0 {
+		return database.DeletionReport{}, nil
+	}
+	after, err := qtx.CountUserReferences(ctx, u.ID)
+	if err != nil {
+		return database.DeletionReport{}, fmt.Errorf("couldn't verify deletion: %w", err)
+	}
+
+	report := newDeletionReport(u.ID, u.DeletedAt.Time, time.Now().UTC(), before, after)
+	data, err := json.Marshal(report)
+	if err != nil {
+		return database.DeletionReport{}, err
+	}
+	stored, err := qtx.CreateDeletionReport(ctx, database.CreateDeletionReportParams{
+		UserID:    u.ID,
+		Verified:  report.Verified,
+		Report:    string(data),
+		Signature: auth.SignReport(cfg.reportSecret, data),
+	})
+	if err != nil {
+		return database.DeletionReport{}, fmt.Errorf("couldn't save deletion report: %w", err)
+	}
+	return stored, nil
+}
+
+type deletionReport struct {
+	UserID    uuid.UUID `json:"user_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgedAt  time.Time `json:"purged_at"`
+	// Removed counts the rows deleted, set to NULL or, for audit_logs
+	// metadata, emptied, keyed by table and column.
+	Removed   map[string]int64 `json:"removed"`
+	Remaining map[string]int64 `json:"remaining"`
+	Verified  bool             `json:"verified"`
+	Notes     []string         `json:"notes"`
+}
+
+func newDeletionReport(userID uuid.UUID, deletedAt, purgedAt time.Time, before, after []database.CountUserReferencesRow) deletionReport {
+	report := deletionReport{
+		UserID:    userID,
+		DeletedAt: deletedAt.UTC(),
+		PurgedAt:  purgedAt,
+		Removed:   map[string]int64{},
+		Remaining: map[string]int64{},
+		Verified:  true,
+		Notes: []string{
+			"Audit log entries about the user are kept without their metadata; the user ID no longer resolves to anyone.",
+			"Database backups taken before the purge still contain the user until they are rotated out.",
+			"Event log entries keep the user's ID, which no longer resolves to anyone, but no other data about them.",
+			"Emails already queued to the user may still be sent; ones that can't be delivered are kept for up to seven days.",
+		},
+	}
+	for _, row := range before {
+		if row.RowCount > 0 {
+			report.Removed[row.Reference] = row.RowCount
+		}
+	}
+	for _, row := range after {
+		if row.RowCount > 0 {
+			report.Remaining[row.Reference] = row.RowCount
+			report.Removed[row.Reference] -= row.RowCount
+			report.Verified = false
+		}
+	}
+	return report
+}
+
+func (cfg *apiConfig) handlerAdminListDeletionReports(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		ID        uuid.UUID `json:"id"`
+		UserID    uuid.UUID `json:"user_id"`
+		CreatedAt time.Time `json:"created_at"`
+		Verified  bool      `json:"verified"`
+	}
+	reports, err := cfg.database.ListDeletionReports(r.Context(), 100)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get deletion reports", err)
+		return
+	}
+	var entries []entry
+	for _, rep := range reports {
+		entries = append(entries, entry{ID: rep.ID, UserID: rep.UserID, CreatedAt: rep.CreatedAt, Verified: rep.Verified})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+// handlerAdminDownloadDeletionReport serves a report exactly as it was
+// signed, with the signature in X-Report-Signature.
+func (cfg *apiConfig) handlerAdminDownloadDeletionReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+	rep, err := cfg.database.GetDeletionReport(r.Context(), reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Deletion report not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get deletion report", err)
+		return
+	}
+	adminID, _ := adminUserIDFromContext(r.Context())
+	cfg.recordAudit(r.Context(), adminID, "deletion_report.downloaded", "deletion_report", rep.ID.String(), nil)
+
+	w.Header().Set("Content-Type", deletionReportType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="deletion-report-%s.json"`, rep.ID))
+	w.Header().Set("X-Report-Signature", "hmac-sha256="+rep.Signature)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(rep.Report))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/oauthserver"
+	"github.com/google/uuid"
+)
+
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityUnlisted  = "unlisted"
+)
+
+func validChirpVisibility(v string) bool {
+	switch v {
+	case chirpVisibilityPublic, chirpVisibilityFollowers, chirpVisibilityUnlisted:
+		return true
+	}
+	return false
+}
+
+// chirpVisible is the single place that decides who may see a chirp. Listing
+// covers timelines and any other feed-like read; direct reads are lookups by
+// ID, threads and permalinks. Unlisted chirps only appear in direct reads.
+// Followers-only chirps are shown to their author and to viewers whose
+// followed set, from viewerFollows, holds the author.
+func chirpVisible(visibility string, authorID, viewerID uuid.UUID, followed followedAuthors, listing bool) bool {
+	if viewerID == uuid.Nil {
+		followed = nil
+	} else if viewerID == authorID {
+		return true
+	}
+	switch visibility {
+	case chirpVisibilityPublic:
+		return true
+	case chirpVisibilityUnlisted:
+		return !listing
+	case chirpVisibilityFollowers:
+		return followed[authorID]
+	}
+	return false
+}
+
+// followedAuthors is the set of authors a viewer follows.
+type followedAuthors map[uuid.UUID]bool
+
+// viewerFollows returns which of authorIDs the viewer follows. Anonymous
+// viewers follow nobody, and nothing is queried for them.
+func (cfg *apiConfig) viewerFollows(ctx context.Context, viewerID uuid.UUID, authorIDs []uuid.UUID) (followedAuthors, error) {
+	if viewerID == uuid.Nil || len(authorIDs) == 0 {
+		return nil, nil
+	}
+	ids, err := cfg.database.ListFollowedAmong(ctx, database.ListFollowedAmongParams{
+		FollowerID:  viewerID,
+		FolloweeIds: authorIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	followed := make(followedAuthors, len(ids))
+	for _, id := range ids {
+		followed[id] = true
+	}
+	return followed, nil
+}
+
+// chirpVisibleTo is chirpVisible for a single direct read, looking up the
+// follow only for followers-only chirps.
+func (cfg *apiConfig) chirpVisibleTo(ctx context.Context, visibility string, authorID, viewerID uuid.UUID) (bool, error) {
+	var followed followedAuthors
+	if visibility == chirpVisibilityFollowers && viewerID != authorID {
+		var err error
+		followed, err = cfg.viewerFollows(ctx, viewerID, []uuid.UUID{authorID})
+		if err != nil {
+			return false, err
+		}
+	}
+	return chirpVisible(visibility, authorID, viewerID, followed, false), nil
+}
+
+// optionalViewer returns the user making a read request, or uuid.Nil for
+// anonymous requests and invalid tokens.
+func (cfg *apiConfig) optionalViewer(r *http.Request) uuid.UUID {
+	token, err := cfg.accessToken(r)
+	if err != nil {
+		return uuid.Nil
+	}
+	userID, err := cfg.userForToken(r.Context(), token, oauthserver.ScopeChirpsRead)
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}
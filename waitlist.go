@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/api"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/mail"
+	"github.com/google/uuid"
+)
+
+const (
+	waitlistPending  = "pending"
+	waitlistRejected = "rejected"
+	// maxWaitlistBatch caps how many users one approve or reject call
+	// can decide.
+	maxWaitlistBatch = 1000
+)
+
+// waitlistLoginError explains why a waitlisted account can't sign in yet.
+func waitlistLoginError(user database.User) (string, bool) {
+	switch user.WaitlistStatus.String {
+	case waitlistPending:
+		return "Your account is on the waitlist and hasn't been approved yet", true
+	case waitlistRejected:
+		return "Your signup was not approved", true
+	}
+	return "", false
+}
+
+type waitlistEntry struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (cfg *apiConfig) handlerAdminListWaitlist(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter", nil)
+			return
+		}
+		limit = n
+	}
+	users, err := cfg.database.ListWaitlistedUsers(r.Context(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get waitlist", err)
+		return
+	}
+	var entries []waitlistEntry
+	for _, u := range users {
+		entries = append(entries, waitlistEntry{ID: u.ID, Email: u.Email, CreatedAt: u.CreatedAt})
+	}
+	respondWithJSON(w, http.StatusOK, api.NewPage(entries))
+}
+
+func (cfg *apiConfig) handlerAdminApproveWaitlist(w http.ResponseWriter, r *http.Request) {
+	cfg.decideWaitlist(w, r, true)
+}
+
+func (cfg *apiConfig) handlerAdminRejectWaitlist(w http.ResponseWriter, r *http.Request) {
+	cfg.decideWaitlist(w, r, false)
+}
+
+// decideWaitlist approves or rejects pending users in bulk. IDs that aren't
+// pending are skipped, so retrying a partly applied request is safe.
+func (cfg *apiConfig) decideWaitlist(w http.ResponseWriter, r *http.Request, approve bool) {
+	type parameters struct {
+		UserIDs []uuid.UUID `json:"user_ids"`
+	}
+	type returnVals struct {
+		Updated []uuid.UUID `json:"updated"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if len(params.UserIDs) == 0 || len(params.UserIDs) > maxWaitlistBatch {
+		respondWithError(w, http.StatusBadRequest, "user_ids must list between 1 and 1000 users", nil)
+		return
+	}
+	status, action := sql.NullString{}, "waitlist.approved"
+	if !approve {
+		status, action = sql.NullString{String: waitlistRejected, Valid: true}, "waitlist.rejected"
+	}
+	users, err := cfg.setWaitlistStatus(r.Context(), database.SetWaitlistStatusParams{
+		WaitlistStatus: status,
+		Ids:            params.UserIDs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update waitlist", err)
+		return
+	}
+
+	adminID, _ := adminUserIDFromContext(r.Context())
+	resp := returnVals{Updated: []uuid.UUID{}}
+	for _, u := range users {
+		cfg.recordAudit(r.Context(), adminID, action, "user", u.ID.String(), nil)
+		resp.Updated = append(resp.Updated, u.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// setWaitlistStatus applies params and, for approvals, records UserApproved
+// and queues an email for each approved user in the same transaction.
+func (cfg *apiConfig) setWaitlistStatus(ctx context.Context, params database.SetWaitlistStatusParams) ([]database.User, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.database.WithTx(tx)
+	users, err := qtx.SetWaitlistStatus(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if !params.WaitlistStatus.Valid {
+		site := cfg.siteName(ctx)
+		for _, u := range users {
+			if err := appendEvent(ctx, qtx, eventUserApproved, aggregateUser, u.ID, nil); err != nil {
+				return nil, err
+			}
+			err := enqueueEmail(ctx, qtx, mail.Message{
+				To:      u.Email,
+				Subject: "Your " + site + " account is ready",
+				Body:    "Good news: your " + site + " account has been approved. You can sign in now with the email and password you signed up with.",
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return users, tx.Commit()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/settings"
+	"github.com/google/uuid"
+)
+
+// warmupTimeout bounds warming so a slow database can't keep a new
+// instance out of rotation for good; whatever isn't warm by then warms on
+// first use.
+const warmupTimeout = 30 * time.Second
+
+// warmCaches loads what the first requests after a deploy would otherwise
+// pay for: the settings cache, the profanity rules and the first
+// page of the public timeline in both orders, which also opens database
+// connections and pulls the timeline index into Postgres' buffers.
+// /api/readyz fails until it returns.
+func (cfg *apiConfig) warmCaches(ctx context.Context) {
+	defer cfg.warming.Store(false)
+	ctx, cancel := context.WithTimeout(ctx, warmupTimeout)
+	defer cancel()
+
+	start := time.Now()
+	cfg.settings.Get(ctx, settings.ChirpMaxLength)
+	cfg.profanityFilter(ctx)
+	params := database.GetMessagesWithAuthorParams{RowLimit: 100}
+	for _, newestFirst := range []bool{false, true} {
+		if _, err := cfg.getChirpPage(ctx, params, uuid.NullUUID{}, newestFirst); err != nil {
+			log.Printf("Error warming the public timeline: %s", err)
+		}
+	}
+	log.Printf("Caches warmed in %s", time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eldeeishere/cautious-octo-dollop/internal/auth"
+	"github.com/eldeeishere/cautious-octo-dollop/internal/database"
+)
+
+const (
+	webhookSourcePolka        = "polka"
+	webhookNoncePurgeInterval = 10 * time.Minute
+	maxWebhookBodyBytes       = 1 << 20
+)
+
+// webhookNonceStore keeps webhook nonces in Postgres so every instance
+// sees the same set.
+type webhookNonceStore struct {
+	queries *database.Queries
+}
+
+func (s webhookNonceStore) ClaimNonce(ctx context.Context, source, nonce string) (bool, error) {
+	n, err := s.queries.ClaimWebhookNonce(ctx, database.ClaimWebhookNonceParams{
+		Source: source,
+		Nonce:  nonce,
+	})
+	return n == 1, err
+}
+
+// claimWebhookNonce claims a verified webhook's nonce through q, the
+// transaction applying it, so the nonce is only used up if the webhook
+// takes effect. It answers the request itself and returns false if the
+// nonce was seen before or can't be claimed.
+func (cfg *apiConfig) claimWebhookNonce(w http.ResponseWriter, r *http.Request, q *database.Store, source, nonce string) bool {
+	err := auth.ClaimWebhookNonce(r.Context(), webhookNonceStore{q.Queries}, source, nonce)
+	if auth.IsWebhookReplayError(err) {
+		respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		return false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't verify webhook", err)
+		return false
+	}
+	return true
+}
+
+// runWebhookNoncePurge drops nonces once their timestamps can no longer
+// pass the freshness check.
+func (cfg *apiConfig) runWebhookNoncePurge(ctx context.Context) {
+	ticker := time.NewTicker(webhookNoncePurgeInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := cfg.database.DeleteWebhookNoncesBefore(ctx, time.Now().Add(-2*auth.WebhookMaxAge)); err != nil {
+			log.Printf("Error purging webhook nonces: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}